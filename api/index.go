@@ -2,20 +2,46 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"cleanbuddy-api/res/auth"
+	"cleanbuddy-api/res/availability"
+	"cleanbuddy-api/res/bookinglifecycle"
+	"cleanbuddy-api/res/bookingreschedule"
+	"cleanbuddy-api/res/bookingseries"
+	"cleanbuddy-api/res/cancellationpolicy"
+	"cleanbuddy-api/res/commission"
+	"cleanbuddy-api/res/credit"
+	"cleanbuddy-api/res/dispute"
+	"cleanbuddy-api/res/giftcard"
+	"cleanbuddy-api/res/invoice"
+	invoicefake "cleanbuddy-api/res/invoice/fake"
+	"cleanbuddy-api/res/ledger"
+	"cleanbuddy-api/res/localtime"
 	"cleanbuddy-api/res/mail"
 	"cleanbuddy-api/res/mail/sidemail"
+	"cleanbuddy-api/res/noshow"
 	"cleanbuddy-api/res/notification"
 	"cleanbuddy-api/res/notification/slack"
+	"cleanbuddy-api/res/payment"
+	"cleanbuddy-api/res/payment/fake"
+	"cleanbuddy-api/res/payment/stripe"
+	"cleanbuddy-api/res/payout"
+	"cleanbuddy-api/res/pricing"
+	"cleanbuddy-api/res/promo"
+	"cleanbuddy-api/res/referral"
+	"cleanbuddy-api/res/statement"
 	"cleanbuddy-api/res/storage"
 	"cleanbuddy-api/res/store"
 	"cleanbuddy-api/res/store/postgresql"
+	"cleanbuddy-api/res/tip"
 	"cleanbuddy-api/sys/graphql"
 	"cleanbuddy-api/sys/http/middleware"
 )
@@ -38,12 +64,40 @@ var logger = log.New(os.Stdout, "", log.LstdFlags|log.LUTC|log.Llongfile)
 // - SIDEMAIL_API_KEY: Sidemail API key for email operations (optional)
 // - SIDEMAIL_API_URL: Sidemail API base URL (default: https://api.sidemail.io/v1)
 // - SIDEMAIL_SIGNUPS_GROUP_ID: Sidemail group ID for user signups (optional)
+// - STRIPE_SECRET_KEY: Stripe secret API key for card payments (optional)
+// - STRIPE_WEBHOOK_SECRET: Stripe webhook signing secret (required to accept Stripe webhooks)
+// - STRIPE_API_URL: Stripe API base URL (default: https://api.stripe.com/v1)
+// - PAYMENT_PROVIDER: Set to "fake" to take payments with the in-memory fake provider when STRIPE_SECRET_KEY is not set (local development only)
+// - FAKE_PAYMENT_WEBHOOK_SECRET: Signing secret of webhooks sent to the fake provider (default: whsec_fake)
+// - PAYMENT_CAPTURE_DELAY_HOURS: Dispute window after completion before the card hold is charged (default: 0, charged at completion)
+// - PAYMENT_HOLD_VALIDITY_DAYS: How long the payment provider keeps a card hold before it lapses (default: 7)
+// - TIP_WINDOW_HOURS: How long after completion customers can tip the cleaner (default: 72)
+// - TIP_MIN_AMOUNT / TIP_MAX_AMOUNT: Smallest and largest tip in bani (default: 500 / 50000)
+// - DISPUTE_FILING_WINDOW_DAYS: How long after completion customers can dispute a booking (default: 14)
 // - SLACK_WEBHOOK_URL: Slack webhook URL for notifications (optional)
 // - SLACK_TIMEOUT_SECONDS: Timeout for notification API requests in seconds (default: 5)
-// - GCS_BUCKET_NAME: Google Cloud Storage bucket name for document uploads (optional)
+// - GCS_BUCKET_NAME: Google Cloud Storage bucket name for document uploads, dispute evidence, payout statements and invoices (optional)
 // - GCS_PROJECT_ID: Google Cloud project ID (optional)
 // - GOOGLE_APPLICATION_CREDENTIALS_JSON: GCS service account credentials as JSON string (for Vercel/serverless, optional)
 // - GOOGLE_APPLICATION_CREDENTIALS: Path to GCS service account credentials file (for local development, optional)
+// - RECURRING_BOOKING_HORIZON_DAYS: How many days ahead recurring booking occurrences are created (default: 56)
+// - CANCELLATION_POLICY_JSON: Cancellation policy tiers and reason overrides as JSON (default: built-in policy)
+// - NO_SHOW_FEE_GENERAL / NO_SHOW_FEE_DEEP / NO_SHOW_FEE_MOVE_IN_OUT: No-show fee per service type in bani (default: 5000 / 8000 / 10000)
+// - NO_SHOW_CLEANER_SHARE_PERCENT: Share of the no-show fee paid to the cleaner (default: 50)
+// - NO_SHOW_CONTEST_WINDOW_HOURS: How long customers can contest a no-show (default: 48)
+// - BOOKING_BUFFER_MINUTES: Minimum gap kept between a cleaner's jobs when offering slots (default: 30)
+// - RESCHEDULE_REQUEST_TTL_HOURS: How long reschedule proposals stay open (default: 48, never past the booking start)
+// - PLATFORM_FEE_PERCENTAGE: Platform fee charged to customers on top of the booking subtotal, until a platform commission rule is set (default: 15)
+// - PLATFORM_COMMISSION_PERCENTAGE: Commission kept from cleaner payouts, until a platform commission rule is set (default: 0)
+// - REFERRAL_REFERRER_CREDIT / REFERRAL_REFEREE_CREDIT: Credit granted to the referrer and the referred customer after the first completed booking, in bani (default: 5000 / 5000)
+// - GIFT_CARD_AMOUNTS: Comma-separated values gift cards can be bought for, in bani (default: 10000,20000,50000)
+// - GIFT_CARD_VALIDITY_DAYS: How long a gift card can be spent after purchase (default: 365)
+// - INVOICE_PLATFORM_TAX_ID: CUI the platform invoices under, prefixed with RO when registered for VAT (invoicing disabled when not set)
+// - INVOICE_PLATFORM_NAME / INVOICE_PLATFORM_REGISTRATION_NUMBER: Legal name and trade register number of the platform on its invoices
+// - INVOICE_PLATFORM_STREET / INVOICE_PLATFORM_CITY / INVOICE_PLATFORM_COUNTY / INVOICE_PLATFORM_POSTAL_CODE: Registered address of the platform (country: Romania)
+// - INVOICE_SERIES / INVOICE_COMPANY_SERIES: Series the platform's and each company's invoices are numbered in (default: CB / CBC)
+// - INVOICE_VAT_RATE: Standard VAT rate in percent charged by issuers registered for VAT (default: 21)
+// - BUSINESS_TIMEZONE: IANA timezone in which booking dates and times of day are interpreted (default: Europe/Bucharest)
 
 // Global service instances initialized once
 var (
@@ -52,27 +106,32 @@ var (
 	mailServiceInstance         mail.MailService
 	notificationServiceInstance notification.NotificationService
 	storageServiceInstance      *storage.GCSService
+	bookingLifecycleInstance    bookinglifecycle.LifecycleService
+	commissionInstance          commission.CommissionService
+	promoInstance               promo.PromoService
+	referralInstance            referral.ReferralService
+	creditInstance              credit.CreditService
+	giftCardInstance            giftcard.GiftCardService
+	paymentProviderInstance     payment.PaymentProvider
+	paymentInstance             payment.PaymentService
+	payoutInstance              payout.PayoutService
+	ledgerInstance              ledger.LedgerService
+	statementInstance           statement.StatementService
+	invoiceInstance             invoice.InvoiceService
+	tipInstance                 tip.TipService
+	disputeInstance             dispute.DisputeService
+	pricingInstance             pricing.PricingService
+	bookingSeriesInstance       bookingseries.SeriesService
+	cancellationPolicyInstance  cancellationpolicy.PolicyService
+	noShowSettlementInstance    noshow.SettlementService
+	bookingRescheduleInstance   bookingreschedule.RescheduleService
+	availabilityServiceInstance availability.AvailabilityService
 	initOnce                    sync.Once
 	initError                   error
 )
 
 func Handler(w http.ResponseWriter, r *http.Request) {
-	// Initialize services only once using sync.Once
-	initOnce.Do(func() {
-		storeInstance, initError = configStore()
-		if initError != nil {
-			return
-		}
-
-		authInstance = configAuth()
-		mailServiceInstance = configMail()
-		notificationServiceInstance = configNotification()
-		storageServiceInstance = configStorage()
-	})
-
-	if initError != nil {
-		logger.Fatalf("Failed to initialize services: %v", initError)
-	}
+	initServices()
 
 	graphqlServerHandler := graphql.New(&graphql.Config{
 		Logger:              logger,
@@ -81,6 +140,25 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 		MailService:         mailServiceInstance,
 		NotificationService: notificationServiceInstance,
 		StorageService:      storageServiceInstance,
+		BookingLifecycle:    bookingLifecycleInstance,
+		Commission:          commissionInstance,
+		Pricing:             pricingInstance,
+		Promo:               promoInstance,
+		Referral:            referralInstance,
+		Credit:              creditInstance,
+		GiftCards:           giftCardInstance,
+		Payments:            paymentInstance,
+		Payouts:             payoutInstance,
+		Ledger:              ledgerInstance,
+		Statements:          statementInstance,
+		Invoicing:           invoiceInstance,
+		Tips:                tipInstance,
+		DisputeService:      disputeInstance,
+		RecurringBookings:   bookingSeriesInstance,
+		CancellationPolicy:  cancellationPolicyInstance,
+		NoShowSettlement:    noShowSettlementInstance,
+		BookingReschedule:   bookingRescheduleInstance,
+		AvailabilityService: availabilityServiceInstance,
 	})
 
 	// GraphQL endpoint with middleware stack
@@ -91,6 +169,51 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 	).ServeHTTP(w, r)
 }
 
+// initServices initializes the global service instances on first use and exits if that fails
+func initServices() {
+	// Initialize services only once using sync.Once
+	initOnce.Do(func() {
+		initError = configTimezone()
+		if initError != nil {
+			return
+		}
+
+		storeInstance, initError = configStore()
+		if initError != nil {
+			return
+		}
+
+		authInstance = configAuth()
+		mailServiceInstance = configMail()
+		notificationServiceInstance = configNotification()
+		storageServiceInstance = configStorage()
+		bookingLifecycleInstance = bookinglifecycle.NewService(storeInstance, logger)
+		commissionInstance = configCommission(storeInstance)
+		promoInstance = configPromo(storeInstance)
+		pricingInstance = configPricing(storeInstance, commissionInstance, promoInstance)
+		referralInstance = configReferral(storeInstance, bookingLifecycleInstance)
+		paymentProviderInstance = configPaymentProvider()
+		paymentInstance = configPayment(storeInstance, bookingLifecycleInstance, paymentProviderInstance)
+		cancellationPolicyInstance = configCancellationPolicy(storeInstance, bookingLifecycleInstance, paymentInstance)
+		creditInstance = credit.NewService(storeInstance, bookingLifecycleInstance, cancellationPolicyInstance, logger)
+		giftCardInstance = configGiftCard(storeInstance, bookingLifecycleInstance, paymentInstance, cancellationPolicyInstance, mailServiceInstance)
+		payoutInstance = configPayout(storeInstance, paymentProviderInstance)
+		ledgerInstance = ledger.NewService(storeInstance, logger)
+		statementInstance = configStatement(storeInstance, storageServiceInstance)
+		invoiceInstance = configInvoice(storeInstance, paymentInstance, storageServiceInstance)
+		tipInstance = configTip(storeInstance, paymentInstance)
+		disputeInstance = configDispute(storeInstance, paymentInstance, storageServiceInstance)
+		bookingSeriesInstance = configBookingSeries(storeInstance, bookingLifecycleInstance, pricingInstance)
+		noShowSettlementInstance = configNoShow(storeInstance, bookingLifecycleInstance, paymentInstance)
+		bookingRescheduleInstance = configBookingReschedule(storeInstance, bookingLifecycleInstance)
+		availabilityServiceInstance = configAvailability(storeInstance)
+	})
+
+	if initError != nil {
+		logger.Fatalf("Failed to initialize services: %v", initError)
+	}
+}
+
 func readRequiredEnvVar(name string) string {
 	val, ok := os.LookupEnv(name)
 	if !ok {
@@ -107,6 +230,17 @@ func readOptionalEnvVar(name, defaultValue string) string {
 	return val
 }
 
+func configTimezone() error {
+	name := readOptionalEnvVar("BUSINESS_TIMEZONE", localtime.DefaultTimezone)
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("invalid BUSINESS_TIMEZONE %q: %w", name, err)
+	}
+
+	localtime.SetLocation(loc)
+	return nil
+}
+
 func configStore() (store.Store, error) {
 	rawStore, err := postgresql.Connect(readRequiredEnvVar("DATABASE_POSTGRES_URL"))
 	if err != nil {
@@ -138,6 +272,149 @@ func configMail() mail.MailService {
 	return sidemail.New(apiKey, apiURL, signUpsGroupId, timeout, logger)
 }
 
+func configPaymentProvider() payment.PaymentProvider {
+	secretKey := readOptionalEnvVar("STRIPE_SECRET_KEY", "")
+	if secretKey != "" {
+		webhookSecret := readOptionalEnvVar("STRIPE_WEBHOOK_SECRET", "")
+		if webhookSecret == "" {
+			logger.Printf("STRIPE_WEBHOOK_SECRET not set, payment webhooks will be rejected")
+		}
+		apiURL := readOptionalEnvVar("STRIPE_API_URL", "https://api.stripe.com/v1")
+		return stripe.New(secretKey, webhookSecret, apiURL, 10*time.Second, logger)
+	}
+
+	if readOptionalEnvVar("PAYMENT_PROVIDER", "") == "fake" {
+		logger.Printf("Using the fake payment provider, no real payments will be taken")
+		return fake.New(readOptionalEnvVar("FAKE_PAYMENT_WEBHOOK_SECRET", "whsec_fake"), logger)
+	}
+
+	logger.Printf("STRIPE_SECRET_KEY not set, payments disabled")
+	return nil
+}
+
+func configPayment(storeInstance store.Store, lifecycle bookinglifecycle.LifecycleService, provider payment.PaymentProvider) payment.PaymentService {
+	if provider == nil {
+		return nil
+	}
+
+	options := payment.DefaultOptions()
+
+	captureDelayHours, err := strconv.Atoi(readOptionalEnvVar("PAYMENT_CAPTURE_DELAY_HOURS", "0"))
+	if err != nil || captureDelayHours < 0 {
+		logger.Printf("Invalid PAYMENT_CAPTURE_DELAY_HOURS, capturing payments at completion")
+		captureDelayHours = 0
+	}
+	options.CaptureDelay = time.Duration(captureDelayHours) * time.Hour
+
+	holdValidityDays, err := strconv.Atoi(readOptionalEnvVar("PAYMENT_HOLD_VALIDITY_DAYS", "7"))
+	if err != nil || holdValidityDays <= 0 {
+		logger.Printf("Invalid PAYMENT_HOLD_VALIDITY_DAYS, using default of 7 days")
+		holdValidityDays = 7
+	}
+	options.HoldValidity = time.Duration(holdValidityDays) * 24 * time.Hour
+
+	return payment.NewService(storeInstance, lifecycle, provider, options, logger)
+}
+
+func configTip(storeInstance store.Store, payments payment.PaymentService) tip.TipService {
+	if payments == nil {
+		return nil
+	}
+
+	options := tip.DefaultOptions()
+
+	windowHours, err := strconv.Atoi(readOptionalEnvVar("TIP_WINDOW_HOURS", "72"))
+	if err != nil || windowHours <= 0 {
+		logger.Printf("Invalid TIP_WINDOW_HOURS, using default of 72 hours")
+		windowHours = 72
+	}
+	options.Window = time.Duration(windowHours) * time.Hour
+
+	minAmount, err := strconv.Atoi(readOptionalEnvVar("TIP_MIN_AMOUNT", "500"))
+	if err != nil || minAmount <= 0 {
+		logger.Printf("Invalid TIP_MIN_AMOUNT, using default of 500")
+		minAmount = 500
+	}
+	maxAmount, err := strconv.Atoi(readOptionalEnvVar("TIP_MAX_AMOUNT", "50000"))
+	if err != nil || maxAmount < minAmount {
+		logger.Printf("Invalid TIP_MAX_AMOUNT, using default of 50000")
+		maxAmount = max(50000, minAmount)
+	}
+	options.MinAmount, options.MaxAmount = minAmount, maxAmount
+
+	return tip.NewService(storeInstance, payments, options, logger)
+}
+
+func configDispute(storeInstance store.Store, payments payment.PaymentService, storageService *storage.GCSService) dispute.DisputeService {
+	options := dispute.DefaultOptions()
+
+	windowDays, err := strconv.Atoi(readOptionalEnvVar("DISPUTE_FILING_WINDOW_DAYS", "14"))
+	if err != nil || windowDays <= 0 {
+		logger.Printf("Invalid DISPUTE_FILING_WINDOW_DAYS, using default of 14 days")
+		windowDays = 14
+	}
+	options.FilingWindow = time.Duration(windowDays) * 24 * time.Hour
+
+	var files dispute.FileStore
+	if storageService != nil {
+		files = storageService
+	}
+	return dispute.NewService(storeInstance, payments, files, options, logger)
+}
+
+func configPayout(storeInstance store.Store, provider payment.PaymentProvider) payout.PayoutService {
+	if provider == nil {
+		return nil
+	}
+	return payout.NewService(storeInstance, provider, logger)
+}
+
+func configStatement(storeInstance store.Store, storageService *storage.GCSService) statement.StatementService {
+	if storageService == nil {
+		return nil
+	}
+	return statement.NewService(storeInstance, storageService, logger)
+}
+
+func configInvoice(storeInstance store.Store, payments payment.PaymentService, storageService *storage.GCSService) invoice.InvoiceService {
+	taxID := readOptionalEnvVar("INVOICE_PLATFORM_TAX_ID", "")
+	if taxID == "" {
+		logger.Printf("INVOICE_PLATFORM_TAX_ID not set, invoicing disabled")
+		return nil
+	}
+
+	options := invoice.DefaultOptions()
+	options.Platform = store.InvoiceParty{
+		Name:               readOptionalEnvVar("INVOICE_PLATFORM_NAME", "CleanBuddy"),
+		TaxID:              taxID,
+		RegistrationNumber: readOptionalEnvVar("INVOICE_PLATFORM_REGISTRATION_NUMBER", ""),
+		Street:             readOptionalEnvVar("INVOICE_PLATFORM_STREET", ""),
+		City:               readOptionalEnvVar("INVOICE_PLATFORM_CITY", ""),
+		County:             readOptionalEnvVar("INVOICE_PLATFORM_COUNTY", ""),
+		PostalCode:         readOptionalEnvVar("INVOICE_PLATFORM_POSTAL_CODE", ""),
+		Country:            "Romania",
+	}
+	options.PlatformSeries = readOptionalEnvVar("INVOICE_SERIES", options.PlatformSeries)
+	options.CompanySeries = readOptionalEnvVar("INVOICE_COMPANY_SERIES", options.CompanySeries)
+
+	vatRate, err := strconv.ParseFloat(readOptionalEnvVar("INVOICE_VAT_RATE", "21"), 64)
+	if err != nil || vatRate < 0 || vatRate > 100 {
+		logger.Printf("Invalid INVOICE_VAT_RATE, using default of 21%%")
+		vatRate = 21
+	}
+	options.VATRate = vatRate
+
+	// Invoices are kept for submission until an e-Factura client with ANAF credentials exists
+	logger.Printf("Using the fake e-Factura client, invoices will not reach ANAF")
+	efactura := invoicefake.New(logger)
+
+	var files invoice.FileStore
+	if storageService != nil {
+		files = storageService
+	}
+	return invoice.NewService(storeInstance, payments, files, efactura, options, logger)
+}
+
 func configNotification() notification.NotificationService {
 	webhookURL := readOptionalEnvVar("SLACK_WEBHOOK_URL", "")
 	if webhookURL == "" {
@@ -181,3 +458,162 @@ func configStorage() *storage.GCSService {
 	logger.Printf("GCS storage service initialized successfully (bucket: %s)", bucketName)
 	return gcsService
 }
+
+func configCommission(storeInstance store.Store) commission.CommissionService {
+	platformFeePercent, err := strconv.ParseFloat(readOptionalEnvVar("PLATFORM_FEE_PERCENTAGE", "15"), 64)
+	if err != nil || platformFeePercent < 0 || platformFeePercent > 100 {
+		logger.Printf("Invalid PLATFORM_FEE_PERCENTAGE, using default of 15%%")
+		platformFeePercent = 15
+	}
+
+	commissionPercent, err := strconv.ParseFloat(readOptionalEnvVar("PLATFORM_COMMISSION_PERCENTAGE", "0"), 64)
+	if err != nil || commissionPercent < 0 || commissionPercent > 100 {
+		logger.Printf("Invalid PLATFORM_COMMISSION_PERCENTAGE, using default of 0%%")
+		commissionPercent = 0
+	}
+
+	return commission.NewService(storeInstance, commission.Terms{
+		PlatformFeePercent: platformFeePercent,
+		CommissionPercent:  commissionPercent,
+	}, logger)
+}
+
+func configPromo(storeInstance store.Store) promo.PromoService {
+	return promo.NewService(storeInstance, logger)
+}
+
+func configReferral(storeInstance store.Store, lifecycle bookinglifecycle.LifecycleService) referral.ReferralService {
+	referrerCredit, err := strconv.Atoi(readOptionalEnvVar("REFERRAL_REFERRER_CREDIT", "5000"))
+	if err != nil || referrerCredit < 0 {
+		logger.Printf("Invalid REFERRAL_REFERRER_CREDIT, using default of 5000 bani")
+		referrerCredit = 5000
+	}
+
+	refereeCredit, err := strconv.Atoi(readOptionalEnvVar("REFERRAL_REFEREE_CREDIT", "5000"))
+	if err != nil || refereeCredit < 0 {
+		logger.Printf("Invalid REFERRAL_REFEREE_CREDIT, using default of 5000 bani")
+		refereeCredit = 5000
+	}
+
+	return referral.NewService(storeInstance, lifecycle, referral.Rewards{
+		ReferrerCredit: referrerCredit,
+		RefereeCredit:  refereeCredit,
+	}, logger)
+}
+
+func configGiftCard(storeInstance store.Store, lifecycle bookinglifecycle.LifecycleService, payments payment.PaymentService, policy cancellationpolicy.PolicyService, mailService mail.MailService) giftcard.GiftCardService {
+	options := giftcard.DefaultOptions()
+
+	if raw := readOptionalEnvVar("GIFT_CARD_AMOUNTS", ""); raw != "" {
+		var amounts []int
+		for _, field := range strings.Split(raw, ",") {
+			amount, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil || amount <= 0 {
+				amounts = nil
+				break
+			}
+			amounts = append(amounts, amount)
+		}
+		if len(amounts) == 0 {
+			logger.Printf("Invalid GIFT_CARD_AMOUNTS, using default of %v", options.Amounts)
+		} else {
+			options.Amounts = amounts
+		}
+	}
+
+	validityDays, err := strconv.Atoi(readOptionalEnvVar("GIFT_CARD_VALIDITY_DAYS", "365"))
+	if err != nil || validityDays <= 0 {
+		logger.Printf("Invalid GIFT_CARD_VALIDITY_DAYS, using default of 365 days")
+		validityDays = 365
+	}
+	options.Validity = time.Duration(validityDays) * 24 * time.Hour
+
+	return giftcard.NewService(storeInstance, lifecycle, payments, policy, mailService, options, logger)
+}
+
+func configPricing(storeInstance store.Store, commissionService commission.CommissionService, promoService promo.PromoService) pricing.PricingService {
+	return pricing.NewService(storeInstance, pricing.HourlyEngine{}, commissionService, promoService, logger)
+}
+
+func configBookingSeries(storeInstance store.Store, lifecycle bookinglifecycle.LifecycleService, pricingService pricing.PricingService) bookingseries.SeriesService {
+	horizonDays, err := strconv.Atoi(readOptionalEnvVar("RECURRING_BOOKING_HORIZON_DAYS", "56"))
+	if err != nil || horizonDays <= 0 {
+		logger.Printf("Invalid RECURRING_BOOKING_HORIZON_DAYS, using default of 56 days")
+		horizonDays = 56
+	}
+
+	return bookingseries.NewService(storeInstance, lifecycle, pricingService, time.Duration(horizonDays)*24*time.Hour, logger)
+}
+
+func configCancellationPolicy(storeInstance store.Store, lifecycle bookinglifecycle.LifecycleService, payments payment.PaymentService) cancellationpolicy.PolicyService {
+	policy := cancellationpolicy.DefaultPolicy()
+
+	if policyJSON := readOptionalEnvVar("CANCELLATION_POLICY_JSON", ""); policyJSON != "" {
+		parsed, err := cancellationpolicy.ParsePolicy([]byte(policyJSON))
+		if err != nil {
+			logger.Printf("Invalid CANCELLATION_POLICY_JSON, using default policy: %v", err)
+		} else {
+			policy = parsed
+		}
+	}
+
+	return cancellationpolicy.NewService(storeInstance, lifecycle, payments, policy, logger)
+}
+
+func configNoShow(storeInstance store.Store, lifecycle bookinglifecycle.LifecycleService, payments payment.PaymentService) noshow.SettlementService {
+	policy := noshow.DefaultPolicy()
+
+	feeEnvVars := map[store.ServiceType]string{
+		store.ServiceTypeGeneral:   "NO_SHOW_FEE_GENERAL",
+		store.ServiceTypeDeep:      "NO_SHOW_FEE_DEEP",
+		store.ServiceTypeMoveInOut: "NO_SHOW_FEE_MOVE_IN_OUT",
+	}
+	for serviceType, envVar := range feeEnvVars {
+		raw := readOptionalEnvVar(envVar, "")
+		if raw == "" {
+			continue
+		}
+		fee, err := strconv.Atoi(raw)
+		if err != nil || fee < 0 {
+			logger.Printf("Invalid %s, using default of %d", envVar, policy.Fees[serviceType])
+			continue
+		}
+		policy.Fees[serviceType] = fee
+	}
+
+	sharePercent, err := strconv.Atoi(readOptionalEnvVar("NO_SHOW_CLEANER_SHARE_PERCENT", "50"))
+	if err != nil || sharePercent < 0 || sharePercent > 100 {
+		logger.Printf("Invalid NO_SHOW_CLEANER_SHARE_PERCENT, using default of 50")
+		sharePercent = 50
+	}
+	policy.CleanerSharePercent = sharePercent
+
+	windowHours, err := strconv.Atoi(readOptionalEnvVar("NO_SHOW_CONTEST_WINDOW_HOURS", "48"))
+	if err != nil || windowHours <= 0 {
+		logger.Printf("Invalid NO_SHOW_CONTEST_WINDOW_HOURS, using default of 48 hours")
+		windowHours = 48
+	}
+	policy.ContestWindow = time.Duration(windowHours) * time.Hour
+
+	return noshow.NewService(storeInstance, lifecycle, payments, policy, logger)
+}
+
+func configAvailability(storeInstance store.Store) availability.AvailabilityService {
+	bufferMinutes, err := strconv.Atoi(readOptionalEnvVar("BOOKING_BUFFER_MINUTES", "30"))
+	if err != nil || bufferMinutes < 0 {
+		logger.Printf("Invalid BOOKING_BUFFER_MINUTES, using default of 30 minutes")
+		bufferMinutes = 30
+	}
+
+	return availability.NewService(storeInstance, time.Duration(bufferMinutes)*time.Minute, logger)
+}
+
+func configBookingReschedule(storeInstance store.Store, lifecycle bookinglifecycle.LifecycleService) bookingreschedule.RescheduleService {
+	ttlHours, err := strconv.Atoi(readOptionalEnvVar("RESCHEDULE_REQUEST_TTL_HOURS", "48"))
+	if err != nil || ttlHours <= 0 {
+		logger.Printf("Invalid RESCHEDULE_REQUEST_TTL_HOURS, using default of 48 hours")
+		ttlHours = 48
+	}
+
+	return bookingreschedule.NewService(storeInstance, lifecycle, time.Duration(ttlHours)*time.Hour, logger)
+}
@@ -0,0 +1,14 @@
+package api
+
+import (
+	"net/http"
+
+	"cleanbuddy-api/sys/http/webhook"
+)
+
+// PaymentWebhookHandler receives webhook events from the payment provider
+func PaymentWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	initServices()
+
+	webhook.NewPaymentsHandler(logger, paymentInstance).ServeHTTP(w, r)
+}
@@ -46,6 +46,9 @@ func main() {
 	// Main GraphQL API endpoint
 	http.HandleFunc("/api", api.Handler)
 
+	// Payment provider webhooks, authenticated by their signature
+	http.HandleFunc("/webhooks/payments", api.PaymentWebhookHandler)
+
 	// GraphQL playground (disabled in production)
 	if environment != "production" {
 		http.HandleFunc("/api/playground", playground.Handler)
@@ -0,0 +1,106 @@
+package availability
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"cleanbuddy-api/res/localtime"
+	"cleanbuddy-api/res/store"
+)
+
+func (s *service) AvailableCleaners(ctx context.Context, query CleanerQuery) ([]*AvailableCleaner, error) {
+	if query.City == "" {
+		return nil, ErrLocationRequired
+	}
+	if query.Duration <= 0 || query.Duration > 24*time.Hour {
+		return nil, ErrInvalidDuration
+	}
+	start, err := localtime.ParseAt(query.Date, query.StartTime)
+	if err != nil {
+		return nil, ErrInvalidTimeRange
+	}
+
+	areas, err := s.store.ServiceAreas().FindMatchingAreas(ctx, query.City, query.Neighborhood, query.PostalCode)
+	if err != nil {
+		s.logger.Printf("Failed to find service areas for %s: %v", query.City, err)
+		return nil, fmt.Errorf("failed to find service areas: %w", err)
+	}
+
+	areaIDs := make([]string, 0, len(areas))
+	areasByProfile := make(map[string][]*store.ServiceArea)
+	for _, area := range areas {
+		areaIDs = append(areaIDs, area.ID)
+		areasByProfile[area.CleanerProfileID] = append(areasByProfile[area.CleanerProfileID], area)
+	}
+
+	profiles, err := s.store.Availability().GetAvailableCleaners(ctx, store.AvailableCleanersQuery{
+		Start:          start,
+		Duration:       query.Duration,
+		Buffer:         s.buffer,
+		ServiceAreaIDs: areaIDs,
+		Filters:        query.Filters,
+	})
+	if err != nil {
+		s.logger.Printf("Failed to get available cleaners: %v", err)
+		return nil, fmt.Errorf("failed to get available cleaners: %w", err)
+	}
+
+	date, startTime, endTime := wallClockRange(start, start.Add(query.Duration))
+
+	cleaners := make([]*AvailableCleaner, 0, len(profiles))
+	for _, profile := range profiles {
+		area := store.MatchServiceArea(areasByProfile[profile.ID], query.City, query.Neighborhood, query.PostalCode)
+		if area == nil {
+			continue
+		}
+
+		// The store only rules out conflicts; the job must also fall inside the cleaner's available windows
+		available, err := s.IsAvailable(ctx, profile.ID, date, startTime, endTime)
+		if err != nil {
+			return nil, err
+		}
+		if !available {
+			continue
+		}
+		cleaners = append(cleaners, &AvailableCleaner{
+			CleanerProfile: profile,
+			ServiceArea:    area,
+			TravelFee:      area.TravelFee,
+		})
+	}
+
+	sort.SliceStable(cleaners, func(i, j int) bool {
+		return ranksBefore(cleaners[i], cleaners[j])
+	})
+
+	return cleaners, nil
+}
+
+// wallClockRange converts a job into the calendar day and wall-clock times
+// availability entries use; jobs running past midnight end at "24:00"
+func wallClockRange(start, end time.Time) (time.Time, string, string) {
+	date := localtime.Date(start.In(localtime.Location()))
+
+	endTime := localtime.ClockOf(end).String()
+	if !localtime.Date(end.In(localtime.Location())).Equal(date) {
+		endTime = "24:00"
+	}
+	return date, localtime.ClockOf(start).String(), endTime
+}
+
+// ranksBefore orders cleaners by rating, then by travel fee, then by areas the
+// cleaner marked as preferred, then by experience
+func ranksBefore(a, b *AvailableCleaner) bool {
+	if a.CleanerProfile.AverageRating != b.CleanerProfile.AverageRating {
+		return a.CleanerProfile.AverageRating > b.CleanerProfile.AverageRating
+	}
+	if a.TravelFee != b.TravelFee {
+		return a.TravelFee < b.TravelFee
+	}
+	if a.ServiceArea.IsPreferred != b.ServiceArea.IsPreferred {
+		return a.ServiceArea.IsPreferred
+	}
+	return a.CleanerProfile.CompletedBookings > b.CleanerProfile.CompletedBookings
+}
@@ -0,0 +1,101 @@
+package availability
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+var (
+	ErrAvailabilityNotFound   = errors.New("availability not found")
+	ErrCleanerProfileNotFound = errors.New("cleaner profile not found")
+	ErrAccessDenied           = errors.New("you can only manage your own availability")
+	ErrInvalidTimeRange       = errors.New("times must use HH:MM and start before they end")
+	ErrInvalidRecurrence      = errors.New("recurring entries must repeat weekly and end after they start")
+	ErrInvalidDateRange       = errors.New("start date must not be after end date")
+	ErrDateRangeTooLong       = errors.New("date range is too long")
+	ErrServiceNotFound        = errors.New("service not found")
+	ErrInvalidGranularity     = errors.New("granularity must be between 5 and 240 minutes")
+	ErrInvalidDuration        = errors.New("duration must be positive and at most 24 hours")
+	ErrLocationRequired       = errors.New("city is required")
+)
+
+// maxExpansionDays caps how many days a single expansion may cover
+const maxExpansionDays = 366
+
+// AvailabilityService manages cleaner availability schedules.
+// A cleaner is available when a (possibly weekly recurring) available window
+// covers the requested time and no unavailable entry overlaps it.
+type AvailabilityService interface {
+	// Get retrieves a single availability entry
+	Get(ctx context.Context, id string) (*store.Availability, error)
+
+	// ListForCleaner lists a cleaner's entries; when both filter dates are set,
+	// weekly recurring entries are expanded into one entry per occurrence
+	ListForCleaner(ctx context.Context, cleanerProfileID string, filters store.AvailabilityFilters) ([]*store.Availability, error)
+
+	// Expand returns every availability occurrence between two dates (inclusive), ordered by date and start time
+	Expand(ctx context.Context, cleanerProfileID string, startDate, endDate time.Time) ([]*store.Availability, error)
+
+	// IsAvailable reports whether the cleaner can work the given time range on a date
+	IsAvailable(ctx context.Context, cleanerProfileID string, date time.Time, startTime, endTime string) (bool, error)
+
+	// Create adds entries to the actor's own schedule atomically
+	Create(ctx context.Context, actor *store.User, entries []*store.Availability) ([]*store.Availability, error)
+
+	// Update changes an entry owned by the actor
+	Update(ctx context.Context, actor *store.User, entry *store.Availability) (*store.Availability, error)
+
+	// Delete removes an entry owned by the actor
+	Delete(ctx context.Context, actor *store.User, id string) error
+
+	// AvailableSlots computes the bookable start times per day for a service
+	AvailableSlots(ctx context.Context, query SlotQuery) ([]*DaySlots, error)
+
+	// AvailableCleaners finds the cleaners serving a location who are free for the
+	// requested time, ranked, each with the travel fee that applies to the location.
+	// Like IsAvailable, the time must fall inside the cleaner's available windows.
+	AvailableCleaners(ctx context.Context, query CleanerQuery) ([]*AvailableCleaner, error)
+
+	// ProfileForUser returns the cleaner profile of a user
+	ProfileForUser(ctx context.Context, userID string) (*store.CleanerProfile, error)
+}
+
+// SlotQuery describes the service to fit into a cleaner's schedule
+type SlotQuery struct {
+	CleanerProfileID string
+	ServiceType      store.ServiceType
+	AddOns           []store.ServiceAddOn
+	StartDate        time.Time
+	EndDate          time.Time
+
+	// Granularity is the spacing between candidate start times (default 30 minutes)
+	Granularity time.Duration
+}
+
+// DaySlots lists the bookable start times ("15:04") of one day
+type DaySlots struct {
+	Date       time.Time
+	StartTimes []string
+}
+
+// CleanerQuery describes the job to find a free cleaner for
+type CleanerQuery struct {
+	Date         time.Time
+	StartTime    string
+	Duration     time.Duration
+	City         string
+	Neighborhood string
+	PostalCode   string
+	Filters      store.CleanerProfileFilters
+}
+
+// AvailableCleaner is a cleaner free for a requested job together with the
+// service area covering the job's location and its travel fee (in bani)
+type AvailableCleaner struct {
+	CleanerProfile *store.CleanerProfile
+	ServiceArea    *store.ServiceArea
+	TravelFee      int
+}
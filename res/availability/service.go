@@ -0,0 +1,315 @@
+package availability
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/localtime"
+	"cleanbuddy-api/res/store"
+)
+
+type service struct {
+	store  store.Store
+	buffer time.Duration
+	logger *log.Logger
+}
+
+// NewService creates a new AvailabilityService.
+// buffer is the minimum gap kept between a cleaner's consecutive jobs.
+func NewService(dataStore store.Store, buffer time.Duration, logger *log.Logger) AvailabilityService {
+	return &service{
+		store:  dataStore,
+		buffer: buffer,
+		logger: logger,
+	}
+}
+
+func (s *service) Get(ctx context.Context, id string) (*store.Availability, error) {
+	entry, err := s.store.Availability().Get(ctx, id)
+	if err != nil {
+		s.logger.Printf("Failed to get availability %s: %v", id, err)
+		return nil, ErrAvailabilityNotFound
+	}
+	return entry, nil
+}
+
+func (s *service) ListForCleaner(ctx context.Context, cleanerProfileID string, filters store.AvailabilityFilters) ([]*store.Availability, error) {
+	if filters.StartDate == nil || filters.EndDate == nil {
+		entries, err := s.store.Availability().GetByCleanerProfile(ctx, cleanerProfileID, filters)
+		if err != nil {
+			s.logger.Printf("Failed to list availability for cleaner profile %s: %v", cleanerProfileID, err)
+			return nil, fmt.Errorf("failed to list availability: %w", err)
+		}
+		return entries, nil
+	}
+
+	entries, err := s.Expand(ctx, cleanerProfileID, *filters.StartDate, *filters.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if filters.Type != nil {
+		filtered := entries[:0]
+		for _, entry := range entries {
+			if entry.Type == *filters.Type {
+				filtered = append(filtered, entry)
+			}
+		}
+		entries = filtered
+	}
+
+	return paginate(entries, filters.Limit, filters.Offset), nil
+}
+
+func (s *service) Expand(ctx context.Context, cleanerProfileID string, startDate, endDate time.Time) ([]*store.Availability, error) {
+	start, end := dateOnly(startDate), dateOnly(endDate)
+	if start.After(end) {
+		return nil, ErrInvalidDateRange
+	}
+	if end.Sub(start) > maxExpansionDays*24*time.Hour {
+		return nil, ErrDateRangeTooLong
+	}
+
+	// Include entries stored with a time of day on the last date
+	endOfRange := end.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	entries, err := s.store.Availability().GetEffectiveInRange(ctx, cleanerProfileID, start, endOfRange)
+	if err != nil {
+		s.logger.Printf("Failed to get availability for cleaner profile %s: %v", cleanerProfileID, err)
+		return nil, fmt.Errorf("failed to get availability: %w", err)
+	}
+
+	var occurrences []*store.Availability
+	for _, entry := range entries {
+		occurrences = append(occurrences, expandEntry(entry, start, end)...)
+	}
+
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		if !occurrences[i].Date.Equal(occurrences[j].Date) {
+			return occurrences[i].Date.Before(occurrences[j].Date)
+		}
+		return occurrences[i].StartTime < occurrences[j].StartTime
+	})
+
+	return occurrences, nil
+}
+
+func (s *service) IsAvailable(ctx context.Context, cleanerProfileID string, date time.Time, startTime, endTime string) (bool, error) {
+	if err := validateTimeRange(startTime, endTime); err != nil {
+		return false, err
+	}
+
+	occurrences, err := s.Expand(ctx, cleanerProfileID, date, date)
+	if err != nil {
+		return false, err
+	}
+
+	return coversRange(occurrences, startTime, endTime), nil
+}
+
+func (s *service) Create(ctx context.Context, actor *store.User, entries []*store.Availability) ([]*store.Availability, error) {
+	profile, err := s.ProfileForUser(ctx, actor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if err := validateEntry(entry); err != nil {
+			return nil, err
+		}
+		entry.ID = uuid.New().String()
+		entry.CleanerProfileID = profile.ID
+	}
+
+	if err := s.store.Availability().CreateMany(ctx, entries); err != nil {
+		s.logger.Printf("Failed to create availability for cleaner profile %s: %v", profile.ID, err)
+		return nil, fmt.Errorf("failed to create availability: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (s *service) Update(ctx context.Context, actor *store.User, entry *store.Availability) (*store.Availability, error) {
+	if err := s.checkOwnership(ctx, actor, entry); err != nil {
+		return nil, err
+	}
+	if err := validateEntry(entry); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Availability().Update(ctx, entry); err != nil {
+		s.logger.Printf("Failed to update availability %s: %v", entry.ID, err)
+		return nil, fmt.Errorf("failed to update availability: %w", err)
+	}
+
+	return entry, nil
+}
+
+func (s *service) Delete(ctx context.Context, actor *store.User, id string) error {
+	entry, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.checkOwnership(ctx, actor, entry); err != nil {
+		return err
+	}
+
+	if err := s.store.Availability().Delete(ctx, id); err != nil {
+		s.logger.Printf("Failed to delete availability %s: %v", id, err)
+		return fmt.Errorf("failed to delete availability: %w", err)
+	}
+	return nil
+}
+
+func (s *service) ProfileForUser(ctx context.Context, userID string) (*store.CleanerProfile, error) {
+	profile, err := s.store.CleanerProfiles().GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, ErrCleanerProfileNotFound
+	}
+	return profile, nil
+}
+
+// checkOwnership allows the cleaner who owns the entry and global admins
+func (s *service) checkOwnership(ctx context.Context, actor *store.User, entry *store.Availability) error {
+	if actor.IsGlobalAdmin() {
+		return nil
+	}
+
+	profile, err := s.ProfileForUser(ctx, actor.ID)
+	if err != nil || profile.ID != entry.CleanerProfileID {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// expandEntry returns the occurrences of an entry that fall between start and end (inclusive)
+func expandEntry(entry *store.Availability, start, end time.Time) []*store.Availability {
+	base := dateOnly(entry.Date)
+
+	if !entry.IsRecurring || entry.RecurrencePattern != store.RecurrencePatternWeekly {
+		if base.Before(start) || base.After(end) {
+			return nil
+		}
+		return []*store.Availability{entry}
+	}
+
+	last := end
+	if entry.RecurrenceEnd != nil && dateOnly(*entry.RecurrenceEnd).Before(last) {
+		last = dateOnly(*entry.RecurrenceEnd)
+	}
+
+	// Jump to the first occurrence on or after start
+	occurrence := base
+	if occurrence.Before(start) {
+		weeks := (int(start.Sub(base).Hours()/24) + 6) / 7
+		occurrence = base.AddDate(0, 0, weeks*7)
+	}
+
+	var occurrences []*store.Availability
+	for ; !occurrence.After(last); occurrence = occurrence.AddDate(0, 0, 7) {
+		copied := *entry
+		copied.Date = occurrence
+		occurrences = append(occurrences, &copied)
+	}
+	return occurrences
+}
+
+// coversRange reports whether the merged available windows of a single day cover
+// the time range and no unavailable entry overlaps it
+func coversRange(occurrences []*store.Availability, startTime, endTime string) bool {
+	var windows [][2]string
+	for _, entry := range occurrences {
+		if entry.Type == store.AvailabilityTypeUnavailable {
+			if entry.StartTime < endTime && startTime < entry.EndTime {
+				return false
+			}
+			continue
+		}
+		windows = append(windows, [2]string{entry.StartTime, entry.EndTime})
+	}
+
+	sort.Slice(windows, func(i, j int) bool { return windows[i][0] < windows[j][0] })
+
+	// Walk the windows in order, extending coverage while they touch or overlap
+	covered := startTime
+	for _, window := range windows {
+		if window[0] > covered {
+			break
+		}
+		if window[1] > covered {
+			covered = window[1]
+		}
+		if covered >= endTime {
+			return true
+		}
+	}
+	return false
+}
+
+func validateEntry(entry *store.Availability) error {
+	if err := validateTimeRange(entry.StartTime, entry.EndTime); err != nil {
+		return err
+	}
+
+	// Store canonical clock times so string comparisons order correctly
+	start, _ := localtime.Parse(entry.StartTime)
+	end, _ := localtime.Parse(entry.EndTime)
+	entry.StartTime, entry.EndTime = start.String(), end.String()
+	entry.Date = dateOnly(entry.Date)
+	if entry.RecurrenceEnd != nil {
+		recurrenceEnd := dateOnly(*entry.RecurrenceEnd)
+		entry.RecurrenceEnd = &recurrenceEnd
+	}
+
+	if !entry.IsRecurring {
+		entry.RecurrencePattern = store.RecurrencePatternNone
+		entry.RecurrenceEnd = nil
+		return nil
+	}
+
+	if entry.RecurrencePattern == "" {
+		entry.RecurrencePattern = store.RecurrencePatternWeekly
+	}
+	if entry.RecurrencePattern != store.RecurrencePatternWeekly {
+		return ErrInvalidRecurrence
+	}
+	if entry.RecurrenceEnd != nil && dateOnly(*entry.RecurrenceEnd).Before(dateOnly(entry.Date)) {
+		return ErrInvalidRecurrence
+	}
+	return nil
+}
+
+func validateTimeRange(startTime, endTime string) error {
+	start, err := localtime.Parse(startTime)
+	if err != nil {
+		return ErrInvalidTimeRange
+	}
+	end, err := localtime.Parse(endTime)
+	if err != nil {
+		return ErrInvalidTimeRange
+	}
+	if start.Minutes() >= end.Minutes() {
+		return ErrInvalidTimeRange
+	}
+	return nil
+}
+
+// dateOnly strips the time of day so dates compare by calendar day
+func dateOnly(t time.Time) time.Time {
+	return localtime.Date(t)
+}
+
+func paginate(entries []*store.Availability, limit, offset int) []*store.Availability {
+	if offset > len(entries) {
+		offset = len(entries)
+	}
+	entries = entries[offset:]
+	if limit > 0 && limit < len(entries) {
+		entries = entries[:limit]
+	}
+	return entries
+}
@@ -0,0 +1,200 @@
+package availability
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"cleanbuddy-api/res/localtime"
+	"cleanbuddy-api/res/store"
+)
+
+const (
+	defaultGranularity = 30 * time.Minute
+	minGranularity     = 5 * time.Minute
+	maxGranularity     = 240 * time.Minute
+)
+
+// interval is a half-open range of instants
+type interval struct {
+	start, end time.Time
+}
+
+func (i interval) overlaps(other interval) bool {
+	return i.start.Before(other.end) && other.start.Before(i.end)
+}
+
+func (s *service) AvailableSlots(ctx context.Context, query SlotQuery) ([]*DaySlots, error) {
+	granularity := query.Granularity
+	if granularity == 0 {
+		granularity = defaultGranularity
+	}
+	if granularity < minGranularity || granularity > maxGranularity {
+		return nil, ErrInvalidGranularity
+	}
+
+	profile, err := s.store.CleanerProfiles().Get(ctx, query.CleanerProfileID)
+	if err != nil {
+		return nil, ErrCleanerProfileNotFound
+	}
+
+	duration, err := s.serviceDuration(ctx, query.ServiceType, query.AddOns)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences, err := s.Expand(ctx, profile.ID, query.StartDate, query.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := dateOnly(query.StartDate), dateOnly(query.EndDate)
+
+	// Neighbouring days are included so jobs spanning midnight still block time
+	bookings, err := s.store.Bookings().GetByDateRange(ctx, profile.UserID, start.AddDate(0, 0, -1), end.AddDate(0, 0, 2))
+	if err != nil {
+		s.logger.Printf("Failed to get bookings for cleaner %s: %v", profile.UserID, err)
+		return nil, fmt.Errorf("failed to get bookings: %w", err)
+	}
+
+	occurrencesByDay := make(map[time.Time][]*store.Availability)
+	for _, occurrence := range occurrences {
+		day := dateOnly(occurrence.Date)
+		occurrencesByDay[day] = append(occurrencesByDay[day], occurrence)
+	}
+
+	now := time.Now()
+	step := int(granularity.Minutes())
+
+	var days []*DaySlots
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		windows, blocked := splitOccurrences(day, occurrencesByDay[day])
+		blocked = append(blocked, s.bookedIntervals(bookings)...)
+
+		daySlots := &DaySlots{Date: day, StartTimes: []string{}}
+		for _, window := range windows {
+			// Candidates follow the wall clock so slots stay on the grid across DST changes
+			first := (window.clocks[0].Minutes() + step - 1) / step * step
+			for minutes := first; minutes < window.clocks[1].Minutes(); minutes += step {
+				clock := localtime.FromMinutes(minutes)
+				candidate, err := localtime.At(day, clock)
+				if err != nil {
+					// The clock skips this time when DST starts
+					continue
+				}
+				slot := interval{start: candidate, end: candidate.Add(duration)}
+				if slot.start.Before(window.start) || slot.end.After(window.end) {
+					continue
+				}
+				if candidate.Before(now) || overlapsAny(slot, blocked) {
+					continue
+				}
+				daySlots.StartTimes = append(daySlots.StartTimes, clock.String())
+			}
+		}
+		days = append(days, daySlots)
+	}
+
+	return days, nil
+}
+
+// serviceDuration returns the base hours of the service plus the estimated hours of the add-ons
+func (s *service) serviceDuration(ctx context.Context, serviceType store.ServiceType, addOns []store.ServiceAddOn) (time.Duration, error) {
+	definition, err := s.store.Services().GetServiceDefinition(ctx, serviceType)
+	if err != nil || !definition.IsActive {
+		return 0, ErrServiceNotFound
+	}
+
+	hours := definition.BaseHours
+	if len(addOns) > 0 {
+		addOnDefs, err := s.store.Services().ListAddOnDefinitions(ctx, true)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get add-on definitions: %w", err)
+		}
+		requested := make(map[store.ServiceAddOn]bool, len(addOns))
+		for _, addOn := range addOns {
+			requested[addOn] = true
+		}
+		for _, def := range addOnDefs {
+			if requested[def.AddOn] {
+				hours += def.EstimatedHours
+			}
+		}
+	}
+
+	return time.Duration(hours * float64(time.Hour)), nil
+}
+
+// bookedIntervals returns the time taken by active bookings, widened by the buffer
+func (s *service) bookedIntervals(bookings []*store.Booking) []interval {
+	var intervals []interval
+	for _, booking := range bookings {
+		if booking.Status != store.BookingStatusPending &&
+			booking.Status != store.BookingStatusConfirmed &&
+			booking.Status != store.BookingStatusInProgress {
+			continue
+		}
+
+		intervals = append(intervals, interval{
+			start: booking.StartsAt().Add(-s.buffer),
+			end:   booking.EndsAt().Add(s.buffer),
+		})
+	}
+	return intervals
+}
+
+// window is a merged available range of a day, kept both as instants and wall-clock times
+type window struct {
+	interval
+	clocks [2]localtime.Clock
+}
+
+// splitOccurrences returns the merged available windows and the unavailable intervals of a day
+func splitOccurrences(day time.Time, occurrences []*store.Availability) ([]window, []interval) {
+	var windows []window
+	var blocked []interval
+	for _, occurrence := range occurrences {
+		startClock, startErr := localtime.Parse(occurrence.StartTime)
+		endClock, endErr := localtime.Parse(occurrence.EndTime)
+		if startErr != nil || endErr != nil || startClock.Minutes() >= endClock.Minutes() {
+			continue
+		}
+
+		// Times inside the spring-forward gap resolve to the first instant after it
+		span := interval{
+			start: localtime.AtOrAfter(day, startClock),
+			end:   localtime.AtOrAfter(day, endClock),
+		}
+		if occurrence.Type == store.AvailabilityTypeUnavailable {
+			blocked = append(blocked, span)
+		} else {
+			windows = append(windows, window{interval: span, clocks: [2]localtime.Clock{startClock, endClock}})
+		}
+	}
+
+	sort.Slice(windows, func(i, j int) bool { return windows[i].start.Before(windows[j].start) })
+
+	var merged []window
+	for _, w := range windows {
+		if last := len(merged) - 1; last >= 0 && !w.start.After(merged[last].end) {
+			if w.end.After(merged[last].end) {
+				merged[last].end = w.end
+				merged[last].clocks[1] = w.clocks[1]
+			}
+			continue
+		}
+		merged = append(merged, w)
+	}
+
+	return merged, blocked
+}
+
+func overlapsAny(slot interval, intervals []interval) bool {
+	for _, other := range intervals {
+		if slot.overlaps(other) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package availability
+
+import (
+	"context"
+	"io"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"cleanbuddy-api/res/localtime"
+	"cleanbuddy-api/res/store"
+)
+
+// fakeStore serves one cleaner with a two hour service and a one hour oven add-on
+type fakeStore struct {
+	store.Store
+	availability *fakeAvailability
+	bookings     *fakeBookings
+}
+
+func (f *fakeStore) CleanerProfiles() store.CleanerProfileStore { return &fakeProfiles{} }
+func (f *fakeStore) Services() store.ServiceStore               { return &fakeServices{} }
+func (f *fakeStore) Availability() store.AvailabilityStore      { return f.availability }
+func (f *fakeStore) Bookings() store.BookingStore               { return f.bookings }
+
+type fakeProfiles struct {
+	store.CleanerProfileStore
+}
+
+func (f *fakeProfiles) Get(ctx context.Context, id string) (*store.CleanerProfile, error) {
+	return &store.CleanerProfile{ID: id, UserID: "cleaner"}, nil
+}
+
+type fakeServices struct {
+	store.ServiceStore
+}
+
+func (f *fakeServices) GetServiceDefinition(ctx context.Context, serviceType store.ServiceType) (*store.ServiceDefinition, error) {
+	return &store.ServiceDefinition{Type: serviceType, BaseHours: 2, IsActive: true}, nil
+}
+
+func (f *fakeServices) ListAddOnDefinitions(ctx context.Context, activeOnly bool) ([]*store.ServiceAddOnDefinition, error) {
+	return []*store.ServiceAddOnDefinition{{AddOn: store.ServiceAddOnOven, EstimatedHours: 1}}, nil
+}
+
+type fakeAvailability struct {
+	store.AvailabilityStore
+	entries []*store.Availability
+}
+
+func (f *fakeAvailability) GetEffectiveInRange(ctx context.Context, cleanerProfileID string, startDate, endDate time.Time) ([]*store.Availability, error) {
+	return f.entries, nil
+}
+
+type fakeBookings struct {
+	store.BookingStore
+	bookings []*store.Booking
+}
+
+func (f *fakeBookings) GetByDateRange(ctx context.Context, cleanerID string, startDate, endDate time.Time) ([]*store.Booking, error) {
+	return f.bookings, nil
+}
+
+func TestAvailableSlots(t *testing.T) {
+	// A day far enough ahead that no candidate is in the past, clear of daylight
+	// saving changes so the job running overnight ends on the hour expected
+	day := dateOnly(time.Now().AddDate(0, 0, 7))
+	for {
+		previousNoon, _ := localtime.At(day.AddDate(0, 0, -1), localtime.Clock{Hour: 12})
+		noon, _ := localtime.At(day, localtime.Clock{Hour: 12})
+		if noon.Sub(previousNoon) == 24*time.Hour {
+			break
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+
+	entry := func(kind store.AvailabilityType, startTime, endTime string) *store.Availability {
+		return &store.Availability{Type: kind, Date: day, StartTime: startTime, EndTime: endTime}
+	}
+	booking := func(date time.Time, startTime string, hours float64, status store.BookingStatus) *store.Booking {
+		start, err := localtime.ParseAt(date, startTime)
+		if err != nil {
+			t.Fatalf("ParseAt(%s) error = %v", startTime, err)
+		}
+		return &store.Booking{ScheduledStart: start, Duration: hours, Status: status}
+	}
+	workingHours := []*store.Availability{entry(store.AvailabilityTypeAvailable, "08:00", "14:00")}
+	fullDay := []string{"08:00", "08:30", "09:00", "09:30", "10:00", "10:30", "11:00", "11:30", "12:00"}
+
+	tests := []struct {
+		name     string
+		entries  []*store.Availability
+		bookings []*store.Booking
+		addOns   []store.ServiceAddOn
+		want     []string
+	}{
+		{
+			name:    "jobs end by the close of the window",
+			entries: workingHours,
+			want:    fullDay,
+		},
+		{
+			name:    "add-ons lengthen the job",
+			entries: workingHours,
+			addOns:  []store.ServiceAddOn{store.ServiceAddOnOven},
+			want:    []string{"08:00", "08:30", "09:00", "09:30", "10:00", "10:30", "11:00"},
+		},
+		{
+			name:    "window shorter than the job",
+			entries: []*store.Availability{entry(store.AvailabilityTypeAvailable, "08:00", "09:30")},
+			want:    []string{},
+		},
+		{
+			name:    "window opening off the grid",
+			entries: []*store.Availability{entry(store.AvailabilityTypeAvailable, "08:15", "12:00")},
+			want:    []string{"08:30", "09:00", "09:30", "10:00"},
+		},
+		{
+			name: "touching windows merge so jobs span the seam",
+			entries: []*store.Availability{
+				entry(store.AvailabilityTypeAvailable, "10:00", "12:00"),
+				entry(store.AvailabilityTypeAvailable, "08:00", "10:00"),
+			},
+			want: []string{"08:00", "08:30", "09:00", "09:30", "10:00"},
+		},
+		{
+			name: "unavailable time inside the window",
+			entries: []*store.Availability{
+				entry(store.AvailabilityTypeAvailable, "08:00", "14:00"),
+				entry(store.AvailabilityTypeUnavailable, "11:00", "12:00"),
+			},
+			want: []string{"08:00", "08:30", "09:00", "12:00"},
+		},
+		{
+			name:     "booking blocks its time and the buffer around it",
+			entries:  workingHours,
+			bookings: []*store.Booking{booking(day, "10:00", 1, store.BookingStatusConfirmed)},
+			want:     []string{"11:30", "12:00"},
+		},
+		{
+			name:    "overlapping bookings block their union",
+			entries: workingHours,
+			bookings: []*store.Booking{
+				booking(day, "09:00", 1, store.BookingStatusConfirmed),
+				booking(day, "09:30", 1.5, store.BookingStatusPending),
+			},
+			want: []string{"11:30", "12:00"},
+		},
+		{
+			name:     "buffer ending at a slot leaves it free",
+			entries:  workingHours,
+			bookings: []*store.Booking{booking(day, "06:00", 1.5, store.BookingStatusInProgress)},
+			want:     fullDay,
+		},
+		{
+			name:     "booking running past midnight blocks the next morning",
+			entries:  workingHours,
+			bookings: []*store.Booking{booking(day.AddDate(0, 0, -1), "23:00", 10, store.BookingStatusConfirmed)},
+			want:     []string{"09:30", "10:00", "10:30", "11:00", "11:30", "12:00"},
+		},
+		{
+			name:    "cancelled and completed bookings free their time",
+			entries: workingHours,
+			bookings: []*store.Booking{
+				booking(day, "09:00", 2, store.BookingStatusCancelled),
+				booking(day, "11:00", 2, store.BookingStatusCompleted),
+			},
+			want: fullDay,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &service{
+				store: &fakeStore{
+					availability: &fakeAvailability{entries: tt.entries},
+					bookings:     &fakeBookings{bookings: tt.bookings},
+				},
+				buffer: 30 * time.Minute,
+				logger: log.New(io.Discard, "", 0),
+			}
+
+			days, err := s.AvailableSlots(context.Background(), SlotQuery{
+				CleanerProfileID: "profile",
+				ServiceType:      store.ServiceTypeGeneral,
+				AddOns:           tt.addOns,
+				StartDate:        day,
+				EndDate:          day,
+			})
+			if err != nil {
+				t.Fatalf("AvailableSlots() error = %v", err)
+			}
+			if len(days) != 1 {
+				t.Fatalf("AvailableSlots() returned %d days, want 1", len(days))
+			}
+			if !reflect.DeepEqual(days[0].StartTimes, tt.want) {
+				t.Errorf("AvailableSlots() = %v, want %v", days[0].StartTimes, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package bookinglifecycle
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+var (
+	ErrBookingNotFound           = errors.New("booking not found")
+	ErrTransitionNotAllowed      = errors.New("booking status transition not allowed")
+	ErrActorNotAllowed           = errors.New("actor is not allowed to perform this transition")
+	ErrConcurrentTransition      = errors.New("booking status was changed by another request")
+	ErrCancellationReasonMissing = errors.New("cancellation reason is required")
+)
+
+// LifecycleService owns the booking status state machine.
+// Every status change of a booking must go through Transition so that the
+// allowed transitions, actor permissions, timestamps, history and side effects
+// are applied consistently.
+type LifecycleService interface {
+	// Transition moves a booking to a new status on behalf of an actor
+	Transition(ctx context.Context, req TransitionRequest) (*store.Booking, error)
+
+	// RecordCreated records the initial status of a newly created booking
+	RecordCreated(ctx context.Context, booking *store.Booking, actor *store.User) error
+
+	// CanTransition reports whether the actor may move the booking to the given status
+	CanTransition(booking *store.Booking, actor *store.User, to store.BookingStatus) error
+
+	// ActorRole resolves the role an actor plays on a booking; ok is false for unrelated users
+	ActorRole(booking *store.Booking, actor *store.User) (role store.BookingActorRole, ok bool)
+
+	// History returns the recorded status changes of a booking, oldest first
+	History(ctx context.Context, bookingID string) ([]*store.BookingStatusHistory, error)
+
+	// OnTransition registers a side effect fired after a booking enters the given status
+	OnTransition(to store.BookingStatus, hook TransitionHook)
+
+	// OnApply registers extra booking changes made when a booking enters the given status.
+	// They run before the transition is persisted, so they commit or fail with it.
+	OnApply(to store.BookingStatus, apply ApplyFunc)
+}
+
+// TransitionRequest describes a requested status change
+type TransitionRequest struct {
+	BookingID string
+	To        store.BookingStatus
+
+	// Actor is the user triggering the change; nil means the system
+	Actor *store.User
+
+	// Cancellation details (required when To is cancelled)
+	CancellationReason *store.CancellationReason
+	Note               *string
+
+	// CleanerNotes are stored when completing a booking
+	CleanerNotes *string
+}
+
+// TransitionEvent is passed to side effects after a transition is persisted
+type TransitionEvent struct {
+	Booking    *store.Booking
+	FromStatus store.BookingStatus
+	ToStatus   store.BookingStatus
+	Actor      *store.User
+	ActorRole  store.BookingActorRole
+}
+
+// ApplyFunc changes the booking as part of a transition, alongside the rule's own changes
+type ApplyFunc func(booking *store.Booking, req TransitionRequest, now time.Time)
+
+// TransitionHook is a side effect fired after a transition is committed.
+// Hook errors are logged and never roll back the transition.
+type TransitionHook func(ctx context.Context, event TransitionEvent) error
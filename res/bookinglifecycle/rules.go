@@ -0,0 +1,117 @@
+package bookinglifecycle
+
+import (
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+// transitionRule defines who may move a booking into a status and what changes on the booking
+type transitionRule struct {
+	allowedActors []store.BookingActorRole
+	apply         ApplyFunc
+}
+
+// transitions lists every allowed status change: from -> to -> rule
+var transitions = map[store.BookingStatus]map[store.BookingStatus]transitionRule{
+	store.BookingStatusPending: {
+		store.BookingStatusConfirmed: {
+			allowedActors: []store.BookingActorRole{store.BookingActorRoleCleaner},
+			apply:         applyConfirmed,
+		},
+		store.BookingStatusCancelled: {
+			allowedActors: []store.BookingActorRole{
+				store.BookingActorRoleCustomer,
+				store.BookingActorRoleCleaner,
+				store.BookingActorRoleAdmin,
+				store.BookingActorRoleSystem,
+			},
+			apply: applyCancelled,
+		},
+	},
+	store.BookingStatusConfirmed: {
+		store.BookingStatusInProgress: {
+			allowedActors: []store.BookingActorRole{store.BookingActorRoleCleaner},
+			apply:         applyInProgress,
+		},
+		store.BookingStatusNoShow: {
+			allowedActors: []store.BookingActorRole{store.BookingActorRoleCleaner},
+			apply:         applyNoShow,
+		},
+		store.BookingStatusCancelled: {
+			allowedActors: []store.BookingActorRole{
+				store.BookingActorRoleCustomer,
+				store.BookingActorRoleCleaner,
+				store.BookingActorRoleAdmin,
+				store.BookingActorRoleSystem,
+			},
+			apply: applyCancelled,
+		},
+	},
+	store.BookingStatusInProgress: {
+		store.BookingStatusCompleted: {
+			allowedActors: []store.BookingActorRole{store.BookingActorRoleCleaner},
+			apply:         applyCompleted,
+		},
+		// Only an admin can cancel a job that is already underway
+		store.BookingStatusCancelled: {
+			allowedActors: []store.BookingActorRole{store.BookingActorRoleAdmin},
+			apply:         applyCancelled,
+		},
+	},
+}
+
+func applyConfirmed(booking *store.Booking, req TransitionRequest, now time.Time) {
+	booking.ConfirmedAt = &now
+}
+
+func applyInProgress(booking *store.Booking, req TransitionRequest, now time.Time) {
+	booking.StartedAt = &now
+}
+
+func applyCompleted(booking *store.Booking, req TransitionRequest, now time.Time) {
+	booking.CompletedAt = &now
+	if req.CleanerNotes != nil {
+		booking.CleanerNotes = *req.CleanerNotes
+	}
+}
+
+func applyNoShow(booking *store.Booking, req TransitionRequest, now time.Time) {
+	// No dedicated timestamp, the history entry records when it happened
+}
+
+func applyCancelled(booking *store.Booking, req TransitionRequest, now time.Time) {
+	booking.CancelledAt = &now
+	booking.CancellationReason = req.CancellationReason
+	if req.Note != nil {
+		booking.CancellationNote = *req.Note
+	}
+	if req.Actor != nil {
+		booking.CancelledByID = &req.Actor.ID
+	}
+}
+
+// actorRoleFor resolves the role an actor plays on a booking.
+// Participants take precedence over admin so an admin booking for themselves acts as customer.
+func actorRoleFor(booking *store.Booking, actor *store.User) (store.BookingActorRole, bool) {
+	switch {
+	case actor == nil:
+		return store.BookingActorRoleSystem, true
+	case actor.ID == booking.CleanerID:
+		return store.BookingActorRoleCleaner, true
+	case actor.ID == booking.CustomerID:
+		return store.BookingActorRoleCustomer, true
+	case actor.IsGlobalAdmin():
+		return store.BookingActorRoleAdmin, true
+	}
+	return "", false
+}
+
+func isActorAllowed(rule transitionRule, role store.BookingActorRole) bool {
+	for _, allowed := range rule.allowedActors {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
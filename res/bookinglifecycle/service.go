@@ -0,0 +1,216 @@
+package bookinglifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/store"
+)
+
+type service struct {
+	store  store.Store
+	logger *log.Logger
+
+	hooksMu  sync.RWMutex
+	hooks    map[store.BookingStatus][]TransitionHook
+	appliers map[store.BookingStatus][]ApplyFunc
+}
+
+// NewService creates a new LifecycleService with the built-in side effects registered
+func NewService(dataStore store.Store, logger *log.Logger) LifecycleService {
+	s := &service{
+		store:    dataStore,
+		logger:   logger,
+		hooks:    make(map[store.BookingStatus][]TransitionHook),
+		appliers: make(map[store.BookingStatus][]ApplyFunc),
+	}
+
+	s.OnTransition(store.BookingStatusCompleted, s.recordCompletedStats)
+	s.OnTransition(store.BookingStatusCancelled, s.recordCancelledStats)
+
+	return s
+}
+
+func (s *service) Transition(ctx context.Context, req TransitionRequest) (*store.Booking, error) {
+	booking, err := s.store.Bookings().Get(ctx, req.BookingID)
+	if err != nil {
+		s.logger.Printf("Failed to get booking %s: %v", req.BookingID, err)
+		return nil, ErrBookingNotFound
+	}
+
+	if err := s.CanTransition(booking, req.Actor, req.To); err != nil {
+		return nil, err
+	}
+	if req.To == store.BookingStatusCancelled && req.CancellationReason == nil {
+		return nil, ErrCancellationReasonMissing
+	}
+
+	fromStatus := booking.Status
+	role, _ := actorRoleFor(booking, req.Actor)
+	rule := transitions[fromStatus][req.To]
+
+	now := time.Now()
+	booking.Status = req.To
+	rule.apply(booking, req, now)
+	s.runAppliers(booking, req, now)
+
+	entry := &store.BookingStatusHistory{
+		ID:         uuid.New().String(),
+		BookingID:  booking.ID,
+		FromStatus: &fromStatus,
+		ToStatus:   req.To,
+		ActorRole:  role,
+	}
+	if req.Actor != nil {
+		entry.ChangedByID = &req.Actor.ID
+	}
+	if req.Note != nil {
+		entry.Note = *req.Note
+	}
+
+	if err := s.store.Bookings().ApplyTransition(ctx, booking, fromStatus, entry); err != nil {
+		if errors.Is(err, store.ErrBookingStatusConflict) {
+			s.logger.Printf("Concurrent status change on booking %s (%s -> %s)", booking.ID, fromStatus, req.To)
+			return nil, ErrConcurrentTransition
+		}
+		s.logger.Printf("Failed to apply transition on booking %s (%s -> %s): %v", booking.ID, fromStatus, req.To, err)
+		return nil, fmt.Errorf("failed to update booking status: %w", err)
+	}
+
+	s.fireHooks(ctx, TransitionEvent{
+		Booking:    booking,
+		FromStatus: fromStatus,
+		ToStatus:   req.To,
+		Actor:      req.Actor,
+		ActorRole:  role,
+	})
+
+	return booking, nil
+}
+
+func (s *service) RecordCreated(ctx context.Context, booking *store.Booking, actor *store.User) error {
+	role, ok := actorRoleFor(booking, actor)
+	if !ok {
+		role = store.BookingActorRoleSystem
+	}
+
+	entry := &store.BookingStatusHistory{
+		ID:        uuid.New().String(),
+		BookingID: booking.ID,
+		ToStatus:  booking.Status,
+		ActorRole: role,
+	}
+	if actor != nil {
+		entry.ChangedByID = &actor.ID
+	}
+
+	if err := s.store.BookingStatusHistory().Create(ctx, entry); err != nil {
+		s.logger.Printf("Failed to record initial status for booking %s: %v", booking.ID, err)
+		return fmt.Errorf("failed to record booking status: %w", err)
+	}
+	return nil
+}
+
+func (s *service) CanTransition(booking *store.Booking, actor *store.User, to store.BookingStatus) error {
+	rule, ok := transitions[booking.Status][to]
+	if !ok {
+		return ErrTransitionNotAllowed
+	}
+
+	role, ok := actorRoleFor(booking, actor)
+	if !ok || !isActorAllowed(rule, role) {
+		return ErrActorNotAllowed
+	}
+
+	return nil
+}
+
+func (s *service) ActorRole(booking *store.Booking, actor *store.User) (store.BookingActorRole, bool) {
+	return actorRoleFor(booking, actor)
+}
+
+func (s *service) History(ctx context.Context, bookingID string) ([]*store.BookingStatusHistory, error) {
+	entries, err := s.store.BookingStatusHistory().GetByBooking(ctx, bookingID)
+	if err != nil {
+		s.logger.Printf("Failed to get status history for booking %s: %v", bookingID, err)
+		return nil, fmt.Errorf("failed to get booking status history: %w", err)
+	}
+	return entries, nil
+}
+
+func (s *service) OnTransition(to store.BookingStatus, hook TransitionHook) {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+	s.hooks[to] = append(s.hooks[to], hook)
+}
+
+func (s *service) OnApply(to store.BookingStatus, apply ApplyFunc) {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+	s.appliers[to] = append(s.appliers[to], apply)
+}
+
+// runAppliers makes the registered booking changes for the new status
+func (s *service) runAppliers(booking *store.Booking, req TransitionRequest, now time.Time) {
+	s.hooksMu.RLock()
+	appliers := s.appliers[req.To]
+	s.hooksMu.RUnlock()
+
+	for _, apply := range appliers {
+		apply(booking, req, now)
+	}
+}
+
+// fireHooks runs the side effects registered for the new status.
+// The transition is already committed, so failures are only logged.
+func (s *service) fireHooks(ctx context.Context, event TransitionEvent) {
+	s.hooksMu.RLock()
+	hooks := s.hooks[event.ToStatus]
+	s.hooksMu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook(ctx, event); err != nil {
+			s.logger.Printf("Side effect failed for booking %s (%s -> %s): %v",
+				event.Booking.ID, event.FromStatus, event.ToStatus, err)
+		}
+	}
+}
+
+// BUILT-IN SIDE EFFECTS
+
+func (s *service) recordCompletedStats(ctx context.Context, event TransitionEvent) error {
+	profile, err := s.store.CleanerProfiles().Get(ctx, event.Booking.CleanerProfileID)
+	if err != nil {
+		return fmt.Errorf("failed to get cleaner profile: %w", err)
+	}
+
+	totalBookings := profile.TotalBookings + 1
+	completedBookings := profile.CompletedBookings + 1
+	return s.store.CleanerProfiles().UpdateStats(ctx, profile.ID, store.CleanerStats{
+		TotalBookings:     &totalBookings,
+		CompletedBookings: &completedBookings,
+	})
+}
+
+func (s *service) recordCancelledStats(ctx context.Context, event TransitionEvent) error {
+	// Only cancellations by the cleaner count against their record
+	if event.ActorRole != store.BookingActorRoleCleaner {
+		return nil
+	}
+
+	profile, err := s.store.CleanerProfiles().Get(ctx, event.Booking.CleanerProfileID)
+	if err != nil {
+		return fmt.Errorf("failed to get cleaner profile: %w", err)
+	}
+
+	cancelledBookings := profile.CancelledBookings + 1
+	return s.store.CleanerProfiles().UpdateStats(ctx, profile.ID, store.CleanerStats{
+		CancelledBookings: &cancelledBookings,
+	})
+}
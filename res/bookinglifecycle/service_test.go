@@ -0,0 +1,191 @@
+package bookinglifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+// fakeStore serves a single booking and fails its transitions with err when set
+type fakeStore struct {
+	store.Store
+	bookings *fakeBookings
+}
+
+func (f *fakeStore) Bookings() store.BookingStore { return f.bookings }
+
+type fakeBookings struct {
+	store.BookingStore
+	booking *store.Booking
+	err     error
+}
+
+func (f *fakeBookings) Get(ctx context.Context, id string) (*store.Booking, error) {
+	copied := *f.booking
+	return &copied, nil
+}
+
+func (f *fakeBookings) ApplyTransition(ctx context.Context, booking *store.Booking, fromStatus store.BookingStatus, entry *store.BookingStatusHistory) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.booking.Status != fromStatus {
+		return store.ErrBookingStatusConflict
+	}
+	f.booking = booking
+	return nil
+}
+
+func newTestService(status store.BookingStatus) (*service, *fakeBookings) {
+	bookings := &fakeBookings{booking: &store.Booking{ID: "b1", CustomerID: "customer", CleanerID: "cleaner", Status: status}}
+	s := &service{
+		store:    &fakeStore{bookings: bookings},
+		logger:   log.New(io.Discard, "", 0),
+		hooks:    make(map[store.BookingStatus][]TransitionHook),
+		appliers: make(map[store.BookingStatus][]ApplyFunc),
+	}
+	return s, bookings
+}
+
+func TestCanTransitionTable(t *testing.T) {
+	statuses := []store.BookingStatus{
+		store.BookingStatusPending, store.BookingStatusConfirmed, store.BookingStatusInProgress,
+		store.BookingStatusCompleted, store.BookingStatusCancelled, store.BookingStatusNoShow,
+	}
+	actors := map[store.BookingActorRole]*store.User{
+		store.BookingActorRoleCustomer: {ID: "customer", Role: store.UserRoleClient},
+		store.BookingActorRoleCleaner:  {ID: "cleaner", Role: store.UserRoleCleaner},
+		store.BookingActorRoleAdmin:    {ID: "admin", Role: store.UserRoleGlobalAdmin},
+		store.BookingActorRoleSystem:   nil,
+	}
+
+	// Every allowed change and who may make it; anything else is forbidden
+	everyone := []store.BookingActorRole{
+		store.BookingActorRoleCustomer, store.BookingActorRoleCleaner, store.BookingActorRoleAdmin, store.BookingActorRoleSystem,
+	}
+	allowed := map[store.BookingStatus]map[store.BookingStatus][]store.BookingActorRole{
+		store.BookingStatusPending: {
+			store.BookingStatusConfirmed: {store.BookingActorRoleCleaner},
+			store.BookingStatusCancelled: everyone,
+		},
+		store.BookingStatusConfirmed: {
+			store.BookingStatusInProgress: {store.BookingActorRoleCleaner},
+			store.BookingStatusNoShow:     {store.BookingActorRoleCleaner},
+			store.BookingStatusCancelled:  everyone,
+		},
+		store.BookingStatusInProgress: {
+			store.BookingStatusCompleted: {store.BookingActorRoleCleaner},
+			store.BookingStatusCancelled: {store.BookingActorRoleAdmin},
+		},
+	}
+
+	s, _ := newTestService(store.BookingStatusPending)
+	stranger := &store.User{ID: "stranger", Role: store.UserRoleClient}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			booking := &store.Booking{ID: "b1", CustomerID: "customer", CleanerID: "cleaner", Status: from}
+			roles, exists := allowed[from][to]
+
+			for role, actor := range actors {
+				err := s.CanTransition(booking, actor, to)
+				want := error(ErrTransitionNotAllowed)
+				if exists {
+					want = ErrActorNotAllowed
+					for _, allowedRole := range roles {
+						if allowedRole == role {
+							want = nil
+						}
+					}
+				}
+				if !errors.Is(err, want) {
+					t.Errorf("%s -> %s by %s: got %v, want %v", from, to, role, err, want)
+				}
+			}
+
+			if exists {
+				if err := s.CanTransition(booking, stranger, to); !errors.Is(err, ErrActorNotAllowed) {
+					t.Errorf("%s -> %s by an unrelated user: got %v, want ErrActorNotAllowed", from, to, err)
+				}
+			}
+		}
+	}
+}
+
+func TestTransitionPersistsAppliedChanges(t *testing.T) {
+	s, bookings := newTestService(store.BookingStatusConfirmed)
+	s.OnApply(store.BookingStatusNoShow, func(booking *store.Booking, req TransitionRequest, now time.Time) {
+		booking.NoShowFee = 5000
+	})
+	fired := 0
+	s.OnTransition(store.BookingStatusNoShow, func(ctx context.Context, event TransitionEvent) error {
+		fired++
+		return nil
+	})
+
+	cleaner := &store.User{ID: "cleaner", Role: store.UserRoleCleaner}
+	booking, err := s.Transition(context.Background(), TransitionRequest{BookingID: "b1", To: store.BookingStatusNoShow, Actor: cleaner})
+	if err != nil {
+		t.Fatalf("Transition() error = %v", err)
+	}
+	if booking.Status != store.BookingStatusNoShow || bookings.booking.NoShowFee != 5000 {
+		t.Errorf("stored booking is %s with fee %d, want no_show with the applied fee of 5000", bookings.booking.Status, bookings.booking.NoShowFee)
+	}
+	if fired != 1 {
+		t.Errorf("side effect fired %d times, want once", fired)
+	}
+}
+
+func TestTransitionRollsBackAppliedChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{"concurrent change", store.ErrBookingStatusConflict, ErrConcurrentTransition},
+		{"store failure", errors.New("connection reset"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, bookings := newTestService(store.BookingStatusConfirmed)
+			bookings.err = tt.err
+			s.OnApply(store.BookingStatusNoShow, func(booking *store.Booking, req TransitionRequest, now time.Time) {
+				booking.NoShowFee = 5000
+			})
+			fired := 0
+			s.OnTransition(store.BookingStatusNoShow, func(ctx context.Context, event TransitionEvent) error {
+				fired++
+				return nil
+			})
+
+			cleaner := &store.User{ID: "cleaner", Role: store.UserRoleCleaner}
+			_, err := s.Transition(context.Background(), TransitionRequest{BookingID: "b1", To: store.BookingStatusNoShow, Actor: cleaner})
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Fatalf("Transition() error = %v, want %v", err, tt.wantErr)
+			}
+			if bookings.booking.Status != store.BookingStatusConfirmed || bookings.booking.NoShowFee != 0 {
+				t.Errorf("stored booking is %s with fee %d, want it confirmed and unchanged", bookings.booking.Status, bookings.booking.NoShowFee)
+			}
+			if fired != 0 {
+				t.Errorf("side effect fired %d times for a transition that was not persisted", fired)
+			}
+		})
+	}
+}
+
+func TestTransitionRequiresACancellationReason(t *testing.T) {
+	s, bookings := newTestService(store.BookingStatusPending)
+	customer := &store.User{ID: "customer", Role: store.UserRoleClient}
+
+	_, err := s.Transition(context.Background(), TransitionRequest{BookingID: "b1", To: store.BookingStatusCancelled, Actor: customer})
+	if !errors.Is(err, ErrCancellationReasonMissing) {
+		t.Errorf("Transition() error = %v, want ErrCancellationReasonMissing", err)
+	}
+	if bookings.booking.Status != store.BookingStatusPending {
+		t.Errorf("stored booking is %s, want it still pending", bookings.booking.Status)
+	}
+}
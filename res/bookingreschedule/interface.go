@@ -0,0 +1,39 @@
+package bookingreschedule
+
+import (
+	"context"
+	"errors"
+
+	"cleanbuddy-api/res/store"
+)
+
+var (
+	ErrBookingNotFound      = errors.New("booking not found")
+	ErrRequestNotFound      = errors.New("reschedule request not found")
+	ErrAccessDenied         = errors.New("you are not allowed to act on this reschedule request")
+	ErrNotReschedulable     = errors.New("only pending or confirmed bookings can be rescheduled")
+	ErrPendingRequestExists = errors.New("booking already has an open reschedule request")
+	ErrNoSlots              = errors.New("at least one slot must be proposed")
+	ErrTooManySlots         = errors.New("too many slots proposed")
+	ErrInvalidSlot          = errors.New("proposed slots must be in the future and use HH:MM times")
+	ErrRequestNotPending    = errors.New("reschedule request is no longer open")
+	ErrRequestExpired       = errors.New("reschedule request has expired")
+	ErrSlotRequired         = errors.New("choose which proposed slot to accept")
+)
+
+// RescheduleService negotiates moving a booking between the customer and the cleaner.
+// Either party proposes slots, the other party accepts one or declines, and the
+// proposer may withdraw while the request is open.
+type RescheduleService interface {
+	// Propose opens a reschedule request with one or more alternative slots
+	Propose(ctx context.Context, bookingID string, actor *store.User, slots []store.RescheduleSlot, message string) (*store.BookingRescheduleRequest, error)
+
+	// Respond accepts one of the proposed slots (moving the booking) or declines the request
+	Respond(ctx context.Context, requestID string, actor *store.User, accept bool, slotIndex *int, note string) (*store.BookingRescheduleRequest, error)
+
+	// Withdraw cancels an open request on behalf of its proposer
+	Withdraw(ctx context.Context, requestID string, actor *store.User) (*store.BookingRescheduleRequest, error)
+
+	// ListForBooking returns the reschedule requests of a booking, newest first
+	ListForBooking(ctx context.Context, bookingID string) ([]*store.BookingRescheduleRequest, error)
+}
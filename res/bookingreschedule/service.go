@@ -0,0 +1,300 @@
+package bookingreschedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/bookinglifecycle"
+	"cleanbuddy-api/res/localtime"
+	"cleanbuddy-api/res/store"
+)
+
+// maxProposedSlots limits how many alternatives a single request can offer
+const maxProposedSlots = 5
+
+type service struct {
+	store     store.Store
+	lifecycle bookinglifecycle.LifecycleService
+	ttl       time.Duration
+	logger    *log.Logger
+}
+
+// NewService creates a new RescheduleService.
+// Requests expire after ttl or when the booking starts, whichever comes first.
+func NewService(dataStore store.Store, lifecycle bookinglifecycle.LifecycleService, ttl time.Duration, logger *log.Logger) RescheduleService {
+	return &service{
+		store:     dataStore,
+		lifecycle: lifecycle,
+		ttl:       ttl,
+		logger:    logger,
+	}
+}
+
+func (s *service) Propose(ctx context.Context, bookingID string, actor *store.User, slots []store.RescheduleSlot, message string) (*store.BookingRescheduleRequest, error) {
+	booking, err := s.store.Bookings().Get(ctx, bookingID)
+	if err != nil {
+		s.logger.Printf("Failed to get booking %s: %v", bookingID, err)
+		return nil, ErrBookingNotFound
+	}
+
+	role, err := s.partyRole(booking, actor)
+	if err != nil {
+		return nil, err
+	}
+	if !isReschedulable(booking) {
+		return nil, ErrNotReschedulable
+	}
+
+	now := time.Now()
+	if err := validateSlots(slots, now); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.ListForBooking(ctx, booking.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, request := range existing {
+		if request.Status == store.RescheduleRequestStatusPending {
+			return nil, ErrPendingRequestExists
+		}
+	}
+
+	encodedSlots, err := json.Marshal(slots)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode proposed slots: %w", err)
+	}
+
+	expiresAt := now.Add(s.ttl)
+	if start := booking.StartsAt(); start.Before(expiresAt) {
+		expiresAt = start
+	}
+
+	originalDate, originalTime := booking.LocalSchedule()
+	request := &store.BookingRescheduleRequest{
+		ID:            uuid.New().String(),
+		BookingID:     booking.ID,
+		ProposedByID:  actor.ID,
+		ProposerRole:  role,
+		Status:        store.RescheduleRequestStatusPending,
+		ProposedSlots: string(encodedSlots),
+		OriginalDate:  originalDate,
+		OriginalTime:  originalTime,
+		Message:       message,
+		ExpiresAt:     expiresAt,
+	}
+
+	if err := s.store.BookingRescheduleRequests().Create(ctx, request); err != nil {
+		s.logger.Printf("Failed to create reschedule request for booking %s: %v", booking.ID, err)
+		return nil, fmt.Errorf("failed to create reschedule request: %w", err)
+	}
+
+	return request, nil
+}
+
+func (s *service) Respond(ctx context.Context, requestID string, actor *store.User, accept bool, slotIndex *int, note string) (*store.BookingRescheduleRequest, error) {
+	request, booking, err := s.getOpenRequest(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the other party can respond to a proposal
+	role, err := s.partyRole(booking, actor)
+	if err != nil {
+		return nil, err
+	}
+	if role == request.ProposerRole {
+		return nil, ErrAccessDenied
+	}
+
+	now := time.Now()
+	request.RespondedByID = &actor.ID
+	request.RespondedAt = &now
+	request.ResponseNote = note
+
+	if !accept {
+		request.Status = store.RescheduleRequestStatusDeclined
+		if err := s.store.BookingRescheduleRequests().Resolve(ctx, request); err != nil {
+			if errors.Is(err, store.ErrRescheduleRequestNotPending) {
+				return nil, ErrRequestNotPending
+			}
+			s.logger.Printf("Failed to decline reschedule request %s: %v", request.ID, err)
+			return nil, fmt.Errorf("failed to decline reschedule request: %w", err)
+		}
+		return request, nil
+	}
+
+	if !isReschedulable(booking) {
+		return nil, ErrNotReschedulable
+	}
+
+	var slots []store.RescheduleSlot
+	if err := json.Unmarshal([]byte(request.ProposedSlots), &slots); err != nil {
+		return nil, fmt.Errorf("failed to decode proposed slots: %w", err)
+	}
+
+	index := 0
+	if slotIndex != nil {
+		index = *slotIndex
+	} else if len(slots) > 1 {
+		return nil, ErrSlotRequired
+	}
+	if index < 0 || index >= len(slots) {
+		return nil, ErrSlotRequired
+	}
+	slot := slots[index]
+
+	// Availability may have changed since the proposal, so validate again
+	if err := validateSlots([]store.RescheduleSlot{slot}, now); err != nil {
+		return nil, err
+	}
+
+	fromDate, fromTime := booking.LocalSchedule()
+	if err := booking.ScheduleAt(slot.Date, slot.Time); err != nil {
+		return nil, ErrInvalidSlot
+	}
+
+	request.Status = store.RescheduleRequestStatusAccepted
+	request.AcceptedDate = &slot.Date
+	request.AcceptedTime = &slot.Time
+
+	// The status does not change; the entry keeps the move in the booking's history
+	entry := &store.BookingStatusHistory{
+		ID:          uuid.New().String(),
+		BookingID:   booking.ID,
+		FromStatus:  &booking.Status,
+		ToStatus:    booking.Status,
+		ChangedByID: &actor.ID,
+		ActorRole:   role,
+		Note: fmt.Sprintf("Rescheduled from %s %s to %s %s",
+			fromDate.Format("2006-01-02"), fromTime, slot.Date.Format("2006-01-02"), slot.Time),
+	}
+
+	// The request and the booking change together so a second response cannot move the booking again
+	if err := s.store.BookingRescheduleRequests().Accept(ctx, request, booking, entry); err != nil {
+		switch {
+		case errors.Is(err, store.ErrRescheduleRequestNotPending):
+			return nil, ErrRequestNotPending
+		case errors.Is(err, store.ErrSlotUnavailable):
+			return nil, err
+		}
+		s.logger.Printf("Failed to accept reschedule request %s for booking %s: %v", request.ID, booking.ID, err)
+		return nil, fmt.Errorf("failed to accept reschedule request: %w", err)
+	}
+
+	return request, nil
+}
+
+func (s *service) Withdraw(ctx context.Context, requestID string, actor *store.User) (*store.BookingRescheduleRequest, error) {
+	request, _, err := s.getOpenRequest(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if actor == nil || actor.ID != request.ProposedByID {
+		return nil, ErrAccessDenied
+	}
+
+	request.Status = store.RescheduleRequestStatusWithdrawn
+	if err := s.store.BookingRescheduleRequests().Resolve(ctx, request); err != nil {
+		if errors.Is(err, store.ErrRescheduleRequestNotPending) {
+			return nil, ErrRequestNotPending
+		}
+		s.logger.Printf("Failed to withdraw reschedule request %s: %v", request.ID, err)
+		return nil, fmt.Errorf("failed to withdraw reschedule request: %w", err)
+	}
+
+	return request, nil
+}
+
+func (s *service) ListForBooking(ctx context.Context, bookingID string) ([]*store.BookingRescheduleRequest, error) {
+	requests, err := s.store.BookingRescheduleRequests().GetByBooking(ctx, bookingID)
+	if err != nil {
+		s.logger.Printf("Failed to get reschedule requests for booking %s: %v", bookingID, err)
+		return nil, fmt.Errorf("failed to get reschedule requests: %w", err)
+	}
+
+	now := time.Now()
+	for _, request := range requests {
+		s.expireIfDue(ctx, request, now)
+	}
+
+	return requests, nil
+}
+
+// getOpenRequest loads a pending request and its booking, expiring it if it is past due
+func (s *service) getOpenRequest(ctx context.Context, requestID string) (*store.BookingRescheduleRequest, *store.Booking, error) {
+	request, err := s.store.BookingRescheduleRequests().Get(ctx, requestID)
+	if err != nil {
+		s.logger.Printf("Failed to get reschedule request %s: %v", requestID, err)
+		return nil, nil, ErrRequestNotFound
+	}
+
+	if s.expireIfDue(ctx, request, time.Now()) {
+		return nil, nil, ErrRequestExpired
+	}
+	if request.Status != store.RescheduleRequestStatusPending {
+		return nil, nil, ErrRequestNotPending
+	}
+
+	booking, err := s.store.Bookings().Get(ctx, request.BookingID)
+	if err != nil {
+		s.logger.Printf("Failed to get booking %s: %v", request.BookingID, err)
+		return nil, nil, ErrBookingNotFound
+	}
+
+	return request, booking, nil
+}
+
+// expireIfDue marks a pending request as expired once ExpiresAt has passed
+func (s *service) expireIfDue(ctx context.Context, request *store.BookingRescheduleRequest, now time.Time) bool {
+	if request.Status != store.RescheduleRequestStatusPending || now.Before(request.ExpiresAt) {
+		return false
+	}
+
+	request.Status = store.RescheduleRequestStatusExpired
+	if err := s.store.BookingRescheduleRequests().Resolve(ctx, request); err != nil {
+		s.logger.Printf("Failed to expire reschedule request %s: %v", request.ID, err)
+	}
+	return true
+}
+
+// partyRole returns whether the actor is the booking's customer or cleaner
+func (s *service) partyRole(booking *store.Booking, actor *store.User) (store.BookingActorRole, error) {
+	if actor == nil {
+		return "", ErrAccessDenied
+	}
+
+	role, ok := s.lifecycle.ActorRole(booking, actor)
+	if !ok || (role != store.BookingActorRoleCustomer && role != store.BookingActorRoleCleaner) {
+		return "", ErrAccessDenied
+	}
+	return role, nil
+}
+
+func isReschedulable(booking *store.Booking) bool {
+	return booking.Status == store.BookingStatusPending || booking.Status == store.BookingStatusConfirmed
+}
+
+func validateSlots(slots []store.RescheduleSlot, now time.Time) error {
+	if len(slots) == 0 {
+		return ErrNoSlots
+	}
+	if len(slots) > maxProposedSlots {
+		return ErrTooManySlots
+	}
+
+	for _, slot := range slots {
+		start, err := localtime.ParseAt(slot.Date, slot.Time)
+		if err != nil || !start.After(now) {
+			return ErrInvalidSlot
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package bookingseries
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+var (
+	ErrBookingNotFound  = errors.New("booking not found")
+	ErrNotRecurring     = errors.New("booking is not part of a recurring series")
+	ErrSeriesEnded      = errors.New("recurring series has already been cancelled")
+	ErrAccessDenied     = errors.New("only the customer can change a recurring series")
+	ErrNothingToUpdate  = errors.New("no changes provided")
+	ErrInvalidFrequency = errors.New("service frequency does not repeat")
+)
+
+// SeriesService manages recurring booking series.
+// A series is a parent booking plus occurrences linked through ParentBookingID
+// (always pointing at the parent) and NextBookingID (pointing at the following occurrence).
+type SeriesService interface {
+	// Materialize creates the missing occurrences of a series up to the rolling horizon
+	Materialize(ctx context.Context, parentBookingID string) ([]*store.Booking, error)
+
+	// MaterializeAll extends every active series up to the rolling horizon
+	MaterializeAll(ctx context.Context) error
+
+	// GetSeries returns all bookings of the series that contains the given booking
+	GetSeries(ctx context.Context, bookingID string) ([]*store.Booking, error)
+
+	// SkipOccurrence cancels a single occurrence without affecting the rest of the series
+	SkipOccurrence(ctx context.Context, bookingID string, actor *store.User) (*store.Booking, error)
+
+	// UpdateThisAndFollowing applies changes to an occurrence and every later open occurrence
+	UpdateThisAndFollowing(ctx context.Context, bookingID string, actor *store.User, changes SeriesChanges) ([]*store.Booking, error)
+
+	// CancelSeries cancels every open occurrence and stops future materialization
+	CancelSeries(ctx context.Context, bookingID string, actor *store.User, reason store.CancellationReason, note *string) ([]*store.Booking, error)
+}
+
+// SeriesChanges contains the editable fields of a series
+type SeriesChanges struct {
+	// ScheduledDate moves the selected occurrence; later occurrences shift by the same offset
+	ScheduledDate *time.Time
+	ScheduledTime *string
+	CustomerNotes *string
+}
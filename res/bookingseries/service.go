@@ -0,0 +1,409 @@
+package bookingseries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/bookinglifecycle"
+	"cleanbuddy-api/res/localtime"
+	"cleanbuddy-api/res/pricing"
+	"cleanbuddy-api/res/store"
+)
+
+const skippedOccurrenceNote = "Occurrence skipped by customer"
+
+type service struct {
+	store     store.Store
+	lifecycle bookinglifecycle.LifecycleService
+	pricing   pricing.PricingService
+	horizon   time.Duration
+	logger    *log.Logger
+}
+
+// NewService creates a new SeriesService.
+// horizon is how far ahead occurrences are materialized.
+// The service keeps series topped up whenever an occurrence is completed or cancelled.
+func NewService(
+	dataStore store.Store,
+	lifecycle bookinglifecycle.LifecycleService,
+	pricingService pricing.PricingService,
+	horizon time.Duration,
+	logger *log.Logger,
+) SeriesService {
+	s := &service{
+		store:     dataStore,
+		lifecycle: lifecycle,
+		pricing:   pricingService,
+		horizon:   horizon,
+		logger:    logger,
+	}
+
+	lifecycle.OnTransition(store.BookingStatusCompleted, s.extendSeriesOnTransition)
+	lifecycle.OnTransition(store.BookingStatusCancelled, s.extendSeriesOnTransition)
+
+	return s
+}
+
+func (s *service) Materialize(ctx context.Context, parentBookingID string) ([]*store.Booking, error) {
+	parent, err := s.store.Bookings().Get(ctx, parentBookingID)
+	if err != nil {
+		s.logger.Printf("Failed to get parent booking %s: %v", parentBookingID, err)
+		return nil, ErrBookingNotFound
+	}
+	if !parent.IsRecurring || parent.ParentBookingID != nil {
+		return nil, ErrNotRecurring
+	}
+	if parent.RecurrenceEndedAt != nil {
+		return nil, ErrSeriesEnded
+	}
+	if !isRepeating(parent.ServiceFrequency) {
+		return nil, ErrInvalidFrequency
+	}
+
+	series, err := s.store.Bookings().GetSeries(ctx, parent.ID)
+	if err != nil {
+		s.logger.Printf("Failed to get series %s: %v", parent.ID, err)
+		return nil, fmt.Errorf("failed to get series: %w", err)
+	}
+
+	// The latest occurrence is the template for the next one so "this and following" edits carry over.
+	// Dates are counted from the parent's anchor so monthly series do not drift after short months.
+	previous := series[len(series)-1]
+	anchor, _ := parent.LocalSchedule()
+	index := recurrenceIndex(series, previous)
+	previousDate, _ := previous.LocalSchedule()
+	shiftDays := daysBetween(occurrenceDate(parent.ServiceFrequency, anchor, index), previousDate)
+	horizonEnd := time.Now().Add(s.horizon)
+
+	created := []*store.Booking{}
+	for {
+		index++
+		nextDate := occurrenceDate(parent.ServiceFrequency, anchor, index).AddDate(0, 0, shiftDays)
+		if nextDate.After(horizonEnd) {
+			break
+		}
+
+		occurrence, err := newOccurrence(parent.ID, previous, nextDate, index)
+		if err != nil {
+			// The time of day does not exist on this date (daylight saving change); skip it
+			s.logger.Printf("Skipping occurrence %d of series %s on %s: %v", index, parent.ID, nextDate.Format("2006-01-02"), err)
+			continue
+		}
+
+		// Occurrences are priced from the current service definition, not the parent's snapshot
+		quote, err := s.pricing.Reprice(ctx, occurrence)
+		if err != nil {
+			s.logger.Printf("Failed to price occurrence of series %s: %v", parent.ID, err)
+			return created, fmt.Errorf("failed to price occurrence: %w", err)
+		}
+		quote.ApplyTo(occurrence)
+
+		if err := s.store.Bookings().Create(ctx, occurrence); err != nil {
+			if errors.Is(err, store.ErrSlotUnavailable) {
+				// The cleaner is taken on this date; skip it so the rest of the series is still created
+				s.logger.Printf("Skipping occurrence %d of series %s on %s: cleaner is not available", index, parent.ID, nextDate.Format("2006-01-02"))
+				continue
+			}
+			s.logger.Printf("Failed to create occurrence of series %s on %s: %v", parent.ID, nextDate.Format("2006-01-02"), err)
+			return created, fmt.Errorf("failed to create occurrence: %w", err)
+		}
+		if err := s.lifecycle.RecordCreated(ctx, occurrence, nil); err != nil {
+			s.logger.Printf("Failed to record creation of occurrence %s: %v", occurrence.ID, err)
+		}
+
+		previous.NextBookingID = &occurrence.ID
+		if err := s.store.Bookings().Update(ctx, previous); err != nil {
+			s.logger.Printf("Failed to link occurrence %s after %s: %v", occurrence.ID, previous.ID, err)
+			return created, fmt.Errorf("failed to link occurrence: %w", err)
+		}
+
+		created = append(created, occurrence)
+		previous = occurrence
+	}
+
+	s.logger.Printf("Materialized %d occurrences for series %s", len(created), parent.ID)
+	return created, nil
+}
+
+func (s *service) MaterializeAll(ctx context.Context) error {
+	parents, err := s.store.Bookings().ListActiveSeriesParents(ctx)
+	if err != nil {
+		s.logger.Printf("Failed to list active series: %v", err)
+		return fmt.Errorf("failed to list active series: %w", err)
+	}
+
+	failed := 0
+	for _, parent := range parents {
+		if _, err := s.Materialize(ctx, parent.ID); err != nil {
+			s.logger.Printf("Failed to materialize series %s: %v", parent.ID, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to materialize %d of %d series", failed, len(parents))
+	}
+	return nil
+}
+
+func (s *service) GetSeries(ctx context.Context, bookingID string) ([]*store.Booking, error) {
+	booking, err := s.store.Bookings().Get(ctx, bookingID)
+	if err != nil {
+		s.logger.Printf("Failed to get booking %s: %v", bookingID, err)
+		return nil, ErrBookingNotFound
+	}
+
+	parentID, err := seriesParentID(booking)
+	if err != nil {
+		return nil, err
+	}
+
+	series, err := s.store.Bookings().GetSeries(ctx, parentID)
+	if err != nil {
+		s.logger.Printf("Failed to get series %s: %v", parentID, err)
+		return nil, fmt.Errorf("failed to get series: %w", err)
+	}
+	return series, nil
+}
+
+func (s *service) SkipOccurrence(ctx context.Context, bookingID string, actor *store.User) (*store.Booking, error) {
+	booking, err := s.store.Bookings().Get(ctx, bookingID)
+	if err != nil {
+		s.logger.Printf("Failed to get booking %s: %v", bookingID, err)
+		return nil, ErrBookingNotFound
+	}
+	if _, err := seriesParentID(booking); err != nil {
+		return nil, err
+	}
+	if !canManageSeries(booking, actor) {
+		return nil, ErrAccessDenied
+	}
+
+	reason := store.CancellationReasonCustomerRequest
+	note := skippedOccurrenceNote
+	return s.lifecycle.Transition(ctx, bookinglifecycle.TransitionRequest{
+		BookingID:          booking.ID,
+		To:                 store.BookingStatusCancelled,
+		Actor:              actor,
+		CancellationReason: &reason,
+		Note:               &note,
+	})
+}
+
+func (s *service) UpdateThisAndFollowing(ctx context.Context, bookingID string, actor *store.User, changes SeriesChanges) ([]*store.Booking, error) {
+	if changes.ScheduledDate == nil && changes.ScheduledTime == nil && changes.CustomerNotes == nil {
+		return nil, ErrNothingToUpdate
+	}
+
+	target, err := s.store.Bookings().Get(ctx, bookingID)
+	if err != nil {
+		s.logger.Printf("Failed to get booking %s: %v", bookingID, err)
+		return nil, ErrBookingNotFound
+	}
+	if !canManageSeries(target, actor) {
+		return nil, ErrAccessDenied
+	}
+
+	series, err := s.GetSeries(ctx, target.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	offsetDays := 0
+	if changes.ScheduledDate != nil {
+		// Whole calendar days, so every occurrence keeps its wall-clock time
+		targetDate, _ := target.LocalSchedule()
+		offsetDays = daysBetween(targetDate, *changes.ScheduledDate)
+	}
+
+	updated := []*store.Booking{}
+	for _, booking := range series {
+		if booking.StartsAt().Before(target.StartsAt()) || !isOpen(booking) {
+			continue
+		}
+
+		date, timeOfDay := booking.LocalSchedule()
+		if changes.ScheduledTime != nil {
+			timeOfDay = *changes.ScheduledTime
+		}
+		if err := booking.ScheduleAt(date.AddDate(0, 0, offsetDays), timeOfDay); err != nil {
+			return nil, err
+		}
+		if changes.CustomerNotes != nil {
+			booking.CustomerNotes = *changes.CustomerNotes
+		}
+		updated = append(updated, booking)
+	}
+
+	// Saved together so occurrences moving onto each other's old slots do not conflict
+	// and a conflict with another booking leaves the whole series untouched
+	if err := s.store.Bookings().UpdateMany(ctx, updated); err != nil {
+		s.logger.Printf("Failed to update occurrences of series %s: %v", target.ID, err)
+		return nil, fmt.Errorf("failed to update occurrences: %w", err)
+	}
+
+	return updated, nil
+}
+
+func (s *service) CancelSeries(ctx context.Context, bookingID string, actor *store.User, reason store.CancellationReason, note *string) ([]*store.Booking, error) {
+	booking, err := s.store.Bookings().Get(ctx, bookingID)
+	if err != nil {
+		s.logger.Printf("Failed to get booking %s: %v", bookingID, err)
+		return nil, ErrBookingNotFound
+	}
+	if !canManageSeries(booking, actor) {
+		return nil, ErrAccessDenied
+	}
+
+	series, err := s.GetSeries(ctx, booking.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// End the series first so cancellation side effects do not materialize new occurrences
+	parent := series[0]
+	if parent.RecurrenceEndedAt != nil {
+		return nil, ErrSeriesEnded
+	}
+	now := time.Now()
+	parent.RecurrenceEndedAt = &now
+	if err := s.store.Bookings().Update(ctx, parent); err != nil {
+		s.logger.Printf("Failed to end series %s: %v", parent.ID, err)
+		return nil, fmt.Errorf("failed to end series: %w", err)
+	}
+
+	cancelled := []*store.Booking{}
+	for _, occurrence := range series {
+		if !isOpen(occurrence) {
+			continue
+		}
+
+		result, err := s.lifecycle.Transition(ctx, bookinglifecycle.TransitionRequest{
+			BookingID:          occurrence.ID,
+			To:                 store.BookingStatusCancelled,
+			Actor:              actor,
+			CancellationReason: &reason,
+			Note:               note,
+		})
+		if err != nil {
+			// Keep going so one stuck occurrence does not leave the rest of the series active
+			s.logger.Printf("Failed to cancel occurrence %s of series %s: %v", occurrence.ID, parent.ID, err)
+			continue
+		}
+		cancelled = append(cancelled, result)
+	}
+
+	return cancelled, nil
+}
+
+// extendSeriesOnTransition keeps the rolling horizon filled as occurrences are consumed
+func (s *service) extendSeriesOnTransition(ctx context.Context, event bookinglifecycle.TransitionEvent) error {
+	parentID, err := seriesParentID(event.Booking)
+	if err != nil {
+		return nil
+	}
+
+	if _, err := s.Materialize(ctx, parentID); err != nil && !errors.Is(err, ErrSeriesEnded) {
+		return err
+	}
+	return nil
+}
+
+// HELPERS
+
+// seriesParentID returns the parent ID of the series a booking belongs to
+func seriesParentID(booking *store.Booking) (string, error) {
+	if booking.ParentBookingID != nil {
+		return *booking.ParentBookingID, nil
+	}
+	if booking.IsRecurring {
+		return booking.ID, nil
+	}
+	return "", ErrNotRecurring
+}
+
+func canManageSeries(booking *store.Booking, actor *store.User) bool {
+	return actor != nil && (actor.ID == booking.CustomerID || actor.IsGlobalAdmin())
+}
+
+// isOpen reports whether an occurrence can still be changed or cancelled
+func isOpen(booking *store.Booking) bool {
+	return booking.Status == store.BookingStatusPending || booking.Status == store.BookingStatusConfirmed
+}
+
+func isRepeating(frequency store.ServiceFrequency) bool {
+	return frequency == store.ServiceFrequencyWeekly ||
+		frequency == store.ServiceFrequencyBiMonthly ||
+		frequency == store.ServiceFrequencyMonthly
+}
+
+// occurrenceDate returns the date of the n-th occurrence of a series starting on anchor.
+// Monthly occurrences keep the anchor's day of the month, clamped to the last day of shorter months.
+func occurrenceDate(frequency store.ServiceFrequency, anchor time.Time, n int) time.Time {
+	switch frequency {
+	case store.ServiceFrequencyWeekly:
+		return anchor.AddDate(0, 0, 7*n)
+	case store.ServiceFrequencyBiMonthly:
+		return anchor.AddDate(0, 0, 14*n)
+	case store.ServiceFrequencyMonthly:
+		year, month, day := anchor.Date()
+		firstOfMonth := time.Date(year, month+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+		if lastDay := firstOfMonth.AddDate(0, 1, -1).Day(); day > lastDay {
+			day = lastDay
+		}
+		return firstOfMonth.AddDate(0, 0, day-1)
+	}
+	return anchor
+}
+
+// recurrenceIndex returns the position of an occurrence in its series.
+// Occurrences created before positions were stored are counted from the series.
+func recurrenceIndex(series []*store.Booking, occurrence *store.Booking) int {
+	if occurrence.RecurrenceIndex > 0 || occurrence.ParentBookingID == nil {
+		return occurrence.RecurrenceIndex
+	}
+	for i, booking := range series {
+		if booking.ID == occurrence.ID {
+			return i
+		}
+	}
+	return len(series) - 1
+}
+
+// daysBetween returns the number of calendar days from one date to another
+func daysBetween(from, to time.Time) int {
+	return int(math.Round(localtime.Date(to).Sub(localtime.Date(from)).Hours() / 24))
+}
+
+// newOccurrence copies the scheduling and service details of the previous occurrence
+func newOccurrence(parentID string, previous *store.Booking, scheduledDate time.Time, index int) (*store.Booking, error) {
+	occurrence := &store.Booking{
+		ID:                uuid.New().String(),
+		CustomerID:        previous.CustomerID,
+		CleanerID:         previous.CleanerID,
+		CleanerProfileID:  previous.CleanerProfileID,
+		ServiceType:       previous.ServiceType,
+		ServiceFrequency:  previous.ServiceFrequency,
+		ServiceAddOns:     previous.ServiceAddOns,
+		AddressID:         previous.AddressID,
+		CleanerHourlyRate: previous.CleanerHourlyRate,
+		TravelFee:         previous.TravelFee,
+		Status:            store.BookingStatusPending,
+		IsRecurring:       true,
+		ParentBookingID:   &parentID,
+		RecurrenceIndex:   index,
+		CustomerNotes:     previous.CustomerNotes,
+	}
+
+	_, timeOfDay := previous.LocalSchedule()
+	if err := occurrence.ScheduleAt(scheduledDate, timeOfDay); err != nil {
+		return nil, err
+	}
+	return occurrence, nil
+}
@@ -0,0 +1,262 @@
+package bookingseries
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"cleanbuddy-api/res/bookinglifecycle"
+	"cleanbuddy-api/res/localtime"
+	"cleanbuddy-api/res/pricing"
+	"cleanbuddy-api/res/store"
+)
+
+// fakeStore keeps a single series in memory
+type fakeStore struct {
+	store.Store
+	bookings *fakeBookings
+}
+
+func (f *fakeStore) Bookings() store.BookingStore { return f.bookings }
+
+type fakeBookings struct {
+	store.BookingStore
+	series []*store.Booking
+	// taken holds the local dates on which the cleaner is already booked
+	taken map[string]bool
+}
+
+func (f *fakeBookings) Get(ctx context.Context, id string) (*store.Booking, error) {
+	for _, booking := range f.series {
+		if booking.ID == id {
+			return booking, nil
+		}
+	}
+	return nil, ErrBookingNotFound
+}
+
+func (f *fakeBookings) GetSeries(ctx context.Context, parentBookingID string) ([]*store.Booking, error) {
+	return append([]*store.Booking(nil), f.series...), nil
+}
+
+func (f *fakeBookings) Create(ctx context.Context, booking *store.Booking) error {
+	if date, _ := booking.LocalSchedule(); f.taken[date.Format("2006-01-02")] {
+		return store.ErrSlotUnavailable
+	}
+	f.series = append(f.series, booking)
+	return nil
+}
+
+func (f *fakeBookings) Update(ctx context.Context, booking *store.Booking) error {
+	return nil
+}
+
+type fakeLifecycle struct {
+	bookinglifecycle.LifecycleService
+}
+
+func (f *fakeLifecycle) RecordCreated(ctx context.Context, booking *store.Booking, actor *store.User) error {
+	return nil
+}
+
+type fakePricing struct {
+	pricing.PricingService
+}
+
+func (f *fakePricing) Reprice(ctx context.Context, booking *store.Booking) (*pricing.Quote, error) {
+	return &pricing.Quote{EstimatedDuration: 2}, nil
+}
+
+func useBucharest(t *testing.T) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation("Europe/Bucharest")
+	if err != nil {
+		t.Skipf("tzdata for Europe/Bucharest not available: %v", err)
+	}
+
+	previous := localtime.Location()
+	localtime.SetLocation(loc)
+	t.Cleanup(func() { localtime.SetLocation(previous) })
+	return loc
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// newTestSeries returns a service holding a parent booking scheduled on anchor at timeOfDay.
+// Occurrences are materialized up to and including the day of until.
+func newTestSeries(t *testing.T, frequency store.ServiceFrequency, anchor time.Time, timeOfDay string, until time.Time) (*service, *fakeBookings) {
+	t.Helper()
+
+	parent := &store.Booking{
+		ID:               "parent",
+		CustomerID:       "customer",
+		CleanerID:        "cleaner",
+		ServiceFrequency: frequency,
+		Status:           store.BookingStatusConfirmed,
+		IsRecurring:      true,
+	}
+	if err := parent.ScheduleAt(anchor, timeOfDay); err != nil {
+		t.Fatalf("ScheduleAt() error = %v", err)
+	}
+
+	bookings := &fakeBookings{series: []*store.Booking{parent}, taken: map[string]bool{}}
+	s := &service{
+		store:     &fakeStore{bookings: bookings},
+		lifecycle: &fakeLifecycle{},
+		pricing:   &fakePricing{},
+		horizon:   time.Until(until.Add(12 * time.Hour)),
+		logger:    log.New(io.Discard, "", 0),
+	}
+	return s, bookings
+}
+
+// occurrence is the expected local start and position of a materialized booking
+type occurrence struct {
+	date  time.Time
+	index int
+}
+
+func checkOccurrences(t *testing.T, loc *time.Location, created []*store.Booking, timeOfDay string, want []occurrence) {
+	t.Helper()
+
+	if len(created) != len(want) {
+		t.Fatalf("created %d occurrences, want %d", len(created), len(want))
+	}
+	clock, err := localtime.Parse(timeOfDay)
+	if err != nil {
+		t.Fatalf("Parse(%s) error = %v", timeOfDay, err)
+	}
+	for i, booking := range created {
+		year, month, day := want[i].date.Date()
+		wantStart := time.Date(year, month, day, clock.Hour, clock.Minute, 0, 0, loc)
+		if !booking.ScheduledStart.Equal(wantStart) || booking.RecurrenceIndex != want[i].index {
+			t.Errorf("occurrence %d starts %v at index %d, want %v at index %d",
+				i, booking.ScheduledStart.In(loc), booking.RecurrenceIndex, wantStart, want[i].index)
+		}
+		if _, gotTime := booking.LocalSchedule(); gotTime != timeOfDay {
+			t.Errorf("occurrence %d starts at %s local time, want %s", i, gotTime, timeOfDay)
+		}
+	}
+}
+
+func TestMaterializeKeepsWallClockAcrossDaylightSaving(t *testing.T) {
+	loc := useBucharest(t)
+
+	// Clocks jump forward on 2025-03-30 and back on 2025-10-26
+	tests := []struct {
+		name      string
+		frequency store.ServiceFrequency
+		anchor    time.Time
+		until     time.Time
+		want      []occurrence
+	}{
+		{
+			name:      "weekly into summer time",
+			frequency: store.ServiceFrequencyWeekly,
+			anchor:    date(2025, time.March, 22),
+			until:     date(2025, time.April, 5),
+			want:      []occurrence{{date(2025, time.March, 29), 1}, {date(2025, time.April, 5), 2}},
+		},
+		{
+			name:      "biweekly into summer time",
+			frequency: store.ServiceFrequencyBiMonthly,
+			anchor:    date(2025, time.March, 22),
+			until:     date(2025, time.April, 19),
+			want:      []occurrence{{date(2025, time.April, 5), 1}, {date(2025, time.April, 19), 2}},
+		},
+		{
+			name:      "weekly into winter time",
+			frequency: store.ServiceFrequencyWeekly,
+			anchor:    date(2025, time.October, 18),
+			until:     date(2025, time.November, 1),
+			want:      []occurrence{{date(2025, time.October, 25), 1}, {date(2025, time.November, 1), 2}},
+		},
+		{
+			name:      "biweekly into winter time",
+			frequency: store.ServiceFrequencyBiMonthly,
+			anchor:    date(2025, time.October, 18),
+			until:     date(2025, time.November, 15),
+			want:      []occurrence{{date(2025, time.November, 1), 1}, {date(2025, time.November, 15), 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestSeries(t, tt.frequency, tt.anchor, "10:00", tt.until)
+
+			created, err := s.Materialize(context.Background(), "parent")
+			if err != nil {
+				t.Fatalf("Materialize() error = %v", err)
+			}
+			checkOccurrences(t, loc, created, "10:00", tt.want)
+		})
+	}
+}
+
+func TestMaterializeSkipsTheHourMissingOnSpringForward(t *testing.T) {
+	loc := useBucharest(t)
+
+	// 03:30 does not exist on 2025-03-30; the series goes on the week after
+	s, _ := newTestSeries(t, store.ServiceFrequencyWeekly, date(2025, time.March, 23), "03:30", date(2025, time.April, 6))
+
+	created, err := s.Materialize(context.Background(), "parent")
+	if err != nil {
+		t.Fatalf("Materialize() error = %v", err)
+	}
+	checkOccurrences(t, loc, created, "03:30", []occurrence{{date(2025, time.April, 6), 2}})
+}
+
+func TestMaterializeContinuesAfterSkippedOccurrences(t *testing.T) {
+	loc := useBucharest(t)
+
+	s, bookings := newTestSeries(t, store.ServiceFrequencyWeekly, date(2025, time.May, 3), "10:00", date(2025, time.June, 7))
+	bookings.series[0].Status = store.BookingStatusCompleted
+
+	// The customer skipped the next two occurrences, so the latest one is cancelled
+	parentID := "parent"
+	for i, day := range []int{10, 17} {
+		skipped := &store.Booking{
+			ID:               []string{"first", "second"}[i],
+			CustomerID:       "customer",
+			CleanerID:        "cleaner",
+			ServiceFrequency: store.ServiceFrequencyWeekly,
+			Status:           store.BookingStatusCancelled,
+			IsRecurring:      true,
+			ParentBookingID:  &parentID,
+			RecurrenceIndex:  i + 1,
+		}
+		if err := skipped.ScheduleAt(date(2025, time.May, day), "10:00"); err != nil {
+			t.Fatalf("ScheduleAt() error = %v", err)
+		}
+		bookings.series = append(bookings.series, skipped)
+	}
+	// The cleaner is already booked on one of the dates to come
+	bookings.taken["2025-05-31"] = true
+
+	created, err := s.Materialize(context.Background(), "parent")
+	if err != nil {
+		t.Fatalf("Materialize() error = %v", err)
+	}
+	checkOccurrences(t, loc, created, "10:00", []occurrence{
+		{date(2025, time.May, 24), 3},
+		{date(2025, time.June, 7), 5},
+	})
+
+	last := bookings.series[2]
+	if last.NextBookingID == nil || *last.NextBookingID != created[0].ID {
+		t.Errorf("skipped occurrence links to %v, want the first new occurrence", last.NextBookingID)
+	}
+	if created[0].Status != store.BookingStatusPending {
+		t.Errorf("new occurrence is %s, want pending", created[0].Status)
+	}
+
+	// Nothing is left to create until the horizon moves
+	again, err := s.Materialize(context.Background(), "parent")
+	if err != nil || len(again) != 0 {
+		t.Errorf("second Materialize() created %d occurrences, %v; want none", len(again), err)
+	}
+}
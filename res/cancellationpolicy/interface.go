@@ -0,0 +1,68 @@
+package cancellationpolicy
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+var (
+	ErrBookingNotFound = errors.New("booking not found")
+	ErrAccessDenied    = errors.New("you are not allowed to cancel this booking")
+	ErrNotCancellable  = errors.New("booking can no longer be cancelled")
+	ErrInvalidPolicy   = errors.New("invalid cancellation policy")
+)
+
+// PolicyService computes and settles the financial outcome of cancelled bookings.
+// Settlement runs as a booking lifecycle side effect, so every cancellation path
+// (single booking, skipped occurrence, cancelled series) is covered.
+type PolicyService interface {
+	// Quote returns what would happen if the actor cancelled the booking now
+	Quote(ctx context.Context, bookingID string, actor *store.User, reason *store.CancellationReason) (*Quote, error)
+
+	// Evaluate applies the policy to a booking without touching the store
+	Evaluate(booking *store.Booking, role store.BookingActorRole, reason store.CancellationReason, at time.Time) Quote
+
+	// EvaluateCancelled applies the policy to a cancelled booking with its recorded reason and time
+	EvaluateCancelled(booking *store.Booking, role store.BookingActorRole) Quote
+
+	// Settle refunds the customer of a cancelled booking and writes the cleaner's compensation.
+	// Card payments are refunded through the payment provider; a refund it turns down is made
+	// again by the next call. Settling a settled booking is a no-op.
+	Settle(ctx context.Context, booking *store.Booking, role store.BookingActorRole) error
+}
+
+// Quote is the outcome of applying the cancellation policy to a booking
+type Quote struct {
+	BookingID           string                   `json:"bookingId"`
+	ActorRole           store.BookingActorRole   `json:"actorRole"`
+	Reason              store.CancellationReason `json:"reason"`
+	HoursBeforeStart    float64                  `json:"hoursBeforeStart"`
+	RefundPercent       int                      `json:"refundPercent"`
+	RefundAmount        int                      `json:"refundAmount"`   // in bani
+	CreditRefund        int                      `json:"creditRefund"`   // in bani
+	GiftCardRefund      int                      `json:"giftCardRefund"` // in bani
+	CompensationPercent int                      `json:"compensationPercent"`
+	CleanerCompensation int                      `json:"cleanerCompensation"` // in bani
+}
+
+// Policy is the configurable set of cancellation rules
+type Policy struct {
+	// Tiers per actor role; the first tier whose MinHoursBefore is met applies.
+	// Roles without tiers get a full refund.
+	Tiers map[store.BookingActorRole][]Tier `json:"tiers"`
+
+	// ReasonOverrides apply regardless of when the booking was cancelled (e.g. weather, emergency),
+	// but only to cancellations made by an admin or the system
+	ReasonOverrides map[store.CancellationReason]Tier `json:"reasonOverrides"`
+}
+
+// Tier defines the refund and compensation for cancellations made at least MinHoursBefore the start.
+// RefundPercent is a share of the booking total; CompensationPercent is a share of the cleaner payout.
+type Tier struct {
+	MinHoursBefore      float64 `json:"minHoursBefore"`
+	RefundPercent       int     `json:"refundPercent"`
+	CompensationPercent int     `json:"compensationPercent"`
+}
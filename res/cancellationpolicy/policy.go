@@ -0,0 +1,100 @@
+package cancellationpolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"cleanbuddy-api/res/store"
+)
+
+// DefaultPolicy returns the platform's standard cancellation policy:
+// customers get a full refund 24+ hours before the start, half within 24 hours
+// (the cleaner keeps half of their payout), and nothing within 2 hours
+// (the cleaner keeps their full payout). Cancellations by the cleaner, an admin
+// or the system are always refunded in full, as are weather and emergency
+// cancellations recorded by an admin or the system.
+func DefaultPolicy() Policy {
+	return Policy{
+		Tiers: map[store.BookingActorRole][]Tier{
+			store.BookingActorRoleCustomer: {
+				{MinHoursBefore: 24, RefundPercent: 100, CompensationPercent: 0},
+				{MinHoursBefore: 2, RefundPercent: 50, CompensationPercent: 50},
+				{MinHoursBefore: 0, RefundPercent: 0, CompensationPercent: 100},
+			},
+		},
+		ReasonOverrides: map[store.CancellationReason]Tier{
+			store.CancellationReasonWeather:   {RefundPercent: 100, CompensationPercent: 0},
+			store.CancellationReasonEmergency: {RefundPercent: 100, CompensationPercent: 0},
+		},
+	}
+}
+
+// ParsePolicy parses a policy from JSON and validates it
+func ParsePolicy(data []byte) (Policy, error) {
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return Policy{}, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return Policy{}, err
+	}
+	return policy, nil
+}
+
+// Validate checks that all percentages are within 0-100
+func (p Policy) Validate() error {
+	for role, tiers := range p.Tiers {
+		for _, tier := range tiers {
+			if err := tier.validate(); err != nil {
+				return fmt.Errorf("%w: tier for %s: %v", ErrInvalidPolicy, role, err)
+			}
+		}
+	}
+	for reason, tier := range p.ReasonOverrides {
+		if err := tier.validate(); err != nil {
+			return fmt.Errorf("%w: override for %s: %v", ErrInvalidPolicy, reason, err)
+		}
+	}
+	return nil
+}
+
+func (t Tier) validate() error {
+	if t.RefundPercent < 0 || t.RefundPercent > 100 {
+		return fmt.Errorf("refundPercent must be between 0 and 100")
+	}
+	if t.CompensationPercent < 0 || t.CompensationPercent > 100 {
+		return fmt.Errorf("compensationPercent must be between 0 and 100")
+	}
+	return nil
+}
+
+// tierFor selects the tier that applies to a cancellation
+func (p Policy) tierFor(role store.BookingActorRole, reason store.CancellationReason, hoursBefore float64) Tier {
+	if override, ok := p.ReasonOverrides[reason]; ok && overridesReason(role) {
+		return override
+	}
+
+	tiers := append([]Tier(nil), p.Tiers[role]...)
+	if len(tiers) == 0 {
+		return Tier{RefundPercent: 100}
+	}
+
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].MinHoursBefore > tiers[j].MinHoursBefore
+	})
+	for _, tier := range tiers {
+		if hoursBefore >= tier.MinHoursBefore {
+			return tier
+		}
+	}
+
+	// Past the start time the strictest tier applies
+	return tiers[len(tiers)-1]
+}
+
+// overridesReason reports whether a role's stated reason can override the time tiers.
+// Customers pick their own reason, so only admins and the system can invoke an override.
+func overridesReason(role store.BookingActorRole) bool {
+	return role == store.BookingActorRoleAdmin || role == store.BookingActorRoleSystem
+}
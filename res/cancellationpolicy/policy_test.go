@@ -0,0 +1,80 @@
+package cancellationpolicy
+
+import (
+	"testing"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+func TestTierForBoundaries(t *testing.T) {
+	policy := DefaultPolicy()
+
+	tests := []struct {
+		name        string
+		role        store.BookingActorRole
+		reason      store.CancellationReason
+		hoursBefore float64
+		want        Tier
+	}{
+		{"well ahead", store.BookingActorRoleCustomer, store.CancellationReasonCustomerRequest, 48, Tier{24, 100, 0}},
+		{"exactly at the 24 hour cutoff", store.BookingActorRoleCustomer, store.CancellationReasonCustomerRequest, 24, Tier{24, 100, 0}},
+		{"just inside 24 hours", store.BookingActorRoleCustomer, store.CancellationReasonCustomerRequest, 23.99, Tier{2, 50, 50}},
+		{"exactly at the 2 hour cutoff", store.BookingActorRoleCustomer, store.CancellationReasonCustomerRequest, 2, Tier{2, 50, 50}},
+		{"just inside 2 hours", store.BookingActorRoleCustomer, store.CancellationReasonCustomerRequest, 1.99, Tier{0, 0, 100}},
+		{"at the start", store.BookingActorRoleCustomer, store.CancellationReasonCustomerRequest, 0, Tier{0, 0, 100}},
+		{"past the start", store.BookingActorRoleCustomer, store.CancellationReasonCustomerRequest, -3, Tier{0, 0, 100}},
+		{"customer cannot invoke an override", store.BookingActorRoleCustomer, store.CancellationReasonWeather, 1, Tier{0, 0, 100}},
+		{"admin override regardless of time", store.BookingActorRoleAdmin, store.CancellationReasonWeather, -3, Tier{0, 100, 0}},
+		{"role without tiers is refunded in full", store.BookingActorRoleCleaner, store.CancellationReasonCleanerRequest, 1, Tier{RefundPercent: 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.tierFor(tt.role, tt.reason, tt.hoursBefore); got != tt.want {
+				t.Errorf("tierFor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateBoundaries(t *testing.T) {
+	s := &service{policy: DefaultPolicy()}
+	start := time.Date(2026, 10, 10, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		at               time.Time
+		totalPrice       int
+		creditApplied    int
+		wantRefund       int
+		wantCreditRefund int
+		wantCompensation int
+	}{
+		{"exactly 24 hours before", start.Add(-24 * time.Hour), 20000, 0, 20000, 0, 0},
+		{"a second inside 24 hours", start.Add(-24*time.Hour + time.Second), 20000, 0, 10000, 0, 8000},
+		{"exactly 2 hours before", start.Add(-2 * time.Hour), 20000, 0, 10000, 0, 8000},
+		{"past the start", start.Add(time.Hour), 20000, 0, 0, 0, 16000},
+		{"fully credit-paid inside 24 hours", start.Add(-10 * time.Hour), 0, 20000, 0, 10000, 8000},
+		{"fully credit-paid past the start", start.Add(time.Hour), 0, 20000, 0, 0, 16000},
+		{"fully credit-paid well ahead", start.Add(-48 * time.Hour), 0, 20000, 0, 20000, 0},
+		{"compensation capped at what is retained", start.Add(time.Hour), 5000, 5000, 0, 0, 10000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booking := &store.Booking{
+				ID:             "b1",
+				TotalPrice:     tt.totalPrice,
+				CreditApplied:  tt.creditApplied,
+				CleanerPayout:  16000,
+				ScheduledStart: start,
+			}
+			quote := s.Evaluate(booking, store.BookingActorRoleCustomer, store.CancellationReasonCustomerRequest, tt.at)
+			if quote.RefundAmount != tt.wantRefund || quote.CreditRefund != tt.wantCreditRefund ||
+				quote.CleanerCompensation != tt.wantCompensation {
+				t.Errorf("Evaluate() refund %d, credit refund %d, compensation %d; want %d, %d, %d",
+					quote.RefundAmount, quote.CreditRefund, quote.CleanerCompensation,
+					tt.wantRefund, tt.wantCreditRefund, tt.wantCompensation)
+			}
+		})
+	}
+}
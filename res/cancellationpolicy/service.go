@@ -0,0 +1,253 @@
+package cancellationpolicy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/bookinglifecycle"
+	"cleanbuddy-api/res/payment"
+	"cleanbuddy-api/res/store"
+)
+
+type service struct {
+	store     store.Store
+	lifecycle bookinglifecycle.LifecycleService
+	payments  payment.PaymentService
+	policy    Policy
+	logger    *log.Logger
+}
+
+// NewService creates a new PolicyService and registers settlement on cancelled bookings.
+// payments may be nil when card payments are not configured.
+func NewService(dataStore store.Store, lifecycle bookinglifecycle.LifecycleService, payments payment.PaymentService, policy Policy, logger *log.Logger) PolicyService {
+	s := &service{
+		store:     dataStore,
+		lifecycle: lifecycle,
+		payments:  payments,
+		policy:    policy,
+		logger:    logger,
+	}
+
+	lifecycle.OnTransition(store.BookingStatusCancelled, s.settleOnCancel)
+
+	return s
+}
+
+func (s *service) Quote(ctx context.Context, bookingID string, actor *store.User, reason *store.CancellationReason) (*Quote, error) {
+	booking, err := s.store.Bookings().Get(ctx, bookingID)
+	if err != nil {
+		s.logger.Printf("Failed to get booking %s: %v", bookingID, err)
+		return nil, ErrBookingNotFound
+	}
+
+	if err := s.lifecycle.CanTransition(booking, actor, store.BookingStatusCancelled); err != nil {
+		if errors.Is(err, bookinglifecycle.ErrActorNotAllowed) {
+			return nil, ErrAccessDenied
+		}
+		return nil, ErrNotCancellable
+	}
+
+	role, _ := s.lifecycle.ActorRole(booking, actor)
+	effectiveReason := defaultReason(role)
+	if reason != nil {
+		effectiveReason = *reason
+	}
+
+	quote := s.Evaluate(booking, role, effectiveReason, time.Now())
+	return &quote, nil
+}
+
+func (s *service) Evaluate(booking *store.Booking, role store.BookingActorRole, reason store.CancellationReason, at time.Time) Quote {
+	hoursBefore := booking.StartsAt().Sub(at).Hours()
+	tier := s.policy.tierFor(role, reason, hoursBefore)
+
+	// The refund percentage applies to each way the booking was paid
+	refund := booking.TotalPrice * tier.RefundPercent / 100
+	creditRefund := booking.CreditApplied * tier.RefundPercent / 100
+	giftCardRefund := booking.GiftCardApplied * tier.RefundPercent / 100
+	compensation := booking.CleanerPayout * tier.CompensationPercent / 100
+
+	// Compensation is funded from the retained part of the gross price; TotalPrice is net of credit
+	gross := booking.TotalPrice + booking.CreditApplied + booking.GiftCardApplied
+	if retained := gross - refund - creditRefund - giftCardRefund; compensation > retained {
+		compensation = retained
+	}
+
+	return Quote{
+		BookingID:           booking.ID,
+		ActorRole:           role,
+		Reason:              reason,
+		HoursBeforeStart:    hoursBefore,
+		RefundPercent:       tier.RefundPercent,
+		RefundAmount:        refund,
+		CreditRefund:        creditRefund,
+		GiftCardRefund:      giftCardRefund,
+		CompensationPercent: tier.CompensationPercent,
+		CleanerCompensation: compensation,
+	}
+}
+
+func (s *service) EvaluateCancelled(booking *store.Booking, role store.BookingActorRole) Quote {
+	reason := store.CancellationReasonOther
+	if booking.CancellationReason != nil {
+		reason = *booking.CancellationReason
+	}
+	cancelledAt := time.Now()
+	if booking.CancelledAt != nil {
+		cancelledAt = *booking.CancelledAt
+	}
+	return s.Evaluate(booking, role, reason, cancelledAt)
+}
+
+func (s *service) Settle(ctx context.Context, booking *store.Booking, role store.BookingActorRole) error {
+	transactions, err := s.store.Transactions().GetByBooking(ctx, booking.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get booking transactions: %w", err)
+	}
+
+	var payment *store.Transaction
+	paid := 0
+	held, refunded := false, false
+	for _, transaction := range transactions {
+		switch transaction.Type {
+		case store.TransactionTypePayout:
+			// Compensation is written last, so the cancellation is settled
+			return nil
+		case store.TransactionTypeRefund:
+			// A refund the provider turned down is made again
+			refunded = refunded || (transaction.Status != store.TransactionStatusFailed &&
+				transaction.Status != store.TransactionStatusCancelled)
+		case store.TransactionTypePayment:
+			switch transaction.Status {
+			case store.TransactionStatusCompleted:
+				payment = transaction
+				paid += transaction.Amount
+			case store.TransactionStatusPending, store.TransactionStatusAuthorized,
+				store.TransactionStatusProcessing, store.TransactionStatusFailed:
+				held = held || transaction.StripePaymentID != nil
+			}
+		}
+	}
+
+	quote := s.EvaluateCancelled(booking, role)
+
+	// The card is only held until the booking is done, so the refund is whatever is not charged
+	if payment == nil && held && s.payments != nil {
+		captured, err := s.payments.SettleHold(ctx, booking.ID, booking.TotalPrice-quote.RefundAmount)
+		if err != nil {
+			return fmt.Errorf("failed to settle card hold: %w", err)
+		}
+		if captured != nil {
+			payment = captured
+			paid = captured.Amount
+		} else {
+			s.logger.Printf("Released card hold of cancelled booking %s", booking.ID)
+		}
+		refunded = true
+	}
+
+	// Credit and gift card value the customer does not get back fund compensation as well as a
+	// retained payment
+	retainedCredit := booking.CreditApplied - quote.CreditRefund + booking.GiftCardApplied - quote.GiftCardRefund
+	if payment == nil && retainedCredit <= 0 {
+		s.logger.Printf("Booking %s was cancelled without a completed payment, nothing to settle", booking.ID)
+		return nil
+	}
+
+	if quote.RefundAmount > paid {
+		quote.RefundAmount = paid
+	}
+	if retained := paid - quote.RefundAmount + retainedCredit; quote.CleanerCompensation > retained {
+		quote.CleanerCompensation = retained
+	}
+
+	metadata, err := json.Marshal(quote)
+	if err != nil {
+		return fmt.Errorf("failed to encode cancellation quote: %w", err)
+	}
+
+	now := time.Now()
+
+	// Refunds reverse the original payment. The cancellation only counts as settled once the
+	// provider accepted the refund of a card payment; other payments are refunded by hand.
+	if payment != nil && quote.RefundAmount > 0 && !refunded {
+		description := fmt.Sprintf("Cancellation refund (%d%%)", quote.RefundPercent)
+		if payment.StripePaymentID != nil {
+			if s.payments == nil {
+				return fmt.Errorf("card payment of booking %s cannot be refunded without payments", booking.ID)
+			}
+			if _, err := s.payments.Refund(ctx, booking.ID, quote.RefundAmount, description); err != nil {
+				return fmt.Errorf("failed to refund cancelled booking: %w", err)
+			}
+		} else {
+			refund := &store.Transaction{
+				ID:            uuid.New().String(),
+				Type:          store.TransactionTypeRefund,
+				Status:        store.TransactionStatusPending,
+				BookingID:     &booking.ID,
+				PayerID:       payment.PayeeID,
+				PayeeID:       payment.PayerID,
+				Amount:        quote.RefundAmount,
+				NetAmount:     quote.RefundAmount,
+				PaymentMethod: payment.PaymentMethod,
+				Currency:      payment.Currency,
+				Description:   description,
+				Metadata:      string(metadata),
+				ProcessedAt:   now,
+			}
+			if err := s.store.Transactions().Create(ctx, refund); err != nil {
+				return fmt.Errorf("failed to create refund transaction: %w", err)
+			}
+		}
+	}
+
+	if quote.CleanerCompensation > 0 {
+		payer, currency := booking.CustomerID, "RON"
+		if payment != nil {
+			payer, currency = payment.PayeeID, payment.Currency
+		}
+		payout := &store.Transaction{
+			ID:            uuid.New().String(),
+			Type:          store.TransactionTypePayout,
+			Status:        store.TransactionStatusPending,
+			BookingID:     &booking.ID,
+			PayerID:       payer,
+			PayeeID:       booking.CleanerID,
+			Amount:        quote.CleanerCompensation,
+			NetAmount:     quote.CleanerCompensation,
+			PaymentMethod: store.PaymentMethodBankTransfer,
+			Currency:      currency,
+			Description:   fmt.Sprintf("Late cancellation compensation (%d%%)", quote.CompensationPercent),
+			Metadata:      string(metadata),
+			ProcessedAt:   now,
+		}
+		if err := s.store.Transactions().Create(ctx, payout); err != nil {
+			return fmt.Errorf("failed to create compensation payout: %w", err)
+		}
+	}
+
+	s.logger.Printf("Settled cancellation of booking %s: refund %d, cleaner compensation %d",
+		booking.ID, quote.RefundAmount, quote.CleanerCompensation)
+	return nil
+}
+
+func (s *service) settleOnCancel(ctx context.Context, event bookinglifecycle.TransitionEvent) error {
+	return s.Settle(ctx, event.Booking, event.ActorRole)
+}
+
+// defaultReason is the reason assumed for a quote when the client does not provide one
+func defaultReason(role store.BookingActorRole) store.CancellationReason {
+	switch role {
+	case store.BookingActorRoleCustomer:
+		return store.CancellationReasonCustomerRequest
+	case store.BookingActorRoleCleaner:
+		return store.CancellationReasonCleanerRequest
+	}
+	return store.CancellationReasonOther
+}
@@ -0,0 +1,189 @@
+package cancellationpolicy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"cleanbuddy-api/res/payment"
+	"cleanbuddy-api/res/store"
+)
+
+// fakeStore keeps the transactions of a single booking
+type fakeStore struct {
+	store.Store
+	transactions *fakeTransactions
+}
+
+func (f *fakeStore) Transactions() store.TransactionStore { return f.transactions }
+
+type fakeTransactions struct {
+	store.TransactionStore
+	all []*store.Transaction
+}
+
+func (f *fakeTransactions) GetByBooking(ctx context.Context, bookingID string) ([]*store.Transaction, error) {
+	return append([]*store.Transaction(nil), f.all...), nil
+}
+
+func (f *fakeTransactions) Create(ctx context.Context, transaction *store.Transaction) error {
+	f.all = append(f.all, transaction)
+	return nil
+}
+
+// refundRecorder refunds through a provider that fails while err is set
+type refundRecorder struct {
+	payment.PaymentService
+	transactions *fakeTransactions
+	refunds      []int
+	err          error
+}
+
+func (r *refundRecorder) Refund(ctx context.Context, bookingID string, amount int, description string) (*store.Transaction, error) {
+	r.refunds = append(r.refunds, amount)
+	if r.err != nil {
+		return nil, r.err
+	}
+	refund := &store.Transaction{Type: store.TransactionTypeRefund, Status: store.TransactionStatusCompleted, Amount: amount}
+	r.transactions.all = append(r.transactions.all, refund)
+	return refund, nil
+}
+
+func TestSettleRefundsCardPaymentsThroughTheProvider(t *testing.T) {
+	intentID := "pi_1"
+	transactions := &fakeTransactions{all: []*store.Transaction{{
+		ID:              "payment",
+		Type:            store.TransactionTypePayment,
+		Status:          store.TransactionStatusCompleted,
+		Amount:          20000,
+		StripePaymentID: &intentID,
+		Currency:        "RON",
+	}}}
+	payments := &refundRecorder{transactions: transactions, err: errors.New("card declined")}
+	s := &service{
+		store:    &fakeStore{transactions: transactions},
+		payments: payments,
+		policy:   DefaultPolicy(),
+		logger:   log.New(io.Discard, "", 0),
+	}
+
+	// Cancelled by the customer 10 hours before the start: half refunded, half the payout owed
+	cancelledAt := time.Date(2026, 10, 10, 8, 0, 0, 0, time.UTC)
+	reason := store.CancellationReasonCustomerRequest
+	booking := &store.Booking{
+		ID:                 "b1",
+		CleanerID:          "cleaner",
+		TotalPrice:         20000,
+		CleanerPayout:      16000,
+		ScheduledStart:     cancelledAt.Add(10 * time.Hour),
+		CancelledAt:        &cancelledAt,
+		CancellationReason: &reason,
+	}
+
+	if err := s.Settle(context.Background(), booking, store.BookingActorRoleCustomer); err == nil {
+		t.Fatal("Settle succeeded although the provider turned the refund down")
+	}
+	if len(transactions.all) != 1 {
+		t.Fatalf("recorded %d transactions after a failed refund, want none", len(transactions.all)-1)
+	}
+
+	payments.err = nil
+	if err := s.Settle(context.Background(), booking, store.BookingActorRoleCustomer); err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+	if len(payments.refunds) != 2 || payments.refunds[1] != 10000 {
+		t.Errorf("refunds asked = %v, want 10000 asked again", payments.refunds)
+	}
+	last := transactions.all[len(transactions.all)-1]
+	if last.Type != store.TransactionTypePayout || last.Amount != 8000 || last.PayeeID != "cleaner" {
+		t.Errorf("last transaction = %s of %d to %s, want the cleaner's compensation of 8000", last.Type, last.Amount, last.PayeeID)
+	}
+
+	// Settled now, so nothing is refunded again
+	if err := s.Settle(context.Background(), booking, store.BookingActorRoleCustomer); err != nil {
+		t.Fatalf("second Settle: %v", err)
+	}
+	if len(payments.refunds) != 2 || len(transactions.all) != 3 {
+		t.Errorf("settling again asked %d refunds and recorded %d transactions, want nothing more",
+			len(payments.refunds), len(transactions.all))
+	}
+}
+
+func TestEvaluateSplitsCreditLikeTheCardPayment(t *testing.T) {
+	s := &service{policy: DefaultPolicy()}
+	at := time.Date(2026, 10, 10, 8, 0, 0, 0, time.UTC)
+	// 30000 gross, of which 10000 paid with credit; the cleaner earns 24000
+	booking := &store.Booking{
+		ID:             "b1",
+		TotalPrice:     20000,
+		CreditApplied:  10000,
+		CleanerPayout:  24000,
+		ScheduledStart: at.Add(10 * time.Hour),
+	}
+
+	quote := s.Evaluate(booking, store.BookingActorRoleCustomer, store.CancellationReasonCustomerRequest, at)
+	if quote.RefundAmount != 10000 || quote.CreditRefund != 5000 {
+		t.Errorf("refund = %d and credit refund = %d, want half of each: 10000 and 5000", quote.RefundAmount, quote.CreditRefund)
+	}
+	// Half the payout is funded from the 15000 retained of the gross price, not the 10000 of the card
+	if quote.CleanerCompensation != 12000 {
+		t.Errorf("compensation = %d, want 12000", quote.CleanerCompensation)
+	}
+}
+
+func TestSettleCompensatesFromRetainedCredit(t *testing.T) {
+	transactions := &fakeTransactions{}
+	s := &service{
+		store:  &fakeStore{transactions: transactions},
+		policy: DefaultPolicy(),
+		logger: log.New(io.Discard, "", 0),
+	}
+
+	// Paid entirely with credit and cancelled an hour before the start: nothing is refunded
+	cancelledAt := time.Date(2026, 10, 10, 8, 0, 0, 0, time.UTC)
+	reason := store.CancellationReasonCustomerRequest
+	booking := &store.Booking{
+		ID:                 "b1",
+		CustomerID:         "customer",
+		CleanerID:          "cleaner",
+		CreditApplied:      15000,
+		CleanerPayout:      12000,
+		ScheduledStart:     cancelledAt.Add(time.Hour),
+		CancelledAt:        &cancelledAt,
+		CancellationReason: &reason,
+	}
+
+	if err := s.Settle(context.Background(), booking, store.BookingActorRoleCustomer); err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+	if len(transactions.all) != 1 {
+		t.Fatalf("recorded %d transactions, want the cleaner's compensation only", len(transactions.all))
+	}
+	payout := transactions.all[0]
+	if payout.Type != store.TransactionTypePayout || payout.Amount != 12000 || payout.PayerID != "customer" {
+		t.Errorf("recorded %s of %d from %s, want the full payout of 12000 from the customer", payout.Type, payout.Amount, payout.PayerID)
+	}
+}
+
+func TestEvaluateSplitsGiftCardValueLikeTheCardPayment(t *testing.T) {
+	s := &service{policy: DefaultPolicy()}
+	at := time.Date(2026, 10, 10, 8, 0, 0, 0, time.UTC)
+	booking := &store.Booking{
+		ID:              "b1",
+		TotalPrice:      0,
+		GiftCardApplied: 20000,
+		CleanerPayout:   16000,
+		ScheduledStart:  at.Add(10 * time.Hour),
+	}
+
+	quote := s.Evaluate(booking, store.BookingActorRoleCustomer, store.CancellationReasonCustomerRequest, at)
+	if quote.RefundAmount != 0 || quote.GiftCardRefund != 10000 {
+		t.Errorf("refund = %d and gift card refund = %d, want 0 and 10000", quote.RefundAmount, quote.GiftCardRefund)
+	}
+	if quote.CleanerCompensation != 8000 {
+		t.Errorf("compensation = %d, want half of the payout, 8000", quote.CleanerCompensation)
+	}
+}
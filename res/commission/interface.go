@@ -0,0 +1,46 @@
+package commission
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+var (
+	ErrRuleNotFound    = errors.New("commission rule not found")
+	ErrInvalidRule     = errors.New("invalid commission rule")
+	ErrCompanyNotFound = errors.New("company not found")
+)
+
+// CommissionService resolves the commission terms of bookings from configurable rules.
+// Rules layer from least to most specific: the platform default, the cleaner's company,
+// and any promotion running at that time (targeted promotions over global ones).
+// The discount for the cleaner's tier is then taken off the commission, promotions included;
+// when several rules cover the tier, the largest discount applies.
+type CommissionService interface {
+	// Terms returns the rates that apply to a booking with the cleaner priced at the given time
+	Terms(ctx context.Context, profile *store.CleanerProfile, at time.Time) (Terms, error)
+
+	// Get retrieves a single rule
+	Get(ctx context.Context, id string) (*store.CommissionRule, error)
+
+	// List returns the rules, optionally of one scope, newest first
+	List(ctx context.Context, scope *store.CommissionRuleScope) ([]*store.CommissionRule, error)
+
+	// Create validates and stores a new rule
+	Create(ctx context.Context, rule *store.CommissionRule) (*store.CommissionRule, error)
+
+	// Update validates and stores changes to a rule
+	Update(ctx context.Context, rule *store.CommissionRule) (*store.CommissionRule, error)
+
+	// Delete removes a rule; bookings keep the terms they were priced with
+	Delete(ctx context.Context, id string) error
+}
+
+// Terms are the commission rates applied to a booking, in percent
+type Terms struct {
+	PlatformFeePercent float64 // Charged to the customer on top of the subtotal
+	CommissionPercent  float64 // Kept from the cleaner payout
+}
@@ -0,0 +1,118 @@
+package commission
+
+import (
+	"math"
+	"sort"
+
+	"cleanbuddy-api/res/store"
+)
+
+// Resolve layers the rules over the defaults: platform rules, then the company's
+// rules, then running promotions from the most general to the most targeted.
+// The tier discount is taken off the result last, so cleaners keep it during promotions.
+// Rules of the same scope and specificity apply in the order given, so a later rule
+// overrides an earlier one. Tier discounts do not stack: of several rules for the
+// cleaner's tier, only the largest discount applies.
+func Resolve(defaults Terms, rules []*store.CommissionRule) Terms {
+	terms := defaults
+
+	layers := [][]*store.CommissionRule{
+		rulesOfScope(rules, store.CommissionRuleScopePlatform),
+		rulesOfScope(rules, store.CommissionRuleScopeCompany),
+		promotionsBySpecificity(rulesOfScope(rules, store.CommissionRuleScopePromotion)),
+	}
+	for _, layer := range layers {
+		for _, rule := range layer {
+			if rule.PlatformFeePercent != nil {
+				terms.PlatformFeePercent = *rule.PlatformFeePercent
+			}
+			if rule.CommissionPercent != nil {
+				terms.CommissionPercent = *rule.CommissionPercent
+			}
+		}
+	}
+
+	discount := 0.0
+	for _, rule := range rulesOfScope(rules, store.CommissionRuleScopeTier) {
+		discount = math.Max(discount, rule.DiscountPercent)
+	}
+	terms.CommissionPercent = math.Max(terms.CommissionPercent-discount, 0)
+
+	return terms
+}
+
+func rulesOfScope(rules []*store.CommissionRule, scope store.CommissionRuleScope) []*store.CommissionRule {
+	var matching []*store.CommissionRule
+	for _, rule := range rules {
+		if rule.Scope == scope {
+			matching = append(matching, rule)
+		}
+	}
+	return matching
+}
+
+// promotionsBySpecificity orders promotions so targeted ones override global ones:
+// global first, then tier-targeted, then company-targeted, then those targeting both
+func promotionsBySpecificity(promotions []*store.CommissionRule) []*store.CommissionRule {
+	specificity := func(rule *store.CommissionRule) int {
+		score := 0
+		if rule.Tier != nil {
+			score++
+		}
+		if rule.CompanyID != nil {
+			score += 2
+		}
+		return score
+	}
+
+	sort.SliceStable(promotions, func(i, j int) bool {
+		return specificity(promotions[i]) < specificity(promotions[j])
+	})
+	return promotions
+}
+
+// Validate checks that a rule is complete for its scope
+func Validate(rule *store.CommissionRule) error {
+	if rule.Name == "" {
+		return ErrInvalidRule
+	}
+	if !validPercent(rule.PlatformFeePercent) || !validPercent(rule.CommissionPercent) {
+		return ErrInvalidRule
+	}
+	if rule.DiscountPercent < 0 || rule.DiscountPercent > 100 {
+		return ErrInvalidRule
+	}
+
+	hasRate := rule.PlatformFeePercent != nil || rule.CommissionPercent != nil
+
+	switch rule.Scope {
+	case store.CommissionRuleScopePlatform:
+		if !hasRate || rule.CompanyID != nil || rule.Tier != nil {
+			return ErrInvalidRule
+		}
+	case store.CommissionRuleScopeCompany:
+		if !hasRate || rule.CompanyID == nil || rule.Tier != nil {
+			return ErrInvalidRule
+		}
+	case store.CommissionRuleScopeTier:
+		if rule.Tier == nil || rule.CompanyID != nil || hasRate || rule.DiscountPercent == 0 {
+			return ErrInvalidRule
+		}
+	case store.CommissionRuleScopePromotion:
+		if !hasRate || rule.ValidFrom == nil || rule.ValidUntil == nil || !rule.ValidFrom.Before(*rule.ValidUntil) {
+			return ErrInvalidRule
+		}
+	default:
+		return ErrInvalidRule
+	}
+
+	// Only promotions are time-boxed
+	if rule.Scope != store.CommissionRuleScopePromotion && (rule.ValidFrom != nil || rule.ValidUntil != nil) {
+		return ErrInvalidRule
+	}
+	return nil
+}
+
+func validPercent(percent *float64) bool {
+	return percent == nil || (*percent >= 0 && *percent <= 100)
+}
@@ -0,0 +1,49 @@
+package commission
+
+import (
+	"testing"
+
+	"cleanbuddy-api/res/store"
+)
+
+func TestResolvePrecedence(t *testing.T) {
+	percent := func(value float64) *float64 { return &value }
+	companyID := "company"
+	premium := store.CleanerTierPremium
+
+	platform := &store.CommissionRule{Scope: store.CommissionRuleScopePlatform, PlatformFeePercent: percent(8), CommissionPercent: percent(18)}
+	company := &store.CommissionRule{Scope: store.CommissionRuleScopeCompany, CompanyID: &companyID, CommissionPercent: percent(15)}
+	globalPromotion := &store.CommissionRule{Scope: store.CommissionRuleScopePromotion, CommissionPercent: percent(10)}
+	tierPromotion := &store.CommissionRule{Scope: store.CommissionRuleScopePromotion, Tier: &premium, CommissionPercent: percent(9)}
+	companyPromotion := &store.CommissionRule{Scope: store.CommissionRuleScopePromotion, CompanyID: &companyID, CommissionPercent: percent(7)}
+	targetedPromotion := &store.CommissionRule{Scope: store.CommissionRuleScopePromotion, CompanyID: &companyID, Tier: &premium, CommissionPercent: percent(5)}
+	tierDiscount := &store.CommissionRule{Scope: store.CommissionRuleScopeTier, Tier: &premium, DiscountPercent: 3}
+	largerTierDiscount := &store.CommissionRule{Scope: store.CommissionRuleScopeTier, Tier: &premium, DiscountPercent: 4}
+
+	defaults := Terms{PlatformFeePercent: 10, CommissionPercent: 20}
+
+	tests := []struct {
+		name  string
+		rules []*store.CommissionRule
+		want  Terms
+	}{
+		{"defaults without rules", nil, defaults},
+		{"platform over defaults", []*store.CommissionRule{platform}, Terms{8, 18}},
+		{"company over platform, keeping the platform fee", []*store.CommissionRule{company, platform}, Terms{8, 15}},
+		{"promotion over company", []*store.CommissionRule{globalPromotion, company, platform}, Terms{8, 10}},
+		{"tier-targeted over global promotion", []*store.CommissionRule{tierPromotion, globalPromotion}, Terms{10, 9}},
+		{"company-targeted over tier-targeted promotion", []*store.CommissionRule{companyPromotion, tierPromotion, globalPromotion}, Terms{10, 7}},
+		{"promotion targeting both over all others", []*store.CommissionRule{targetedPromotion, companyPromotion, tierPromotion, globalPromotion}, Terms{10, 5}},
+		{"later rule of the same specificity wins", []*store.CommissionRule{tierPromotion, {Scope: store.CommissionRuleScopePromotion, Tier: &premium, CommissionPercent: percent(6)}}, Terms{10, 6}},
+		{"tier discount taken off a promotion", []*store.CommissionRule{globalPromotion, tierDiscount}, Terms{10, 7}},
+		{"only the largest tier discount applies", []*store.CommissionRule{tierDiscount, largerTierDiscount, company}, Terms{10, 11}},
+		{"discount does not go below zero", []*store.CommissionRule{{Scope: store.CommissionRuleScopeTier, Tier: &premium, DiscountPercent: 50}}, Terms{10, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Resolve(defaults, tt.rules); got != tt.want {
+				t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package commission
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/store"
+)
+
+type service struct {
+	store    store.Store
+	defaults Terms
+	logger   *log.Logger
+}
+
+// NewService creates a new CommissionService.
+// defaults apply when no platform rule has been configured.
+func NewService(dataStore store.Store, defaults Terms, logger *log.Logger) CommissionService {
+	return &service{
+		store:    dataStore,
+		defaults: defaults,
+		logger:   logger,
+	}
+}
+
+func (s *service) Terms(ctx context.Context, profile *store.CleanerProfile, at time.Time) (Terms, error) {
+	rules, err := s.store.CommissionRules().GetActive(ctx, profile.CompanyID, profile.Tier, at)
+	if err != nil {
+		s.logger.Printf("Failed to get commission rules for cleaner profile %s: %v", profile.ID, err)
+		return Terms{}, fmt.Errorf("failed to get commission rules: %w", err)
+	}
+	return Resolve(s.defaults, rules), nil
+}
+
+func (s *service) Get(ctx context.Context, id string) (*store.CommissionRule, error) {
+	rule, err := s.store.CommissionRules().Get(ctx, id)
+	if err != nil {
+		s.logger.Printf("Failed to get commission rule %s: %v", id, err)
+		return nil, ErrRuleNotFound
+	}
+	return rule, nil
+}
+
+func (s *service) List(ctx context.Context, scope *store.CommissionRuleScope) ([]*store.CommissionRule, error) {
+	rules, err := s.store.CommissionRules().List(ctx, scope)
+	if err != nil {
+		s.logger.Printf("Failed to list commission rules: %v", err)
+		return nil, fmt.Errorf("failed to list commission rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (s *service) Create(ctx context.Context, rule *store.CommissionRule) (*store.CommissionRule, error) {
+	if err := s.validate(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	rule.ID = uuid.New().String()
+	if err := s.store.CommissionRules().Create(ctx, rule); err != nil {
+		s.logger.Printf("Failed to create commission rule: %v", err)
+		return nil, fmt.Errorf("failed to create commission rule: %w", err)
+	}
+	return rule, nil
+}
+
+func (s *service) Update(ctx context.Context, rule *store.CommissionRule) (*store.CommissionRule, error) {
+	if err := s.validate(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.CommissionRules().Update(ctx, rule); err != nil {
+		s.logger.Printf("Failed to update commission rule %s: %v", rule.ID, err)
+		return nil, fmt.Errorf("failed to update commission rule: %w", err)
+	}
+	return rule, nil
+}
+
+func (s *service) Delete(ctx context.Context, id string) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.store.CommissionRules().Delete(ctx, id); err != nil {
+		s.logger.Printf("Failed to delete commission rule %s: %v", id, err)
+		return fmt.Errorf("failed to delete commission rule: %w", err)
+	}
+	return nil
+}
+
+func (s *service) validate(ctx context.Context, rule *store.CommissionRule) error {
+	if err := Validate(rule); err != nil {
+		return err
+	}
+	if rule.CompanyID != nil {
+		if _, err := s.store.Companies().Get(ctx, *rule.CompanyID); err != nil {
+			return ErrCompanyNotFound
+		}
+	}
+	return nil
+}
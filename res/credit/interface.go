@@ -0,0 +1,35 @@
+package credit
+
+import (
+	"context"
+	"errors"
+
+	"cleanbuddy-api/res/store"
+)
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidAmount      = errors.New("credit amount must not be zero")
+	ErrInsufficientCredit = errors.New("insufficient credit balance")
+)
+
+// CreditService manages customer credit balances. Credit is granted by referrals
+// and admins, and spent on bookings at checkout. Credit spent on a booking that
+// is cancelled is returned to the extent the cancellation policy refunds it.
+type CreditService interface {
+	// Balance returns a customer's spendable credit in bani
+	Balance(ctx context.Context, userID string) (int, error)
+
+	// History returns a customer's credit entries, newest first
+	History(ctx context.Context, userID string, limit, offset int) ([]*store.CreditEntry, error)
+
+	// Apply spends as much of the customer's balance as the booking total allows,
+	// recording it on the booking before it is placed
+	Apply(ctx context.Context, booking *store.Booking) error
+
+	// Release returns all credit spent on a booking that could not be placed
+	Release(ctx context.Context, bookingID string) error
+
+	// Adjust grants (positive amount) or removes (negative amount) credit on behalf of an admin
+	Adjust(ctx context.Context, userID string, amount int, note string) (*store.CreditEntry, error)
+}
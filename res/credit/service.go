@@ -0,0 +1,142 @@
+package credit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/bookinglifecycle"
+	"cleanbuddy-api/res/cancellationpolicy"
+	"cleanbuddy-api/res/store"
+)
+
+type service struct {
+	store  store.Store
+	policy cancellationpolicy.PolicyService
+	logger *log.Logger
+}
+
+// NewService creates a new CreditService and registers the return of credit spent on cancelled bookings.
+// policy may be nil, in which case the credit of cancelled bookings is returned in full.
+func NewService(dataStore store.Store, lifecycle bookinglifecycle.LifecycleService, policy cancellationpolicy.PolicyService, logger *log.Logger) CreditService {
+	s := &service{
+		store:  dataStore,
+		policy: policy,
+		logger: logger,
+	}
+
+	lifecycle.OnTransition(store.BookingStatusCancelled, s.releaseOnCancel)
+
+	return s
+}
+
+func (s *service) Balance(ctx context.Context, userID string) (int, error) {
+	balance, err := s.store.Credits().Balance(ctx, userID)
+	if err != nil {
+		s.logger.Printf("Failed to get credit balance of user %s: %v", userID, err)
+		return 0, fmt.Errorf("failed to get credit balance: %w", err)
+	}
+	return balance, nil
+}
+
+func (s *service) History(ctx context.Context, userID string, limit, offset int) ([]*store.CreditEntry, error) {
+	entries, err := s.store.Credits().ListByUser(ctx, userID, limit, offset)
+	if err != nil {
+		s.logger.Printf("Failed to list credit entries of user %s: %v", userID, err)
+		return nil, fmt.Errorf("failed to list credit entries: %w", err)
+	}
+	return entries, nil
+}
+
+func (s *service) Apply(ctx context.Context, booking *store.Booking) error {
+	balance, err := s.Balance(ctx, booking.CustomerID)
+	if err != nil {
+		return err
+	}
+
+	amount := balance
+	if amount > booking.TotalPrice {
+		amount = booking.TotalPrice
+	}
+	if amount <= 0 {
+		return nil
+	}
+
+	entry := &store.CreditEntry{
+		ID:        uuid.New().String(),
+		UserID:    booking.CustomerID,
+		Amount:    -amount,
+		Reason:    store.CreditReasonBooking,
+		BookingID: &booking.ID,
+	}
+	if err := s.store.Credits().Add(ctx, entry); err != nil {
+		// The balance was spent by a concurrent checkout since it was read
+		if errors.Is(err, store.ErrInsufficientCredit) {
+			return ErrInsufficientCredit
+		}
+		s.logger.Printf("Failed to spend credit of user %s on booking %s: %v", booking.CustomerID, booking.ID, err)
+		return fmt.Errorf("failed to spend credit: %w", err)
+	}
+
+	booking.CreditApplied = amount
+	booking.TotalPrice -= amount
+	return nil
+}
+
+func (s *service) Release(ctx context.Context, bookingID string) error {
+	return s.release(ctx, bookingID, 100)
+}
+
+// release returns percent of the credit spent on a booking
+func (s *service) release(ctx context.Context, bookingID string, percent int) error {
+	refund, err := s.store.Credits().RefundBooking(ctx, bookingID, percent)
+	if err != nil {
+		s.logger.Printf("Failed to return credit spent on booking %s: %v", bookingID, err)
+		return fmt.Errorf("failed to return credit: %w", err)
+	}
+	if refund != nil {
+		s.logger.Printf("Returned %d credit to user %s from booking %s", refund.Amount, refund.UserID, bookingID)
+	}
+	return nil
+}
+
+func (s *service) Adjust(ctx context.Context, userID string, amount int, note string) (*store.CreditEntry, error) {
+	if amount == 0 {
+		return nil, ErrInvalidAmount
+	}
+	if _, err := s.store.Users().Get(ctx, userID); err != nil {
+		s.logger.Printf("Failed to get user %s for credit adjustment: %v", userID, err)
+		return nil, ErrUserNotFound
+	}
+
+	entry := &store.CreditEntry{
+		ID:     uuid.New().String(),
+		UserID: userID,
+		Amount: amount,
+		Reason: store.CreditReasonAdjustment,
+		Note:   note,
+	}
+	if err := s.store.Credits().Add(ctx, entry); err != nil {
+		if errors.Is(err, store.ErrInsufficientCredit) {
+			return nil, ErrInsufficientCredit
+		}
+		s.logger.Printf("Failed to adjust credit of user %s: %v", userID, err)
+		return nil, fmt.Errorf("failed to adjust credit: %w", err)
+	}
+	return entry, nil
+}
+
+// releaseOnCancel returns as much of the credit as the cancellation policy refunds of the booking
+func (s *service) releaseOnCancel(ctx context.Context, event bookinglifecycle.TransitionEvent) error {
+	if event.Booking.CreditApplied == 0 {
+		return nil
+	}
+	percent := 100
+	if s.policy != nil {
+		percent = s.policy.EvaluateCancelled(event.Booking, event.ActorRole).RefundPercent
+	}
+	return s.release(ctx, event.Booking.ID, percent)
+}
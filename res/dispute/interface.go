@@ -0,0 +1,110 @@
+package dispute
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+var (
+	ErrDisputeNotFound    = errors.New("dispute not found")
+	ErrBookingNotFound    = errors.New("booking not found")
+	ErrAccessDenied       = errors.New("access denied")
+	ErrNotCustomer        = errors.New("only the customer of a booking can dispute it")
+	ErrNotDisputable      = errors.New("only completed bookings can be disputed")
+	ErrWindowClosed       = errors.New("the booking can no longer be disputed")
+	ErrAlreadyDisputed    = errors.New("the booking already has an open dispute")
+	ErrInvalidDispute     = errors.New("a dispute needs a known reason and a description")
+	ErrInvalidStatus      = errors.New("the dispute is not in a state that allows this")
+	ErrTooMuchEvidence    = errors.New("the dispute has the maximum number of evidence files")
+	ErrStorageUnavailable = errors.New("evidence files cannot be stored")
+	ErrInvalidResolution  = errors.New("refund and clawback amounts must not be negative and the clawback must not exceed the cleaner's payout")
+	ErrRefundsUnavailable = errors.New("refunds are not available without payments")
+)
+
+// DisputeService handles customers' complaints about completed bookings. A dispute is opened by
+// the customer, may wait for the cleaner's response, and is decided by an admin, who can refund
+// part of the payment, claw the cleaner's share of it back from their payouts and remove the
+// customer's review. Until a dispute is resolved, the cleaner's earnings from the booking are
+// left out of payout batches.
+type DisputeService interface {
+	// Open disputes a booking the customer had completed, with any evidence they attach
+	Open(ctx context.Context, customer *store.User, input OpenInput) (*store.Dispute, error)
+
+	// Get retrieves a dispute its customer, its cleaner or an admin may see
+	Get(ctx context.Context, user *store.User, id string) (*store.Dispute, error)
+
+	// AddEvidence attaches a file to a dispute that is not resolved, by either side or an admin
+	AddEvidence(ctx context.Context, user *store.User, disputeID string, file Upload) (*store.DisputeEvidence, error)
+
+	// EvidenceURL signs a download URL of an evidence file
+	EvidenceURL(ctx context.Context, evidence *store.DisputeEvidence) (string, error)
+
+	// RequestResponse asks the cleaner for their side of an open dispute
+	RequestResponse(ctx context.Context, admin *store.User, id string) (*store.Dispute, error)
+
+	// Respond records the cleaner's side and hands the dispute to the admins
+	Respond(ctx context.Context, cleaner *store.User, id string, response string) (*store.Dispute, error)
+
+	// StartReview moves a dispute to admin review without waiting for the cleaner
+	StartReview(ctx context.Context, admin *store.User, id string) (*store.Dispute, error)
+
+	// Resolve decides a dispute under admin review and applies its outcome
+	Resolve(ctx context.Context, admin *store.User, id string, resolution Resolution) (*store.Dispute, error)
+}
+
+// OpenInput is what a customer disputes a booking with
+type OpenInput struct {
+	BookingID   string
+	Reason      store.DisputeReason
+	Description string
+	Evidence    []Upload
+}
+
+// Upload is a file attached to a dispute
+type Upload struct {
+	File        io.Reader
+	FileName    string
+	Size        int64
+	ContentType string
+}
+
+// Resolution is an admin's decision on a dispute; amounts in bani
+type Resolution struct {
+	// RefundAmount is returned to the customer's card
+	RefundAmount int
+
+	// ClawbackAmount is taken off the cleaner's next payouts, at most what they earned
+	ClawbackAmount int
+
+	// RemoveReview rejects the customer's review of the booking
+	RemoveReview bool
+
+	Notes string
+}
+
+// FileStore keeps evidence files and signs their download URLs
+type FileStore interface {
+	UploadFromReader(ctx context.Context, reader io.Reader, filename string, fileSize int64, contentType string, objectPath string) (string, error)
+	GenerateSignedURL(ctx context.Context, objectPath string, expiration time.Duration) (string, error)
+}
+
+// Options configures which bookings can be disputed and with how much evidence
+type Options struct {
+	// FilingWindow is how long after completion a booking can be disputed
+	FilingWindow time.Duration
+
+	// MaxEvidence limits the files attached to a dispute
+	MaxEvidence int
+}
+
+// DefaultOptions allows disputes for two weeks after completion with up to ten files
+func DefaultOptions() Options {
+	return Options{
+		FilingWindow: 14 * 24 * time.Hour,
+		MaxEvidence:  10,
+	}
+}
@@ -0,0 +1,370 @@
+package dispute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/payment"
+	"cleanbuddy-api/res/storage"
+	"cleanbuddy-api/res/store"
+)
+
+// urlExpiry is how long the download URL of an evidence file stays valid
+const urlExpiry = 15 * time.Minute
+
+var reasons = map[store.DisputeReason]bool{
+	store.DisputeReasonQuality:    true,
+	store.DisputeReasonIncomplete: true,
+	store.DisputeReasonDamage:     true,
+	store.DisputeReasonNoShow:     true,
+	store.DisputeReasonConduct:    true,
+	store.DisputeReasonOther:      true,
+}
+
+type service struct {
+	store    store.Store
+	payments payment.PaymentService
+	files    FileStore
+	options  Options
+	logger   *log.Logger
+}
+
+// NewService creates a new DisputeService. Without payments disputes cannot refund, and without
+// files they cannot take evidence.
+func NewService(dataStore store.Store, payments payment.PaymentService, files FileStore, options Options, logger *log.Logger) DisputeService {
+	return &service{
+		store:    dataStore,
+		payments: payments,
+		files:    files,
+		options:  options,
+		logger:   logger,
+	}
+}
+
+func (s *service) Open(ctx context.Context, customer *store.User, input OpenInput) (*store.Dispute, error) {
+	booking, err := s.store.Bookings().Get(ctx, input.BookingID)
+	if err != nil {
+		return nil, ErrBookingNotFound
+	}
+	if customer == nil || booking.CustomerID != customer.ID {
+		return nil, ErrNotCustomer
+	}
+	if booking.Status != store.BookingStatusCompleted || booking.CompletedAt == nil {
+		return nil, ErrNotDisputable
+	}
+	if time.Since(*booking.CompletedAt) > s.options.FilingWindow {
+		return nil, ErrWindowClosed
+	}
+	description := strings.TrimSpace(input.Description)
+	if !reasons[input.Reason] || description == "" {
+		return nil, ErrInvalidDispute
+	}
+	if len(input.Evidence) > s.options.MaxEvidence {
+		return nil, ErrTooMuchEvidence
+	}
+	if len(input.Evidence) > 0 && s.files == nil {
+		return nil, ErrStorageUnavailable
+	}
+
+	dispute := &store.Dispute{
+		ID:          uuid.New().String(),
+		BookingID:   booking.ID,
+		CustomerID:  booking.CustomerID,
+		CleanerID:   booking.CleanerID,
+		Reason:      input.Reason,
+		Description: description,
+		Status:      store.DisputeStatusOpen,
+	}
+	if err := s.store.Disputes().Create(ctx, dispute); err != nil {
+		if errors.Is(err, store.ErrDisputeExists) {
+			return nil, ErrAlreadyDisputed
+		}
+		s.logger.Printf("Failed to open dispute of booking %s: %v", booking.ID, err)
+		return nil, fmt.Errorf("failed to open dispute: %w", err)
+	}
+
+	for _, file := range input.Evidence {
+		evidence, err := s.attach(ctx, dispute, customer, file)
+		if err != nil {
+			return nil, err
+		}
+		dispute.Evidence = append(dispute.Evidence, evidence)
+	}
+
+	s.logger.Printf("Customer %s disputed booking %s (%s)", customer.ID, booking.ID, dispute.Reason)
+	return dispute, nil
+}
+
+func (s *service) Get(ctx context.Context, user *store.User, id string) (*store.Dispute, error) {
+	dispute, err := s.store.Disputes().Get(ctx, id)
+	if err != nil {
+		return nil, ErrDisputeNotFound
+	}
+	if user == nil || !(user.IsGlobalAdmin() || dispute.CustomerID == user.ID || dispute.CleanerID == user.ID) {
+		return nil, ErrAccessDenied
+	}
+	return dispute, nil
+}
+
+func (s *service) AddEvidence(ctx context.Context, user *store.User, disputeID string, file Upload) (*store.DisputeEvidence, error) {
+	dispute, err := s.Get(ctx, user, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.IsResolved() {
+		return nil, ErrInvalidStatus
+	}
+	if s.files == nil {
+		return nil, ErrStorageUnavailable
+	}
+
+	count, err := s.store.Disputes().CountEvidence(ctx, dispute.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count evidence: %w", err)
+	}
+	if count >= s.options.MaxEvidence {
+		return nil, ErrTooMuchEvidence
+	}
+	return s.attach(ctx, dispute, user, file)
+}
+
+func (s *service) EvidenceURL(ctx context.Context, evidence *store.DisputeEvidence) (string, error) {
+	if s.files == nil {
+		return "", ErrStorageUnavailable
+	}
+	url, err := s.files.GenerateSignedURL(ctx, evidence.StorageURL, urlExpiry)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign evidence URL: %w", err)
+	}
+	return url, nil
+}
+
+func (s *service) RequestResponse(ctx context.Context, admin *store.User, id string) (*store.Dispute, error) {
+	dispute, err := s.getAsAdmin(ctx, admin, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.transition(ctx, dispute, store.DisputeStatusAwaitingCleaner, store.DisputeStatusOpen)
+}
+
+func (s *service) Respond(ctx context.Context, cleaner *store.User, id string, response string) (*store.Dispute, error) {
+	dispute, err := s.Get(ctx, cleaner, id)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.CleanerID != cleaner.ID {
+		return nil, ErrAccessDenied
+	}
+	response = strings.TrimSpace(response)
+	if response == "" {
+		return nil, ErrInvalidDispute
+	}
+
+	now := time.Now()
+	dispute.CleanerResponse = response
+	dispute.RespondedAt = &now
+	return s.transition(ctx, dispute, store.DisputeStatusUnderAdminReview, store.DisputeStatusAwaitingCleaner)
+}
+
+func (s *service) StartReview(ctx context.Context, admin *store.User, id string) (*store.Dispute, error) {
+	dispute, err := s.getAsAdmin(ctx, admin, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.transition(ctx, dispute, store.DisputeStatusUnderAdminReview, store.DisputeStatusOpen, store.DisputeStatusAwaitingCleaner)
+}
+
+func (s *service) Resolve(ctx context.Context, admin *store.User, id string, resolution Resolution) (*store.Dispute, error) {
+	dispute, err := s.getAsAdmin(ctx, admin, id)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status != store.DisputeStatusUnderAdminReview {
+		return nil, ErrInvalidStatus
+	}
+	booking, err := s.store.Bookings().Get(ctx, dispute.BookingID)
+	if err != nil {
+		return nil, ErrBookingNotFound
+	}
+	if err := validateResolution(resolution, booking); err != nil {
+		return nil, err
+	}
+	if resolution.RefundAmount > 0 && s.payments == nil {
+		return nil, ErrRefundsUnavailable
+	}
+
+	// Claiming the dispute first keeps a concurrent resolution from refunding twice
+	now := time.Now()
+	dispute.ResolutionNotes = strings.TrimSpace(resolution.Notes)
+	dispute.RefundAmount = resolution.RefundAmount
+	dispute.ClawbackAmount = resolution.ClawbackAmount
+	dispute.ResolvedByID = &admin.ID
+	dispute.ResolvedAt = &now
+	if _, err := s.transition(ctx, dispute, store.DisputeStatusResolved, store.DisputeStatusUnderAdminReview); err != nil {
+		return nil, err
+	}
+
+	if resolution.RefundAmount > 0 {
+		refund, err := s.payments.Refund(ctx, booking.ID, resolution.RefundAmount, "Dispute refund")
+		if err != nil {
+			s.logger.Printf("Failed to refund %d for dispute %s: %v", resolution.RefundAmount, dispute.ID, err)
+			s.reopen(ctx, dispute)
+			return nil, err
+		}
+		if refund != nil {
+			dispute.RefundTransactionID = &refund.ID
+		}
+	}
+
+	// The refund went out, so the rest is logged and left for an admin to fix rather than undone
+	if resolution.ClawbackAmount > 0 {
+		clawback, err := s.clawBack(ctx, dispute, admin, resolution.ClawbackAmount)
+		if err != nil {
+			s.logger.Printf("Failed to claw back %d for dispute %s: %v", resolution.ClawbackAmount, dispute.ID, err)
+		} else {
+			dispute.ClawbackTransactionID = &clawback.ID
+		}
+	}
+	if resolution.RemoveReview {
+		removed, err := s.removeReview(ctx, dispute, admin)
+		if err != nil {
+			s.logger.Printf("Failed to remove review of disputed booking %s: %v", dispute.BookingID, err)
+		}
+		dispute.ReviewRemoved = removed
+	}
+
+	if err := s.store.Disputes().Update(ctx, dispute, []store.DisputeStatus{store.DisputeStatusResolved}); err != nil {
+		s.logger.Printf("Failed to save resolution of dispute %s: %v", dispute.ID, err)
+		return nil, fmt.Errorf("failed to save resolution: %w", err)
+	}
+
+	s.logger.Printf("Admin %s resolved dispute %s: refund %d, clawback %d, review removed %t",
+		admin.ID, dispute.ID, dispute.RefundAmount, dispute.ClawbackAmount, dispute.ReviewRemoved)
+	return dispute, nil
+}
+
+// getAsAdmin retrieves a dispute for a step only admins take
+func (s *service) getAsAdmin(ctx context.Context, admin *store.User, id string) (*store.Dispute, error) {
+	if admin == nil || !admin.IsGlobalAdmin() {
+		return nil, ErrAccessDenied
+	}
+	return s.Get(ctx, admin, id)
+}
+
+// transition moves a dispute to a status if it is in one of from
+func (s *service) transition(ctx context.Context, dispute *store.Dispute, to store.DisputeStatus, from ...store.DisputeStatus) (*store.Dispute, error) {
+	allowed := false
+	for _, status := range from {
+		allowed = allowed || dispute.Status == status
+	}
+	if !allowed {
+		return nil, ErrInvalidStatus
+	}
+
+	previous := dispute.Status
+	dispute.Status = to
+	if err := s.store.Disputes().Update(ctx, dispute, from); err != nil {
+		dispute.Status = previous
+		if errors.Is(err, store.ErrDisputeStatusConflict) {
+			return nil, ErrInvalidStatus
+		}
+		s.logger.Printf("Failed to move dispute %s to %s: %v", dispute.ID, to, err)
+		return nil, fmt.Errorf("failed to update dispute: %w", err)
+	}
+	return dispute, nil
+}
+
+// reopen puts a dispute whose resolution failed back under admin review
+func (s *service) reopen(ctx context.Context, dispute *store.Dispute) {
+	dispute.Status = store.DisputeStatusUnderAdminReview
+	dispute.RefundAmount, dispute.ClawbackAmount = 0, 0
+	dispute.ResolvedByID, dispute.ResolvedAt = nil, nil
+	if err := s.store.Disputes().Update(ctx, dispute, []store.DisputeStatus{store.DisputeStatusResolved}); err != nil {
+		s.logger.Printf("Failed to reopen dispute %s after its refund failed: %v", dispute.ID, err)
+	}
+}
+
+// attach uploads a file and records it as evidence of a dispute
+func (s *service) attach(ctx context.Context, dispute *store.Dispute, user *store.User, file Upload) (*store.DisputeEvidence, error) {
+	evidence := &store.DisputeEvidence{
+		ID:           uuid.New().String(),
+		DisputeID:    dispute.ID,
+		UploadedByID: user.ID,
+		FileName:     file.FileName,
+		ContentType:  file.ContentType,
+	}
+
+	path := storage.BuildDisputeEvidencePath(dispute.ID, evidence.ID, file.FileName)
+	url, err := s.files.UploadFromReader(ctx, file.File, file.FileName, file.Size, file.ContentType, path)
+	if err != nil {
+		s.logger.Printf("Failed to upload evidence %s of dispute %s: %v", file.FileName, dispute.ID, err)
+		return nil, fmt.Errorf("failed to upload evidence %s: %w", file.FileName, err)
+	}
+	evidence.StorageURL = url
+
+	if err := s.store.Disputes().AddEvidence(ctx, evidence); err != nil {
+		s.logger.Printf("Failed to record evidence of dispute %s: %v", dispute.ID, err)
+		return nil, fmt.Errorf("failed to record evidence: %w", err)
+	}
+	return evidence, nil
+}
+
+// clawBack records what the cleaner gives back, to be deducted from their next payouts
+func (s *service) clawBack(ctx context.Context, dispute *store.Dispute, admin *store.User, amount int) (*store.Transaction, error) {
+	now := time.Now()
+	clawback := &store.Transaction{
+		ID:        uuid.New().String(),
+		Type:      store.TransactionTypeClawback,
+		Status:    store.TransactionStatusCompleted,
+		BookingID: &dispute.BookingID,
+		PayerID:   dispute.CleanerID,
+		// The platform takes the money back, represented by the admin who resolved the dispute
+		PayeeID:       admin.ID,
+		Amount:        amount,
+		NetAmount:     amount,
+		PaymentMethod: store.PaymentMethodBankTransfer,
+		Currency:      "RON",
+		Description:   "Dispute clawback",
+		ProcessedAt:   now,
+		CompletedAt:   &now,
+	}
+	if err := s.store.Transactions().Create(ctx, clawback); err != nil {
+		return nil, err
+	}
+	return clawback, nil
+}
+
+// removeReview rejects the customer's review of the booking and updates the cleaner's rating.
+// It reports whether the booking had a review to remove.
+func (s *service) removeReview(ctx context.Context, dispute *store.Dispute, admin *store.User) (bool, error) {
+	review, err := s.store.Reviews().GetByBooking(ctx, dispute.BookingID)
+	if err != nil || review == nil {
+		return false, nil
+	}
+	if err := s.store.Reviews().UpdateStatus(ctx, review.ID, store.ReviewStatusRejected, admin.ID, "Removed in dispute "+dispute.ID); err != nil {
+		return false, err
+	}
+
+	average, count, err := s.store.Reviews().GetAverageRatingForCleaner(ctx, review.CleanerProfileID)
+	if err != nil {
+		return true, fmt.Errorf("failed to recompute rating: %w", err)
+	}
+	return true, s.store.CleanerProfiles().UpdateStats(ctx, review.CleanerProfileID, store.CleanerStats{
+		AverageRating: &average,
+		TotalReviews:  &count,
+	})
+}
+
+// validateResolution checks the amounts of a resolution against what the cleaner earned
+func validateResolution(resolution Resolution, booking *store.Booking) error {
+	if resolution.RefundAmount < 0 || resolution.ClawbackAmount < 0 || resolution.ClawbackAmount > booking.CleanerPayout {
+		return ErrInvalidResolution
+	}
+	return nil
+}
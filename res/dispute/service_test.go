@@ -0,0 +1,244 @@
+package dispute
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+
+	"cleanbuddy-api/res/payment"
+	"cleanbuddy-api/res/store"
+)
+
+// fakeStore keeps one dispute of a completed booking and the customer's review of it
+type fakeStore struct {
+	store.Store
+	disputes     *fakeDisputes
+	transactions *fakeTransactions
+	reviews      *fakeReviews
+	profiles     *fakeProfiles
+}
+
+func (f *fakeStore) Disputes() store.DisputeStore               { return f.disputes }
+func (f *fakeStore) Bookings() store.BookingStore               { return &fakeBookings{} }
+func (f *fakeStore) Transactions() store.TransactionStore       { return f.transactions }
+func (f *fakeStore) Reviews() store.ReviewStore                 { return f.reviews }
+func (f *fakeStore) CleanerProfiles() store.CleanerProfileStore { return f.profiles }
+
+type fakeDisputes struct {
+	store.DisputeStore
+	dispute *store.Dispute
+}
+
+func (f *fakeDisputes) Get(ctx context.Context, id string) (*store.Dispute, error) {
+	copied := *f.dispute
+	return &copied, nil
+}
+
+func (f *fakeDisputes) Update(ctx context.Context, dispute *store.Dispute, from []store.DisputeStatus) error {
+	for _, status := range from {
+		if f.dispute.Status == status {
+			copied := *dispute
+			f.dispute = &copied
+			return nil
+		}
+	}
+	return store.ErrDisputeStatusConflict
+}
+
+type fakeBookings struct {
+	store.BookingStore
+}
+
+func (f *fakeBookings) Get(ctx context.Context, id string) (*store.Booking, error) {
+	return &store.Booking{ID: id, CustomerID: "customer", CleanerID: "cleaner", Status: store.BookingStatusCompleted, TotalPrice: 20000, CleanerPayout: 16000}, nil
+}
+
+type fakeTransactions struct {
+	store.TransactionStore
+	created []*store.Transaction
+}
+
+func (f *fakeTransactions) Create(ctx context.Context, transaction *store.Transaction) error {
+	f.created = append(f.created, transaction)
+	return nil
+}
+
+type fakeReviews struct {
+	store.ReviewStore
+	review *store.Review
+}
+
+func (f *fakeReviews) GetByBooking(ctx context.Context, bookingID string) (*store.Review, error) {
+	return f.review, nil
+}
+
+func (f *fakeReviews) UpdateStatus(ctx context.Context, reviewID string, status store.ReviewStatus, moderatorID string, note string) error {
+	f.review.Status = status
+	return nil
+}
+
+func (f *fakeReviews) GetAverageRatingForCleaner(ctx context.Context, cleanerProfileID string) (float64, int, error) {
+	return 4.5, 2, nil
+}
+
+type fakeProfiles struct {
+	store.CleanerProfileStore
+	stats *store.CleanerStats
+}
+
+func (f *fakeProfiles) UpdateStats(ctx context.Context, profileID string, stats store.CleanerStats) error {
+	f.stats = &stats
+	return nil
+}
+
+// refundRecorder refunds through the provider, failing with err when set
+type refundRecorder struct {
+	payment.PaymentService
+	refunds []int
+	err     error
+}
+
+func (r *refundRecorder) Refund(ctx context.Context, bookingID string, amount int, description string) (*store.Transaction, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.refunds = append(r.refunds, amount)
+	return &store.Transaction{ID: "refund", Type: store.TransactionTypeRefund, Amount: amount}, nil
+}
+
+func newTestService(payments payment.PaymentService) (*service, *fakeStore) {
+	dataStore := &fakeStore{
+		disputes: &fakeDisputes{dispute: &store.Dispute{
+			ID:         "d1",
+			BookingID:  "b1",
+			CustomerID: "customer",
+			CleanerID:  "cleaner",
+			Status:     store.DisputeStatusUnderAdminReview,
+		}},
+		transactions: &fakeTransactions{},
+		reviews:      &fakeReviews{review: &store.Review{ID: "r1", BookingID: "b1", CleanerProfileID: "profile", Status: store.ReviewStatusApproved}},
+		profiles:     &fakeProfiles{},
+	}
+	s := &service{
+		store:    dataStore,
+		payments: payments,
+		options:  DefaultOptions(),
+		logger:   log.New(io.Discard, "", 0),
+	}
+	return s, dataStore
+}
+
+func TestValidateResolutionCapsTheClawbackAtTheCleanersPayout(t *testing.T) {
+	booking := &store.Booking{ID: "b1", CleanerPayout: 8000}
+
+	tests := []struct {
+		name       string
+		resolution Resolution
+		valid      bool
+	}{
+		{"review removal only", Resolution{RemoveReview: true}, true},
+		{"refund beyond the cleaner's payout", Resolution{RefundAmount: 10000, ClawbackAmount: 8000}, true},
+		{"clawback of more than was earned", Resolution{ClawbackAmount: 8001}, false},
+		{"negative refund", Resolution{RefundAmount: -1}, false},
+		{"negative clawback", Resolution{ClawbackAmount: -1}, false},
+	}
+	for _, tt := range tests {
+		err := validateResolution(tt.resolution, booking)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: got error %v, want valid %t", tt.name, err, tt.valid)
+		}
+	}
+}
+
+func TestResolveAppliesTheOutcomeOnce(t *testing.T) {
+	payments := &refundRecorder{}
+	s, dataStore := newTestService(payments)
+	admin := &store.User{ID: "admin", Role: store.UserRoleGlobalAdmin}
+	resolution := Resolution{RefundAmount: 10000, ClawbackAmount: 8000, RemoveReview: true, Notes: " Half the rooms were skipped "}
+
+	dispute, err := s.Resolve(context.Background(), admin, "d1", resolution)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	stored := dataStore.disputes.dispute
+	if stored.Status != store.DisputeStatusResolved || stored.ResolutionNotes != "Half the rooms were skipped" {
+		t.Errorf("stored dispute is %s with notes %q, want it resolved with the trimmed notes", stored.Status, stored.ResolutionNotes)
+	}
+	if len(payments.refunds) != 1 || payments.refunds[0] != 10000 {
+		t.Errorf("refunds = %v, want a single refund of 10000", payments.refunds)
+	}
+	if dispute.RefundTransactionID == nil || *dispute.RefundTransactionID != "refund" {
+		t.Errorf("refund transaction = %v, want the provider's refund", dispute.RefundTransactionID)
+	}
+
+	created := dataStore.transactions.created
+	if len(created) != 1 || created[0].Type != store.TransactionTypeClawback || created[0].Amount != 8000 || created[0].PayerID != "cleaner" {
+		t.Fatalf("created %d transactions, want one clawback of 8000 paid by the cleaner", len(created))
+	}
+	if dispute.ClawbackTransactionID == nil || *dispute.ClawbackTransactionID != created[0].ID {
+		t.Errorf("clawback transaction = %v, want %s", dispute.ClawbackTransactionID, created[0].ID)
+	}
+
+	if !dispute.ReviewRemoved || dataStore.reviews.review.Status != store.ReviewStatusRejected {
+		t.Errorf("review removed %t with status %s, want it rejected", dispute.ReviewRemoved, dataStore.reviews.review.Status)
+	}
+	if stats := dataStore.profiles.stats; stats == nil || *stats.AverageRating != 4.5 || *stats.TotalReviews != 2 {
+		t.Errorf("cleaner stats = %+v, want the rating recomputed without the review", stats)
+	}
+
+	// A second resolution finds the dispute resolved and changes nothing
+	if _, err := s.Resolve(context.Background(), admin, "d1", resolution); !errors.Is(err, ErrInvalidStatus) {
+		t.Errorf("second Resolve() error = %v, want ErrInvalidStatus", err)
+	}
+	if len(payments.refunds) != 1 || len(dataStore.transactions.created) != 1 {
+		t.Errorf("after a second resolution: %d refunds and %d transactions, want 1 and 1", len(payments.refunds), len(dataStore.transactions.created))
+	}
+}
+
+func TestResolveReopensTheDisputeWhenTheRefundFails(t *testing.T) {
+	payments := &refundRecorder{err: errors.New("card declined")}
+	s, dataStore := newTestService(payments)
+	admin := &store.User{ID: "admin", Role: store.UserRoleGlobalAdmin}
+
+	_, err := s.Resolve(context.Background(), admin, "d1", Resolution{RefundAmount: 10000, ClawbackAmount: 8000, RemoveReview: true})
+	if err == nil {
+		t.Fatal("Resolve() error = nil, want the refund failure")
+	}
+
+	stored := dataStore.disputes.dispute
+	if stored.Status != store.DisputeStatusUnderAdminReview || stored.RefundAmount != 0 || stored.ResolvedAt != nil {
+		t.Errorf("stored dispute is %s with refund %d, want it back under review without a resolution", stored.Status, stored.RefundAmount)
+	}
+	if len(dataStore.transactions.created) != 0 {
+		t.Errorf("created %d transactions, want no clawback", len(dataStore.transactions.created))
+	}
+	if dataStore.reviews.review.Status != store.ReviewStatusApproved {
+		t.Errorf("review status = %s, want it left approved", dataStore.reviews.review.Status)
+	}
+
+	// Once the provider accepts it, the same resolution goes through
+	payments.err = nil
+	if _, err := s.Resolve(context.Background(), admin, "d1", Resolution{RefundAmount: 10000}); err != nil {
+		t.Errorf("retried Resolve() error = %v", err)
+	}
+}
+
+func TestResolveWithoutPaymentsCannotRefund(t *testing.T) {
+	s, dataStore := newTestService(nil)
+	admin := &store.User{ID: "admin", Role: store.UserRoleGlobalAdmin}
+
+	if _, err := s.Resolve(context.Background(), admin, "d1", Resolution{RefundAmount: 5000}); !errors.Is(err, ErrRefundsUnavailable) {
+		t.Fatalf("Resolve() error = %v, want ErrRefundsUnavailable", err)
+	}
+	if dataStore.disputes.dispute.Status != store.DisputeStatusUnderAdminReview {
+		t.Errorf("stored dispute is %s, want it still under review", dataStore.disputes.dispute.Status)
+	}
+
+	// Outcomes without a refund need no payments
+	if _, err := s.Resolve(context.Background(), admin, "d1", Resolution{ClawbackAmount: 5000}); err != nil {
+		t.Errorf("Resolve() clawback only error = %v", err)
+	}
+}
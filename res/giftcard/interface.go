@@ -0,0 +1,83 @@
+package giftcard
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+var (
+	ErrInvalidAmount     = errors.New("gift card amount is not one of the offered values")
+	ErrRecipientRequired = errors.New("recipient email is required")
+	ErrCodeNotFound      = errors.New("gift card not found")
+	ErrAlreadyRedeemed   = errors.New("gift card has already been redeemed")
+	ErrExpired           = errors.New("gift card has expired")
+	ErrCodeNotGenerated  = errors.New("failed to generate a unique gift card code")
+	ErrNotPaid           = errors.New("gift card has not been paid for")
+	ErrPaymentsDisabled  = errors.New("gift cards cannot be bought without card payments")
+)
+
+// GiftCardService sells gift cards and spends them on bookings. A purchased card waits for the
+// purchaser's card payment, and once it is charged the card is emailed to its recipient with a
+// code; redeeming the code adds the card to the recipient's gift card balance, which is spent at
+// checkout until it runs out or expires.
+type GiftCardService interface {
+	// Amounts returns the values a card can be bought for, in bani
+	Amounts() []int
+
+	// Purchase issues a pending card of one of the offered amounts and opens its card payment,
+	// which the purchaser confirms with the client secret of the card's purchase transaction
+	Purchase(ctx context.Context, purchaser *store.User, request PurchaseRequest) (*store.GiftCard, error)
+
+	// Redeem adds a card to the user's balance
+	Redeem(ctx context.Context, userID, code string) (*store.GiftCard, error)
+
+	// Balance returns a customer's spendable gift card value in bani
+	Balance(ctx context.Context, userID string) (int, error)
+
+	// ListForUser returns the cards a user bought or redeemed, newest first
+	ListForUser(ctx context.Context, userID string) ([]*store.GiftCard, error)
+
+	// Apply spends the customer's gift card balance on a placed booking, lowering its total
+	Apply(ctx context.Context, booking *store.Booking) error
+
+	// Release returns all value spent on a booking to its cards; a cancelled booking gets back
+	// what the cancellation policy refunds of it
+	Release(ctx context.Context, bookingID string) error
+
+	// ExpireDue forfeits the balance of cards past their expiry and returns how many expired
+	ExpireDue(ctx context.Context) (int, error)
+
+	// Liability returns the outstanding value of cards sold but not spent or expired
+	Liability(ctx context.Context) (*store.GiftCardLiability, error)
+
+	// Outstanding lists the cards making up the liability, soonest to expire first
+	Outstanding(ctx context.Context, limit, offset int) ([]*store.GiftCard, error)
+}
+
+// PurchaseRequest describes a gift card bought for someone else
+type PurchaseRequest struct {
+	Amount         int // in bani, one of Options.Amounts
+	RecipientEmail string
+	RecipientName  string
+	Message        string
+}
+
+// Options configures the cards on sale
+type Options struct {
+	// Amounts are the values a card can be bought for, in bani
+	Amounts []int
+
+	// Validity is how long a card can be spent after it is paid for
+	Validity time.Duration
+}
+
+// DefaultOptions offers cards of 100, 200 and 500 RON valid for a year
+func DefaultOptions() Options {
+	return Options{
+		Amounts:  []int{10000, 20000, 50000},
+		Validity: 365 * 24 * time.Hour,
+	}
+}
@@ -0,0 +1,323 @@
+package giftcard
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/bookinglifecycle"
+	"cleanbuddy-api/res/cancellationpolicy"
+	"cleanbuddy-api/res/mail"
+	"cleanbuddy-api/res/payment"
+	"cleanbuddy-api/res/store"
+)
+
+const (
+	codeGroups     = 3
+	codeGroupWidth = 4
+	codeAttempts   = 5
+
+	// Letters and digits that cannot be confused with each other when read aloud or copied
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+	// deliveryTemplate is the mail template the code is sent to the recipient with
+	deliveryTemplate = "gift-card"
+)
+
+type service struct {
+	store    store.Store
+	payments payment.PaymentService
+	policy   cancellationpolicy.PolicyService
+	mail     mail.MailService
+	options  Options
+	logger   *log.Logger
+}
+
+// NewService creates a new GiftCardService, registers the return of gift card value spent on
+// cancelled bookings and the activation of cards once they are paid for. payments may be nil, in
+// which case cards cannot be bought, policy may be nil, in which case the value spent on cancelled
+// bookings is returned in full, and mailService may be nil, in which case codes are not emailed.
+func NewService(dataStore store.Store, lifecycle bookinglifecycle.LifecycleService, payments payment.PaymentService, policy cancellationpolicy.PolicyService, mailService mail.MailService, options Options, logger *log.Logger) GiftCardService {
+	s := &service{
+		store:    dataStore,
+		payments: payments,
+		policy:   policy,
+		mail:     mailService,
+		options:  options,
+		logger:   logger,
+	}
+
+	lifecycle.OnTransition(store.BookingStatusCancelled, s.releaseOnCancel)
+	if payments != nil {
+		payments.OnSettled(s.activateOnPayment)
+	}
+
+	return s
+}
+
+func (s *service) Amounts() []int {
+	return s.options.Amounts
+}
+
+func (s *service) Purchase(ctx context.Context, purchaser *store.User, request PurchaseRequest) (*store.GiftCard, error) {
+	if !s.offers(request.Amount) {
+		return nil, ErrInvalidAmount
+	}
+	recipientEmail := strings.TrimSpace(request.RecipientEmail)
+	if recipientEmail == "" {
+		return nil, ErrRecipientRequired
+	}
+	if s.payments == nil {
+		return nil, ErrPaymentsDisabled
+	}
+
+	now := time.Now()
+	for attempt := 0; attempt < codeAttempts; attempt++ {
+		code, err := generateCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate gift card code: %w", err)
+		}
+
+		card := &store.GiftCard{
+			ID:             uuid.New().String(),
+			Code:           code,
+			InitialAmount:  request.Amount,
+			Balance:        request.Amount,
+			Status:         store.GiftCardStatusPending,
+			PurchaserID:    purchaser.ID,
+			RecipientEmail: recipientEmail,
+			RecipientName:  strings.TrimSpace(request.RecipientName),
+			Message:        request.Message,
+			ExpiresAt:      now.Add(s.options.Validity),
+		}
+
+		// The platform holds the value until it is spent, so the purchaser is on both sides
+		purchase := &store.Transaction{
+			ID:            uuid.New().String(),
+			Type:          store.TransactionTypeGiftCardPurchase,
+			Status:        store.TransactionStatusPending,
+			PayerID:       purchaser.ID,
+			PayeeID:       purchaser.ID,
+			Amount:        request.Amount,
+			NetAmount:     request.Amount,
+			PaymentMethod: store.PaymentMethodCard,
+			Currency:      "RON",
+			Description:   fmt.Sprintf("Gift card for %s", recipientEmail),
+			ProcessedAt:   now,
+		}
+
+		err = s.store.GiftCards().Create(ctx, card, purchase)
+		if errors.Is(err, store.ErrUniqueViolation) {
+			continue
+		}
+		if err != nil {
+			s.logger.Printf("Failed to create gift card for user %s: %v", purchaser.ID, err)
+			return nil, fmt.Errorf("failed to create gift card: %w", err)
+		}
+
+		// The code is only emailed once the payment is charged, by activateOnPayment
+		if _, err := s.payments.OpenGiftCardPayment(ctx, purchase); err != nil {
+			s.logger.Printf("Failed to open payment of gift card %s: %v", card.ID, err)
+			return nil, err
+		}
+		return card, nil
+	}
+
+	s.logger.Printf("Failed to generate a unique gift card code for user %s after %d attempts", purchaser.ID, codeAttempts)
+	return nil, ErrCodeNotGenerated
+}
+
+func (s *service) Redeem(ctx context.Context, userID, code string) (*store.GiftCard, error) {
+	card, err := s.store.GiftCards().Redeem(ctx, Normalize(code), userID, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrGiftCardAlreadyRedeemed):
+			return nil, ErrAlreadyRedeemed
+		case errors.Is(err, store.ErrGiftCardExpired):
+			return nil, ErrExpired
+		case errors.Is(err, store.ErrGiftCardNotPaid):
+			return nil, ErrNotPaid
+		}
+		s.logger.Printf("Failed to redeem gift card %s for user %s: %v", Normalize(code), userID, err)
+		return nil, ErrCodeNotFound
+	}
+
+	s.logger.Printf("User %s redeemed gift card %s worth %d", userID, card.ID, card.Balance)
+	return card, nil
+}
+
+func (s *service) Balance(ctx context.Context, userID string) (int, error) {
+	balance, err := s.store.GiftCards().Balance(ctx, userID, time.Now())
+	if err != nil {
+		s.logger.Printf("Failed to get gift card balance of user %s: %v", userID, err)
+		return 0, fmt.Errorf("failed to get gift card balance: %w", err)
+	}
+	return balance, nil
+}
+
+func (s *service) ListForUser(ctx context.Context, userID string) ([]*store.GiftCard, error) {
+	cards, err := s.store.GiftCards().ListByUser(ctx, userID)
+	if err != nil {
+		s.logger.Printf("Failed to list gift cards of user %s: %v", userID, err)
+		return nil, fmt.Errorf("failed to list gift cards: %w", err)
+	}
+	return cards, nil
+}
+
+func (s *service) Apply(ctx context.Context, booking *store.Booking) error {
+	if booking.TotalPrice <= 0 {
+		return nil
+	}
+
+	spent, err := s.store.GiftCards().Spend(ctx, booking, time.Now())
+	if err != nil {
+		s.logger.Printf("Failed to spend gift cards of user %s on booking %s: %v", booking.CustomerID, booking.ID, err)
+		return fmt.Errorf("failed to spend gift card balance: %w", err)
+	}
+	if spent > 0 {
+		s.logger.Printf("Spent %d of gift card balance of user %s on booking %s", spent, booking.CustomerID, booking.ID)
+	}
+	return nil
+}
+
+func (s *service) Release(ctx context.Context, bookingID string) error {
+	return s.release(ctx, bookingID, 100)
+}
+
+// release returns percent of the value spent on a booking to its cards
+func (s *service) release(ctx context.Context, bookingID string, percent int) error {
+	refunded, err := s.store.GiftCards().RefundBooking(ctx, bookingID, percent)
+	if err != nil {
+		s.logger.Printf("Failed to return gift card value spent on booking %s: %v", bookingID, err)
+		return fmt.Errorf("failed to return gift card value: %w", err)
+	}
+	if refunded > 0 {
+		s.logger.Printf("Returned %d to gift cards from booking %s", refunded, bookingID)
+	}
+	return nil
+}
+
+func (s *service) ExpireDue(ctx context.Context) (int, error) {
+	expired, err := s.store.GiftCards().ExpireDue(ctx, time.Now())
+	if err != nil {
+		s.logger.Printf("Failed to expire gift cards: %v", err)
+		return 0, fmt.Errorf("failed to expire gift cards: %w", err)
+	}
+	return expired, nil
+}
+
+func (s *service) Liability(ctx context.Context) (*store.GiftCardLiability, error) {
+	liability, err := s.store.GiftCards().Liability(ctx, time.Now())
+	if err != nil {
+		s.logger.Printf("Failed to get gift card liability: %v", err)
+		return nil, fmt.Errorf("failed to get gift card liability: %w", err)
+	}
+	return liability, nil
+}
+
+func (s *service) Outstanding(ctx context.Context, limit, offset int) ([]*store.GiftCard, error) {
+	cards, err := s.store.GiftCards().ListOutstanding(ctx, time.Now(), limit, offset)
+	if err != nil {
+		s.logger.Printf("Failed to list outstanding gift cards: %v", err)
+		return nil, fmt.Errorf("failed to list outstanding gift cards: %w", err)
+	}
+	return cards, nil
+}
+
+// releaseOnCancel returns as much of the gift card value as the cancellation policy refunds of the booking
+func (s *service) releaseOnCancel(ctx context.Context, event bookinglifecycle.TransitionEvent) error {
+	if event.Booking.GiftCardApplied == 0 {
+		return nil
+	}
+	percent := 100
+	if s.policy != nil {
+		percent = s.policy.EvaluateCancelled(event.Booking, event.ActorRole).RefundPercent
+	}
+	return s.release(ctx, event.Booking.ID, percent)
+}
+
+// activateOnPayment makes a card spendable once its purchase is charged and emails its code.
+// Its validity starts with the payment; a purchase settled again leaves the card alone.
+func (s *service) activateOnPayment(ctx context.Context, transaction *store.Transaction) error {
+	if transaction.Type != store.TransactionTypeGiftCardPurchase || transaction.Status != store.TransactionStatusCompleted ||
+		transaction.GiftCardID == nil {
+		return nil
+	}
+
+	card, err := s.store.GiftCards().Activate(ctx, *transaction.GiftCardID, time.Now().Add(s.options.Validity))
+	if err != nil {
+		if errors.Is(err, store.ErrGiftCardNotPending) {
+			return nil
+		}
+		return fmt.Errorf("failed to activate gift card %s: %w", *transaction.GiftCardID, err)
+	}
+
+	purchaser, err := s.store.Users().Get(ctx, card.PurchaserID)
+	if err != nil {
+		return fmt.Errorf("failed to get purchaser of gift card %s: %w", card.ID, err)
+	}
+	s.logger.Printf("Gift card %s worth %d was paid for by user %s", card.ID, card.InitialAmount, purchaser.ID)
+	s.deliver(ctx, purchaser, card)
+	return nil
+}
+
+// deliver emails the code to the recipient; the card exists either way, so failures are only logged
+func (s *service) deliver(ctx context.Context, purchaser *store.User, card *store.GiftCard) {
+	if s.mail == nil {
+		s.logger.Printf("Mail service not configured, gift card %s was not emailed", card.ID)
+		return
+	}
+
+	err := s.mail.SendTemplateEmail(ctx, card.RecipientEmail, deliveryTemplate, map[string]interface{}{
+		"recipientName": card.RecipientName,
+		"senderName":    purchaser.DisplayName,
+		"message":       card.Message,
+		"code":          card.Code,
+		"amount":        fmt.Sprintf("%d.%02d RON", card.InitialAmount/100, card.InitialAmount%100),
+		"expiresAt":     card.ExpiresAt.Format("2006-01-02"),
+	})
+	if err != nil {
+		s.logger.Printf("Failed to email gift card %s to %s: %v", card.ID, card.RecipientEmail, err)
+	}
+}
+
+func (s *service) offers(amount int) bool {
+	for _, offered := range s.options.Amounts {
+		if amount == offered {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize returns the canonical form gift card codes are stored in, so codes
+// typed without dashes or in lower case still match
+func Normalize(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+
+	groups := make([]string, 0, codeGroups)
+	for len(code) > codeGroupWidth {
+		groups = append(groups, code[:codeGroupWidth])
+		code = code[codeGroupWidth:]
+	}
+	return strings.Join(append(groups, code), "-")
+}
+
+// generateCode returns a random code in groups, e.g. "K7PX-2QMD-9HRT"
+func generateCode() (string, error) {
+	buf := make([]byte, codeGroups*codeGroupWidth)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = codeAlphabet[int(b)%len(codeAlphabet)]
+	}
+	return Normalize(string(buf)), nil
+}
@@ -0,0 +1,155 @@
+package invoice
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"cleanbuddy-api/res/localtime"
+	"cleanbuddy-api/res/store"
+)
+
+// issuer is who a document is issued by and the series it is numbered in
+type issuer struct {
+	id     string
+	series string
+	party  store.InvoiceParty
+}
+
+// item is a line of a document before VAT is taken out of its price
+type item struct {
+	description string
+	quantity    int // -1 for discounts
+	gross       int // unit price including VAT, in bani
+}
+
+// vatRegistered reports whether a party is registered for VAT, which the RO prefix of its
+// tax ID shows
+func vatRegistered(party store.InvoiceParty) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(party.TaxID)), "RO")
+}
+
+// taxOf returns the VAT treatment of what a seller sells at the standard rate
+func taxOf(seller store.InvoiceParty, rate float64) (store.VATCategory, float64) {
+	if vatRegistered(seller) {
+		return store.VATCategoryStandard, rate
+	}
+	return store.VATCategoryNotSubject, 0
+}
+
+// newDocument prices the items of a document net of VAT. Prices are VAT inclusive, so the net
+// price of each line is rounded and the VAT of the whole document is computed from the net
+// total; the rounding amount keeps the total at exactly what the items charged.
+func newDocument(kind store.InvoiceKind, seller issuer, category store.VATCategory, rate float64, items []item) *store.Invoice {
+	invoice := &store.Invoice{
+		Kind:           kind,
+		IssuerID:       seller.id,
+		Series:         seller.series,
+		Seller:         seller.party,
+		Currency:       "RON",
+		VATCategory:    category,
+		VATRate:        rate,
+		EFacturaStatus: store.EFacturaStatusPending,
+	}
+	for _, it := range items {
+		line := &store.InvoiceLine{
+			Description: it.description,
+			Quantity:    it.quantity,
+			UnitPrice:   netOf(it.gross, rate),
+			VATIncluded: it.gross,
+		}
+		invoice.Lines = append(invoice.Lines, line)
+		invoice.NetAmount += line.NetAmount()
+		invoice.TotalAmount += it.quantity * it.gross
+	}
+	invoice.VATAmount = roundBani(float64(invoice.NetAmount) * rate / 100)
+	invoice.RoundingAmount = invoice.TotalAmount - invoice.NetAmount - invoice.VATAmount
+	return invoice
+}
+
+// netOf takes VAT at rate out of a gross price
+func netOf(gross int, rate float64) int {
+	return roundBani(float64(gross) * 100 / (100 + rate))
+}
+
+// bookingItems splits what a completed booking charged between the company of its cleaner, when
+// there is one, and the platform. The company sells the cleaning and the platform its fee, and a
+// discount comes off the part of who funded it. A platform discount larger than the fee comes
+// off the cleaning, since the customer paid that much less for it.
+func bookingItems(booking *store.Booking, hasCompany bool) (company []item, platform []item) {
+	date := booking.ScheduledStart.In(localtime.Location()).Format("02.01.2006")
+	cleaning := []item{{fmt.Sprintf("Cleaning service, %s", date), 1, booking.ServicePrice}}
+	if booking.AddOnsPrice > 0 {
+		cleaning = append(cleaning, item{"Add-ons", 1, booking.AddOnsPrice})
+	}
+	if booking.TravelFee > 0 {
+		cleaning = append(cleaning, item{"Travel fee", 1, booking.TravelFee})
+	}
+	var fee []item
+	if booking.PlatformFee > 0 {
+		fee = append(fee, item{"Platform fee", 1, booking.PlatformFee})
+	}
+
+	if !hasCompany {
+		platform = append(cleaning, fee...)
+		if booking.DiscountAmount > 0 {
+			platform = append(platform, item{"Discount", -1, booking.DiscountAmount})
+		}
+		return nil, platform
+	}
+
+	companyDiscount, platformDiscount := 0, booking.DiscountAmount
+	if booking.DiscountFundedBy != nil && *booking.DiscountFundedBy == store.PromoFunderCompany {
+		companyDiscount, platformDiscount = booking.DiscountAmount, 0
+	}
+	if platformDiscount > booking.PlatformFee {
+		companyDiscount += platformDiscount - booking.PlatformFee
+		platformDiscount = booking.PlatformFee
+	}
+	if companyDiscount > 0 {
+		cleaning = append(cleaning, item{"Discount", -1, companyDiscount})
+	}
+	if platformDiscount > 0 {
+		fee = append(fee, item{"Discount", -1, platformDiscount})
+	}
+	return cleaning, fee
+}
+
+// chargeDescription names what a payment that is not the price of a completed booking charged
+func chargeDescription(booking *store.Booking) string {
+	switch booking.Status {
+	case store.BookingStatusCancelled:
+		return "Cancellation charge"
+	case store.BookingStatusNoShow:
+		return "No-show fee"
+	}
+	return "Booking charge"
+}
+
+// allocate splits amount between documents in proportion to what is left on each, never
+// taking more than is left. Returns nil when nothing is left.
+func allocate(amount int, remaining []int) []int {
+	total := 0
+	for _, left := range remaining {
+		total += left
+	}
+	if total <= 0 || amount <= 0 {
+		return nil
+	}
+	amount = min(amount, total)
+
+	// Rounding the running total keeps the shares summing to amount
+	shares := make([]int, len(remaining))
+	cumulative, allocated := 0, 0
+	for i, left := range remaining {
+		cumulative += left
+		next := roundBani(float64(amount) * float64(cumulative) / float64(total))
+		shares[i] = next - allocated
+		allocated = next
+	}
+	return shares
+}
+
+func roundBani(amount float64) int {
+	return int(math.Round(amount))
+}
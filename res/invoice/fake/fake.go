@@ -0,0 +1,64 @@
+package fake
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"sync"
+
+	"cleanbuddy-api/res/invoice"
+)
+
+// FakeClient implements the EFacturaClient interface in memory for local development and tests.
+// Uploads that are well-formed XML are accepted the first time their state is checked; anything
+// else is rejected, as ANAF would.
+type FakeClient struct {
+	logger *log.Logger
+
+	mu      sync.Mutex
+	next    int
+	uploads map[string]string // Upload index to the validation error, empty when valid
+}
+
+// New creates a new fake e-Factura client
+func New(logger *log.Logger) *FakeClient {
+	return &FakeClient{
+		logger:  logger,
+		uploads: map[string]string{},
+	}
+}
+
+func (f *FakeClient) Upload(ctx context.Context, taxID string, document []byte) (string, error) {
+	if taxID == "" {
+		return "", fmt.Errorf("seller tax ID is required")
+	}
+
+	var root struct{}
+	problem := ""
+	if err := xml.Unmarshal(document, &root); err != nil {
+		problem = fmt.Sprintf("document is not valid XML: %v", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.next++
+	uploadID := fmt.Sprintf("%d", 5000000000+f.next)
+	f.uploads[uploadID] = problem
+	f.logger.Printf("Fake e-Factura received upload %s from %s (%d bytes)", uploadID, taxID, len(document))
+	return uploadID, nil
+}
+
+func (f *FakeClient) Status(ctx context.Context, uploadID string) (invoice.SubmissionState, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	problem, ok := f.uploads[uploadID]
+	if !ok {
+		return "", "", fmt.Errorf("upload %s not found", uploadID)
+	}
+	if problem != "" {
+		return invoice.SubmissionRejected, problem, nil
+	}
+	return invoice.SubmissionAccepted, "", nil
+}
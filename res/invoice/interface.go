@@ -0,0 +1,97 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+var (
+	ErrNothingToCredit    = errors.New("the booking has no invoice left to credit")
+	ErrInvalidBillingInfo = errors.New("billing details need a name, tax ID, street, city and, in Romania, a valid county")
+	ErrStorageUnavailable = errors.New("invoice files cannot be stored")
+)
+
+// InvoiceService issues invoices for card payments of bookings and credit notes for their
+// refunds, and submits them to e-Factura.
+//
+// A booking of a cleaner who works for a company is invoiced twice: the company invoices the
+// cleaning and the platform invoices its fee. Bookings of independent cleaners, cancellation
+// charges and no-show fees are invoiced by the platform alone. Documents are numbered per issuer
+// and series without gaps, and are rendered as UBL 2.1 following CIUS-RO and as PDF on demand.
+type InvoiceService interface {
+	// Issue issues the documents of a completed card payment or refund. Transactions that are
+	// not invoiced, or were invoiced already, issue nothing.
+	Issue(ctx context.Context, transaction *store.Transaction) ([]*store.Invoice, error)
+
+	// IssuePending issues the documents of every settled payment and refund that has none yet and
+	// returns how many transactions were invoiced
+	IssuePending(ctx context.Context) (int, error)
+
+	// SubmitPending uploads invoices to e-Factura and checks on those awaiting validation, and
+	// returns how many changed state
+	SubmitPending(ctx context.Context) (int, error)
+
+	// Documents stores the XML and PDF of an invoice and returns signed download URLs
+	Documents(ctx context.Context, invoice *store.Invoice) (*Documents, error)
+
+	// SaveBillingDetails validates and stores the details a customer is invoiced under
+	SaveBillingDetails(ctx context.Context, details *store.BillingDetails) error
+}
+
+// Documents are the download URLs of an invoice's files
+type Documents struct {
+	XMLURL    string
+	PDFURL    string
+	ExpiresAt time.Time
+}
+
+// FileStore keeps generated files and signs their download URLs
+type FileStore interface {
+	UploadGenerated(ctx context.Context, data []byte, contentType string, objectPath string) (string, error)
+	GenerateSignedURL(ctx context.Context, objectPath string, expiration time.Duration) (string, error)
+}
+
+// SubmissionState is what e-Factura reports about an upload
+type SubmissionState string
+
+const (
+	SubmissionProcessing SubmissionState = "processing" // Still being validated
+	SubmissionAccepted   SubmissionState = "accepted"   // Valid and delivered to the buyer
+	SubmissionRejected   SubmissionState = "rejected"   // Failed validation
+)
+
+// EFacturaClient talks to the e-Factura system of ANAF
+type EFacturaClient interface {
+	// Upload sends the UBL XML of a document on behalf of the seller with taxID and returns the
+	// upload index to check its state with
+	Upload(ctx context.Context, taxID string, document []byte) (string, error)
+
+	// Status returns the state of an upload, with the validation errors when it was rejected
+	Status(ctx context.Context, uploadID string) (SubmissionState, string, error)
+}
+
+// Options configures who the platform invoices as and how documents are numbered and taxed
+type Options struct {
+	// Platform is the seller on the platform's invoices; its TaxID is prefixed with RO when the
+	// platform is registered for VAT
+	Platform store.InvoiceParty
+
+	// PlatformSeries numbers the platform's invoices; CompanySeries numbers each company's own
+	PlatformSeries string
+	CompanySeries  string
+
+	// VATRate is the standard VAT rate in percent charged by issuers registered for VAT
+	VATRate float64
+}
+
+// DefaultOptions uses the Romanian standard VAT rate
+func DefaultOptions() Options {
+	return Options{
+		PlatformSeries: "CB",
+		CompanySeries:  "CBC",
+		VATRate:        21,
+	}
+}
@@ -0,0 +1,193 @@
+package invoice
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+func TestNewDocumentTakesVATOutOfGrossPrices(t *testing.T) {
+	seller := issuer{id: "company-1", series: "CBC", party: store.InvoiceParty{Name: "Curat SRL", TaxID: "RO123456"}}
+	category, rate := taxOf(seller.party, 21)
+	if category != store.VATCategoryStandard || rate != 21 {
+		t.Fatalf("got category %s at %v%%, want S at 21%%", category, rate)
+	}
+
+	invoice := newDocument(store.InvoiceKindInvoice, seller, category, rate, []item{
+		{"Cleaning service", 1, 10001},
+		{"Travel fee", 1, 2000},
+		{"Discount", -1, 999},
+	})
+
+	// 10001 / 1.21 = 8265.29, 2000 / 1.21 = 1652.89, 999 / 1.21 = 825.62
+	if got := []int{invoice.Lines[0].UnitPrice, invoice.Lines[1].UnitPrice, invoice.Lines[2].UnitPrice}; got[0] != 8265 || got[1] != 1653 || got[2] != 826 {
+		t.Errorf("got net unit prices %v, want [8265 1653 826]", got)
+	}
+	if invoice.NetAmount != 9092 {
+		t.Errorf("got net amount %d, want 9092", invoice.NetAmount)
+	}
+	if invoice.VATAmount != 1909 {
+		t.Errorf("got VAT %d, want 1909", invoice.VATAmount)
+	}
+	if invoice.TotalAmount != 11002 {
+		t.Errorf("got total %d, want 11002", invoice.TotalAmount)
+	}
+	if invoice.NetAmount+invoice.VATAmount+invoice.RoundingAmount != invoice.TotalAmount {
+		t.Errorf("net %d + VAT %d + rounding %d does not make total %d",
+			invoice.NetAmount, invoice.VATAmount, invoice.RoundingAmount, invoice.TotalAmount)
+	}
+}
+
+func TestNewDocumentNotSubjectToVAT(t *testing.T) {
+	seller := issuer{party: store.InvoiceParty{TaxID: "123456"}}
+	category, rate := taxOf(seller.party, 21)
+	invoice := newDocument(store.InvoiceKindInvoice, seller, category, rate, []item{{"Cleaning service", 1, 10000}})
+
+	if invoice.VATCategory != store.VATCategoryNotSubject || invoice.VATAmount != 0 || invoice.NetAmount != 10000 {
+		t.Errorf("got category %s, VAT %d and net %d, want O, 0 and 10000", invoice.VATCategory, invoice.VATAmount, invoice.NetAmount)
+	}
+}
+
+func TestBookingItems(t *testing.T) {
+	platformFunded, companyFunded := store.PromoFunderPlatform, store.PromoFunderCompany
+	booking := &store.Booking{ServicePrice: 10000, AddOnsPrice: 1500, TravelFee: 500, PlatformFee: 1800}
+
+	tests := []struct {
+		name                    string
+		discount                int
+		fundedBy                *store.PromoFunder
+		hasCompany              bool
+		companyTotal, platTotal int
+	}{
+		{"independent cleaner", 1000, &platformFunded, false, 0, 12800},
+		{"platform discount", 1000, &platformFunded, true, 12000, 800},
+		{"discount without funder", 1000, nil, true, 12000, 800},
+		{"company discount", 1000, &companyFunded, true, 11000, 1800},
+		{"platform discount over the fee", 2500, &platformFunded, true, 11300, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := *booking
+			b.DiscountAmount, b.DiscountFundedBy = tt.discount, tt.fundedBy
+
+			company, platform := bookingItems(&b, tt.hasCompany)
+			if got := sum(company); got != tt.companyTotal {
+				t.Errorf("got company items of %d, want %d", got, tt.companyTotal)
+			}
+			if got := sum(platform); got != tt.platTotal {
+				t.Errorf("got platform items of %d, want %d", got, tt.platTotal)
+			}
+			if charged := b.ServicePrice + b.AddOnsPrice + b.TravelFee + b.PlatformFee - b.DiscountAmount; sum(company)+sum(platform) != charged {
+				t.Errorf("items add up to %d, want what was charged, %d", sum(company)+sum(platform), charged)
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		amount    int
+		remaining []int
+		want      []int
+	}{
+		{1000, []int{12000, 800}, []int{938, 62}},
+		{12800, []int{12000, 800}, []int{12000, 800}},
+		{20000, []int{12000, 800}, []int{12000, 800}},
+		{200, []int{100, 0, 100, 100}, []int{67, 0, 66, 67}},
+		{500, []int{0, 0}, nil},
+	}
+	for _, tt := range tests {
+		got := allocate(tt.amount, tt.remaining)
+		if len(got) != len(tt.want) {
+			t.Errorf("allocate(%d, %v) = %v, want %v", tt.amount, tt.remaining, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("allocate(%d, %v) = %v, want %v", tt.amount, tt.remaining, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestRenderUBLCreditNote(t *testing.T) {
+	issued := time.Date(2026, 10, 2, 9, 0, 0, 0, time.UTC)
+	original := &store.Invoice{Series: "CB", Number: 41, IssuedAt: issued.AddDate(0, 0, -3)}
+	seller := issuer{id: store.InvoiceIssuerPlatform, series: "CB", party: store.InvoiceParty{
+		Name: "CleanBuddy SRL", TaxID: "RO40000000", RegistrationNumber: "J40/1/2020",
+		Street: "Str. Exemplu 1", City: "Sector 2", County: "București", Country: "Romania",
+	}}
+	note := newDocument(store.InvoiceKindCreditNote, seller, store.VATCategoryStandard, 21, []item{{"Refund & more", 1, 1210}})
+	note.Number = 42
+	note.IssuedAt = issued
+	note.CorrectedInvoice = original
+	note.Buyer = store.InvoiceParty{Name: "Ana Pop", Street: "Str. Lungă 3", City: "Cluj-Napoca", County: "Cluj", Country: "Romania"}
+
+	out := renderUBL(note)
+
+	var doc struct {
+		XMLName   xml.Name
+		ID        string `xml:"ID"`
+		TypeCode  string `xml:"CreditNoteTypeCode"`
+		Reference string `xml:"BillingReference>InvoiceDocumentReference>ID"`
+		Supplier  struct {
+			City      string `xml:"Party>PostalAddress>CityName"`
+			County    string `xml:"Party>PostalAddress>CountrySubentity"`
+			VATID     string `xml:"Party>PartyTaxScheme>CompanyID"`
+			CompanyID string `xml:"Party>PartyLegalEntity>CompanyID"`
+		} `xml:"AccountingSupplierParty"`
+		Customer struct {
+			County    string `xml:"Party>PostalAddress>CountrySubentity"`
+			CompanyID string `xml:"Party>PartyLegalEntity>CompanyID"`
+		} `xml:"AccountingCustomerParty"`
+		TaxAmount string `xml:"TaxTotal>TaxAmount"`
+		Payable   string `xml:"LegalMonetaryTotal>PayableAmount"`
+		Lines     []struct {
+			Quantity string `xml:"CreditedQuantity"`
+			Name     string `xml:"Item>Name"`
+			Price    string `xml:"Price>PriceAmount"`
+		} `xml:"CreditNoteLine"`
+	}
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("output is not well-formed XML: %v\n%s", err, out)
+	}
+
+	checks := []struct{ name, got, want string }{
+		{"root", doc.XMLName.Local, "CreditNote"},
+		{"namespace", doc.XMLName.Space, ublNamespaces[store.InvoiceKindCreditNote]},
+		{"number", doc.ID, "CB-000042"},
+		{"type code", doc.TypeCode, "381"},
+		{"corrected invoice", doc.Reference, "CB-000041"},
+		{"seller city", doc.Supplier.City, "SECTOR2"},
+		{"seller county", doc.Supplier.County, "RO-B"},
+		{"seller VAT ID", doc.Supplier.VATID, "RO40000000"},
+		{"seller legal ID", doc.Supplier.CompanyID, "40000000"},
+		{"buyer county", doc.Customer.County, "RO-CJ"},
+		{"buyer legal ID", doc.Customer.CompanyID, consumerID},
+		{"VAT", doc.TaxAmount, "2.10"},
+		{"payable", doc.Payable, "0.00"},
+	}
+	for _, check := range checks {
+		if check.got != check.want {
+			t.Errorf("got %s %q, want %q", check.name, check.got, check.want)
+		}
+	}
+	if len(doc.Lines) != 1 || doc.Lines[0].Quantity != "1" || doc.Lines[0].Name != "Refund & more" || doc.Lines[0].Price != "10.00" {
+		t.Errorf("got lines %+v, want one line of 1 x 10.00", doc.Lines)
+	}
+	if !strings.Contains(string(out), customizationID) {
+		t.Errorf("output does not declare CIUS-RO")
+	}
+}
+
+func sum(items []item) int {
+	total := 0
+	for _, it := range items {
+		total += it.quantity * it.gross
+	}
+	return total
+}
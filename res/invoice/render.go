@@ -0,0 +1,135 @@
+package invoice
+
+import (
+	"fmt"
+
+	"cleanbuddy-api/res/localtime"
+	"cleanbuddy-api/res/pdf"
+	"cleanbuddy-api/res/store"
+)
+
+// Layout of the PDF in points
+const (
+	margin     = 40.0
+	rowHeight  = 14.0
+	tableSize  = 9.0
+	bottomEdge = pdf.PageHeight - margin
+)
+
+// Right edges of the quantity, unit price and amount columns
+const (
+	quantityColumn = 360.0
+	priceColumn    = 450.0
+	amountColumn   = pdf.PageWidth - margin
+)
+
+// renderPDF lays out an invoice or credit note with its parties, lines and totals
+func renderPDF(invoice *store.Invoice) []byte {
+	doc := pdf.New()
+	doc.AddPage()
+
+	title := "Invoice"
+	if invoice.Kind == store.InvoiceKindCreditNote {
+		title = "Credit note"
+	}
+	doc.Text(margin, 60, 16, pdf.Bold, fmt.Sprintf("%s %s", title, invoice.DocumentNumber()))
+	doc.Text(margin, 78, 10, pdf.Regular, "Issued "+invoice.IssuedAt.In(localtime.Location()).Format("02.01.2006"))
+	if invoice.CorrectedInvoice != nil {
+		doc.Text(margin, 92, 10, pdf.Regular, fmt.Sprintf("Corrects invoice %s of %s",
+			invoice.CorrectedInvoice.DocumentNumber(),
+			invoice.CorrectedInvoice.IssuedAt.In(localtime.Location()).Format("02.01.2006")))
+	}
+
+	partyBlock(doc, margin, 125, "Seller", invoice.Seller)
+	partyBlock(doc, pdf.PageWidth/2, 125, "Buyer", invoice.Buyer)
+
+	y := linesHeader(doc, 240)
+	for _, line := range invoice.Lines {
+		if y > bottomEdge {
+			doc.AddPage()
+			y = linesHeader(doc, margin+20)
+		}
+		doc.Text(margin, y, tableSize, pdf.Regular, line.Description)
+		doc.TextRight(quantityColumn, y, tableSize, pdf.Regular, fmt.Sprint(line.Quantity))
+		doc.TextRight(priceColumn, y, tableSize, pdf.Regular, formatBani(line.UnitPrice))
+		doc.TextRight(amountColumn, y, tableSize, pdf.Regular, formatBani(line.NetAmount()))
+		y += rowHeight
+	}
+
+	if y+6*rowHeight > bottomEdge {
+		doc.AddPage()
+		y = margin + 20
+	}
+	doc.Line(margin, y-rowHeight+4, amountColumn, y-rowHeight+4)
+	vat := "VAT " + formatRate(invoice.VATRate) + "%"
+	if invoice.VATCategory == store.VATCategoryNotSubject {
+		vat = "VAT (not subject to VAT)"
+	}
+	totals := [][2]string{
+		{"Net amount", formatBani(invoice.NetAmount)},
+		{vat, formatBani(invoice.VATAmount)},
+	}
+	if invoice.RoundingAmount != 0 {
+		totals = append(totals, [2]string{"Rounding", formatBani(invoice.RoundingAmount)})
+	}
+	totals = append(totals, [2]string{"Total " + invoice.Currency, formatBani(invoice.TotalAmount)})
+	for i, total := range totals {
+		font := pdf.Regular
+		if i == len(totals)-1 {
+			font = pdf.Bold
+		}
+		doc.TextRight(priceColumn, y, tableSize, font, total[0])
+		doc.TextRight(amountColumn, y, tableSize, font, total[1])
+		y += rowHeight
+	}
+
+	paid := "Paid by card"
+	if invoice.Kind == store.InvoiceKindCreditNote {
+		paid = "Refunded to card"
+	}
+	doc.Text(margin, y+rowHeight, 9, pdf.Regular, paid)
+	return doc.Bytes()
+}
+
+// partyBlock writes the name, identifiers and address of a party from (x, y) down
+func partyBlock(doc *pdf.Document, x, y float64, title string, party store.InvoiceParty) {
+	doc.Text(x, y, 9, pdf.Bold, title)
+	rows := []string{party.Name}
+	if party.TaxID != "" {
+		rows = append(rows, "Tax ID: "+party.TaxID)
+	}
+	if party.RegistrationNumber != "" {
+		rows = append(rows, "Reg. no.: "+party.RegistrationNumber)
+	}
+	rows = append(rows, party.Street, joinNonEmpty(party.PostalCode, party.City), joinNonEmpty(party.County, party.Country))
+	for _, row := range rows {
+		y += 13
+		doc.Text(x, y, 9, pdf.Regular, row)
+	}
+}
+
+// linesHeader draws the column titles at y and returns where the first line goes
+func linesHeader(doc *pdf.Document, y float64) float64 {
+	doc.Text(margin, y, tableSize, pdf.Bold, "Description")
+	doc.TextRight(quantityColumn, y, tableSize, pdf.Bold, "Qty")
+	doc.TextRight(priceColumn, y, tableSize, pdf.Bold, "Unit price")
+	doc.TextRight(amountColumn, y, tableSize, pdf.Bold, "Amount")
+	doc.Line(margin, y+4, amountColumn, y+4)
+	return y + rowHeight + 2
+}
+
+func joinNonEmpty(first, second string) string {
+	if first == "" || second == "" {
+		return first + second
+	}
+	return first + ", " + second
+}
+
+// formatBani writes an amount in bani as RON with two decimals, e.g. "-12.05"
+func formatBani(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
@@ -0,0 +1,395 @@
+package invoice
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cleanbuddy-api/res/payment"
+	"cleanbuddy-api/res/storage"
+	"cleanbuddy-api/res/store"
+)
+
+const (
+	// urlExpiry is how long the download URLs of an invoice stay valid
+	urlExpiry = 15 * time.Minute
+
+	// batchSize bounds how many transactions or invoices one sweep handles
+	batchSize = 100
+)
+
+type service struct {
+	store    store.Store
+	files    FileStore
+	efactura EFacturaClient
+	options  Options
+	logger   *log.Logger
+}
+
+// NewService creates a new InvoiceService that issues documents as payments settle. files may be
+// nil, in which case documents are issued but cannot be downloaded.
+func NewService(dataStore store.Store, payments payment.PaymentService, files FileStore, efactura EFacturaClient, options Options, logger *log.Logger) InvoiceService {
+	s := &service{
+		store:    dataStore,
+		files:    files,
+		efactura: efactura,
+		options:  options,
+		logger:   logger,
+	}
+	if payments != nil {
+		payments.OnSettled(s.issueOnSettled)
+	}
+	return s
+}
+
+func (s *service) issueOnSettled(ctx context.Context, transaction *store.Transaction) error {
+	_, err := s.Issue(ctx, transaction)
+	return err
+}
+
+func (s *service) Issue(ctx context.Context, transaction *store.Transaction) ([]*store.Invoice, error) {
+	if transaction.Status != store.TransactionStatusCompleted || transaction.PaymentMethod != store.PaymentMethodCard ||
+		transaction.BookingID == nil {
+		return nil, nil
+	}
+
+	var invoices []*store.Invoice
+	var err error
+	switch {
+	case transaction.Type == store.TransactionTypePayment && transaction.StripePaymentID != nil:
+		invoices, err = s.paymentInvoices(ctx, transaction)
+	case transaction.Type == store.TransactionTypeRefund:
+		invoices, err = s.creditNotes(ctx, transaction)
+	default:
+		return nil, nil
+	}
+	if err != nil || len(invoices) == 0 {
+		return nil, err
+	}
+
+	issuedAt := time.Now()
+	if transaction.CompletedAt != nil {
+		issuedAt = *transaction.CompletedAt
+	}
+	for _, invoice := range invoices {
+		invoice.SourceTransactionID = transaction.ID
+		invoice.BookingID = transaction.BookingID
+		invoice.IssuedAt = issuedAt
+		if transaction.Currency != "" {
+			invoice.Currency = transaction.Currency
+		}
+	}
+
+	issued, err := s.store.Invoices().Issue(ctx, invoices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue documents for %s %s: %w", transaction.Type, transaction.ID, err)
+	}
+	if !issued {
+		return nil, nil
+	}
+	for _, invoice := range invoices {
+		s.logger.Printf("Issued %s %s for %s %s", invoice.Kind, invoice.DocumentNumber(), transaction.Type, transaction.ID)
+	}
+	return invoices, nil
+}
+
+// paymentInvoices invoices a card payment. The price of a completed booking is itemized, split
+// between the cleaner's company and the platform; anything else the card was charged, such as a
+// cancellation charge, is invoiced by the platform as a single line.
+func (s *service) paymentInvoices(ctx context.Context, transaction *store.Transaction) ([]*store.Invoice, error) {
+	booking, err := s.store.Bookings().Get(ctx, *transaction.BookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking %s: %w", *transaction.BookingID, err)
+	}
+	buyer, err := s.buyer(ctx, booking)
+	if err != nil {
+		return nil, err
+	}
+
+	platform := issuer{id: store.InvoiceIssuerPlatform, series: s.options.PlatformSeries, party: s.options.Platform}
+	var company *issuer
+	var companyItems, platformItems []item
+	if booking.Status == store.BookingStatusCompleted && transaction.Amount == booking.TotalPrice {
+		company, err = s.companyIssuer(ctx, booking.CleanerID)
+		if err != nil {
+			return nil, err
+		}
+		companyItems, platformItems = bookingItems(booking, company != nil)
+	} else {
+		platformItems = []item{{chargeDescription(booking), 1, transaction.Amount}}
+	}
+
+	var invoices []*store.Invoice
+	for _, part := range []struct {
+		seller *issuer
+		items  []item
+	}{{company, companyItems}, {&platform, platformItems}} {
+		if part.seller == nil || len(part.items) == 0 {
+			continue
+		}
+		category, rate := taxOf(part.seller.party, s.options.VATRate)
+		invoice := newDocument(store.InvoiceKindInvoice, *part.seller, category, rate, part.items)
+		if invoice.TotalAmount <= 0 {
+			continue
+		}
+		invoice.CustomerID = booking.CustomerID
+		invoice.Buyer = buyer
+		invoices = append(invoices, invoice)
+	}
+	return invoices, nil
+}
+
+// creditNotes credits a refund against the invoices of its booking, in proportion to what is
+// left on each of them. A credit note keeps the seller, buyer and VAT treatment of its invoice.
+func (s *service) creditNotes(ctx context.Context, refund *store.Transaction) ([]*store.Invoice, error) {
+	documents, err := s.store.Invoices().List(ctx, store.InvoiceFilters{BookingID: refund.BookingID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoices of booking %s: %w", *refund.BookingID, err)
+	}
+
+	// Oldest first, so shares are allocated in the order invoices were issued
+	var invoices []*store.Invoice
+	credited := make(map[string]int)
+	for i := len(documents) - 1; i >= 0; i-- {
+		document := documents[i]
+		switch {
+		case document.Kind == store.InvoiceKindInvoice:
+			invoices = append(invoices, document)
+		case document.CorrectedInvoiceID != nil:
+			credited[*document.CorrectedInvoiceID] += document.TotalAmount
+		}
+	}
+	remaining := make([]int, len(invoices))
+	for i, invoice := range invoices {
+		remaining[i] = invoice.TotalAmount - credited[invoice.ID]
+	}
+
+	shares := allocate(refund.Amount, remaining)
+	if shares == nil {
+		return nil, ErrNothingToCredit
+	}
+
+	var notes []*store.Invoice
+	for i, invoice := range invoices {
+		if shares[i] <= 0 {
+			continue
+		}
+		seller := issuer{id: invoice.IssuerID, series: invoice.Series, party: invoice.Seller}
+		note := newDocument(store.InvoiceKindCreditNote, seller, invoice.VATCategory, invoice.VATRate, []item{
+			{fmt.Sprintf("Refund for invoice %s", invoice.DocumentNumber()), 1, shares[i]},
+		})
+		note.CustomerID = invoice.CustomerID
+		note.Buyer = invoice.Buyer
+		note.CorrectedInvoiceID = &invoice.ID
+		note.CorrectedInvoice = invoice
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+// companyIssuer returns the company a cleaner works for, or nil when they work on their own or
+// their company cannot issue invoices
+func (s *service) companyIssuer(ctx context.Context, cleanerID string) (*issuer, error) {
+	profile, err := s.store.CleanerProfiles().GetByUserID(ctx, cleanerID)
+	if err != nil || profile.CompanyID == nil {
+		return nil, nil
+	}
+	company, err := s.store.Companies().Get(ctx, *profile.CompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get company %s: %w", *profile.CompanyID, err)
+	}
+	if !company.IsApproved() || strings.TrimSpace(company.TaxID) == "" {
+		return nil, nil
+	}
+
+	party := store.InvoiceParty{
+		Name:               company.CompanyName,
+		TaxID:              company.TaxID,
+		RegistrationNumber: company.RegistrationNumber,
+		Street:             company.CompanyStreet,
+		City:               company.CompanyCity,
+		PostalCode:         company.CompanyPostalCode,
+		Country:            company.CompanyCountry,
+	}
+	if company.CompanyCounty != nil {
+		party.County = *company.CompanyCounty
+	}
+	return &issuer{id: company.ID, series: s.options.CompanySeries, party: party}, nil
+}
+
+// buyer returns who a booking is invoiced to: the billing details of the customer when they gave
+// them, otherwise the customer at the booking's address
+func (s *service) buyer(ctx context.Context, booking *store.Booking) (store.InvoiceParty, error) {
+	if details, err := s.store.Invoices().GetBillingDetails(ctx, booking.CustomerID); err == nil {
+		return details.InvoiceParty, nil
+	}
+
+	customer, err := s.store.Users().Get(ctx, booking.CustomerID)
+	if err != nil {
+		return store.InvoiceParty{}, fmt.Errorf("failed to get customer %s: %w", booking.CustomerID, err)
+	}
+	address, err := s.store.Addresses().Get(ctx, booking.AddressID)
+	if err != nil {
+		return store.InvoiceParty{}, fmt.Errorf("failed to get address %s: %w", booking.AddressID, err)
+	}
+
+	street := address.Street
+	for _, part := range []string{address.Building, address.Apartment} {
+		if part != "" {
+			street += " " + part
+		}
+	}
+	return store.InvoiceParty{
+		Name:       customer.DisplayName,
+		Street:     street,
+		City:       address.City,
+		County:     address.County,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+	}, nil
+}
+
+func (s *service) IssuePending(ctx context.Context) (int, error) {
+	payments, err := s.store.Invoices().UninvoicedPayments(ctx, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get uninvoiced payments: %w", err)
+	}
+	// Refunds are fetched after payments are invoiced, so they find their invoices
+	invoiced := s.issueAll(ctx, payments)
+
+	refunds, err := s.store.Invoices().UncreditedRefunds(ctx, batchSize)
+	if err != nil {
+		return invoiced, fmt.Errorf("failed to get uncredited refunds: %w", err)
+	}
+	return invoiced + s.issueAll(ctx, refunds), nil
+}
+
+func (s *service) issueAll(ctx context.Context, transactions []*store.Transaction) int {
+	issued := 0
+	for _, transaction := range transactions {
+		invoices, err := s.Issue(ctx, transaction)
+		if err != nil {
+			s.logger.Printf("Failed to invoice %s %s: %v", transaction.Type, transaction.ID, err)
+			continue
+		}
+		if len(invoices) > 0 {
+			issued++
+		}
+	}
+	return issued
+}
+
+func (s *service) SubmitPending(ctx context.Context) (int, error) {
+	invoices, err := s.store.Invoices().GetUnsubmitted(ctx, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get unsubmitted invoices: %w", err)
+	}
+
+	changed := 0
+	for _, invoice := range invoices {
+		if !s.submit(ctx, invoice) {
+			continue
+		}
+		if err := s.store.Invoices().UpdateSubmission(ctx, invoice); err != nil {
+			return changed, fmt.Errorf("failed to record submission of invoice %s: %w", invoice.ID, err)
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// submit uploads a pending invoice or checks on an uploaded one, and reports whether its
+// submission state changed
+func (s *service) submit(ctx context.Context, invoice *store.Invoice) bool {
+	if invoice.EFacturaStatus == store.EFacturaStatusPending {
+		taxID := strings.TrimPrefix(strings.ToUpper(strings.ReplaceAll(invoice.Seller.TaxID, " ", "")), "RO")
+		uploadID, err := s.efactura.Upload(ctx, taxID, renderUBL(invoice))
+		if err != nil {
+			s.logger.Printf("Failed to upload invoice %s to e-Factura: %v", invoice.DocumentNumber(), err)
+			return false
+		}
+		now := time.Now()
+		invoice.EFacturaStatus = store.EFacturaStatusUploaded
+		invoice.EFacturaUploadID = &uploadID
+		invoice.EFacturaError = ""
+		invoice.SubmittedAt = &now
+		return true
+	}
+
+	if invoice.EFacturaUploadID == nil {
+		return false
+	}
+	state, message, err := s.efactura.Status(ctx, *invoice.EFacturaUploadID)
+	if err != nil {
+		s.logger.Printf("Failed to check e-Factura upload %s of invoice %s: %v", *invoice.EFacturaUploadID, invoice.DocumentNumber(), err)
+		return false
+	}
+	switch state {
+	case SubmissionAccepted:
+		invoice.EFacturaStatus = store.EFacturaStatusAccepted
+	case SubmissionRejected:
+		s.logger.Printf("e-Factura rejected invoice %s: %s", invoice.DocumentNumber(), message)
+		invoice.EFacturaStatus = store.EFacturaStatusRejected
+		invoice.EFacturaError = message
+	default:
+		return false
+	}
+	return true
+}
+
+func (s *service) Documents(ctx context.Context, invoice *store.Invoice) (*Documents, error) {
+	if s.files == nil {
+		return nil, ErrStorageUnavailable
+	}
+
+	documents := &Documents{}
+	files := []struct {
+		ext         string
+		contentType string
+		data        []byte
+		url         *string
+	}{
+		{".xml", "application/xml", renderUBL(invoice), &documents.XMLURL},
+		{".pdf", "application/pdf", renderPDF(invoice), &documents.PDFURL},
+	}
+
+	for _, file := range files {
+		path := storage.BuildInvoicePath(invoice.IssuerID, invoice.DocumentNumber(), file.ext)
+		object, err := s.files.UploadGenerated(ctx, file.data, file.contentType, path)
+		if err != nil {
+			s.logger.Printf("Failed to upload invoice file %s: %v", path, err)
+			return nil, fmt.Errorf("failed to upload invoice file: %w", err)
+		}
+		url, err := s.files.GenerateSignedURL(ctx, object, urlExpiry)
+		if err != nil {
+			s.logger.Printf("Failed to sign invoice file %s: %v", path, err)
+			return nil, fmt.Errorf("failed to sign invoice file URL: %w", err)
+		}
+		*file.url = url
+	}
+	documents.ExpiresAt = time.Now().Add(urlExpiry)
+	return documents, nil
+}
+
+func (s *service) SaveBillingDetails(ctx context.Context, details *store.BillingDetails) error {
+	party := &details.InvoiceParty
+	for _, field := range []*string{&party.Name, &party.TaxID, &party.RegistrationNumber, &party.Street,
+		&party.City, &party.County, &party.PostalCode, &party.Country} {
+		*field = strings.TrimSpace(*field)
+	}
+	party.TaxID = strings.ToUpper(strings.ReplaceAll(party.TaxID, " ", ""))
+	if party.Country == "" {
+		party.Country = "Romania"
+	}
+
+	if party.Name == "" || party.TaxID == "" || party.Street == "" || party.City == "" {
+		return ErrInvalidBillingInfo
+	}
+	// CIUS-RO requires the county of Romanian addresses
+	if countryCode(party.Country) == "RO" && countrySubentity(party.County, "RO") == "" {
+		return ErrInvalidBillingInfo
+	}
+	return s.store.Invoices().SaveBillingDetails(ctx, details)
+}
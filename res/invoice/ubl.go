@@ -0,0 +1,307 @@
+package invoice
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"cleanbuddy-api/res/localtime"
+	"cleanbuddy-api/res/store"
+)
+
+const (
+	// customizationID declares the Romanian national use of EN 16931
+	customizationID = "urn:cen.eu:en16931:2017#compliant#urn:efactura.mfinante.ro:CIUS-RO:1.0.1"
+
+	typeCodeInvoice    = "380"
+	typeCodeCreditNote = "381"
+
+	// paymentMeansCard is the UNCL 4461 code of a payment by bank card
+	paymentMeansCard = "48"
+
+	// consumerID stands in for the identifier of buyers who are individuals
+	consumerID = "0000000000000"
+
+	// exemptionNotSubject explains the VAT category O
+	exemptionNotSubject = "VATEX-EU-O"
+)
+
+var (
+	ublNamespaces = map[store.InvoiceKind]string{
+		store.InvoiceKindInvoice:    "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2",
+		store.InvoiceKindCreditNote: "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2",
+	}
+	namespaceCAC = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	namespaceCBC = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+)
+
+// renderUBL writes an invoice or credit note as UBL 2.1 following CIUS-RO. Prices are already
+// net of VAT on the stored document, so the output is the same every time it is rendered.
+func renderUBL(invoice *store.Invoice) []byte {
+	root, typeCode, lineName, quantityName := "Invoice", typeCodeInvoice, "cac:InvoiceLine", "cbc:InvoicedQuantity"
+	if invoice.Kind == store.InvoiceKindCreditNote {
+		root, typeCode, lineName, quantityName = "CreditNote", typeCodeCreditNote, "cac:CreditNoteLine", "cbc:CreditedQuantity"
+	}
+	currency := invoice.Currency
+
+	w := &xmlWriter{}
+	w.buf.WriteString(xml.Header)
+	w.open(root, "xmlns", ublNamespaces[invoice.Kind], "xmlns:cac", namespaceCAC, "xmlns:cbc", namespaceCBC)
+	w.leaf("cbc:CustomizationID", customizationID)
+	w.leaf("cbc:ID", invoice.DocumentNumber())
+	w.leaf("cbc:IssueDate", issueDate(invoice))
+	w.leaf("cbc:"+root+"TypeCode", typeCode)
+	w.leaf("cbc:DocumentCurrencyCode", currency)
+
+	if invoice.CorrectedInvoice != nil {
+		w.open("cac:BillingReference")
+		w.open("cac:InvoiceDocumentReference")
+		w.leaf("cbc:ID", invoice.CorrectedInvoice.DocumentNumber())
+		w.leaf("cbc:IssueDate", issueDate(invoice.CorrectedInvoice))
+		w.close("cac:InvoiceDocumentReference")
+		w.close("cac:BillingReference")
+	}
+
+	w.open("cac:AccountingSupplierParty")
+	writeParty(w, invoice.Seller, vatRegistered(invoice.Seller), false)
+	w.close("cac:AccountingSupplierParty")
+	w.open("cac:AccountingCustomerParty")
+	// No VAT identifier may appear on a document not subject to VAT
+	writeParty(w, invoice.Buyer, vatRegistered(invoice.Buyer) && invoice.VATCategory != store.VATCategoryNotSubject, true)
+	w.close("cac:AccountingCustomerParty")
+
+	w.open("cac:PaymentMeans")
+	w.leaf("cbc:PaymentMeansCode", paymentMeansCard)
+	w.close("cac:PaymentMeans")
+
+	w.open("cac:TaxTotal")
+	w.leaf("cbc:TaxAmount", formatBani(invoice.VATAmount), "currencyID", currency)
+	w.open("cac:TaxSubtotal")
+	w.leaf("cbc:TaxableAmount", formatBani(invoice.NetAmount), "currencyID", currency)
+	w.leaf("cbc:TaxAmount", formatBani(invoice.VATAmount), "currencyID", currency)
+	w.open("cac:TaxCategory")
+	writeTaxCategory(w, invoice, true)
+	w.close("cac:TaxCategory")
+	w.close("cac:TaxSubtotal")
+	w.close("cac:TaxTotal")
+
+	// Documents are issued once the card was charged, so nothing is left to pay
+	w.open("cac:LegalMonetaryTotal")
+	w.leaf("cbc:LineExtensionAmount", formatBani(invoice.NetAmount), "currencyID", currency)
+	w.leaf("cbc:TaxExclusiveAmount", formatBani(invoice.NetAmount), "currencyID", currency)
+	w.leaf("cbc:TaxInclusiveAmount", formatBani(invoice.NetAmount+invoice.VATAmount), "currencyID", currency)
+	w.leaf("cbc:PrepaidAmount", formatBani(invoice.TotalAmount), "currencyID", currency)
+	if invoice.RoundingAmount != 0 {
+		w.leaf("cbc:PayableRoundingAmount", formatBani(invoice.RoundingAmount), "currencyID", currency)
+	}
+	w.leaf("cbc:PayableAmount", formatBani(0), "currencyID", currency)
+	w.close("cac:LegalMonetaryTotal")
+
+	for i, line := range invoice.Lines {
+		w.open(lineName)
+		w.leaf("cbc:ID", fmt.Sprint(i+1))
+		w.leaf(quantityName, fmt.Sprint(line.Quantity), "unitCode", "C62")
+		w.leaf("cbc:LineExtensionAmount", formatBani(line.NetAmount()), "currencyID", currency)
+		w.open("cac:Item")
+		w.leaf("cbc:Name", line.Description)
+		w.open("cac:ClassifiedTaxCategory")
+		writeTaxCategory(w, invoice, false)
+		w.close("cac:ClassifiedTaxCategory")
+		w.close("cac:Item")
+		w.open("cac:Price")
+		w.leaf("cbc:PriceAmount", formatBani(line.UnitPrice), "currencyID", currency)
+		w.close("cac:Price")
+		w.close(lineName)
+	}
+
+	w.close(root)
+	return w.buf.Bytes()
+}
+
+// writeParty writes a seller or buyer. The tax ID goes in the VAT scheme when withVAT is set and
+// identifies the party as a legal entity without its RO prefix; individuals are identified by the
+// placeholder CIUS-RO allows for them.
+func writeParty(w *xmlWriter, party store.InvoiceParty, withVAT bool, buyer bool) {
+	taxID := strings.ToUpper(strings.ReplaceAll(party.TaxID, " ", ""))
+	legalID := strings.TrimPrefix(taxID, "RO")
+	if legalID == "" && buyer {
+		legalID = consumerID
+	}
+
+	w.open("cac:Party")
+	if party.RegistrationNumber != "" {
+		w.open("cac:PartyIdentification")
+		w.leaf("cbc:ID", party.RegistrationNumber)
+		w.close("cac:PartyIdentification")
+	}
+
+	country := countryCode(party.Country)
+	w.open("cac:PostalAddress")
+	w.leaf("cbc:StreetName", party.Street)
+	w.leaf("cbc:CityName", cityName(party, country))
+	if party.PostalCode != "" {
+		w.leaf("cbc:PostalZone", party.PostalCode)
+	}
+	if subentity := countrySubentity(party.County, country); subentity != "" {
+		w.leaf("cbc:CountrySubentity", subentity)
+	}
+	w.open("cac:Country")
+	w.leaf("cbc:IdentificationCode", country)
+	w.close("cac:Country")
+	w.close("cac:PostalAddress")
+
+	if withVAT {
+		w.open("cac:PartyTaxScheme")
+		w.leaf("cbc:CompanyID", taxID)
+		w.open("cac:TaxScheme")
+		w.leaf("cbc:ID", "VAT")
+		w.close("cac:TaxScheme")
+		w.close("cac:PartyTaxScheme")
+	}
+
+	w.open("cac:PartyLegalEntity")
+	w.leaf("cbc:RegistrationName", party.Name)
+	if legalID != "" {
+		w.leaf("cbc:CompanyID", legalID)
+	}
+	w.close("cac:PartyLegalEntity")
+	w.close("cac:Party")
+}
+
+// writeTaxCategory writes the VAT category of the document; the category of a subtotal
+// explains why no VAT is charged
+func writeTaxCategory(w *xmlWriter, invoice *store.Invoice, subtotal bool) {
+	w.leaf("cbc:ID", string(invoice.VATCategory))
+	if invoice.VATCategory == store.VATCategoryNotSubject {
+		if subtotal {
+			w.leaf("cbc:TaxExemptionReasonCode", exemptionNotSubject)
+		}
+	} else {
+		w.leaf("cbc:Percent", formatRate(invoice.VATRate))
+	}
+	w.open("cac:TaxScheme")
+	w.leaf("cbc:ID", "VAT")
+	w.close("cac:TaxScheme")
+}
+
+func issueDate(invoice *store.Invoice) string {
+	return invoice.IssuedAt.In(localtime.Location()).Format("2006-01-02")
+}
+
+func formatRate(rate float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", rate), "0"), ".")
+}
+
+// countryCode returns the ISO 3166-1 code of a country; addresses are in Romania unless they
+// give another code
+func countryCode(country string) string {
+	country = strings.TrimSpace(country)
+	if len(country) == 2 {
+		return strings.ToUpper(country)
+	}
+	return "RO"
+}
+
+// sectorPattern finds the sector of a Bucharest address
+var sectorPattern = regexp.MustCompile(`(?i)sector(?:ul)?\s*([1-6])`)
+
+// cityName returns the city of a party. CIUS-RO names the sector instead for Bucharest.
+func cityName(party store.InvoiceParty, country string) string {
+	if country == "RO" && countySubdivisions[normalizeCounty(party.County)] == "B" {
+		for _, text := range []string{party.City, party.Street} {
+			if match := sectorPattern.FindStringSubmatch(text); match != nil {
+				return "SECTOR" + match[1]
+			}
+		}
+	}
+	return party.City
+}
+
+// countrySubentity returns the ISO 3166-2 code of a Romanian county, e.g. RO-CJ, or the county as
+// it was written elsewhere
+func countrySubentity(county string, country string) string {
+	if country != "RO" {
+		return strings.TrimSpace(county)
+	}
+	if code, ok := countySubdivisions[normalizeCounty(county)]; ok {
+		return "RO-" + code
+	}
+	return ""
+}
+
+// countySubdivisions maps county names, without diacritics, spaces or hyphens, and their codes
+// to the ISO 3166-2 code of the county
+var countySubdivisions = func() map[string]string {
+	counties := map[string]string{
+		"alba": "AB", "arad": "AR", "arges": "AG", "bacau": "BC", "bihor": "BH",
+		"bistritanasaud": "BN", "botosani": "BT", "braila": "BR", "brasov": "BV", "buzau": "BZ",
+		"calarasi": "CL", "carasseverin": "CS", "cluj": "CJ", "constanta": "CT", "covasna": "CV",
+		"dambovita": "DB", "dolj": "DJ", "galati": "GL", "giurgiu": "GR", "gorj": "GJ",
+		"harghita": "HR", "hunedoara": "HD", "ialomita": "IL", "iasi": "IS", "ilfov": "IF",
+		"maramures": "MM", "mehedinti": "MH", "mures": "MS", "neamt": "NT", "olt": "OT",
+		"prahova": "PH", "salaj": "SJ", "satumare": "SM", "sibiu": "SB", "suceava": "SV",
+		"teleorman": "TR", "timis": "TM", "tulcea": "TL", "valcea": "VL", "vaslui": "VS",
+		"vrancea": "VN", "bucuresti": "B", "municipiulbucuresti": "B", "bucharest": "B",
+	}
+	codes := make(map[string]string, 3*len(counties))
+	for name, code := range counties {
+		codes[name] = code
+		codes[strings.ToLower(code)] = code
+		codes["ro"+strings.ToLower(code)] = code
+	}
+	return codes
+}()
+
+var diacritics = strings.NewReplacer(
+	"ă", "a", "â", "a", "î", "i", "ș", "s", "ş", "s", "ț", "t", "ţ", "t",
+	"Ă", "a", "Â", "a", "Î", "i", "Ș", "s", "Ş", "s", "Ț", "t", "Ţ", "t",
+)
+
+func normalizeCounty(county string) string {
+	county = strings.ToLower(diacritics.Replace(strings.TrimSpace(county)))
+	county = strings.TrimPrefix(county, "judetul ")
+	county = strings.TrimPrefix(county, "jud. ")
+	return strings.NewReplacer(" ", "", "-", "", ".", "").Replace(county)
+}
+
+// xmlWriter writes indented XML elements
+type xmlWriter struct {
+	buf   bytes.Buffer
+	depth int
+}
+
+func (w *xmlWriter) open(name string, attrs ...string) {
+	w.start(name, attrs)
+	w.buf.WriteString(">\n")
+	w.depth++
+}
+
+func (w *xmlWriter) close(name string) {
+	w.depth--
+	w.indent()
+	fmt.Fprintf(&w.buf, "</%s>\n", name)
+}
+
+// leaf writes an element holding text; attrs are name and value pairs
+func (w *xmlWriter) leaf(name string, value string, attrs ...string) {
+	w.start(name, attrs)
+	w.buf.WriteByte('>')
+	_ = xml.EscapeText(&w.buf, []byte(value))
+	fmt.Fprintf(&w.buf, "</%s>\n", name)
+}
+
+func (w *xmlWriter) start(name string, attrs []string) {
+	w.indent()
+	w.buf.WriteString("<" + name)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		fmt.Fprintf(&w.buf, ` %s="`, attrs[i])
+		_ = xml.EscapeText(&w.buf, []byte(attrs[i+1]))
+		w.buf.WriteByte('"')
+	}
+}
+
+func (w *xmlWriter) indent() {
+	w.buf.WriteString(strings.Repeat("  ", w.depth))
+}
@@ -0,0 +1,41 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+// LedgerService keeps an append-only double-entry ledger underneath transactions.
+//
+// Entries are posted from what the other services record: completed transactions, credit
+// entries, compensation owed to cleaners and the outcome of bookings. Money customers pay for
+// a booking sits in their receivable until the booking is completed, marked as a no-show or
+// cancelled, when it is split between the cleaner's payable and platform revenue. Posting is
+// idempotent, so it can run as often as needed and picks up anything missed.
+type LedgerService interface {
+	// PostPending posts entries for everything recorded but not posted yet and returns how
+	// many entries were posted
+	PostPending(ctx context.Context) (int, error)
+
+	// Balances returns the balance of each account per owner, posting pending entries first
+	Balances(ctx context.Context, filter store.LedgerBalanceFilter) ([]*store.LedgerBalance, error)
+
+	// TrialBalance returns the totals of every account of the chart for entries posted before
+	// asOf, or all entries when it is nil, posting pending entries first
+	TrialBalance(ctx context.Context, asOf *time.Time) (*TrialBalance, error)
+}
+
+// TrialBalance lists the debit and credit totals of every account
+type TrialBalance struct {
+	Accounts    []*store.LedgerBalance // In the order of store.LedgerAccounts
+	TotalDebit  int                    // in bani
+	TotalCredit int                    // in bani
+	AsOf        *time.Time
+}
+
+// Balanced reports whether debits equal credits, which holds unless entries were tampered with
+func (t *TrialBalance) Balanced() bool {
+	return t.TotalDebit == t.TotalCredit
+}
@@ -0,0 +1,229 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/store"
+)
+
+// transactionEntry moves the money of a completed transaction between accounts
+func (s *service) transactionEntry(ctx context.Context, transaction *store.Transaction) (*store.JournalEntry, error) {
+	postedAt := transaction.ProcessedAt
+	if transaction.CompletedAt != nil {
+		postedAt = *transaction.CompletedAt
+	}
+	entry := newEntry(store.JournalSourceTransaction, transaction.ID, transaction.BookingID, transaction.Description, postedAt)
+	amount := transaction.Amount
+
+	switch transaction.Type {
+	case store.TransactionTypePayment:
+		addLine(entry, store.LedgerAccountCash, "", amount)
+		addLine(entry, store.LedgerAccountCustomerReceivables, transaction.PayerID, -amount)
+
+	case store.TransactionTypeRefund:
+		// Refunds of a delivered service reduce revenue; before that they return a prepayment
+		account, owner := store.LedgerAccountCustomerReceivables, transaction.PayeeID
+		delivered, err := s.delivered(ctx, transaction.BookingID)
+		if err != nil {
+			return nil, err
+		}
+		if delivered {
+			account, owner = store.LedgerAccountRefunds, ""
+		}
+		addLine(entry, account, owner, amount)
+		addLine(entry, store.LedgerAccountCash, "", -amount)
+
+	case store.TransactionTypePayout:
+		account, owner := s.payable(ctx, transaction.PayeeID)
+		addLine(entry, account, owner, amount)
+		addLine(entry, store.LedgerAccountCash, "", -amount)
+
+	case store.TransactionTypeTip:
+		// The whole tip is owed to the cleaner and leaves with their next payout
+		account, owner := s.payable(ctx, transaction.PayeeID)
+		addLine(entry, store.LedgerAccountCash, "", amount)
+		addLine(entry, account, owner, -amount)
+
+	case store.TransactionTypeClawback:
+		// The cleaner bears part of a disputed booking's refund, taken off what they are owed
+		account, owner := s.payable(ctx, transaction.PayerID)
+		addLine(entry, account, owner, amount)
+		addLine(entry, store.LedgerAccountRefunds, "", -amount)
+
+	case store.TransactionTypeGiftCardPurchase:
+		addLine(entry, store.LedgerAccountCash, "", amount)
+		addLine(entry, store.LedgerAccountGiftCards, "", -amount)
+
+	case store.TransactionTypeGiftCardRedemption:
+		addLine(entry, store.LedgerAccountGiftCards, "", amount)
+		addLine(entry, store.LedgerAccountCustomerReceivables, transaction.PayerID, -amount)
+
+	case store.TransactionTypeGiftCardExpiry:
+		// Breakage: value nobody will spend anymore is kept
+		addLine(entry, store.LedgerAccountGiftCards, "", amount)
+		addLine(entry, store.LedgerAccountPlatformRevenue, "", -amount)
+
+	default:
+		return nil, fmt.Errorf("transaction %s has type %s, which has no posting rule", transaction.ID, transaction.Type)
+	}
+	return entry, nil
+}
+
+// obligationEntry records the compensation a cleaner is owed for a booking that did not take
+// place, paid out of what its customer was charged
+func (s *service) obligationEntry(ctx context.Context, payout *store.Transaction) (*store.JournalEntry, error) {
+	booking, err := s.store.Bookings().Get(ctx, *payout.BookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking %s: %w", *payout.BookingID, err)
+	}
+
+	entry := newEntry(store.JournalSourceObligation, payout.ID, payout.BookingID, payout.Description, payout.ProcessedAt)
+	account, owner := s.payable(ctx, payout.PayeeID)
+	addLine(entry, store.LedgerAccountCustomerReceivables, booking.CustomerID, payout.Amount)
+	addLine(entry, account, owner, -payout.Amount)
+	return entry, nil
+}
+
+// reversalEntry undoes the entry of a transaction that was cancelled after it was posted
+func (s *service) reversalEntry(ctx context.Context, transaction *store.Transaction) (*store.JournalEntry, error) {
+	original, err := s.store.Ledger().GetBySource(ctx, store.JournalSourceTransaction, transaction.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get journal entry of transaction %s: %w", transaction.ID, err)
+	}
+
+	entry := newEntry(store.JournalSourceReversal, transaction.ID, transaction.BookingID,
+		"Reversal: "+original.Description, time.Now())
+	for _, line := range original.Lines {
+		addLine(entry, line.Account, line.OwnerID, line.Credit-line.Debit)
+	}
+	return entry, nil
+}
+
+// bookingEntry splits what the customer owes for a booking between the cleaner and the platform
+func (s *service) bookingEntry(ctx context.Context, booking *store.Booking) (*store.JournalEntry, error) {
+	switch booking.Status {
+	case store.BookingStatusCompleted:
+		account, owner := s.payable(ctx, booking.CleanerID)
+		return completedBookingEntry(booking, account, owner), nil
+
+	case store.BookingStatusNoShow:
+		postedAt := booking.UpdatedAt
+		if booking.NoShowResolvedAt != nil {
+			postedAt = *booking.NoShowResolvedAt
+		}
+		// The cleaner's share is owed through the compensation payout
+		entry := newEntry(store.JournalSourceBooking, booking.ID, &booking.ID, "No-show fee", postedAt)
+		fee := booking.NoShowFee - booking.NoShowCleanerPayout
+		addLine(entry, store.LedgerAccountCustomerReceivables, booking.CustomerID, fee)
+		addLine(entry, store.LedgerAccountPlatformRevenue, "", -fee)
+		return entry, nil
+
+	case store.BookingStatusCancelled:
+		postedAt := booking.UpdatedAt
+		if booking.CancelledAt != nil {
+			postedAt = *booking.CancelledAt
+		}
+		// What the customer paid and was not refunded, less the cleaner's compensation, is kept
+		balance, err := s.store.Ledger().BookingBalance(ctx, booking.ID, store.LedgerAccountCustomerReceivables)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get receivable of booking %s: %w", booking.ID, err)
+		}
+		entry := newEntry(store.JournalSourceBooking, booking.ID, &booking.ID, "Cancellation charge", postedAt)
+		addLine(entry, store.LedgerAccountCustomerReceivables, booking.CustomerID, -balance)
+		addLine(entry, store.LedgerAccountPlatformRevenue, "", balance)
+		return entry, nil
+	}
+	return nil, fmt.Errorf("booking %s is %s and cannot be posted", booking.ID, booking.Status)
+}
+
+// completedBookingEntry charges the customer the full value of a completed booking, including
+// what was paid with credit and gift cards, and owes the cleaner their payout. Discounts the
+// platform funds are a promotion expense; the rest is platform revenue.
+func completedBookingEntry(booking *store.Booking, payable store.LedgerAccount, payee string) *store.JournalEntry {
+	postedAt := booking.UpdatedAt
+	if booking.CompletedAt != nil {
+		postedAt = *booking.CompletedAt
+	}
+	entry := newEntry(store.JournalSourceBooking, booking.ID, &booking.ID, "Booking completed", postedAt)
+
+	charged := booking.TotalPrice + booking.CreditApplied + booking.GiftCardApplied
+	promotion := 0
+	if booking.DiscountFundedBy == nil || *booking.DiscountFundedBy == store.PromoFunderPlatform {
+		promotion = booking.DiscountAmount
+	}
+
+	addLine(entry, store.LedgerAccountCustomerReceivables, booking.CustomerID, charged)
+	addLine(entry, store.LedgerAccountPromotions, "", promotion)
+	addLine(entry, payable, payee, -booking.CleanerPayout)
+	addLine(entry, store.LedgerAccountPlatformRevenue, "", booking.CleanerPayout-charged-promotion)
+	return entry
+}
+
+// creditEntry records a change to a customer's credit. Credit granted by referrals and admins
+// is a promotion; credit spent on a booking pays towards it and is returned if it is cancelled.
+func creditEntry(credit *store.CreditEntry) *store.JournalEntry {
+	entry := newEntry(store.JournalSourceCreditEntry, credit.ID, credit.BookingID, creditDescription(credit), credit.CreatedAt)
+
+	counterpart, owner := store.LedgerAccountPromotions, ""
+	if credit.Reason == store.CreditReasonBooking || credit.Reason == store.CreditReasonRefund {
+		counterpart, owner = store.LedgerAccountCustomerReceivables, credit.UserID
+	}
+	addLine(entry, counterpart, owner, credit.Amount)
+	addLine(entry, store.LedgerAccountCustomerCredits, credit.UserID, -credit.Amount)
+	return entry
+}
+
+func creditDescription(credit *store.CreditEntry) string {
+	if credit.Note != "" {
+		return credit.Note
+	}
+	return fmt.Sprintf("Credit %s", credit.Reason)
+}
+
+// delivered reports whether the booking a transaction belongs to was completed
+func (s *service) delivered(ctx context.Context, bookingID *string) (bool, error) {
+	if bookingID == nil {
+		return false, nil
+	}
+	booking, err := s.store.Bookings().Get(ctx, *bookingID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get booking %s: %w", *bookingID, err)
+	}
+	return booking.Status == store.BookingStatusCompleted, nil
+}
+
+// payable returns the account a cleaner's earnings are owed on: their company's when they work
+// for one, their own otherwise
+func (s *service) payable(ctx context.Context, cleanerID string) (store.LedgerAccount, string) {
+	profile, err := s.store.CleanerProfiles().GetByUserID(ctx, cleanerID)
+	if err == nil && profile.CompanyID != nil {
+		return store.LedgerAccountCompanyPayables, *profile.CompanyID
+	}
+	return store.LedgerAccountCleanerPayables, cleanerID
+}
+
+func newEntry(source store.JournalSource, sourceID string, bookingID *string, description string, postedAt time.Time) *store.JournalEntry {
+	return &store.JournalEntry{
+		ID:          uuid.New().String(),
+		SourceType:  source,
+		SourceID:    sourceID,
+		BookingID:   bookingID,
+		Description: description,
+		PostedAt:    postedAt,
+	}
+}
+
+// addLine debits a positive amount to an account and credits a negative one. Zero amounts
+// add no line.
+func addLine(entry *store.JournalEntry, account store.LedgerAccount, owner string, amount int) {
+	switch {
+	case amount > 0:
+		entry.Lines = append(entry.Lines, &store.JournalLine{Account: account, OwnerID: owner, Debit: amount})
+	case amount < 0:
+		entry.Lines = append(entry.Lines, &store.JournalLine{Account: account, OwnerID: owner, Credit: -amount})
+	}
+}
@@ -0,0 +1,63 @@
+package ledger
+
+import (
+	"testing"
+
+	"cleanbuddy-api/res/store"
+)
+
+func TestCompletedBookingEntryBalances(t *testing.T) {
+	platform, company := store.PromoFunderPlatform, store.PromoFunderCompany
+
+	tests := []struct {
+		name      string
+		booking   *store.Booking
+		revenue   int
+		promotion int
+	}{
+		{
+			name:    "paid by card",
+			booking: &store.Booking{TotalPrice: 11000, CleanerPayout: 9000},
+			revenue: 2000,
+		},
+		{
+			name: "platform discount, credit and gift card",
+			booking: &store.Booking{TotalPrice: 6000, CreditApplied: 2000, GiftCardApplied: 2000,
+				DiscountAmount: 1000, DiscountFundedBy: &platform, CleanerPayout: 9000},
+			revenue:   2000,
+			promotion: 1000,
+		},
+		{
+			name: "company discount",
+			booking: &store.Booking{TotalPrice: 10000, DiscountAmount: 1000, DiscountFundedBy: &company,
+				CleanerPayout: 8000},
+			revenue: 2000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.booking.ID, tt.booking.CustomerID, tt.booking.CleanerID = "booking", "customer", "cleaner"
+			entry := completedBookingEntry(tt.booking, store.LedgerAccountCleanerPayables, "cleaner")
+
+			debits, credits := 0, 0
+			net := map[store.LedgerAccount]int{}
+			for _, line := range entry.Lines {
+				debits += line.Debit
+				credits += line.Credit
+				net[line.Account] += line.Debit - line.Credit
+			}
+			if debits != credits {
+				t.Fatalf("debits %d != credits %d", debits, credits)
+			}
+			if got := -net[store.LedgerAccountPlatformRevenue]; got != tt.revenue {
+				t.Errorf("revenue = %d, want %d", got, tt.revenue)
+			}
+			if got := net[store.LedgerAccountPromotions]; got != tt.promotion {
+				t.Errorf("promotion = %d, want %d", got, tt.promotion)
+			}
+			if got := -net[store.LedgerAccountCleanerPayables]; got != tt.booking.CleanerPayout {
+				t.Errorf("cleaner payable = %d, want %d", got, tt.booking.CleanerPayout)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+// postBatchSize bounds how many records of each kind one run posts
+const postBatchSize = 200
+
+type service struct {
+	store  store.Store
+	logger *log.Logger
+}
+
+// NewService creates a new LedgerService
+func NewService(dataStore store.Store, logger *log.Logger) LedgerService {
+	return &service{
+		store:  dataStore,
+		logger: logger,
+	}
+}
+
+func (s *service) PostPending(ctx context.Context) (int, error) {
+	posted := 0
+	var lastErr error
+	post := func(entry *store.JournalEntry, err error) {
+		if err != nil {
+			s.logger.Printf("Failed to prepare journal entry: %v", err)
+			lastErr = err
+			return
+		}
+		if err := s.post(ctx, entry, &posted); err != nil {
+			lastErr = err
+		}
+	}
+
+	// Bookings come last, as a cancelled booking is posted from what was posted before it
+	credits, err := s.store.Ledger().UnpostedCreditEntries(ctx, postBatchSize)
+	if err != nil {
+		return posted, s.listFailed("credit entries", err)
+	}
+	for _, credit := range credits {
+		post(creditEntry(credit), nil)
+	}
+
+	transactions, err := s.store.Ledger().UnpostedTransactions(ctx, postBatchSize)
+	if err != nil {
+		return posted, s.listFailed("transactions", err)
+	}
+	for _, transaction := range transactions {
+		post(s.transactionEntry(ctx, transaction))
+	}
+
+	obligations, err := s.store.Ledger().UnpostedObligations(ctx, postBatchSize)
+	if err != nil {
+		return posted, s.listFailed("compensation payouts", err)
+	}
+	for _, obligation := range obligations {
+		post(s.obligationEntry(ctx, obligation))
+	}
+
+	reversed, err := s.store.Ledger().UnreversedTransactions(ctx, postBatchSize)
+	if err != nil {
+		return posted, s.listFailed("cancelled transactions", err)
+	}
+	for _, transaction := range reversed {
+		post(s.reversalEntry(ctx, transaction))
+	}
+
+	bookings, err := s.store.Ledger().UnpostedBookings(ctx, postBatchSize)
+	if err != nil {
+		return posted, s.listFailed("bookings", err)
+	}
+	for _, booking := range bookings {
+		post(s.bookingEntry(ctx, booking))
+	}
+
+	return posted, lastErr
+}
+
+func (s *service) Balances(ctx context.Context, filter store.LedgerBalanceFilter) ([]*store.LedgerBalance, error) {
+	s.postPendingBeforeReport(ctx)
+
+	balances, err := s.store.Ledger().Balances(ctx, filter)
+	if err != nil {
+		s.logger.Printf("Failed to get ledger balances: %v", err)
+		return nil, fmt.Errorf("failed to get ledger balances: %w", err)
+	}
+	return balances, nil
+}
+
+func (s *service) TrialBalance(ctx context.Context, asOf *time.Time) (*TrialBalance, error) {
+	s.postPendingBeforeReport(ctx)
+
+	totals, err := s.store.Ledger().TrialBalance(ctx, asOf)
+	if err != nil {
+		s.logger.Printf("Failed to get trial balance: %v", err)
+		return nil, fmt.Errorf("failed to get trial balance: %w", err)
+	}
+
+	byAccount := make(map[store.LedgerAccount]*store.LedgerBalance, len(totals))
+	for _, total := range totals {
+		byAccount[total.Account] = total
+	}
+
+	trial := &TrialBalance{AsOf: asOf}
+	for _, account := range store.LedgerAccounts {
+		total, ok := byAccount[account]
+		if !ok {
+			total = &store.LedgerBalance{Account: account}
+		}
+		trial.Accounts = append(trial.Accounts, total)
+		trial.TotalDebit += total.Debit
+		trial.TotalCredit += total.Credit
+	}
+	return trial, nil
+}
+
+// postPendingBeforeReport brings the ledger up to date for a report. Anything that cannot be
+// posted is logged and left for the next run, so the report still shows what was posted.
+func (s *service) postPendingBeforeReport(ctx context.Context) {
+	if _, err := s.PostPending(ctx); err != nil {
+		s.logger.Printf("Ledger is not fully posted: %v", err)
+	}
+}
+
+func (s *service) post(ctx context.Context, entry *store.JournalEntry, posted *int) error {
+	ok, err := s.store.Ledger().Post(ctx, entry)
+	if err != nil {
+		s.logger.Printf("Failed to post journal entry for %s %s: %v", entry.SourceType, entry.SourceID, err)
+		return fmt.Errorf("failed to post journal entry: %w", err)
+	}
+	if ok {
+		*posted++
+	}
+	return nil
+}
+
+func (s *service) listFailed(what string, err error) error {
+	s.logger.Printf("Failed to list %s to post: %v", what, err)
+	return fmt.Errorf("failed to list %s to post: %w", what, err)
+}
@@ -0,0 +1,152 @@
+// Package localtime models wall-clock times of day in the business timezone.
+// Bookings and availability are entered as a calendar date plus a local time
+// ("14:00"); this package turns them into absolute instants so that overlap
+// checks stay correct across daylight saving transitions.
+package localtime
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultTimezone is the business timezone used when none is configured
+const DefaultTimezone = "Europe/Bucharest"
+
+var (
+	ErrInvalidClock     = errors.New("time of day must use HH:MM between 00:00 and 24:00")
+	ErrNonexistentClock = errors.New("time of day does not exist on this date (daylight saving change)")
+)
+
+var (
+	locationMu sync.RWMutex
+	location   = loadDefaultLocation()
+)
+
+// Clock is a wall-clock time of day; 24:00 is allowed to express the end of a day
+type Clock struct {
+	Hour   int
+	Minute int
+}
+
+// Parse validates a "HH:MM" time of day
+func Parse(value string) (Clock, error) {
+	if len(value) != 5 || value[2] != ':' {
+		return Clock{}, ErrInvalidClock
+	}
+	for _, i := range []int{0, 1, 3, 4} {
+		if value[i] < '0' || value[i] > '9' {
+			return Clock{}, ErrInvalidClock
+		}
+	}
+
+	clock := Clock{
+		Hour:   int(value[0]-'0')*10 + int(value[1]-'0'),
+		Minute: int(value[3]-'0')*10 + int(value[4]-'0'),
+	}
+	if clock.Hour > 24 || clock.Minute > 59 || (clock.Hour == 24 && clock.Minute != 0) {
+		return Clock{}, ErrInvalidClock
+	}
+	return clock, nil
+}
+
+// String formats the clock as "HH:MM"
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", c.Hour, c.Minute)
+}
+
+// Minutes returns the number of minutes since midnight
+func (c Clock) Minutes() int {
+	return c.Hour*60 + c.Minute
+}
+
+// FromMinutes builds a clock from minutes since midnight
+func FromMinutes(minutes int) Clock {
+	return Clock{Hour: minutes / 60, Minute: minutes % 60}
+}
+
+// ClockOf returns the wall-clock time of day of t in the business timezone
+func ClockOf(t time.Time) Clock {
+	local := t.In(Location())
+	return Clock{Hour: local.Hour(), Minute: local.Minute()}
+}
+
+// Location returns the configured business timezone
+func Location() *time.Location {
+	locationMu.RLock()
+	defer locationMu.RUnlock()
+	return location
+}
+
+// SetLocation configures the business timezone
+func SetLocation(loc *time.Location) {
+	locationMu.Lock()
+	defer locationMu.Unlock()
+	location = loc
+}
+
+// Day returns local midnight of the calendar day of date.
+// The calendar day is read from date as given, so dates sent as UTC midnight keep their day.
+func Day(date time.Time) time.Time {
+	year, month, day := date.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, Location())
+}
+
+// Date returns the calendar day of date as UTC midnight, the form in which dates are stored
+func Date(date time.Time) time.Time {
+	year, month, day := date.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// At returns the instant at which the wall clock in the business timezone shows
+// clock on the calendar day of date. Times skipped by a spring-forward change
+// return ErrNonexistentClock; times repeated by a fall-back change resolve to
+// their first occurrence.
+func At(date time.Time, clock Clock) (time.Time, error) {
+	loc := Location()
+	year, month, day := date.Date()
+
+	if clock.Hour == 24 {
+		return time.Date(year, month, day+1, 0, 0, 0, 0, loc), nil
+	}
+
+	instant := time.Date(year, month, day, clock.Hour, clock.Minute, 0, 0, loc)
+	if instant.Hour() != clock.Hour || instant.Minute() != clock.Minute {
+		return time.Time{}, ErrNonexistentClock
+	}
+
+	// time.Date may pick the later of two repeated instants; prefer the earlier one
+	if earlier := instant.Add(-time.Hour); earlier.Hour() == clock.Hour && earlier.Minute() == clock.Minute {
+		return earlier, nil
+	}
+	return instant, nil
+}
+
+// AtOrAfter is like At but resolves times skipped by a spring-forward change
+// to the first instant after the gap, which suits window boundaries
+func AtOrAfter(date time.Time, clock Clock) time.Time {
+	for minutes := clock.Minutes(); ; minutes++ {
+		if instant, err := At(date, FromMinutes(minutes)); err == nil {
+			return instant
+		}
+	}
+}
+
+// ParseAt parses a "HH:MM" time of day and resolves it on the calendar day of date
+func ParseAt(date time.Time, value string) (time.Time, error) {
+	clock, err := Parse(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return At(date, clock)
+}
+
+func loadDefaultLocation() *time.Location {
+	loc, err := time.LoadLocation(DefaultTimezone)
+	if err != nil {
+		// Without tzdata fall back to UTC rather than failing at startup
+		return time.UTC
+	}
+	return loc
+}
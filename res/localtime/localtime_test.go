@@ -0,0 +1,142 @@
+package localtime
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func useBucharest(t *testing.T) {
+	t.Helper()
+
+	loc, err := time.LoadLocation("Europe/Bucharest")
+	if err != nil {
+		t.Skipf("tzdata for Europe/Bucharest not available: %v", err)
+	}
+
+	previous := Location()
+	SetLocation(loc)
+	t.Cleanup(func() { SetLocation(previous) })
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestAtSpringForwardGap(t *testing.T) {
+	useBucharest(t)
+
+	// Clocks jump from 03:00 to 04:00 on 2025-03-30
+	_, err := At(date(2025, time.March, 30), Clock{Hour: 3, Minute: 30})
+	if !errors.Is(err, ErrNonexistentClock) {
+		t.Fatalf("At(03:30) error = %v, want ErrNonexistentClock", err)
+	}
+}
+
+func TestAtFallBackResolvesToEarlierInstant(t *testing.T) {
+	useBucharest(t)
+
+	// Clocks go back from 04:00 to 03:00 on 2025-10-26, so 03:30 happens twice
+	got, err := At(date(2025, time.October, 26), Clock{Hour: 3, Minute: 30})
+	if err != nil {
+		t.Fatalf("At(03:30) error = %v", err)
+	}
+
+	want := time.Date(2025, time.October, 26, 3, 30, 0, 0, time.FixedZone("EEST", 3*60*60))
+	if !got.Equal(want) {
+		t.Errorf("At(03:30) = %v, want %v", got, want)
+	}
+	if _, offset := got.Zone(); offset != 3*60*60 {
+		t.Errorf("At(03:30) offset = %d, want +03:00", offset)
+	}
+}
+
+func TestAtOrdinaryDays(t *testing.T) {
+	useBucharest(t)
+
+	tests := []struct {
+		name  string
+		date  time.Time
+		clock Clock
+		want  time.Time
+	}{
+		{
+			name:  "winter time",
+			date:  date(2025, time.January, 15),
+			clock: Clock{Hour: 14, Minute: 0},
+			want:  time.Date(2025, time.January, 15, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "summer time",
+			date:  date(2025, time.July, 15),
+			clock: Clock{Hour: 14, Minute: 0},
+			want:  time.Date(2025, time.July, 15, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "end of day",
+			date:  date(2025, time.July, 15),
+			clock: Clock{Hour: 24, Minute: 0},
+			want:  time.Date(2025, time.July, 15, 21, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := At(tt.date, tt.clock)
+			if err != nil {
+				t.Fatalf("At(%s) error = %v", tt.clock, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("At(%s) = %v, want %v", tt.clock, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAtOrAfterAcrossSpringForwardGap(t *testing.T) {
+	useBucharest(t)
+
+	day := date(2025, time.March, 30)
+	afterGap := time.Date(2025, time.March, 30, 1, 0, 0, 0, time.UTC) // 04:00 EEST
+
+	tests := []struct {
+		name  string
+		clock Clock
+		want  time.Time
+	}{
+		{name: "start of gap", clock: Clock{Hour: 3, Minute: 0}, want: afterGap},
+		{name: "inside gap", clock: Clock{Hour: 3, Minute: 30}, want: afterGap},
+		{name: "before gap", clock: Clock{Hour: 2, Minute: 30}, want: time.Date(2025, time.March, 30, 0, 30, 0, 0, time.UTC)},
+		{name: "after gap", clock: Clock{Hour: 4, Minute: 30}, want: time.Date(2025, time.March, 30, 1, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AtOrAfter(day, tt.clock)
+			if !got.Equal(tt.want) {
+				t.Errorf("AtOrAfter(%s) = %v, want %v", tt.clock, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	valid := map[string]Clock{
+		"00:00": {Hour: 0, Minute: 0},
+		"09:05": {Hour: 9, Minute: 5},
+		"23:59": {Hour: 23, Minute: 59},
+		"24:00": {Hour: 24, Minute: 0},
+	}
+	for value, want := range valid {
+		got, err := Parse(value)
+		if err != nil || got != want {
+			t.Errorf("Parse(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+
+	for _, value := range []string{"", "9:05", "24:01", "12:60", "25:00", "ab:cd", "12-00"} {
+		if _, err := Parse(value); !errors.Is(err, ErrInvalidClock) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalidClock", value, err)
+		}
+	}
+}
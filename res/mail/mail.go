@@ -14,4 +14,7 @@ type MailService interface {
 
 	// UpdateContactProperty updates a specific custom property for a contact
 	UpdateContactProperty(ctx context.Context, email, propertyName, propertyValue string) error
+
+	// SendTemplateEmail sends a transactional email rendered from a template with the given properties
+	SendTemplateEmail(ctx context.Context, email, templateName string, templateProps map[string]interface{}) error
 }
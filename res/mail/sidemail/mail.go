@@ -234,6 +234,66 @@ func (s *SidemailService) UpdateContactProperty(ctx context.Context, email, prop
 	return s.handleSidemailContactResponse(resp, fmt.Sprintf("property update %s=%s for %s", propertyName, propertyValue, email))
 }
 
+// SidemailEmailPayload represents the payload for sending a templated email via Sidemail API
+type SidemailEmailPayload struct {
+	ToAddress     string                 `json:"toAddress"`
+	FromAddress   string                 `json:"fromAddress,omitempty"`
+	TemplateName  string                 `json:"templateName"`
+	TemplateProps map[string]interface{} `json:"templateProps,omitempty"`
+}
+
+// SendTemplateEmail sends a transactional email using a Sidemail template.
+// It validates the email address before making the API call.
+// If no API key is configured, this method returns nil (graceful degradation).
+func (s *SidemailService) SendTemplateEmail(ctx context.Context, email, templateName string, templateProps map[string]interface{}) error {
+	if s.apiKey == "" {
+		s.logger.Printf("Sidemail API key not configured, skipping %s email", templateName)
+		return nil
+	}
+
+	// Validate email address
+	if err := s.validateEmail(email); err != nil {
+		return fmt.Errorf("sending %s email failed: %w", templateName, err)
+	}
+
+	payload := SidemailEmailPayload{
+		ToAddress:     s.sanitizeInput(email),
+		TemplateName:  templateName,
+		TemplateProps: templateProps,
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error marshaling email data: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/email/send", s.apiBaseURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("sidemail email API returned status %d: %s", resp.StatusCode, s.sanitizeResponseBody(string(body)))
+	}
+
+	s.logger.Printf("[SIDEMAIL_EMAIL_SUCCESS] template=%s status=%d", templateName, resp.StatusCode)
+	return nil
+}
+
 // handleSidemailContactResponse handles and validates responses from the Sidemail contacts API.
 // It parses the JSON response, checks for errors, and logs the outcome with structured logging.
 func (s *SidemailService) handleSidemailContactResponse(resp *http.Response, operation string) error {
@@ -0,0 +1,58 @@
+package noshow
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+var (
+	ErrBookingNotFound      = errors.New("booking not found")
+	ErrNotNoShow            = errors.New("booking is not marked as a no-show")
+	ErrAccessDenied         = errors.New("you are not allowed to change this no-show")
+	ErrContestWindowClosed  = errors.New("the no-show can no longer be contested")
+	ErrNotContested         = errors.New("the no-show is not awaiting review")
+	ErrContestReasonMissing = errors.New("a reason is required to contest a no-show")
+	ErrAlreadySettled       = errors.New("the no-show was changed by another request")
+)
+
+// SettlementService charges the no-show fee and compensates the cleaner.
+// When a booking becomes a no-show the amounts are computed and the customer gets
+// a contest window; the fee is only charged once the no-show is final.
+type SettlementService interface {
+	// Contest lets the customer dispute a no-show within the contest window
+	Contest(ctx context.Context, bookingID string, actor *store.User, reason string) (*store.Booking, error)
+
+	// ResolveContest lets an admin uphold (charge) or reject (waive) a contested no-show
+	ResolveContest(ctx context.Context, bookingID string, actor *store.User, upheld bool) (*store.Booking, error)
+
+	// FinalizeDue charges every uncontested no-show whose contest window has closed
+	FinalizeDue(ctx context.Context) (int, error)
+}
+
+// Policy configures no-show settlement
+type Policy struct {
+	// Fees is the no-show fee per service type in bani, charged on top of the travel fee
+	Fees map[store.ServiceType]int
+
+	// CleanerSharePercent is the share of the fee paid to the cleaner in addition to the travel fee
+	CleanerSharePercent int
+
+	// ContestWindow is how long the customer has to contest a no-show
+	ContestWindow time.Duration
+}
+
+// DefaultPolicy returns the standard no-show fees and a 48 hour contest window
+func DefaultPolicy() Policy {
+	return Policy{
+		Fees: map[store.ServiceType]int{
+			store.ServiceTypeGeneral:   5000,
+			store.ServiceTypeDeep:      8000,
+			store.ServiceTypeMoveInOut: 10000,
+		},
+		CleanerSharePercent: 50,
+		ContestWindow:       48 * time.Hour,
+	}
+}
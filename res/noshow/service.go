@@ -0,0 +1,219 @@
+package noshow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/bookinglifecycle"
+	"cleanbuddy-api/res/payment"
+	"cleanbuddy-api/res/store"
+)
+
+type service struct {
+	store    store.Store
+	payments payment.PaymentService
+	policy   Policy
+	logger   *log.Logger
+}
+
+// NewService creates a new SettlementService and registers it on no-show transitions.
+// payments may be nil, in which case fees are recorded on the booking but not charged.
+func NewService(dataStore store.Store, lifecycle bookinglifecycle.LifecycleService, payments payment.PaymentService, policy Policy, logger *log.Logger) SettlementService {
+	s := &service{
+		store:    dataStore,
+		payments: payments,
+		policy:   policy,
+		logger:   logger,
+	}
+
+	lifecycle.OnApply(store.BookingStatusNoShow, s.openSettlement)
+
+	return s
+}
+
+func (s *service) Contest(ctx context.Context, bookingID string, actor *store.User, reason string) (*store.Booking, error) {
+	booking, err := s.getNoShow(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if actor == nil || actor.ID != booking.CustomerID {
+		return nil, ErrAccessDenied
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, ErrContestReasonMissing
+	}
+
+	now := time.Now()
+	if *booking.NoShowStatus != store.NoShowStatusContestable ||
+		booking.NoShowContestDeadline == nil || now.After(*booking.NoShowContestDeadline) {
+		return nil, ErrContestWindowClosed
+	}
+
+	status := store.NoShowStatusContested
+	booking.NoShowStatus = &status
+	booking.NoShowContestedAt = &now
+	booking.NoShowContestReason = reason
+
+	if err := s.store.Bookings().UpdateNoShow(ctx, booking, []store.NoShowStatus{store.NoShowStatusContestable}, nil); err != nil {
+		if errors.Is(err, store.ErrBookingStatusConflict) {
+			return nil, ErrAlreadySettled
+		}
+		s.logger.Printf("Failed to contest no-show for booking %s: %v", booking.ID, err)
+		return nil, fmt.Errorf("failed to contest no-show: %w", err)
+	}
+
+	return booking, nil
+}
+
+func (s *service) ResolveContest(ctx context.Context, bookingID string, actor *store.User, upheld bool) (*store.Booking, error) {
+	booking, err := s.getNoShow(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if actor == nil || !actor.IsGlobalAdmin() {
+		return nil, ErrAccessDenied
+	}
+	if *booking.NoShowStatus != store.NoShowStatusContested {
+		return nil, ErrNotContested
+	}
+
+	if upheld {
+		if err := s.finalize(ctx, booking); err != nil {
+			return nil, err
+		}
+		return booking, nil
+	}
+
+	now := time.Now()
+	status := store.NoShowStatusWaived
+	booking.NoShowStatus = &status
+	booking.NoShowResolvedAt = &now
+
+	if err := s.store.Bookings().UpdateNoShow(ctx, booking, []store.NoShowStatus{store.NoShowStatusContested}, nil); err != nil {
+		if errors.Is(err, store.ErrBookingStatusConflict) {
+			return nil, ErrAlreadySettled
+		}
+		s.logger.Printf("Failed to waive no-show for booking %s: %v", booking.ID, err)
+		return nil, fmt.Errorf("failed to waive no-show: %w", err)
+	}
+
+	// Nothing is charged, so the hold on the customer's card is let go
+	if err := s.settleHold(ctx, booking.ID, 0); err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+func (s *service) FinalizeDue(ctx context.Context) (int, error) {
+	bookings, err := s.store.Bookings().GetNoShowsPastContestDeadline(ctx, time.Now())
+	if err != nil {
+		s.logger.Printf("Failed to list no-shows past contest deadline: %v", err)
+		return 0, fmt.Errorf("failed to list no-shows: %w", err)
+	}
+
+	finalized := 0
+	var lastErr error
+	for _, booking := range bookings {
+		if err := s.finalize(ctx, booking); err != nil {
+			// Contested or finalized by another request since the list was read
+			if errors.Is(err, ErrAlreadySettled) {
+				continue
+			}
+			lastErr = err
+			continue
+		}
+		finalized++
+	}
+
+	return finalized, lastErr
+}
+
+// openSettlement computes the no-show amounts and opens the contest window.
+// It runs as part of the no-show transition so the amounts are stored with the status.
+func (s *service) openSettlement(booking *store.Booking, req bookinglifecycle.TransitionRequest, now time.Time) {
+	fee := s.policy.Fees[booking.ServiceType]
+	deadline := now.Add(s.policy.ContestWindow)
+	status := store.NoShowStatusContestable
+
+	booking.NoShowStatus = &status
+	booking.NoShowFee = fee + booking.TravelFee
+	booking.NoShowCleanerPayout = booking.TravelFee + fee*s.policy.CleanerSharePercent/100
+	booking.NoShowContestDeadline = &deadline
+}
+
+// finalize creates the cleaner payout and charges the fee from the hold on the customer's card.
+// The status change and the payout are written together, and only while the no-show is still
+// open, so a no-show is never settled twice. A charge that fails leaves the hold in place; the
+// payment service charges it when it next renews holds.
+func (s *service) finalize(ctx context.Context, booking *store.Booking) error {
+	now := time.Now()
+
+	transactions := []*store.Transaction{}
+	if booking.NoShowCleanerPayout > 0 {
+		transactions = append(transactions, &store.Transaction{
+			ID:            uuid.New().String(),
+			Type:          store.TransactionTypePayout,
+			Status:        store.TransactionStatusPending,
+			BookingID:     &booking.ID,
+			PayerID:       booking.CustomerID,
+			PayeeID:       booking.CleanerID,
+			Amount:        booking.NoShowCleanerPayout,
+			NetAmount:     booking.NoShowCleanerPayout,
+			PaymentMethod: store.PaymentMethodBankTransfer,
+			Currency:      "RON",
+			Description:   "No-show travel compensation",
+			ProcessedAt:   now,
+		})
+	}
+
+	status := store.NoShowStatusFinal
+	booking.NoShowStatus = &status
+	booking.NoShowResolvedAt = &now
+
+	openStatuses := []store.NoShowStatus{store.NoShowStatusContestable, store.NoShowStatusContested}
+	if err := s.store.Bookings().UpdateNoShow(ctx, booking, openStatuses, transactions); err != nil {
+		if errors.Is(err, store.ErrBookingStatusConflict) {
+			return ErrAlreadySettled
+		}
+		s.logger.Printf("Failed to finalize no-show for booking %s: %v", booking.ID, err)
+		return fmt.Errorf("failed to finalize no-show: %w", err)
+	}
+
+	return s.settleHold(ctx, booking.ID, booking.NoShowFee)
+}
+
+// settleHold charges fee from the customer's card hold and releases the rest
+func (s *service) settleHold(ctx context.Context, bookingID string, fee int) error {
+	if s.payments == nil {
+		if fee > 0 {
+			s.logger.Printf("Payments not configured, no-show fee %d of booking %s was not charged", fee, bookingID)
+		}
+		return nil
+	}
+	if _, err := s.payments.SettleHold(ctx, bookingID, fee); err != nil {
+		s.logger.Printf("Failed to settle card hold of no-show booking %s: %v", bookingID, err)
+		return fmt.Errorf("failed to charge no-show fee: %w", err)
+	}
+	return nil
+}
+
+func (s *service) getNoShow(ctx context.Context, bookingID string) (*store.Booking, error) {
+	booking, err := s.store.Bookings().Get(ctx, bookingID)
+	if err != nil {
+		s.logger.Printf("Failed to get booking %s: %v", bookingID, err)
+		return nil, ErrBookingNotFound
+	}
+
+	if booking.Status != store.BookingStatusNoShow || booking.NoShowStatus == nil {
+		return nil, ErrNotNoShow
+	}
+	return booking, nil
+}
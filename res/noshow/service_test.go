@@ -0,0 +1,124 @@
+package noshow
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"cleanbuddy-api/res/payment"
+	"cleanbuddy-api/res/store"
+)
+
+// fakeStore serves a single booking and records the no-show updates written to it
+type fakeStore struct {
+	store.Store
+	bookings *fakeBookings
+}
+
+func (f *fakeStore) Bookings() store.BookingStore { return f.bookings }
+
+type fakeBookings struct {
+	store.BookingStore
+	booking      *store.Booking
+	transactions []*store.Transaction
+}
+
+func (f *fakeBookings) Get(ctx context.Context, id string) (*store.Booking, error) {
+	copied := *f.booking
+	return &copied, nil
+}
+
+func (f *fakeBookings) UpdateNoShow(ctx context.Context, booking *store.Booking, fromStatuses []store.NoShowStatus, transactions []*store.Transaction) error {
+	for _, status := range fromStatuses {
+		if *f.booking.NoShowStatus == status {
+			f.booking = booking
+			f.transactions = append(f.transactions, transactions...)
+			return nil
+		}
+	}
+	return store.ErrBookingStatusConflict
+}
+
+// holdRecorder records what is charged from card holds
+type holdRecorder struct {
+	payment.PaymentService
+	charges []int
+}
+
+func (h *holdRecorder) SettleHold(ctx context.Context, bookingID string, charge int) (*store.Transaction, error) {
+	h.charges = append(h.charges, charge)
+	return nil, nil
+}
+
+func newTestService(status store.NoShowStatus) (*service, *fakeBookings, *holdRecorder) {
+	deadline := time.Now().Add(-time.Hour)
+	bookings := &fakeBookings{booking: &store.Booking{
+		ID:                    "b1",
+		CustomerID:            "customer",
+		CleanerID:             "cleaner",
+		Status:                store.BookingStatusNoShow,
+		NoShowStatus:          &status,
+		NoShowFee:             8000,
+		NoShowCleanerPayout:   5000,
+		NoShowContestDeadline: &deadline,
+	}}
+	payments := &holdRecorder{}
+	s := &service{
+		store:    &fakeStore{bookings: bookings},
+		payments: payments,
+		policy:   DefaultPolicy(),
+		logger:   log.New(io.Discard, "", 0),
+	}
+	return s, bookings, payments
+}
+
+func TestFinalizeChargesTheFeeFromTheHold(t *testing.T) {
+	s, bookings, payments := newTestService(store.NoShowStatusContestable)
+	booking, _ := bookings.Get(context.Background(), "b1")
+
+	if err := s.finalize(context.Background(), booking); err != nil {
+		t.Fatalf("finalize: %v", err)
+	}
+
+	if *bookings.booking.NoShowStatus != store.NoShowStatusFinal {
+		t.Errorf("no-show status = %s, want final", *bookings.booking.NoShowStatus)
+	}
+	if len(payments.charges) != 1 || payments.charges[0] != 8000 {
+		t.Errorf("charged %v from the hold, want [8000]", payments.charges)
+	}
+	if len(bookings.transactions) != 1 || bookings.transactions[0].Type != store.TransactionTypePayout ||
+		bookings.transactions[0].Amount != 5000 {
+		t.Errorf("recorded %d transactions, want only the cleaner's payout of 5000", len(bookings.transactions))
+	}
+
+	// A second run finds the no-show settled and charges nothing more
+	booking, _ = bookings.Get(context.Background(), "b1")
+	if err := s.finalize(context.Background(), booking); err != ErrAlreadySettled {
+		t.Errorf("second finalize returned %v, want ErrAlreadySettled", err)
+	}
+	if len(payments.charges) != 1 {
+		t.Errorf("charged the hold %d times, want once", len(payments.charges))
+	}
+}
+
+func TestWaivedContestReleasesTheHold(t *testing.T) {
+	s, bookings, payments := newTestService(store.NoShowStatusContested)
+	admin := &store.User{ID: "admin", Role: store.UserRoleGlobalAdmin}
+
+	booking, err := s.ResolveContest(context.Background(), "b1", admin, false)
+	if err != nil {
+		t.Fatalf("ResolveContest: %v", err)
+	}
+
+	if *booking.NoShowStatus != store.NoShowStatusWaived {
+		t.Errorf("no-show status = %s, want waived", *booking.NoShowStatus)
+	}
+	if len(payments.charges) != 1 || payments.charges[0] != 0 {
+		t.Errorf("charged %v from the hold, want the whole hold released", payments.charges)
+	}
+	if len(bookings.transactions) != 0 {
+		t.Errorf("recorded %d transactions for a waived no-show, want none", len(bookings.transactions))
+	}
+}
@@ -0,0 +1,312 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/store"
+)
+
+const (
+	// eventClaimTimeout is how long a delivery may hold an event before another may take it over
+	eventClaimTimeout = 5 * time.Minute
+
+	// retryBatchSize bounds how many stored events one retry run reprocesses
+	retryBatchSize = 100
+)
+
+func (s *service) VerifyWebhook(payload []byte, signatureHeader string) (*Event, error) {
+	return s.provider.VerifyWebhook(payload, signatureHeader)
+}
+
+func (s *service) HandleEvent(ctx context.Context, event *Event, payload []byte) error {
+	stored, err := s.store.PaymentEvents().Record(ctx, &store.PaymentEvent{
+		ID:         uuid.New().String(),
+		ExternalID: event.ID,
+		Type:       event.Type,
+		Payload:    string(payload),
+		Status:     store.PaymentEventStatusReceived,
+	})
+	if err != nil {
+		s.logger.Printf("Failed to store payment event %s: %v", event.ID, err)
+		return fmt.Errorf("failed to store payment event: %w", err)
+	}
+
+	return s.processStored(ctx, stored, event)
+}
+
+func (s *service) RetryEvents(ctx context.Context) (int, error) {
+	events, err := s.store.PaymentEvents().ListRetryable(ctx, time.Now().Add(-eventClaimTimeout), retryBatchSize)
+	if err != nil {
+		s.logger.Printf("Failed to list retryable payment events: %v", err)
+		return 0, fmt.Errorf("failed to list payment events: %w", err)
+	}
+
+	applied := 0
+	var firstErr error
+	for _, stored := range events {
+		event, err := ParseEvent([]byte(stored.Payload))
+		if err != nil {
+			s.logger.Printf("Stored payment event %s cannot be decoded: %v", stored.ExternalID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		if err := s.processStored(ctx, stored, event); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		applied++
+	}
+	return applied, firstErr
+}
+
+// processStored claims a stored event and applies it. Events already applied or being
+// applied by another delivery are skipped, which makes redeliveries no-ops.
+func (s *service) processStored(ctx context.Context, stored *store.PaymentEvent, event *Event) error {
+	if stored.Status == store.PaymentEventStatusProcessed {
+		return nil
+	}
+
+	claimed, err := s.store.PaymentEvents().Claim(ctx, stored.ID, time.Now().Add(-eventClaimTimeout))
+	if err != nil {
+		s.logger.Printf("Failed to claim payment event %s: %v", event.ID, err)
+		return fmt.Errorf("failed to claim payment event: %w", err)
+	}
+	if !claimed {
+		return nil
+	}
+
+	if err := s.process(ctx, event); err != nil {
+		s.logger.Printf("Failed to process payment event %s (%s): %v", event.ID, event.Type, err)
+		if markErr := s.store.PaymentEvents().MarkFailed(ctx, stored.ID, err.Error()); markErr != nil {
+			s.logger.Printf("Failed to mark payment event %s as failed: %v", event.ID, markErr)
+		}
+		return err
+	}
+
+	if err := s.store.PaymentEvents().MarkProcessed(ctx, stored.ID, time.Now()); err != nil {
+		// Applying an event twice is harmless, so a retry after this is safe
+		s.logger.Printf("Failed to mark payment event %s as processed: %v", event.ID, err)
+		return fmt.Errorf("failed to mark payment event as processed: %w", err)
+	}
+	return nil
+}
+
+// process applies an event to the transactions and booking it concerns. Event types the
+// platform does not act on are stored and acknowledged.
+func (s *service) process(ctx context.Context, event *Event) error {
+	switch event.Type {
+	case EventIntentAuthorized, EventIntentSucceeded, EventIntentFailed, EventIntentCanceled:
+		intent, err := event.Intent()
+		if err != nil {
+			return err
+		}
+		return s.applyIntent(ctx, intent)
+
+	case EventRefundCreated, EventRefundUpdated, EventRefundFailed:
+		refund, err := event.Refund()
+		if err != nil {
+			return err
+		}
+		return s.applyRefund(ctx, refund)
+
+	case EventDisputeCreated, EventDisputeClosed:
+		dispute, err := event.Dispute()
+		if err != nil {
+			return err
+		}
+		return s.applyDispute(ctx, dispute)
+	}
+	return nil
+}
+
+func (s *service) applyIntent(ctx context.Context, intent *Intent) error {
+	// Intents created outside of bookings and gift cards are not ours to track
+	if intent.Metadata["booking_id"] == "" && intent.Metadata["gift_card_id"] == "" {
+		return nil
+	}
+
+	transaction, err := s.store.Transactions().GetByStripePaymentID(ctx, intent.ID)
+	if err != nil {
+		// The event can arrive before the payment is recorded; failing lets it be retried
+		return fmt.Errorf("payment for intent %s not found: %w", intent.ID, err)
+	}
+	return s.syncPayment(ctx, transaction, intent)
+}
+
+func (s *service) applyRefund(ctx context.Context, refund *Refund) error {
+	status := refundTransactionStatus(refund.Status)
+
+	existing, err := s.store.Transactions().GetByStripeRefundID(ctx, refund.ID)
+	if err == nil {
+		if existing.Status != status {
+			if err := s.store.Transactions().UpdateStatus(ctx, existing.ID, status); err != nil {
+				return fmt.Errorf("failed to update refund status: %w", err)
+			}
+			if status == store.TransactionStatusCompleted {
+				existing.Status = status
+				s.fireSettled(ctx, existing)
+			}
+		}
+		if existing.BookingID == nil {
+			return nil
+		}
+		return s.refreshRefundedStatus(ctx, *existing.BookingID)
+	}
+
+	// Refunds issued from the provider's dashboard are recorded when they are first reported
+	payment, err := s.store.Transactions().GetByStripePaymentID(ctx, refund.IntentID)
+	if err != nil {
+		return fmt.Errorf("payment for intent %s not found: %w", refund.IntentID, err)
+	}
+
+	transaction := s.refundTransaction(payment, refund.Amount, status, "Refund")
+	transaction.StripeRefundID = &refund.ID
+	if err := s.store.Transactions().Create(ctx, transaction); err != nil {
+		return fmt.Errorf("failed to record refund: %w", err)
+	}
+	if status == store.TransactionStatusCompleted {
+		s.fireSettled(ctx, transaction)
+	}
+
+	if payment.BookingID == nil {
+		return nil
+	}
+	return s.refreshRefundedStatus(ctx, *payment.BookingID)
+}
+
+func (s *service) applyDispute(ctx context.Context, dispute *Dispute) error {
+	payment, err := s.store.Transactions().GetByStripePaymentID(ctx, dispute.IntentID)
+	if err != nil {
+		return fmt.Errorf("payment for intent %s not found: %w", dispute.IntentID, err)
+	}
+	if payment.BookingID == nil {
+		return nil
+	}
+	bookingID := *payment.BookingID
+
+	switch dispute.Status {
+	case DisputeStatusWon:
+		s.logger.Printf("Dispute %s of booking %s was won", dispute.ID, bookingID)
+		return s.refreshRefundedStatus(ctx, bookingID)
+
+	case DisputeStatusLost:
+		// A redelivered or retried event finds the chargeback recorded already
+		recorded, err := s.chargebackRecorded(ctx, payment, dispute.ID)
+		if err != nil {
+			return err
+		}
+		if recorded {
+			return s.refreshRefundedStatus(ctx, bookingID)
+		}
+
+		// The bank returned the disputed amount to the customer
+		transaction := s.refundTransaction(payment, dispute.Amount, store.TransactionStatusCompleted, fmt.Sprintf("Chargeback (dispute %s)", dispute.ID))
+		metadata, _ := json.Marshal(map[string]string{"paymentId": payment.ID, "disputeId": dispute.ID})
+		transaction.Metadata = string(metadata)
+		if err := s.store.Transactions().Create(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to record chargeback: %w", err)
+		}
+		s.fireSettled(ctx, transaction)
+		s.logger.Printf("Dispute %s of booking %s was lost, %d returned to the customer", dispute.ID, bookingID, dispute.Amount)
+		return s.refreshRefundedStatus(ctx, bookingID)
+	}
+
+	s.logger.Printf("Booking %s payment disputed (%s): %s", bookingID, dispute.ID, dispute.Reason)
+	return s.setBookingStatus(ctx, bookingID, store.BookingPaymentStatusDisputed)
+}
+
+// chargebackRecorded reports whether the chargeback of a lost dispute was recorded against the payment
+func (s *service) chargebackRecorded(ctx context.Context, payment *store.Transaction, disputeID string) (bool, error) {
+	refunds, err := s.refundsOf(ctx, payment)
+	if err != nil {
+		return false, err
+	}
+	for _, refund := range refunds {
+		var metadata map[string]string
+		if err := json.Unmarshal([]byte(refund.Metadata), &metadata); err == nil && metadata["disputeId"] == disputeID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// refreshRefundedStatus sets a paid booking's payment status from the refunds completed against it
+func (s *service) refreshRefundedStatus(ctx context.Context, bookingID string) error {
+	transactions, err := s.store.Transactions().GetByBooking(ctx, bookingID)
+	if err != nil {
+		return fmt.Errorf("failed to get booking transactions: %w", err)
+	}
+
+	paid, refunded := 0, 0
+	for _, transaction := range transactions {
+		switch {
+		case transaction.Type == store.TransactionTypePayment && transaction.StripePaymentID != nil &&
+			transaction.Status == store.TransactionStatusCompleted:
+			paid += transaction.Amount
+		case transaction.Type == store.TransactionTypeRefund && transaction.PaymentMethod == store.PaymentMethodCard &&
+			transaction.Status == store.TransactionStatusCompleted:
+			refunded += transaction.Amount
+		}
+	}
+	if paid == 0 {
+		return nil
+	}
+
+	status := store.BookingPaymentStatusPaid
+	switch {
+	case refunded >= paid:
+		status = store.BookingPaymentStatusRefunded
+	case refunded > 0:
+		status = store.BookingPaymentStatusPartiallyRefunded
+	}
+	return s.setBookingStatus(ctx, bookingID, status)
+}
+
+// refundTransaction builds the transaction returning money from a card payment to its payer
+func (s *service) refundTransaction(payment *store.Transaction, amount int, status store.TransactionStatus, description string) *store.Transaction {
+	now := time.Now()
+	metadata, _ := json.Marshal(map[string]string{"paymentId": payment.ID})
+
+	transaction := &store.Transaction{
+		ID:            uuid.New().String(),
+		Type:          store.TransactionTypeRefund,
+		Status:        status,
+		BookingID:     payment.BookingID,
+		PayerID:       payment.PayeeID,
+		PayeeID:       payment.PayerID,
+		Amount:        amount,
+		NetAmount:     amount,
+		PaymentMethod: store.PaymentMethodCard,
+		Currency:      payment.Currency,
+		Description:   description,
+		Metadata:      string(metadata),
+		ProcessedAt:   now,
+	}
+	if status == store.TransactionStatusCompleted {
+		transaction.CompletedAt = &now
+	}
+	return transaction
+}
+
+// refundTransactionStatus maps the state of a refund to the status of its transaction
+func refundTransactionStatus(status RefundStatus) store.TransactionStatus {
+	switch status {
+	case RefundStatusSucceeded:
+		return store.TransactionStatusCompleted
+	case RefundStatusFailed:
+		return store.TransactionStatusFailed
+	case RefundStatusCanceled:
+		return store.TransactionStatusCancelled
+	}
+	return store.TransactionStatusProcessing
+}
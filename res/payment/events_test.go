@@ -0,0 +1,102 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+
+	"cleanbuddy-api/res/store"
+)
+
+// fakeStore keeps the transactions of a single booking
+type fakeStore struct {
+	store.Store
+	transactions *fakeTransactions
+	bookings     *fakeBookings
+}
+
+func (f *fakeStore) Transactions() store.TransactionStore { return f.transactions }
+func (f *fakeStore) Bookings() store.BookingStore         { return f.bookings }
+
+type fakeTransactions struct {
+	store.TransactionStore
+	all []*store.Transaction
+}
+
+func (f *fakeTransactions) GetByStripePaymentID(ctx context.Context, stripePaymentID string) (*store.Transaction, error) {
+	for _, transaction := range f.all {
+		if transaction.StripePaymentID != nil && *transaction.StripePaymentID == stripePaymentID {
+			return transaction, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (f *fakeTransactions) GetByBooking(ctx context.Context, bookingID string) ([]*store.Transaction, error) {
+	return append([]*store.Transaction(nil), f.all...), nil
+}
+
+func (f *fakeTransactions) Create(ctx context.Context, transaction *store.Transaction) error {
+	f.all = append(f.all, transaction)
+	return nil
+}
+
+type fakeBookings struct {
+	store.BookingStore
+	paymentStatus store.BookingPaymentStatus
+}
+
+func (f *fakeBookings) UpdatePaymentStatus(ctx context.Context, bookingID string, status store.BookingPaymentStatus) error {
+	f.paymentStatus = status
+	return nil
+}
+
+func TestApplyLostDisputeRecordsTheChargebackOnce(t *testing.T) {
+	bookingID, intentID := "b1", "pi_1"
+	transactions := &fakeTransactions{all: []*store.Transaction{{
+		ID:              "payment",
+		Type:            store.TransactionTypePayment,
+		Status:          store.TransactionStatusCompleted,
+		BookingID:       &bookingID,
+		PayerID:         "customer",
+		Amount:          20000,
+		PaymentMethod:   store.PaymentMethodCard,
+		StripePaymentID: &intentID,
+	}}}
+	bookings := &fakeBookings{}
+	s := &service{
+		store:  &fakeStore{transactions: transactions, bookings: bookings},
+		logger: log.New(io.Discard, "", 0),
+	}
+
+	dispute := &Dispute{ID: "dp_1", IntentID: intentID, Amount: 20000, Status: DisputeStatusLost}
+	for i := 0; i < 2; i++ {
+		if err := s.applyDispute(context.Background(), dispute); err != nil {
+			t.Fatalf("applyDispute() attempt %d error = %v", i+1, err)
+		}
+	}
+
+	chargebacks := 0
+	for _, transaction := range transactions.all {
+		if transaction.Type == store.TransactionTypeRefund {
+			chargebacks++
+		}
+	}
+	if chargebacks != 1 {
+		t.Errorf("recorded %d chargebacks after the event was applied twice, want 1", chargebacks)
+	}
+	if bookings.paymentStatus != store.BookingPaymentStatusRefunded {
+		t.Errorf("booking payment status = %s, want refunded", bookings.paymentStatus)
+	}
+
+	// Another dispute of the same payment is a chargeback of its own
+	second := &Dispute{ID: "dp_2", IntentID: intentID, Amount: 5000, Status: DisputeStatusLost}
+	if err := s.applyDispute(context.Background(), second); err != nil {
+		t.Fatalf("applyDispute() second dispute error = %v", err)
+	}
+	if len(transactions.all) != 3 {
+		t.Errorf("got %d transactions, want the payment and two chargebacks", len(transactions.all))
+	}
+}
@@ -0,0 +1,239 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/payment"
+)
+
+// DeclineAmount is an amount the fake provider always declines, for exercising failed payments
+const DeclineAmount = 13
+
+// FakeProvider implements the PaymentProvider interface in memory for local development and tests.
+// Intents authorize immediately as if the customer had confirmed them with a working card.
+type FakeProvider struct {
+	webhookSecret string
+	logger        *log.Logger
+
+	mu        sync.Mutex
+	intents   map[string]*payment.Intent
+	refunded  map[string]int
+	transfers map[string]*payment.Transfer
+	keys      map[string]interface{} // Idempotency key to the object it created
+}
+
+// New creates a new fake provider; webhooks signed with webhookSecret verify like Stripe's
+func New(webhookSecret string, logger *log.Logger) *FakeProvider {
+	return &FakeProvider{
+		webhookSecret: webhookSecret,
+		logger:        logger,
+		intents:       map[string]*payment.Intent{},
+		refunded:      map[string]int{},
+		transfers:     map[string]*payment.Transfer{},
+		keys:          map[string]interface{}{},
+	}
+}
+
+func (f *FakeProvider) Authorize(ctx context.Context, request payment.IntentRequest) (*payment.Intent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if existing, ok := f.keys[request.IdempotencyKey].(*payment.Intent); ok {
+		return copyIntent(existing), nil
+	}
+	if request.Amount <= 0 {
+		return nil, &payment.Error{Type: "invalid_request_error", Code: "amount_too_small", Message: "amount must be positive"}
+	}
+
+	id := newID("pi")
+	paymentMethod := request.PaymentMethod
+	if paymentMethod == "" {
+		paymentMethod = "pm_fake_card"
+	}
+	intent := &payment.Intent{
+		ID:               id,
+		Status:           payment.IntentStatusRequiresCapture,
+		Amount:           request.Amount,
+		AmountCapturable: request.Amount,
+		Currency:         strings.ToLower(request.Currency),
+		ClientSecret:     id + "_secret_fake",
+		PaymentMethod:    paymentMethod,
+		Metadata:         request.Metadata,
+	}
+	if request.Amount == DeclineAmount {
+		intent.Status = payment.IntentStatusRequiresPaymentMethod
+		intent.AmountCapturable = 0
+		intent.LastPaymentError = &payment.Error{Type: "card_error", Code: "card_declined", DeclineCode: "generic_decline", Message: "Your card was declined."}
+	}
+
+	f.intents[id] = intent
+	if request.IdempotencyKey != "" {
+		f.keys[request.IdempotencyKey] = intent
+	}
+	f.logger.Printf("Fake payment provider authorized intent %s for %d", id, request.Amount)
+	return copyIntent(intent), nil
+}
+
+func (f *FakeProvider) GetIntent(ctx context.Context, intentID string) (*payment.Intent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	intent, err := f.intent(intentID)
+	if err != nil {
+		return nil, err
+	}
+	return copyIntent(intent), nil
+}
+
+func (f *FakeProvider) Capture(ctx context.Context, intentID string, amount int) (*payment.Intent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	intent, err := f.intent(intentID)
+	if err != nil {
+		return nil, err
+	}
+	if intent.Status != payment.IntentStatusRequiresCapture {
+		return nil, unexpectedState(intent)
+	}
+	if amount > intent.AmountCapturable {
+		return nil, &payment.Error{Type: "invalid_request_error", Code: "amount_too_large", Message: "amount to capture exceeds the authorized amount"}
+	}
+
+	intent.Status = payment.IntentStatusSucceeded
+	intent.AmountReceived = amount
+	intent.AmountCapturable = 0
+	return copyIntent(intent), nil
+}
+
+func (f *FakeProvider) Cancel(ctx context.Context, intentID string) (*payment.Intent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	intent, err := f.intent(intentID)
+	if err != nil {
+		return nil, err
+	}
+	if intent.Status == payment.IntentStatusSucceeded {
+		return nil, unexpectedState(intent)
+	}
+
+	intent.Status = payment.IntentStatusCanceled
+	intent.AmountCapturable = 0
+	return copyIntent(intent), nil
+}
+
+func (f *FakeProvider) Refund(ctx context.Context, request payment.RefundRequest) (*payment.Refund, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if existing, ok := f.keys[request.IdempotencyKey].(*payment.Refund); ok {
+		refund := *existing
+		return &refund, nil
+	}
+
+	intent, err := f.intent(request.IntentID)
+	if err != nil {
+		return nil, err
+	}
+	if intent.Status != payment.IntentStatusSucceeded {
+		return nil, unexpectedState(intent)
+	}
+
+	refundable := intent.AmountReceived - f.refunded[intent.ID]
+	amount := request.Amount
+	if amount == 0 {
+		amount = refundable
+	}
+	if amount <= 0 || amount > refundable {
+		return nil, &payment.Error{Type: "invalid_request_error", Code: "charge_already_refunded", Message: "amount exceeds the refundable amount"}
+	}
+
+	f.refunded[intent.ID] += amount
+	refund := &payment.Refund{ID: newID("re"), IntentID: intent.ID, Amount: amount, Status: payment.RefundStatusSucceeded}
+	if request.IdempotencyKey != "" {
+		f.keys[request.IdempotencyKey] = refund
+	}
+	result := *refund
+	return &result, nil
+}
+
+func (f *FakeProvider) Transfer(ctx context.Context, request payment.TransferRequest) (*payment.Transfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if existing, ok := f.keys[request.IdempotencyKey].(*payment.Transfer); ok {
+		transfer := *existing
+		return &transfer, nil
+	}
+	if request.Destination == "" {
+		return nil, &payment.Error{Type: "invalid_request_error", Code: "parameter_missing", Message: "destination is required"}
+	}
+
+	transfer := &payment.Transfer{ID: newID("tr"), Amount: request.Amount, Destination: request.Destination, Metadata: request.Metadata}
+	f.transfers[transfer.ID] = transfer
+	if request.IdempotencyKey != "" {
+		f.keys[request.IdempotencyKey] = transfer
+	}
+	result := *transfer
+	return &result, nil
+}
+
+func (f *FakeProvider) VerifyWebhook(payload []byte, signatureHeader string) (*payment.Event, error) {
+	if err := payment.VerifySignature(f.webhookSecret, payload, signatureHeader, time.Now()); err != nil {
+		return nil, err
+	}
+	return payment.ParseEvent(payload)
+}
+
+// SignedEvent builds a webhook delivery as the provider would send it, returning the
+// payload and its signature header, so webhook handling can be driven locally
+func (f *FakeProvider) SignedEvent(eventType string, object interface{}) ([]byte, string, error) {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode event object: %w", err)
+	}
+
+	now := time.Now()
+	event := payment.Event{ID: newID("evt"), Type: eventType, Created: now.Unix()}
+	event.Data.Object = data
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode event: %w", err)
+	}
+	return payload, payment.SignPayload(f.webhookSecret, payload, now), nil
+}
+
+func (f *FakeProvider) intent(intentID string) (*payment.Intent, error) {
+	intent, ok := f.intents[intentID]
+	if !ok {
+		return nil, &payment.Error{Type: "invalid_request_error", Code: "resource_missing", Message: fmt.Sprintf("no such payment intent: %s", intentID)}
+	}
+	return intent, nil
+}
+
+func unexpectedState(intent *payment.Intent) error {
+	return &payment.Error{
+		Type:    "invalid_request_error",
+		Code:    "payment_intent_unexpected_state",
+		Message: fmt.Sprintf("payment intent %s is %s", intent.ID, intent.Status),
+	}
+}
+
+func copyIntent(intent *payment.Intent) *payment.Intent {
+	result := *intent
+	return &result
+}
+
+func newID(prefix string) string {
+	return prefix + "_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+}
@@ -0,0 +1,405 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"cleanbuddy-api/res/bookinglifecycle"
+	"cleanbuddy-api/res/store"
+)
+
+func (s *service) CaptureBooking(ctx context.Context, bookingID string) (*store.Transaction, error) {
+	payments, err := s.openPayments(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	hold := authorizedHold(payments)
+	if hold == nil {
+		return nil, ErrNoHold
+	}
+
+	if err := s.capture(ctx, hold, hold.Amount); err != nil {
+		return nil, err
+	}
+	if err := s.releaseAll(ctx, payments, hold); err != nil {
+		s.logger.Printf("Failed to release other payments of booking %s: %v", bookingID, err)
+	}
+	return hold, nil
+}
+
+func (s *service) SettleHold(ctx context.Context, bookingID string, charge int) (*store.Transaction, error) {
+	payments, err := s.openPayments(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	for _, payment := range payments {
+		if payment.Status == store.TransactionStatusProcessing {
+			return nil, ErrPaymentProcessing
+		}
+	}
+
+	hold := authorizedHold(payments)
+	if charge <= 0 || hold == nil {
+		if charge > 0 && len(payments) > 0 {
+			s.logger.Printf("Booking %s card was never authorized, %d cannot be charged", bookingID, charge)
+		}
+		return nil, s.releaseAll(ctx, payments, nil)
+	}
+
+	if charge > hold.Amount {
+		charge = hold.Amount
+	}
+	if err := s.capture(ctx, hold, charge); err != nil {
+		return nil, err
+	}
+	if err := s.releaseAll(ctx, payments, hold); err != nil {
+		s.logger.Printf("Failed to release other payments of booking %s: %v", bookingID, err)
+	}
+
+	released := hold.Amount - charge
+	if released > 0 {
+		refund := s.refundTransaction(hold, released, store.TransactionStatusCompleted, "Released from card hold")
+		if err := s.store.Transactions().Create(ctx, refund); err != nil {
+			return nil, fmt.Errorf("failed to record released hold: %w", err)
+		}
+		s.fireSettled(ctx, refund)
+		if err := s.refreshRefundedStatus(ctx, bookingID); err != nil {
+			s.logger.Printf("Failed to refresh payment status of booking %s: %v", bookingID, err)
+		}
+	}
+	return hold, nil
+}
+
+func (s *service) Refund(ctx context.Context, bookingID string, amount int, description string) (*store.Transaction, error) {
+	payment, err := s.latestPayment(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return nil, ErrNoPayment
+	}
+
+	switch payment.Status {
+	case store.TransactionStatusAuthorized:
+		if amount <= 0 || amount > payment.Amount {
+			return nil, ErrNotRefundable
+		}
+		if _, err := s.SettleHold(ctx, bookingID, payment.Amount-amount); err != nil {
+			return nil, err
+		}
+		return s.releasedRefund(ctx, payment)
+	case store.TransactionStatusCompleted:
+	case store.TransactionStatusProcessing:
+		return nil, ErrPaymentProcessing
+	default:
+		return nil, ErrNoPayment
+	}
+
+	refunded, err := s.refundedFrom(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 || amount > payment.Amount-refunded {
+		return nil, ErrNotRefundable
+	}
+
+	refund, err := s.provider.Refund(ctx, RefundRequest{
+		IntentID: *payment.StripePaymentID,
+		Amount:   amount,
+		Reason:   "requested_by_customer",
+		// A retry before anything else was refunded returns the same refund
+		IdempotencyKey: fmt.Sprintf("refund-%s-%d-%d", payment.ID, refunded, amount),
+	})
+	if err != nil {
+		s.logger.Printf("Failed to refund %d of payment %s: %v", amount, payment.ID, err)
+		return nil, fmt.Errorf("failed to refund payment: %w", err)
+	}
+
+	status := refundTransactionStatus(refund.Status)
+	transaction := s.refundTransaction(payment, amount, status, description)
+	transaction.StripeRefundID = &refund.ID
+	if err := s.store.Transactions().Create(ctx, transaction); err != nil {
+		// The refund's webhook event may have recorded it first
+		if existing, getErr := s.store.Transactions().GetByStripeRefundID(ctx, refund.ID); getErr == nil {
+			return existing, nil
+		}
+		return nil, fmt.Errorf("failed to record refund: %w", err)
+	}
+	if status == store.TransactionStatusCompleted {
+		s.fireSettled(ctx, transaction)
+	}
+	if err := s.refreshRefundedStatus(ctx, bookingID); err != nil {
+		s.logger.Printf("Failed to refresh payment status of booking %s: %v", bookingID, err)
+	}
+	return transaction, nil
+}
+
+// refundedFrom sums the refunds of a card payment that did not fail
+func (s *service) refundedFrom(ctx context.Context, payment *store.Transaction) (int, error) {
+	refunds, err := s.refundsOf(ctx, payment)
+	if err != nil {
+		return 0, err
+	}
+	refunded := 0
+	for _, refund := range refunds {
+		if refund.Status != store.TransactionStatusFailed && refund.Status != store.TransactionStatusCancelled {
+			refunded += refund.Amount
+		}
+	}
+	return refunded, nil
+}
+
+// releasedRefund returns the refund recorded when a hold of a payment was charged in part
+func (s *service) releasedRefund(ctx context.Context, payment *store.Transaction) (*store.Transaction, error) {
+	refunds, err := s.refundsOf(ctx, payment)
+	if err != nil || len(refunds) == 0 {
+		return nil, err
+	}
+	return refunds[len(refunds)-1], nil
+}
+
+// refundsOf returns the refunds of a card payment, oldest first
+func (s *service) refundsOf(ctx context.Context, payment *store.Transaction) ([]*store.Transaction, error) {
+	if payment.BookingID == nil {
+		return nil, nil
+	}
+	transactions, err := s.store.Transactions().GetByBooking(ctx, *payment.BookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking transactions: %w", err)
+	}
+
+	var refunds []*store.Transaction
+	for _, transaction := range transactions {
+		if transaction.Type != store.TransactionTypeRefund || transaction.Metadata == "" {
+			continue
+		}
+		var metadata map[string]string
+		if err := json.Unmarshal([]byte(transaction.Metadata), &metadata); err != nil || metadata["paymentId"] != payment.ID {
+			continue
+		}
+		refunds = append(refunds, transaction)
+	}
+	sort.SliceStable(refunds, func(i, j int) bool {
+		return refunds[i].ProcessedAt.Before(refunds[j].ProcessedAt)
+	})
+	return refunds, nil
+}
+
+func (s *service) CaptureDue(ctx context.Context) (int, error) {
+	holds, err := s.store.Transactions().GetHoldsToCapture(ctx, time.Now().Add(-s.options.CaptureDelay))
+	if err != nil {
+		s.logger.Printf("Failed to list payment holds to capture: %v", err)
+		return 0, fmt.Errorf("failed to list payment holds: %w", err)
+	}
+
+	captured := 0
+	var lastErr error
+	for _, hold := range holds {
+		if _, err := s.CaptureBooking(ctx, *hold.BookingID); err != nil {
+			lastErr = err
+			continue
+		}
+		captured++
+	}
+	return captured, lastErr
+}
+
+func (s *service) RenewHolds(ctx context.Context) (int, error) {
+	holds, err := s.store.Transactions().GetExpiringHolds(ctx, time.Now().Add(holdRenewalMargin))
+	if err != nil {
+		s.logger.Printf("Failed to list expiring payment holds: %v", err)
+		return 0, fmt.Errorf("failed to list payment holds: %w", err)
+	}
+
+	handled := 0
+	var lastErr error
+	for _, hold := range holds {
+		if err := s.renewOrRelease(ctx, hold); err != nil {
+			s.logger.Printf("Failed to renew payment hold %s: %v", hold.ID, err)
+			lastErr = err
+			continue
+		}
+		handled++
+	}
+	return handled, lastErr
+}
+
+// renewOrRelease renews an expiring hold if its booking will still be charged, and releases it otherwise
+func (s *service) renewOrRelease(ctx context.Context, hold *store.Transaction) error {
+	if hold.BookingID == nil {
+		return s.release(ctx, hold)
+	}
+
+	booking, err := s.store.Bookings().Get(ctx, *hold.BookingID)
+	if err != nil {
+		return fmt.Errorf("failed to get booking %s: %w", *hold.BookingID, err)
+	}
+
+	latest, err := s.latestPayment(ctx, booking.ID)
+	if err != nil {
+		return err
+	}
+	if latest != nil && latest.ID != hold.ID {
+		// A renewal the customer had to confirm replaced this hold; it is kept until they do
+		if latest.Status != store.TransactionStatusAuthorized {
+			return nil
+		}
+		return s.release(ctx, hold)
+	}
+
+	switch booking.Status {
+	case store.BookingStatusConfirmed, store.BookingStatusInProgress, store.BookingStatusCompleted:
+		return s.renew(ctx, booking, hold)
+	case store.BookingStatusNoShow:
+		// The fee of a no-show is charged from the hold once it is final, so it is kept until then
+		if booking.NoShowStatus == nil {
+			break
+		}
+		switch *booking.NoShowStatus {
+		case store.NoShowStatusContestable, store.NoShowStatusContested:
+			return s.renew(ctx, booking, hold)
+		case store.NoShowStatusFinal:
+			_, err := s.SettleHold(ctx, booking.ID, booking.NoShowFee)
+			return err
+		}
+	}
+	// Cancelled bookings settle their hold when cancelled; anything left is not going to be captured
+	return s.release(ctx, hold)
+}
+
+// renew authorizes the hold again on the card it was confirmed with and releases the old one.
+// If the card cannot be charged without the customer, the new payment waits for them to confirm
+// it and the old hold is kept until it lapses.
+func (s *service) renew(ctx context.Context, booking *store.Booking, hold *store.Transaction) error {
+	current, err := s.provider.GetIntent(ctx, *hold.StripePaymentID)
+	if err != nil {
+		return fmt.Errorf("failed to get payment intent: %w", err)
+	}
+	if current.PaymentMethod == "" {
+		return fmt.Errorf("payment intent %s has no card to renew the hold with", current.ID)
+	}
+
+	intent, err := s.provider.Authorize(ctx, IntentRequest{
+		Amount:         hold.Amount,
+		Currency:       hold.Currency,
+		Description:    bookingDescription(booking),
+		Metadata:       bookingMetadata(booking),
+		PaymentMethod:  current.PaymentMethod,
+		IdempotencyKey: "renew-" + current.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to authorize renewed hold: %w", err)
+	}
+
+	renewed := s.paymentTransaction(booking, intent)
+	renewed.Amount = hold.Amount
+	renewed.PlatformFee = hold.PlatformFee
+	renewed.NetAmount = hold.NetAmount
+	if err := s.store.Transactions().Create(ctx, renewed); err != nil {
+		return fmt.Errorf("failed to record renewed hold: %w", err)
+	}
+	if err := s.setBookingStatus(ctx, booking.ID, bookingPaymentStatus(renewed.Status)); err != nil {
+		s.logger.Printf("Failed to set payment status of booking %s: %v", booking.ID, err)
+	}
+
+	if renewed.Status != store.TransactionStatusAuthorized {
+		s.logger.Printf("Renewing the hold of booking %s needs the customer to confirm payment %s", booking.ID, renewed.ID)
+		return nil
+	}
+	return s.release(ctx, hold)
+}
+
+// capture charges amount of an authorized payment; the rest of the hold is released
+func (s *service) capture(ctx context.Context, payment *store.Transaction, amount int) error {
+	intent, err := s.provider.Capture(ctx, *payment.StripePaymentID, amount)
+	if err != nil {
+		s.logger.Printf("Failed to capture payment %s: %v", payment.ID, err)
+		return fmt.Errorf("failed to capture payment: %w", err)
+	}
+	return s.syncPayment(ctx, payment, intent)
+}
+
+// release cancels a payment that has not been captured, freeing any hold on the card
+func (s *service) release(ctx context.Context, payment *store.Transaction) error {
+	intent, err := s.provider.Cancel(ctx, *payment.StripePaymentID)
+	if err != nil {
+		s.logger.Printf("Failed to release payment %s: %v", payment.ID, err)
+		return fmt.Errorf("failed to release payment: %w", err)
+	}
+	return s.syncPayment(ctx, payment, intent)
+}
+
+// releaseAll cancels the uncaptured payments of a booking other than keep, oldest first so the
+// booking's payment status ends on the newest
+func (s *service) releaseAll(ctx context.Context, payments []*store.Transaction, keep *store.Transaction) error {
+	var lastErr error
+	for i := len(payments) - 1; i >= 0; i-- {
+		if payments[i] == keep {
+			continue
+		}
+		if err := s.release(ctx, payments[i]); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// openPayments returns the card payments of a booking that can still change, newest first
+func (s *service) openPayments(ctx context.Context, bookingID string) ([]*store.Transaction, error) {
+	payments, err := s.cardPayments(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	var open []*store.Transaction
+	for _, payment := range payments {
+		if !isFinal(payment.Status) {
+			open = append(open, payment)
+		}
+	}
+	return open, nil
+}
+
+// authorizedHold returns the newest authorized payment, or nil if none holds the card
+func authorizedHold(payments []*store.Transaction) *store.Transaction {
+	for _, payment := range payments {
+		if payment.Status == store.TransactionStatusAuthorized {
+			return payment
+		}
+	}
+	return nil
+}
+
+// holdOnConfirm opens the card payment of a booking once a cleaner has taken it
+func (s *service) holdOnConfirm(ctx context.Context, event bookinglifecycle.TransitionEvent) error {
+	latest, err := s.latestPayment(ctx, event.Booking.ID)
+	if err != nil {
+		return err
+	}
+	if latest != nil {
+		return nil
+	}
+
+	_, err = s.OpenBookingPayment(ctx, event.Booking)
+	return err
+}
+
+// captureOnComplete charges the hold of a completed booking unless a dispute window delays it
+func (s *service) captureOnComplete(ctx context.Context, event bookinglifecycle.TransitionEvent) error {
+	if s.options.CaptureDelay > 0 {
+		return nil
+	}
+
+	if _, err := s.CaptureBooking(ctx, event.Booking.ID); err != nil {
+		if errors.Is(err, ErrNoHold) {
+			// Paid in full with credit or gift cards, or the customer never confirmed their card
+			return nil
+		}
+		return err
+	}
+	return nil
+}
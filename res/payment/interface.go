@@ -0,0 +1,108 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+var (
+	ErrNoPayment         = errors.New("booking has no card payment")
+	ErrNotPayer          = errors.New("only the payer can confirm a payment")
+	ErrNotConfirmable    = errors.New("payment no longer needs confirmation")
+	ErrNoHold            = errors.New("booking has no authorized card payment")
+	ErrPaymentProcessing = errors.New("payment is being processed by the provider")
+	ErrNotRefundable     = errors.New("amount exceeds what is left to refund of the booking's card payment")
+)
+
+// PaymentService takes card payments for bookings through a PaymentProvider and keeps
+// the booking's payment Transaction in step with the provider.
+//
+// The customer's card is held when the cleaner confirms the booking and only charged
+// once the booking is completed, after the dispute window when one is configured.
+// Holds lapse at the provider after a few days, so holds of bookings further out are
+// renewed before they expire.
+type PaymentService interface {
+	// OpenBookingPayment creates a payment intent for the booking's total and records it as a
+	// payment transaction. Bookings paid in full with credit or gift cards need no card payment
+	// and return nil.
+	OpenBookingPayment(ctx context.Context, booking *store.Booking) (*store.Transaction, error)
+
+	// OpenTip creates a payment intent for a customer's tip on a booking and records it as a tip
+	// transaction paid to the cleaner in full. Tips are charged as soon as the card is authorized.
+	OpenTip(ctx context.Context, booking *store.Booking, amount int) (*store.Transaction, error)
+
+	// OpenGiftCardPayment creates a payment intent for a recorded gift card purchase and links it to
+	// the purchase transaction. The card is charged as soon as it is authorized; settlement hooks
+	// see the purchase complete once it is charged.
+	OpenGiftCardPayment(ctx context.Context, purchase *store.Transaction) (*store.Transaction, error)
+
+	// CaptureBooking charges the full hold of a booking's card payment.
+	// Returns ErrNoHold if the booking has no authorized payment.
+	CaptureBooking(ctx context.Context, bookingID string) (*store.Transaction, error)
+
+	// SettleHold charges part of a booking's uncaptured card payment and releases the rest, as when
+	// a booking is cancelled. The released part is recorded as a completed refund. With nothing to
+	// charge, or no authorization to charge, the payment is cancelled and nil is returned.
+	SettleHold(ctx context.Context, bookingID string, charge int) (*store.Transaction, error)
+
+	// Refund returns amount of a booking's card payment to the customer and records it as a refund.
+	// A payment still held is charged that much less instead, in which case the released part is
+	// the refund. Returns ErrNotRefundable if more than what is left of the payment is asked back.
+	Refund(ctx context.Context, bookingID string, amount int, description string) (*store.Transaction, error)
+
+	// CaptureDue charges the holds of bookings completed before the dispute window closed and
+	// returns how many were captured
+	CaptureDue(ctx context.Context) (int, error)
+
+	// RenewHolds re-authorizes holds about to lapse while their booking still needs them and
+	// releases holds nothing will capture. Returns how many holds were renewed or released.
+	RenewHolds(ctx context.Context) (int, error)
+
+	// BookingPayment returns the card payment of a booking with its status refreshed from the
+	// provider, or nil if the booking has none
+	BookingPayment(ctx context.Context, bookingID string) (*store.Transaction, error)
+
+	// ClientSecret returns the secret the payer's browser confirms the payment intent with
+	ClientSecret(ctx context.Context, transaction *store.Transaction, user *store.User) (string, error)
+
+	// VerifyWebhook checks the signature of a webhook delivery and decodes its event.
+	// Returns ErrInvalidSignature if the payload was not sent by the provider.
+	VerifyWebhook(payload []byte, signatureHeader string) (*Event, error)
+
+	// HandleEvent stores a verified event and applies it to the payment transactions and booking
+	// it concerns. Redelivered events that were already applied are no-ops; an event that fails
+	// is kept and applied again on its next delivery or by RetryEvents.
+	HandleEvent(ctx context.Context, event *Event, payload []byte) error
+
+	// RetryEvents applies stored events that failed or stalled and returns how many were applied
+	RetryEvents(ctx context.Context) (int, error)
+
+	// OnSettled registers a side effect fired after a card payment is captured or a refund of one
+	// completes
+	OnSettled(hook SettlementHook)
+}
+
+// SettlementHook is a side effect of a payment or refund transaction that completed. Money has
+// already moved, so failures are only logged.
+type SettlementHook func(ctx context.Context, transaction *store.Transaction) error
+
+// Options configures when held payments are captured and renewed
+type Options struct {
+	// CaptureDelay is the dispute window after completion before the hold is captured; zero
+	// captures when the booking is completed
+	CaptureDelay time.Duration
+
+	// HoldValidity is how long the provider keeps a card authorization
+	HoldValidity time.Duration
+}
+
+// DefaultOptions captures at completion and assumes card holds last seven days, as at Stripe
+func DefaultOptions() Options {
+	return Options{
+		CaptureDelay: 0,
+		HoldValidity: 7 * 24 * time.Hour,
+	}
+}
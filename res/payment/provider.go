@@ -0,0 +1,223 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// IntentStatus is the state of a payment intent at the provider
+type IntentStatus string
+
+const (
+	IntentStatusRequiresPaymentMethod IntentStatus = "requires_payment_method" // Waiting for the customer's card
+	IntentStatusRequiresConfirmation  IntentStatus = "requires_confirmation"   // Card attached, not yet confirmed
+	IntentStatusRequiresAction        IntentStatus = "requires_action"         // Customer must complete 3-D Secure
+	IntentStatusProcessing            IntentStatus = "processing"              // Provider is processing the charge
+	IntentStatusRequiresCapture       IntentStatus = "requires_capture"        // Authorized, funds held on the card
+	IntentStatusSucceeded             IntentStatus = "succeeded"               // Captured
+	IntentStatusCanceled              IntentStatus = "canceled"                // Authorization released or never completed
+)
+
+// RefundStatus is the state of a refund at the provider
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "pending"
+	RefundStatusSucceeded RefundStatus = "succeeded"
+	RefundStatusFailed    RefundStatus = "failed"
+	RefundStatusCanceled  RefundStatus = "canceled"
+)
+
+// Webhook event types the platform reacts to
+const (
+	EventIntentAuthorized = "payment_intent.amount_capturable_updated"
+	EventIntentSucceeded  = "payment_intent.succeeded"
+	EventIntentFailed     = "payment_intent.payment_failed"
+	EventIntentCanceled   = "payment_intent.canceled"
+	EventRefundCreated    = "refund.created"
+	EventRefundUpdated    = "refund.updated"
+	EventRefundFailed     = "refund.failed"
+	EventDisputeCreated   = "charge.dispute.created"
+	EventDisputeClosed    = "charge.dispute.closed"
+	EventTransferCreated  = "transfer.created"
+	EventTransferReversed = "transfer.reversed"
+)
+
+// PaymentProvider moves money through a card processor. Amounts are in bani.
+// Payments are authorized first and captured later, so the customer's card is
+// only charged once the booking is done.
+type PaymentProvider interface {
+	// Authorize creates a payment intent that holds the amount on the customer's card once confirmed
+	Authorize(ctx context.Context, request IntentRequest) (*Intent, error)
+
+	// GetIntent retrieves the current state of a payment intent
+	GetIntent(ctx context.Context, intentID string) (*Intent, error)
+
+	// Capture charges up to the authorized amount; a smaller amount releases the rest
+	Capture(ctx context.Context, intentID string, amount int) (*Intent, error)
+
+	// Cancel releases an authorization without charging the card
+	Cancel(ctx context.Context, intentID string) (*Intent, error)
+
+	// Refund returns all or part of a captured payment to the customer
+	Refund(ctx context.Context, request RefundRequest) (*Refund, error)
+
+	// Transfer pays out to a cleaner's connected account
+	Transfer(ctx context.Context, request TransferRequest) (*Transfer, error)
+
+	// VerifyWebhook checks the signature of a webhook delivery and decodes its event.
+	// Returns ErrInvalidSignature if the payload was not sent by the provider.
+	VerifyWebhook(payload []byte, signatureHeader string) (*Event, error)
+}
+
+// IntentRequest describes a payment to authorize
+type IntentRequest struct {
+	Amount      int
+	Currency    string
+	Description string
+	Metadata    map[string]string
+
+	// PaymentMethod charges a card the customer confirmed on an earlier intent, without them
+	// being present. Empty creates an intent the customer confirms with its client secret.
+	PaymentMethod string
+
+	// IdempotencyKey makes a retried request return the intent created by the first one
+	IdempotencyKey string
+}
+
+// RefundRequest describes money returned from a captured payment
+type RefundRequest struct {
+	IntentID       string
+	Amount         int // 0 refunds everything not refunded yet
+	Reason         string
+	IdempotencyKey string
+}
+
+// TransferRequest describes a payout to a connected account
+type TransferRequest struct {
+	Amount         int
+	Currency       string
+	Destination    string // Connected account ID
+	Description    string
+	Metadata       map[string]string
+	IdempotencyKey string
+}
+
+// Intent is a payment at the provider. Field names follow the Stripe API so responses
+// and webhook objects decode directly.
+type Intent struct {
+	ID               string            `json:"id"`
+	Status           IntentStatus      `json:"status"`
+	Amount           int               `json:"amount"`
+	AmountCapturable int               `json:"amount_capturable"`
+	AmountReceived   int               `json:"amount_received"`
+	Currency         string            `json:"currency"`
+	ClientSecret     string            `json:"client_secret"`
+	PaymentMethod    string            `json:"payment_method"` // Card the intent was confirmed with
+	Metadata         map[string]string `json:"metadata"`
+	LastPaymentError *Error            `json:"last_payment_error"`
+}
+
+// Refund is money returned from a payment
+type Refund struct {
+	ID       string       `json:"id"`
+	IntentID string       `json:"payment_intent"`
+	Amount   int          `json:"amount"`
+	Status   RefundStatus `json:"status"`
+}
+
+// Transfer is a payout to a connected account
+type Transfer struct {
+	ID          string            `json:"id"`
+	Amount      int               `json:"amount"`
+	Destination string            `json:"destination"`
+	Reversed    bool              `json:"reversed"`
+	Metadata    map[string]string `json:"metadata"`
+}
+
+// DisputeStatus is the state of a chargeback
+type DisputeStatus string
+
+const (
+	DisputeStatusNeedsResponse DisputeStatus = "needs_response"
+	DisputeStatusUnderReview   DisputeStatus = "under_review"
+	DisputeStatusWon           DisputeStatus = "won"
+	DisputeStatusLost          DisputeStatus = "lost"
+)
+
+// Dispute is a chargeback the customer opened with their bank
+type Dispute struct {
+	ID       string        `json:"id"`
+	IntentID string        `json:"payment_intent"`
+	Amount   int           `json:"amount"`
+	Reason   string        `json:"reason"`
+	Status   DisputeStatus `json:"status"`
+}
+
+// Error is an error reported by the provider, such as a declined card
+type Error struct {
+	Type        string `json:"type"`
+	Code        string `json:"code"`
+	DeclineCode string `json:"decline_code"`
+	Message     string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("payment provider error: %s", e.Message)
+	}
+	return fmt.Sprintf("payment provider error (%s): %s", e.Code, e.Message)
+}
+
+// Event is a webhook notification about an object that changed at the provider
+type Event struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// CreatedAt returns when the provider created the event
+func (e *Event) CreatedAt() time.Time {
+	return time.Unix(e.Created, 0)
+}
+
+// Intent decodes the event's object as a payment intent
+func (e *Event) Intent() (*Intent, error) {
+	var intent Intent
+	if err := json.Unmarshal(e.Data.Object, &intent); err != nil {
+		return nil, fmt.Errorf("failed to decode payment intent of event %s: %w", e.ID, err)
+	}
+	return &intent, nil
+}
+
+// Refund decodes the event's object as a refund
+func (e *Event) Refund() (*Refund, error) {
+	var refund Refund
+	if err := json.Unmarshal(e.Data.Object, &refund); err != nil {
+		return nil, fmt.Errorf("failed to decode refund of event %s: %w", e.ID, err)
+	}
+	return &refund, nil
+}
+
+// Dispute decodes the event's object as a dispute
+func (e *Event) Dispute() (*Dispute, error) {
+	var dispute Dispute
+	if err := json.Unmarshal(e.Data.Object, &dispute); err != nil {
+		return nil, fmt.Errorf("failed to decode dispute of event %s: %w", e.ID, err)
+	}
+	return &dispute, nil
+}
+
+// Transfer decodes the event's object as a transfer
+func (e *Event) Transfer() (*Transfer, error) {
+	var transfer Transfer
+	if err := json.Unmarshal(e.Data.Object, &transfer); err != nil {
+		return nil, fmt.Errorf("failed to decode transfer of event %s: %w", e.ID, err)
+	}
+	return &transfer, nil
+}
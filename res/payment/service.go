@@ -0,0 +1,419 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/bookinglifecycle"
+	"cleanbuddy-api/res/store"
+)
+
+// holdRenewalMargin is how long before a hold lapses it is renewed; RenewHolds must run more often than this
+const holdRenewalMargin = 24 * time.Hour
+
+type service struct {
+	store    store.Store
+	provider PaymentProvider
+	options  Options
+	logger   *log.Logger
+
+	hooksMu sync.RWMutex
+	hooks   []SettlementHook
+}
+
+// NewService creates a new PaymentService and registers holding the card on confirmed
+// bookings and capturing it on completed ones
+func NewService(dataStore store.Store, lifecycle bookinglifecycle.LifecycleService, provider PaymentProvider, options Options, logger *log.Logger) PaymentService {
+	s := &service{
+		store:    dataStore,
+		provider: provider,
+		options:  options,
+		logger:   logger,
+	}
+
+	lifecycle.OnTransition(store.BookingStatusConfirmed, s.holdOnConfirm)
+	lifecycle.OnTransition(store.BookingStatusCompleted, s.captureOnComplete)
+
+	return s
+}
+
+func (s *service) OpenBookingPayment(ctx context.Context, booking *store.Booking) (*store.Transaction, error) {
+	if booking.TotalPrice <= 0 {
+		return nil, nil
+	}
+
+	intent, err := s.provider.Authorize(ctx, IntentRequest{
+		Amount:      booking.TotalPrice,
+		Currency:    "RON",
+		Description: bookingDescription(booking),
+		Metadata:    bookingMetadata(booking),
+		// A retried confirmation reuses the intent instead of holding the amount twice
+		IdempotencyKey: "booking-" + booking.ID,
+	})
+	if err != nil {
+		s.logger.Printf("Failed to create payment intent for booking %s: %v", booking.ID, err)
+		return nil, fmt.Errorf("failed to create payment intent: %w", err)
+	}
+
+	transaction := s.paymentTransaction(booking, intent)
+	if err := s.store.Transactions().Create(ctx, transaction); err != nil {
+		s.logger.Printf("Failed to record payment intent %s of booking %s: %v", intent.ID, booking.ID, err)
+		return nil, fmt.Errorf("failed to record payment: %w", err)
+	}
+
+	status := bookingPaymentStatus(transaction.Status)
+	booking.PaymentStatus = &status
+	if err := s.setBookingStatus(ctx, booking.ID, status); err != nil {
+		// Webhooks and reads of the payment bring the booking in step again
+		s.logger.Printf("Failed to set payment status of booking %s: %v", booking.ID, err)
+	}
+	return transaction, nil
+}
+
+func (s *service) OpenTip(ctx context.Context, booking *store.Booking, amount int) (*store.Transaction, error) {
+	transaction := &store.Transaction{
+		ID:            uuid.New().String(),
+		Type:          store.TransactionTypeTip,
+		BookingID:     &booking.ID,
+		PayerID:       booking.CustomerID,
+		PayeeID:       booking.CleanerID,
+		Amount:        amount,
+		NetAmount:     amount,
+		PaymentMethod: store.PaymentMethodCard,
+		Currency:      "RON",
+		Description:   "Tip",
+		ProcessedAt:   time.Now(),
+	}
+
+	intent, err := s.provider.Authorize(ctx, IntentRequest{
+		Amount:         amount,
+		Currency:       "RON",
+		Description:    "Tip for " + bookingDescription(booking),
+		Metadata:       bookingMetadata(booking),
+		IdempotencyKey: "tip-" + transaction.ID,
+	})
+	if err != nil {
+		s.logger.Printf("Failed to create tip intent for booking %s: %v", booking.ID, err)
+		return nil, fmt.Errorf("failed to create payment intent: %w", err)
+	}
+
+	transaction.Status = TransactionStatus(intent)
+	transaction.StripePaymentID = &intent.ID
+	recordFailure(transaction, intent)
+	if err := s.store.Transactions().Create(ctx, transaction); err != nil {
+		s.logger.Printf("Failed to record tip intent %s of booking %s: %v", intent.ID, booking.ID, err)
+		return nil, fmt.Errorf("failed to record tip: %w", err)
+	}
+
+	if transaction.Status == store.TransactionStatusAuthorized {
+		if err := s.capture(ctx, transaction, amount); err != nil {
+			return nil, err
+		}
+	}
+	return transaction, nil
+}
+
+func (s *service) OpenGiftCardPayment(ctx context.Context, purchase *store.Transaction) (*store.Transaction, error) {
+	if purchase.GiftCardID == nil {
+		return nil, fmt.Errorf("purchase %s is not linked to a gift card", purchase.ID)
+	}
+
+	intent, err := s.provider.Authorize(ctx, IntentRequest{
+		Amount:      purchase.Amount,
+		Currency:    purchase.Currency,
+		Description: purchase.Description,
+		Metadata: map[string]string{
+			"gift_card_id": *purchase.GiftCardID,
+			"customer_id":  purchase.PayerID,
+		},
+		IdempotencyKey: "gift-card-" + purchase.ID,
+	})
+	if err != nil {
+		s.logger.Printf("Failed to create payment intent for gift card %s: %v", *purchase.GiftCardID, err)
+		return nil, fmt.Errorf("failed to create payment intent: %w", err)
+	}
+
+	purchase.Status = TransactionStatus(intent)
+	purchase.StripePaymentID = &intent.ID
+	recordFailure(purchase, intent)
+	if err := s.store.Transactions().Update(ctx, purchase); err != nil {
+		s.logger.Printf("Failed to record payment intent %s of gift card %s: %v", intent.ID, *purchase.GiftCardID, err)
+		return nil, fmt.Errorf("failed to record gift card payment: %w", err)
+	}
+
+	switch purchase.Status {
+	case store.TransactionStatusAuthorized:
+		if err := s.capture(ctx, purchase, purchase.Amount); err != nil {
+			return nil, err
+		}
+	case store.TransactionStatusCompleted:
+		s.fireSettled(ctx, purchase)
+	}
+	return purchase, nil
+}
+
+func (s *service) BookingPayment(ctx context.Context, bookingID string) (*store.Transaction, error) {
+	transaction, err := s.latestPayment(ctx, bookingID)
+	if err != nil || transaction == nil {
+		return nil, err
+	}
+
+	if isFinal(transaction.Status) {
+		return transaction, nil
+	}
+
+	intent, err := s.provider.GetIntent(ctx, *transaction.StripePaymentID)
+	if err != nil {
+		// The stored state is the best we know until the provider answers again
+		s.logger.Printf("Failed to refresh payment intent %s: %v", *transaction.StripePaymentID, err)
+		return transaction, nil
+	}
+
+	if err := s.syncPayment(ctx, transaction, intent); err != nil {
+		s.logger.Printf("Failed to update payment %s from its intent: %v", transaction.ID, err)
+	}
+	return transaction, nil
+}
+
+func (s *service) ClientSecret(ctx context.Context, transaction *store.Transaction, user *store.User) (string, error) {
+	if transaction.StripePaymentID == nil {
+		return "", ErrNoPayment
+	}
+	if transaction.PayerID != user.ID {
+		return "", ErrNotPayer
+	}
+	// A declined card leaves the intent open for another card
+	if transaction.Status != store.TransactionStatusPending && transaction.Status != store.TransactionStatusFailed {
+		return "", ErrNotConfirmable
+	}
+
+	intent, err := s.provider.GetIntent(ctx, *transaction.StripePaymentID)
+	if err != nil {
+		s.logger.Printf("Failed to get payment intent %s: %v", *transaction.StripePaymentID, err)
+		return "", fmt.Errorf("failed to get payment intent: %w", err)
+	}
+	return intent.ClientSecret, nil
+}
+
+func (s *service) OnSettled(hook SettlementHook) {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+	s.hooks = append(s.hooks, hook)
+}
+
+// fireSettled runs the side effects registered for completed payments and refunds
+func (s *service) fireSettled(ctx context.Context, transaction *store.Transaction) {
+	s.hooksMu.RLock()
+	hooks := s.hooks
+	s.hooksMu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook(ctx, transaction); err != nil {
+			s.logger.Printf("Side effect failed for %s %s: %v", transaction.Type, transaction.ID, err)
+		}
+	}
+}
+
+// syncPayment moves a payment transaction and its booking to the state of the payment intent.
+// Completed and cancelled payments are left alone, so events arriving out of order cannot move them back.
+func (s *service) syncPayment(ctx context.Context, transaction *store.Transaction, intent *Intent) error {
+	status := TransactionStatus(intent)
+	if isFinal(transaction.Status) || status == transaction.Status {
+		return nil
+	}
+
+	transaction.Status = status
+	now := time.Now()
+	switch status {
+	case store.TransactionStatusFailed:
+		// The failure reason is kept with the status, so the whole transaction is saved
+		transaction.FailedAt = &now
+		recordFailure(transaction, intent)
+		if err := s.store.Transactions().Update(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to record payment failure: %w", err)
+		}
+	case store.TransactionStatusAuthorized:
+		// So is the expiry of a new hold
+		s.startHold(transaction, now)
+		if err := s.store.Transactions().Update(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to record payment hold: %w", err)
+		}
+	default:
+		if err := s.store.Transactions().UpdateStatus(ctx, transaction.ID, status); err != nil {
+			return fmt.Errorf("failed to update payment status: %w", err)
+		}
+	}
+
+	if status == store.TransactionStatusCompleted {
+		s.fireSettled(ctx, transaction)
+	}
+
+	// Tips and gift cards are charged once the customer confirms them and leave bookings alone
+	if transaction.Type == store.TransactionTypeTip || transaction.Type == store.TransactionTypeGiftCardPurchase {
+		if status == store.TransactionStatusAuthorized {
+			return s.capture(ctx, transaction, transaction.Amount)
+		}
+		return nil
+	}
+
+	if transaction.BookingID == nil {
+		return nil
+	}
+
+	// A payment replaced by a renewed hold no longer speaks for the booking
+	latest, err := s.latestPayment(ctx, *transaction.BookingID)
+	if err != nil {
+		return err
+	}
+	if latest != nil && latest.ID != transaction.ID {
+		return nil
+	}
+	return s.setBookingStatus(ctx, *transaction.BookingID, bookingPaymentStatus(status))
+}
+
+// latestPayment returns the card payment that speaks for a booking, or nil if it has none.
+// A payment reopened after a failure or a renewed hold wins over the one it replaced, and a
+// cancelled payment only counts once every payment of the booking is cancelled.
+func (s *service) latestPayment(ctx context.Context, bookingID string) (*store.Transaction, error) {
+	payments, err := s.cardPayments(ctx, bookingID)
+	if err != nil || len(payments) == 0 {
+		return nil, err
+	}
+
+	for _, payment := range payments {
+		if payment.Status != store.TransactionStatusCancelled {
+			return payment, nil
+		}
+	}
+	return payments[0], nil
+}
+
+// cardPayments returns the card payments of a booking, newest first
+func (s *service) cardPayments(ctx context.Context, bookingID string) ([]*store.Transaction, error) {
+	transactions, err := s.store.Transactions().GetByBooking(ctx, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking transactions: %w", err)
+	}
+
+	var payments []*store.Transaction
+	for _, transaction := range transactions {
+		if transaction.Type == store.TransactionTypePayment && transaction.StripePaymentID != nil {
+			payments = append(payments, transaction)
+		}
+	}
+	return payments, nil
+}
+
+// paymentTransaction builds the payment transaction recording a booking's payment intent
+func (s *service) paymentTransaction(booking *store.Booking, intent *Intent) *store.Transaction {
+	netAmount := booking.TotalPrice - booking.PlatformFee
+	if netAmount < 0 {
+		netAmount = 0
+	}
+
+	now := time.Now()
+	transaction := &store.Transaction{
+		ID:              uuid.New().String(),
+		Type:            store.TransactionTypePayment,
+		Status:          TransactionStatus(intent),
+		BookingID:       &booking.ID,
+		PayerID:         booking.CustomerID,
+		PayeeID:         booking.CleanerID,
+		Amount:          booking.TotalPrice,
+		PlatformFee:     booking.TotalPrice - netAmount,
+		NetAmount:       netAmount,
+		PaymentMethod:   store.PaymentMethodCard,
+		Currency:        "RON",
+		StripePaymentID: &intent.ID,
+		Description:     "Booking payment",
+		ProcessedAt:     now,
+	}
+	if transaction.Status == store.TransactionStatusAuthorized {
+		s.startHold(transaction, now)
+	}
+	recordFailure(transaction, intent)
+	return transaction
+}
+
+// startHold records when the authorization of a payment held from now lapses
+func (s *service) startHold(transaction *store.Transaction, now time.Time) {
+	expiresAt := now.Add(s.options.HoldValidity)
+	transaction.HoldExpiresAt = &expiresAt
+}
+
+func (s *service) setBookingStatus(ctx context.Context, bookingID string, status store.BookingPaymentStatus) error {
+	if err := s.store.Bookings().UpdatePaymentStatus(ctx, bookingID, status); err != nil {
+		return fmt.Errorf("failed to update payment status of booking %s: %w", bookingID, err)
+	}
+	return nil
+}
+
+// bookingDescription is the statement description of a booking's payment
+func bookingDescription(booking *store.Booking) string {
+	return fmt.Sprintf("Cleaning on %s at %s", booking.ScheduledDate.Format("2006-01-02"), booking.ScheduledTime)
+}
+
+// bookingMetadata ties a payment intent to its booking so webhook events can be matched
+func bookingMetadata(booking *store.Booking) map[string]string {
+	return map[string]string{
+		"booking_id":  booking.ID,
+		"customer_id": booking.CustomerID,
+	}
+}
+
+// bookingPaymentStatus maps the status of a booking's payment transaction to the booking's payment status
+func bookingPaymentStatus(status store.TransactionStatus) store.BookingPaymentStatus {
+	switch status {
+	case store.TransactionStatusAuthorized, store.TransactionStatusProcessing:
+		return store.BookingPaymentStatusAuthorized
+	case store.TransactionStatusCompleted:
+		return store.BookingPaymentStatusPaid
+	case store.TransactionStatusFailed:
+		return store.BookingPaymentStatusFailed
+	case store.TransactionStatusCancelled:
+		return store.BookingPaymentStatusCancelled
+	}
+	return store.BookingPaymentStatusPending
+}
+
+// TransactionStatus maps the state of a payment intent to the status of its transaction
+func TransactionStatus(intent *Intent) store.TransactionStatus {
+	switch intent.Status {
+	case IntentStatusRequiresCapture:
+		return store.TransactionStatusAuthorized
+	case IntentStatusProcessing:
+		return store.TransactionStatusProcessing
+	case IntentStatusSucceeded:
+		return store.TransactionStatusCompleted
+	case IntentStatusCanceled:
+		return store.TransactionStatusCancelled
+	case IntentStatusRequiresPaymentMethod:
+		// A declined card sends the intent back for another card
+		if intent.LastPaymentError != nil {
+			return store.TransactionStatusFailed
+		}
+	}
+	return store.TransactionStatusPending
+}
+
+// recordFailure keeps the provider's reason for the last failed attempt
+func recordFailure(transaction *store.Transaction, intent *Intent) {
+	if intent.LastPaymentError == nil {
+		return
+	}
+	transaction.FailureCode = intent.LastPaymentError.Code
+	if intent.LastPaymentError.DeclineCode != "" {
+		transaction.FailureCode = intent.LastPaymentError.DeclineCode
+	}
+	transaction.FailureReason = intent.LastPaymentError.Message
+}
+
+// isFinal reports whether a payment can no longer change at the provider
+func isFinal(status store.TransactionStatus) bool {
+	return status == store.TransactionStatusCompleted || status == store.TransactionStatusCancelled
+}
@@ -0,0 +1,204 @@
+package stripe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"cleanbuddy-api/res/payment"
+)
+
+// StripeProvider implements the PaymentProvider interface using the Stripe REST API
+type StripeProvider struct {
+	secretKey     string
+	webhookSecret string
+	apiBaseURL    string
+	logger        *log.Logger
+	httpClient    *http.Client
+}
+
+// stripeErrorResponse is the body Stripe returns with a non-2xx status
+type stripeErrorResponse struct {
+	Error *payment.Error `json:"error"`
+}
+
+// New creates a new Stripe provider instance
+func New(secretKey, webhookSecret, apiURL string, timeout time.Duration, logger *log.Logger) payment.PaymentProvider {
+	return &StripeProvider{
+		secretKey:     secretKey,
+		webhookSecret: webhookSecret,
+		apiBaseURL:    strings.TrimRight(apiURL, "/"),
+		logger:        logger,
+		httpClient:    &http.Client{Timeout: timeout},
+	}
+}
+
+// Authorize creates a payment intent with manual capture, so confirming it only holds the amount.
+// Cards are kept for off-session use, so an expiring hold can be renewed without the customer.
+func (s *StripeProvider) Authorize(ctx context.Context, request payment.IntentRequest) (*payment.Intent, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.Itoa(request.Amount))
+	form.Set("currency", strings.ToLower(request.Currency))
+	form.Set("capture_method", "manual")
+	if request.PaymentMethod != "" {
+		form.Set("payment_method", request.PaymentMethod)
+		form.Set("confirm", "true")
+		form.Set("off_session", "true")
+	} else {
+		form.Set("automatic_payment_methods[enabled]", "true")
+		form.Set("setup_future_usage", "off_session")
+	}
+	if request.Description != "" {
+		form.Set("description", request.Description)
+	}
+	setMetadata(form, request.Metadata)
+
+	var intent payment.Intent
+	if err := s.post(ctx, "/payment_intents", form, request.IdempotencyKey, &intent); err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+// GetIntent retrieves a payment intent
+func (s *StripeProvider) GetIntent(ctx context.Context, intentID string) (*payment.Intent, error) {
+	var intent payment.Intent
+	if err := s.do(ctx, http.MethodGet, "/payment_intents/"+url.PathEscape(intentID), nil, "", &intent); err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+// Capture charges an authorized payment intent
+func (s *StripeProvider) Capture(ctx context.Context, intentID string, amount int) (*payment.Intent, error) {
+	form := url.Values{}
+	form.Set("amount_to_capture", strconv.Itoa(amount))
+
+	var intent payment.Intent
+	path := "/payment_intents/" + url.PathEscape(intentID) + "/capture"
+	if err := s.post(ctx, path, form, fmt.Sprintf("capture-%s-%d", intentID, amount), &intent); err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+// Cancel releases the authorization of a payment intent
+func (s *StripeProvider) Cancel(ctx context.Context, intentID string) (*payment.Intent, error) {
+	var intent payment.Intent
+	path := "/payment_intents/" + url.PathEscape(intentID) + "/cancel"
+	if err := s.post(ctx, path, url.Values{}, "cancel-"+intentID, &intent); err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+// Refund refunds a captured payment intent. Stripe only accepts a fixed set of reasons,
+// so the platform's reason is kept in the refund's metadata.
+func (s *StripeProvider) Refund(ctx context.Context, request payment.RefundRequest) (*payment.Refund, error) {
+	form := url.Values{}
+	form.Set("payment_intent", request.IntentID)
+	if request.Amount > 0 {
+		form.Set("amount", strconv.Itoa(request.Amount))
+	}
+	if request.Reason != "" {
+		form.Set("metadata[reason]", request.Reason)
+	}
+
+	var refund payment.Refund
+	if err := s.post(ctx, "/refunds", form, request.IdempotencyKey, &refund); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// Transfer moves funds from the platform balance to a connected account
+func (s *StripeProvider) Transfer(ctx context.Context, request payment.TransferRequest) (*payment.Transfer, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.Itoa(request.Amount))
+	form.Set("currency", strings.ToLower(request.Currency))
+	form.Set("destination", request.Destination)
+	if request.Description != "" {
+		form.Set("description", request.Description)
+	}
+	setMetadata(form, request.Metadata)
+
+	var transfer payment.Transfer
+	if err := s.post(ctx, "/transfers", form, request.IdempotencyKey, &transfer); err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// VerifyWebhook verifies the Stripe-Signature header of a webhook delivery
+func (s *StripeProvider) VerifyWebhook(payload []byte, signatureHeader string) (*payment.Event, error) {
+	if s.webhookSecret == "" {
+		s.logger.Printf("Stripe webhook secret not configured, rejecting webhook")
+		return nil, payment.ErrInvalidSignature
+	}
+	if err := payment.VerifySignature(s.webhookSecret, payload, signatureHeader, time.Now()); err != nil {
+		return nil, err
+	}
+	return payment.ParseEvent(payload)
+}
+
+func (s *StripeProvider) post(ctx context.Context, path string, form url.Values, idempotencyKey string, out interface{}) error {
+	return s.do(ctx, http.MethodPost, path, form, idempotencyKey, out)
+}
+
+func (s *StripeProvider) do(ctx context.Context, method, path string, form url.Values, idempotencyKey string, out interface{}) error {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.apiBaseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.SetBasicAuth(s.secretKey, "")
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errorResponse stripeErrorResponse
+		if err := json.Unmarshal(respBody, &errorResponse); err == nil && errorResponse.Error != nil {
+			return errorResponse.Error
+		}
+		return fmt.Errorf("stripe %s %s failed with status %d", method, path, resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
+
+// setMetadata adds metadata in Stripe's form encoding, metadata[key]=value
+func setMetadata(form url.Values, metadata map[string]string) {
+	for key, value := range metadata {
+		form.Set("metadata["+key+"]", value)
+	}
+}
@@ -0,0 +1,83 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// SignatureTolerance is how old a signed delivery may be before it is rejected as a replay
+const SignatureTolerance = 5 * time.Minute
+
+// SignPayload returns a signature header for a webhook payload in the Stripe format,
+// "t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<payload>">"
+func SignPayload(secret string, payload []byte, at time.Time) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", timestamp, computeSignature(secret, timestamp, payload))
+}
+
+// VerifySignature checks a signature header created by SignPayload. Any of several
+// v1 signatures may match, which happens while the provider rolls the secret.
+func VerifySignature(secret string, payload []byte, header string, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > SignatureTolerance || age < -SignatureTolerance {
+		return ErrInvalidSignature
+	}
+
+	expected := []byte(computeSignature(secret, timestamp, payload))
+	for _, signature := range signatures {
+		if hmac.Equal(expected, []byte(signature)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// ParseEvent decodes a verified webhook payload
+func ParseEvent(payload []byte) (*Event, error) {
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook event: %w", err)
+	}
+	if event.ID == "" || event.Type == "" {
+		return nil, errors.New("webhook event is missing its id or type")
+	}
+	return &event, nil
+}
+
+func computeSignature(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,58 @@
+package payment
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerifySignatureAcceptsSignedPayload(t *testing.T) {
+	payload := []byte(`{"id":"evt_1","type":"payment_intent.succeeded","data":{"object":{"id":"pi_1","status":"succeeded"}}}`)
+	now := time.Unix(1700000000, 0)
+
+	header := SignPayload("whsec_test", payload, now)
+	if err := VerifySignature("whsec_test", payload, header, now.Add(time.Minute)); err != nil {
+		t.Fatalf("VerifySignature() error = %v", err)
+	}
+
+	event, err := ParseEvent(payload)
+	if err != nil {
+		t.Fatalf("ParseEvent() error = %v", err)
+	}
+	intent, err := event.Intent()
+	if err != nil {
+		t.Fatalf("Intent() error = %v", err)
+	}
+	if intent.ID != "pi_1" || intent.Status != IntentStatusSucceeded {
+		t.Errorf("Intent() = %+v, want pi_1 succeeded", intent)
+	}
+}
+
+func TestVerifySignatureRejects(t *testing.T) {
+	payload := []byte(`{"id":"evt_1","type":"payment_intent.succeeded"}`)
+	now := time.Unix(1700000000, 0)
+	header := SignPayload("whsec_test", payload, now)
+
+	tests := []struct {
+		name    string
+		secret  string
+		payload []byte
+		header  string
+		at      time.Time
+	}{
+		{"wrong secret", "whsec_other", payload, header, now},
+		{"tampered payload", "whsec_test", []byte(`{"id":"evt_2","type":"payment_intent.succeeded"}`), header, now},
+		{"replayed", "whsec_test", payload, header, now.Add(SignatureTolerance + time.Second)},
+		{"missing signature", "whsec_test", payload, "t=1700000000", now},
+		{"malformed header", "whsec_test", payload, "garbage", now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(tt.secret, tt.payload, tt.header, tt.at)
+			if !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("VerifySignature() error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
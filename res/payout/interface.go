@@ -0,0 +1,47 @@
+package payout
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+var (
+	ErrBatchNotFound       = errors.New("payout batch not found")
+	ErrInvalidPeriod       = errors.New("payout period must start before it ends and end in the past")
+	ErrNothingToPay        = errors.New("no completed bookings, tips or compensation are due for payout in the period")
+	ErrBatchConflict       = errors.New("bookings of the period were taken into another payout batch")
+	ErrBatchNotProcessable = errors.New("payout batch is already paid or being processed")
+	ErrProfileNotFound     = errors.New("cleaner profile not found")
+)
+
+// PayoutService pays cleaners their earnings in batches. A batch collects the cleaner payout of
+// every completed booking and every tip in a period that has not been paid out yet, as one payout
+// transaction per cleaner, less the clawbacks decided in disputes against them, together with the
+// compensation still owed for cancelled and no-show bookings, and transfers each through the
+// payment provider. Bookings under dispute are held back until the dispute is
+// resolved. Payouts succeed or fail on their own; processing a batch again retries only the
+// payouts that failed.
+type PayoutService interface {
+	// CreateBatch collects the bookings, tips and compensation due for payout in the period into a
+	// new pending batch
+	CreateBatch(ctx context.Context, request BatchRequest, initiator *store.User) (*store.PayoutBatch, error)
+
+	// ProcessBatch transfers the pending and failed payouts of a batch and finalizes its totals and status
+	ProcessBatch(ctx context.Context, batchID string) (*store.PayoutBatch, error)
+
+	// Payouts returns the payout transactions of a batch
+	Payouts(ctx context.Context, batchID string) ([]*store.Transaction, error)
+
+	// SetPayoutAccount sets the payment provider account a cleaner's earnings are transferred to
+	SetPayoutAccount(ctx context.Context, cleanerProfileID string, accountID string) (*store.CleanerProfile, error)
+}
+
+// BatchRequest describes the period a payout batch covers
+type BatchRequest struct {
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Notes       string
+}
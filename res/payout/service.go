@@ -0,0 +1,310 @@
+package payout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/payment"
+	"cleanbuddy-api/res/store"
+)
+
+// batchClaimTimeout is how long processing may hold a batch before it can be processed again
+const batchClaimTimeout = 10 * time.Minute
+
+type service struct {
+	store    store.Store
+	provider payment.PaymentProvider
+	logger   *log.Logger
+}
+
+// NewService creates a new PayoutService transferring payouts through the given provider
+func NewService(dataStore store.Store, provider payment.PaymentProvider, logger *log.Logger) PayoutService {
+	return &service{
+		store:    dataStore,
+		provider: provider,
+		logger:   logger,
+	}
+}
+
+func (s *service) CreateBatch(ctx context.Context, request BatchRequest, initiator *store.User) (*store.PayoutBatch, error) {
+	if !request.PeriodStart.Before(request.PeriodEnd) || request.PeriodEnd.After(time.Now()) {
+		return nil, ErrInvalidPeriod
+	}
+
+	bookings, err := s.store.Bookings().GetDueForPayout(ctx, request.PeriodStart, request.PeriodEnd)
+	if err != nil {
+		s.logger.Printf("Failed to list bookings due for payout: %v", err)
+		return nil, fmt.Errorf("failed to list bookings due for payout: %w", err)
+	}
+	tips, err := s.store.Transactions().GetTipsDueForPayout(ctx, request.PeriodStart, request.PeriodEnd)
+	if err != nil {
+		s.logger.Printf("Failed to list tips due for payout: %v", err)
+		return nil, fmt.Errorf("failed to list tips due for payout: %w", err)
+	}
+	compensations, err := s.store.Transactions().GetCompensationDue(ctx, request.PeriodEnd)
+	if err != nil {
+		s.logger.Printf("Failed to list compensation due: %v", err)
+		return nil, fmt.Errorf("failed to list compensation due: %w", err)
+	}
+	if len(bookings) == 0 && len(tips) == 0 && len(compensations) == 0 {
+		return nil, ErrNothingToPay
+	}
+	clawbacks, err := s.store.Transactions().GetClawbacksDue(ctx)
+	if err != nil {
+		s.logger.Printf("Failed to list clawbacks due: %v", err)
+		return nil, fmt.Errorf("failed to list clawbacks due: %w", err)
+	}
+
+	batch := &store.PayoutBatch{
+		ID:            uuid.New().String(),
+		Status:        store.TransactionStatusPending,
+		PeriodStart:   request.PeriodStart,
+		PeriodEnd:     request.PeriodEnd,
+		InitiatedByID: initiator.ID,
+		Notes:         request.Notes,
+	}
+
+	allocations := allocate(bookings, tips, clawbacks, compensations, batch, initiator)
+	for _, allocation := range allocations {
+		batch.TotalAmount += allocation.Payout.Amount
+	}
+	batch.TotalPayouts = len(allocations)
+
+	if err := s.store.Transactions().OpenPayoutBatch(ctx, batch, allocations); err != nil {
+		if errors.Is(err, store.ErrPayoutConflict) {
+			return nil, ErrBatchConflict
+		}
+		s.logger.Printf("Failed to create payout batch: %v", err)
+		return nil, fmt.Errorf("failed to create payout batch: %w", err)
+	}
+
+	s.logger.Printf("Created payout batch %s: %d payouts, %d total", batch.ID, batch.TotalPayouts, batch.TotalAmount)
+	return batch, nil
+}
+
+func (s *service) ProcessBatch(ctx context.Context, batchID string) (*store.PayoutBatch, error) {
+	batch, err := s.store.Transactions().GetPayoutBatch(ctx, batchID)
+	if err != nil {
+		s.logger.Printf("Failed to get payout batch %s: %v", batchID, err)
+		return nil, ErrBatchNotFound
+	}
+
+	claimed, err := s.store.Transactions().ClaimPayoutBatch(ctx, batch.ID, time.Now().Add(-batchClaimTimeout))
+	if err != nil {
+		s.logger.Printf("Failed to claim payout batch %s: %v", batch.ID, err)
+		return nil, fmt.Errorf("failed to claim payout batch: %w", err)
+	}
+	if !claimed {
+		return nil, ErrBatchNotProcessable
+	}
+
+	payouts, err := s.store.Transactions().GetByPayoutBatch(ctx, batch.ID)
+	if err != nil {
+		s.logger.Printf("Failed to get payouts of batch %s: %v", batch.ID, err)
+		return nil, fmt.Errorf("failed to get payouts: %w", err)
+	}
+
+	for _, payout := range payouts {
+		if payout.Status != store.TransactionStatusPending && payout.Status != store.TransactionStatusFailed {
+			continue
+		}
+		if err := s.pay(ctx, payout); err != nil {
+			// The payout keeps its last state and is retried when the batch is processed again
+			s.logger.Printf("Failed to record payout %s of batch %s: %v", payout.ID, batch.ID, err)
+		}
+	}
+
+	if err := s.finalize(ctx, batch, payouts); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+func (s *service) Payouts(ctx context.Context, batchID string) ([]*store.Transaction, error) {
+	payouts, err := s.store.Transactions().GetByPayoutBatch(ctx, batchID)
+	if err != nil {
+		s.logger.Printf("Failed to get payouts of batch %s: %v", batchID, err)
+		return nil, fmt.Errorf("failed to get payouts: %w", err)
+	}
+	return payouts, nil
+}
+
+func (s *service) SetPayoutAccount(ctx context.Context, cleanerProfileID string, accountID string) (*store.CleanerProfile, error) {
+	profile, err := s.store.CleanerProfiles().Get(ctx, cleanerProfileID)
+	if err != nil {
+		s.logger.Printf("Failed to get cleaner profile %s: %v", cleanerProfileID, err)
+		return nil, ErrProfileNotFound
+	}
+
+	profile.PayoutAccountID = &accountID
+	if accountID == "" {
+		profile.PayoutAccountID = nil
+	}
+	if err := s.store.CleanerProfiles().Update(ctx, profile); err != nil {
+		s.logger.Printf("Failed to set payout account of cleaner profile %s: %v", profile.ID, err)
+		return nil, fmt.Errorf("failed to set payout account: %w", err)
+	}
+	return profile, nil
+}
+
+// pay transfers a payout to the cleaner's account and records the outcome on the payout
+func (s *service) pay(ctx context.Context, payout *store.Transaction) error {
+	profile, err := s.store.CleanerProfiles().GetByUserID(ctx, payout.PayeeID)
+	if err != nil || profile.PayoutAccountID == nil {
+		return s.recordFailure(ctx, payout, "no_payout_account", "cleaner has no payout account")
+	}
+
+	// A retry after a failure needs a new key, or the provider would answer with the failure again
+	idempotencyKey := "payout-" + payout.ID
+	if payout.FailedAt != nil {
+		idempotencyKey += "-" + strconv.FormatInt(payout.FailedAt.Unix(), 10)
+	}
+
+	metadata := map[string]string{"payout_id": payout.ID}
+	if payout.PayoutBatchID != nil {
+		metadata["payout_batch_id"] = *payout.PayoutBatchID
+	}
+
+	transfer, err := s.provider.Transfer(ctx, payment.TransferRequest{
+		Amount:         payout.Amount,
+		Currency:       payout.Currency,
+		Destination:    *profile.PayoutAccountID,
+		Description:    payout.Description,
+		Metadata:       metadata,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		code := "transfer_failed"
+		var providerErr *payment.Error
+		if errors.As(err, &providerErr) && providerErr.Code != "" {
+			code = providerErr.Code
+		}
+		s.logger.Printf("Transfer of payout %s failed: %v", payout.ID, err)
+		return s.recordFailure(ctx, payout, code, err.Error())
+	}
+
+	now := time.Now()
+	payout.Status = store.TransactionStatusCompleted
+	payout.StripeTransferID = &transfer.ID
+	payout.CompletedAt = &now
+	payout.FailureCode = ""
+	payout.FailureReason = ""
+	return s.store.Transactions().Update(ctx, payout)
+}
+
+func (s *service) recordFailure(ctx context.Context, payout *store.Transaction, code, reason string) error {
+	now := time.Now()
+	payout.Status = store.TransactionStatusFailed
+	payout.FailedAt = &now
+	payout.FailureCode = code
+	payout.FailureReason = reason
+	return s.store.Transactions().Update(ctx, payout)
+}
+
+// finalize sets the batch totals from its payouts. A batch is completed once every payout was
+// transferred; with failures left it is failed and can be processed again.
+func (s *service) finalize(ctx context.Context, batch *store.PayoutBatch, payouts []*store.Transaction) error {
+	batch.TotalAmount, batch.TotalPayouts = 0, len(payouts)
+	batch.PaidAmount, batch.FailedPayouts = 0, 0
+	for _, payout := range payouts {
+		batch.TotalAmount += payout.Amount
+		switch payout.Status {
+		case store.TransactionStatusCompleted:
+			batch.PaidAmount += payout.Amount
+		case store.TransactionStatusFailed:
+			batch.FailedPayouts++
+		}
+	}
+
+	now := time.Now()
+	batch.ProcessedAt = &now
+	batch.Status = store.TransactionStatusCompleted
+	batch.CompletedAt = &now
+	if batch.PaidAmount < batch.TotalAmount {
+		batch.Status = store.TransactionStatusFailed
+		batch.CompletedAt = nil
+	}
+
+	if err := s.store.Transactions().UpdatePayoutBatch(ctx, batch); err != nil {
+		s.logger.Printf("Failed to finalize payout batch %s: %v", batch.ID, err)
+		return fmt.Errorf("failed to finalize payout batch: %w", err)
+	}
+
+	s.logger.Printf("Processed payout batch %s: %d of %d paid, %d payouts failed",
+		batch.ID, batch.PaidAmount, batch.TotalAmount, batch.FailedPayouts)
+	return nil
+}
+
+// allocate groups bookings and tips by cleaner into one payout each, in a stable order, and takes
+// the cleaners' clawbacks off them. Compensation for cancelled and no-show bookings is paid as
+// its own payout, after the cleaners' earnings.
+func allocate(bookings []*store.Booking, tips, clawbacks, compensations []*store.Transaction, batch *store.PayoutBatch, initiator *store.User) []*store.PayoutAllocation {
+	byCleaner := map[string]*store.PayoutAllocation{}
+	allocationOf := func(cleanerID string) *store.PayoutAllocation {
+		allocation, ok := byCleaner[cleanerID]
+		if !ok {
+			allocation = &store.PayoutAllocation{
+				Payout: &store.Transaction{
+					ID:     uuid.New().String(),
+					Type:   store.TransactionTypePayout,
+					Status: store.TransactionStatusPending,
+					// The platform pays out, represented by the admin who opened the batch
+					PayerID:       initiator.ID,
+					PayeeID:       cleanerID,
+					PaymentMethod: store.PaymentMethodBankTransfer,
+					Currency:      "RON",
+					Description: fmt.Sprintf("Earnings %s to %s",
+						batch.PeriodStart.Format("2006-01-02"), batch.PeriodEnd.Format("2006-01-02")),
+					ProcessedAt: time.Now(),
+				},
+			}
+			byCleaner[cleanerID] = allocation
+		}
+		return allocation
+	}
+
+	for _, booking := range bookings {
+		allocation := allocationOf(booking.CleanerID)
+		allocation.Payout.Amount += booking.CleanerPayout
+		allocation.Payout.NetAmount += booking.CleanerPayout
+		allocation.BookingIDs = append(allocation.BookingIDs, booking.ID)
+	}
+	// Tips are passed on in full
+	for _, tip := range tips {
+		allocation := allocationOf(tip.PayeeID)
+		allocation.Payout.Amount += tip.NetAmount
+		allocation.Payout.NetAmount += tip.NetAmount
+		allocation.TipIDs = append(allocation.TipIDs, tip.ID)
+	}
+	// Clawbacks are taken oldest first from cleaners being paid, as long as something is left to
+	// pay them; the rest wait for a later batch
+	for _, clawback := range clawbacks {
+		allocation, ok := byCleaner[clawback.PayerID]
+		if !ok || allocation.Payout.Amount <= clawback.Amount {
+			continue
+		}
+		allocation.Payout.Amount -= clawback.Amount
+		allocation.Payout.NetAmount -= clawback.Amount
+		allocation.ClawbackIDs = append(allocation.ClawbackIDs, clawback.ID)
+	}
+
+	allocations := make([]*store.PayoutAllocation, 0, len(byCleaner))
+	for _, allocation := range byCleaner {
+		allocations = append(allocations, allocation)
+	}
+	sort.Slice(allocations, func(i, j int) bool {
+		return allocations[i].Payout.PayeeID < allocations[j].Payout.PayeeID
+	})
+
+	for _, compensation := range compensations {
+		allocations = append(allocations, &store.PayoutAllocation{Payout: compensation, Compensation: true})
+	}
+	return allocations
+}
@@ -0,0 +1,138 @@
+package payout
+
+import (
+	"testing"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+func TestAllocateGroupsBookingsByCleaner(t *testing.T) {
+	batch := &store.PayoutBatch{
+		ID:          "batch-1",
+		PeriodStart: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:   time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+	}
+	admin := &store.User{ID: "admin"}
+	bookings := []*store.Booking{
+		{ID: "b1", CleanerID: "cleaner-b", CleanerPayout: 10000},
+		{ID: "b2", CleanerID: "cleaner-a", CleanerPayout: 5000},
+		{ID: "b3", CleanerID: "cleaner-b", CleanerPayout: 7500},
+	}
+
+	allocations := allocate(bookings, nil, nil, nil, batch, admin)
+	if len(allocations) != 2 {
+		t.Fatalf("got %d allocations, want 2", len(allocations))
+	}
+
+	tests := []struct {
+		payee    string
+		amount   int
+		bookings []string
+	}{
+		{payee: "cleaner-a", amount: 5000, bookings: []string{"b2"}},
+		{payee: "cleaner-b", amount: 17500, bookings: []string{"b1", "b3"}},
+	}
+	for i, tt := range tests {
+		payout := allocations[i].Payout
+		if payout.PayeeID != tt.payee || payout.Amount != tt.amount || payout.NetAmount != tt.amount {
+			t.Errorf("allocation %d = payee %s, amount %d, net %d; want payee %s, amount %d",
+				i, payout.PayeeID, payout.Amount, payout.NetAmount, tt.payee, tt.amount)
+		}
+		if payout.Type != store.TransactionTypePayout || payout.Status != store.TransactionStatusPending || payout.PayerID != admin.ID {
+			t.Errorf("allocation %d = %s %s from %s, want a pending payout from the admin", i, payout.Status, payout.Type, payout.PayerID)
+		}
+		if len(allocations[i].BookingIDs) != len(tt.bookings) {
+			t.Fatalf("allocation %d covers %v, want %v", i, allocations[i].BookingIDs, tt.bookings)
+		}
+		for j, id := range tt.bookings {
+			if allocations[i].BookingIDs[j] != id {
+				t.Errorf("allocation %d covers %v, want %v", i, allocations[i].BookingIDs, tt.bookings)
+			}
+		}
+	}
+}
+
+func TestAllocateAddsTipsToTheCleanersPayout(t *testing.T) {
+	batch := &store.PayoutBatch{ID: "batch-1"}
+	admin := &store.User{ID: "admin"}
+	bookings := []*store.Booking{{ID: "b1", CleanerID: "cleaner-a", CleanerPayout: 10000}}
+	tips := []*store.Transaction{
+		{ID: "t1", Type: store.TransactionTypeTip, PayeeID: "cleaner-a", Amount: 2000, NetAmount: 2000},
+		{ID: "t2", Type: store.TransactionTypeTip, PayeeID: "cleaner-b", Amount: 1500, NetAmount: 1500},
+	}
+
+	allocations := allocate(bookings, tips, nil, nil, batch, admin)
+	if len(allocations) != 2 {
+		t.Fatalf("got %d allocations, want 2", len(allocations))
+	}
+
+	a, b := allocations[0], allocations[1]
+	if a.Payout.Amount != 12000 || len(a.BookingIDs) != 1 || len(a.TipIDs) != 1 || a.TipIDs[0] != "t1" {
+		t.Errorf("cleaner-a payout = %d for bookings %v and tips %v, want 12000 for b1 and t1", a.Payout.Amount, a.BookingIDs, a.TipIDs)
+	}
+	if b.Payout.PayeeID != "cleaner-b" || b.Payout.Amount != 1500 || len(b.BookingIDs) != 0 || len(b.TipIDs) != 1 {
+		t.Errorf("cleaner-b payout = %d for bookings %v and tips %v, want 1500 for t2 only", b.Payout.Amount, b.BookingIDs, b.TipIDs)
+	}
+}
+
+func TestAllocateTakesClawbacksWhileSomethingIsLeftToPay(t *testing.T) {
+	batch := &store.PayoutBatch{ID: "batch-1"}
+	admin := &store.User{ID: "admin"}
+	bookings := []*store.Booking{
+		{ID: "b1", CleanerID: "cleaner-a", CleanerPayout: 10000},
+		{ID: "b2", CleanerID: "cleaner-b", CleanerPayout: 3000},
+	}
+	clawbacks := []*store.Transaction{
+		{ID: "c1", Type: store.TransactionTypeClawback, PayerID: "cleaner-a", Amount: 4000, NetAmount: 4000},
+		{ID: "c2", Type: store.TransactionTypeClawback, PayerID: "cleaner-a", Amount: 6000, NetAmount: 6000},
+		{ID: "c3", Type: store.TransactionTypeClawback, PayerID: "cleaner-a", Amount: 2000, NetAmount: 2000},
+		{ID: "c4", Type: store.TransactionTypeClawback, PayerID: "cleaner-c", Amount: 1000, NetAmount: 1000},
+	}
+
+	allocations := allocate(bookings, nil, clawbacks, nil, batch, admin)
+	if len(allocations) != 2 {
+		t.Fatalf("got %d allocations, want 2 without one for the unpaid cleaner-c", len(allocations))
+	}
+
+	a, b := allocations[0], allocations[1]
+	if a.Payout.Amount != 4000 || a.Payout.NetAmount != 4000 {
+		t.Errorf("cleaner-a payout = %d net %d, want 4000 after c1 and c3", a.Payout.Amount, a.Payout.NetAmount)
+	}
+	if len(a.ClawbackIDs) != 2 || a.ClawbackIDs[0] != "c1" || a.ClawbackIDs[1] != "c3" {
+		t.Errorf("cleaner-a clawbacks = %v, want [c1 c3] with c2 left for a later batch", a.ClawbackIDs)
+	}
+	if b.Payout.Amount != 3000 || len(b.ClawbackIDs) != 0 {
+		t.Errorf("cleaner-b payout = %d with clawbacks %v, want 3000 untouched", b.Payout.Amount, b.ClawbackIDs)
+	}
+}
+
+func TestAllocateAddsPendingCompensationAsItIs(t *testing.T) {
+	batch := &store.PayoutBatch{ID: "batch-1"}
+	admin := &store.User{ID: "admin"}
+	bookingID := "b2"
+	bookings := []*store.Booking{{ID: "b1", CleanerID: "cleaner-a", CleanerPayout: 10000}}
+	clawbacks := []*store.Transaction{
+		{ID: "c1", Type: store.TransactionTypeClawback, PayerID: "cleaner-b", Amount: 1000, NetAmount: 1000},
+	}
+	compensations := []*store.Transaction{
+		{ID: "p1", Type: store.TransactionTypePayout, Status: store.TransactionStatusPending,
+			BookingID: &bookingID, PayeeID: "cleaner-b", Amount: 3000, NetAmount: 3000},
+	}
+
+	allocations := allocate(bookings, nil, clawbacks, compensations, batch, admin)
+	if len(allocations) != 2 {
+		t.Fatalf("got %d allocations, want the earnings of cleaner-a and the compensation of cleaner-b", len(allocations))
+	}
+
+	earnings, compensation := allocations[0], allocations[1]
+	if earnings.Compensation || earnings.Payout.PayeeID != "cleaner-a" || earnings.Payout.Amount != 10000 {
+		t.Errorf("first allocation = %d to %s, want the earnings of 10000 to cleaner-a", earnings.Payout.Amount, earnings.Payout.PayeeID)
+	}
+	if !compensation.Compensation || compensation.Payout != compensations[0] {
+		t.Errorf("second allocation is not the compensation payout p1")
+	}
+	if compensation.Payout.Amount != 3000 || len(compensation.ClawbackIDs) != 0 {
+		t.Errorf("compensation = %d with clawbacks %v, want 3000 untouched", compensation.Payout.Amount, compensation.ClawbackIDs)
+	}
+}
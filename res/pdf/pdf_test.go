@@ -0,0 +1,52 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestBytesCrossReferencesEveryObject(t *testing.T) {
+	doc := New()
+	doc.Text(40, 40, 12, Bold, "Statement (September)")
+	doc.AddPage()
+	doc.TextRight(555, 40, 10, Regular, "1.234,50 lei")
+	out := doc.Bytes()
+
+	match := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(out)
+	if match == nil {
+		t.Fatalf("no startxref trailer in %q", out)
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(out[xref:], []byte("xref\n0 9\n")) {
+		t.Fatalf("startxref %d does not point at a table of 9 entries", xref)
+	}
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n \n`).FindAllSubmatch(out[xref:], -1)
+	if len(entries) != 8 {
+		t.Fatalf("got %d objects in the table, want 8", len(entries))
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(out[offset:], []byte(want)) {
+			t.Errorf("object %d offset %d points at %q", i+1, offset, out[offset:offset+10])
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := map[string]string{
+		`a (b) c\d`:       `a \(b\) c\\d`,
+		"Factură fiscală": "Factura fiscala",
+		"Brașov – 10 €":   "Brasov \x96 10 \x80",
+		"Ön":              "\xd6n",
+		"日":               "?",
+	}
+	for in, want := range tests {
+		if got := encode(in); got != want {
+			t.Errorf("encode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package pricing
+
+import (
+	"math"
+
+	"cleanbuddy-api/res/promo"
+	"cleanbuddy-api/res/store"
+)
+
+// HourlyEngine prices the service as hourly rate × base hours × the service's
+// price multiplier and adds fixed-price add-ons and the travel fee. The platform
+// fee is charged on top of that subtotal and the commission is kept from it;
+// the cleaner is paid the rest. A promo code discount comes off the total; when
+// a company funds it, it also comes off the cleaner payout.
+type HourlyEngine struct{}
+
+func (e HourlyEngine) Price(inputs Inputs) *Quote {
+	quote := &Quote{
+		HourlyRate:         inputs.HourlyRate,
+		BaseHours:          inputs.Service.BaseHours,
+		PriceMultiplier:    inputs.Service.PriceMultiplier,
+		AddOns:             []*AddOnPrice{},
+		TravelFee:          inputs.TravelFee,
+		PlatformFeePercent: inputs.Terms.PlatformFeePercent,
+		CommissionPercent:  inputs.Terms.CommissionPercent,
+	}
+
+	quote.ServicePrice = roundBani(float64(inputs.HourlyRate) * inputs.Service.BaseHours * inputs.Service.PriceMultiplier)
+	quote.EstimatedDuration = inputs.Service.BaseHours
+
+	for _, def := range inputs.AddOns {
+		quote.AddOns = append(quote.AddOns, &AddOnPrice{
+			AddOn: def.AddOn,
+			Name:  def.Name,
+			Price: def.FixedPrice,
+			Hours: def.EstimatedHours,
+		})
+		quote.AddOnsPrice += def.FixedPrice
+		quote.EstimatedDuration += def.EstimatedHours
+	}
+
+	quote.Subtotal = quote.ServicePrice + quote.AddOnsPrice + quote.TravelFee
+	quote.PlatformFee = roundBani(float64(quote.Subtotal) * quote.PlatformFeePercent / 100.0)
+	quote.TotalPrice = quote.Subtotal + quote.PlatformFee
+	quote.PlatformCommission = roundBani(float64(quote.Subtotal) * quote.CommissionPercent / 100.0)
+	quote.CleanerPayout = quote.Subtotal - quote.PlatformCommission
+
+	if inputs.Promo != nil {
+		fundedBy := inputs.Promo.FundedBy
+		quote.PromoCodeID = &inputs.Promo.ID
+		quote.DiscountFundedBy = &fundedBy
+		quote.Discount = promo.Discount(inputs.Promo, quote.TotalPrice, quote.CleanerPayout)
+		quote.TotalPrice -= quote.Discount
+		if fundedBy == store.PromoFunderCompany {
+			quote.CleanerPayout -= quote.Discount
+		}
+	}
+
+	return quote
+}
+
+func roundBani(amount float64) int {
+	return int(math.Round(amount))
+}
@@ -0,0 +1,111 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+
+	"cleanbuddy-api/res/commission"
+	"cleanbuddy-api/res/store"
+)
+
+var (
+	ErrCleanerNotFound   = errors.New("cleaner profile not found")
+	ErrCleanerInactive   = errors.New("cleaner is not currently accepting bookings")
+	ErrServiceNotFound   = errors.New("service definition not found")
+	ErrServiceInactive   = errors.New("service is not currently available")
+	ErrAddressNotFound   = errors.New("address not found")
+	ErrAddressNotOwned   = errors.New("address does not belong to the customer")
+	ErrDuplicateAddOn    = errors.New("add-ons may only be requested once")
+	ErrHourlyRateNotSet  = errors.New("cleaner has not set an hourly rate")
+	ErrLocationNotServed = errors.New("cleaner does not serve the address location")
+)
+
+// PricingService prices bookings. It gathers everything a price depends on
+// (hourly rate, service definition, add-ons, travel fee) and hands it to an
+// Engine, so the price quoted to the customer is the price stored on the booking.
+type PricingService interface {
+	// Quote prices a prospective booking at one of the customer's own addresses
+	Quote(ctx context.Context, request QuoteRequest) (*Quote, error)
+
+	// Reprice prices an existing booking from the current service and add-on
+	// definitions, keeping the hourly rate, travel fee and commission terms it was booked with
+	Reprice(ctx context.Context, booking *store.Booking) (*Quote, error)
+
+	// HourlyRate returns the cleaner's own rate, falling back to their company's rate
+	HourlyRate(ctx context.Context, profile *store.CleanerProfile) (int, error)
+}
+
+// Engine computes an itemized quote from resolved inputs without touching the store
+type Engine interface {
+	Price(inputs Inputs) *Quote
+}
+
+// QuoteRequest describes a prospective booking
+type QuoteRequest struct {
+	CleanerProfileID string
+	ServiceType      store.ServiceType
+	AddOns           []store.ServiceAddOn
+	AddressID        string
+	CustomerID       string
+	PromoCode        string // Optional code to discount the booking with
+}
+
+// Inputs are the resolved values a price is computed from
+type Inputs struct {
+	HourlyRate int // in bani
+	Service    *store.ServiceDefinition
+	AddOns     []*store.ServiceAddOnDefinition
+	TravelFee  int // in bani
+	Terms      commission.Terms
+	Promo      *store.PromoCode // Validated code to discount with, if any
+}
+
+// Quote is an itemized price; amounts are in bani
+type Quote struct {
+	HourlyRate         int
+	BaseHours          float64
+	PriceMultiplier    float64
+	ServicePrice       int
+	AddOns             []*AddOnPrice
+	AddOnsPrice        int
+	TravelFee          int
+	Subtotal           int
+	PlatformFeePercent float64
+	PlatformFee        int // Charged to the customer on top of the subtotal
+	TotalPrice         int
+	CommissionPercent  float64
+	PlatformCommission int // Kept from the subtotal before paying the cleaner
+	CleanerPayout      int
+	PromoCodeID        *string
+	Discount           int                // Taken off the total the customer pays
+	DiscountFundedBy   *store.PromoFunder // Company-funded discounts are taken off the cleaner payout
+	EstimatedDuration  float64            // in hours
+}
+
+// AddOnPrice is the line item of one add-on
+type AddOnPrice struct {
+	AddOn store.ServiceAddOn
+	Name  string
+	Price int
+	Hours float64
+}
+
+// ApplyTo copies the price, commission terms, discount and duration onto a booking
+func (q *Quote) ApplyTo(booking *store.Booking) {
+	platformFeePercent := q.PlatformFeePercent
+
+	booking.CleanerHourlyRate = q.HourlyRate
+	booking.ServicePrice = q.ServicePrice
+	booking.AddOnsPrice = q.AddOnsPrice
+	booking.TravelFee = q.TravelFee
+	booking.PlatformFee = q.PlatformFee
+	booking.TotalPrice = q.TotalPrice
+	booking.CleanerPayout = q.CleanerPayout
+	booking.PlatformFeePercent = &platformFeePercent
+	booking.CommissionPercent = q.CommissionPercent
+	booking.PlatformCommission = q.PlatformCommission
+	booking.PromoCodeID = q.PromoCodeID
+	booking.DiscountAmount = q.Discount
+	booking.DiscountFundedBy = q.DiscountFundedBy
+	booking.Duration = q.EstimatedDuration
+}
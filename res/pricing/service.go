@@ -0,0 +1,234 @@
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"cleanbuddy-api/res/commission"
+	"cleanbuddy-api/res/promo"
+	"cleanbuddy-api/res/store"
+)
+
+type service struct {
+	store      store.Store
+	engine     Engine
+	commission commission.CommissionService
+	promo      promo.PromoService
+	logger     *log.Logger
+}
+
+// NewService creates a new PricingService that computes prices with engine
+// under the commission terms in effect when the price is quoted
+func NewService(dataStore store.Store, engine Engine, commissionService commission.CommissionService, promoService promo.PromoService, logger *log.Logger) PricingService {
+	return &service{
+		store:      dataStore,
+		engine:     engine,
+		commission: commissionService,
+		promo:      promoService,
+		logger:     logger,
+	}
+}
+
+func (s *service) Quote(ctx context.Context, request QuoteRequest) (*Quote, error) {
+	requested := make(map[store.ServiceAddOn]bool, len(request.AddOns))
+	for _, addOn := range request.AddOns {
+		if requested[addOn] {
+			return nil, ErrDuplicateAddOn
+		}
+		requested[addOn] = true
+	}
+
+	profile, err := s.store.CleanerProfiles().Get(ctx, request.CleanerProfileID)
+	if err != nil {
+		s.logger.Printf("Failed to get cleaner profile %s: %v", request.CleanerProfileID, err)
+		return nil, ErrCleanerNotFound
+	}
+	if !profile.IsActive {
+		return nil, ErrCleanerInactive
+	}
+
+	hourlyRate, err := s.HourlyRate(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceDefinition, err := s.serviceDefinition(ctx, request.ServiceType)
+	if err != nil {
+		return nil, err
+	}
+
+	addOns, err := s.addOnDefinitions(ctx, request.AddOns)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := s.store.Addresses().Get(ctx, request.AddressID)
+	if err != nil {
+		s.logger.Printf("Failed to get address %s: %v", request.AddressID, err)
+		return nil, ErrAddressNotFound
+	}
+	if address.UserID != request.CustomerID {
+		return nil, ErrAddressNotOwned
+	}
+
+	areas, err := s.store.ServiceAreas().GetByCleanerProfile(ctx, profile.ID)
+	if err != nil {
+		s.logger.Printf("Failed to get service areas for cleaner profile %s: %v", profile.ID, err)
+		return nil, fmt.Errorf("failed to get service areas: %w", err)
+	}
+	area := store.MatchServiceArea(areas, address.City, address.Neighborhood, address.PostalCode)
+	if area == nil {
+		return nil, ErrLocationNotServed
+	}
+	travelFee := area.TravelFee
+
+	now := time.Now()
+	terms, err := s.commission.Terms(ctx, profile, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var promoCode *store.PromoCode
+	if request.PromoCode != "" {
+		promoCode, err = s.promo.Validate(ctx, request.PromoCode, promo.Checkout{
+			CustomerID:  request.CustomerID,
+			ServiceType: request.ServiceType,
+			City:        address.City,
+			CompanyID:   profile.CompanyID,
+			At:          now,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.engine.Price(Inputs{
+		HourlyRate: hourlyRate,
+		Service:    serviceDefinition,
+		AddOns:     addOns,
+		TravelFee:  travelFee,
+		Terms:      terms,
+		Promo:      promoCode,
+	}), nil
+}
+
+func (s *service) Reprice(ctx context.Context, booking *store.Booking) (*Quote, error) {
+	serviceDefinition, err := s.serviceDefinition(ctx, booking.ServiceType)
+	if err != nil {
+		return nil, err
+	}
+
+	var requested []store.ServiceAddOn
+	if booking.ServiceAddOns != "" {
+		if err := json.Unmarshal([]byte(booking.ServiceAddOns), &requested); err != nil {
+			return nil, fmt.Errorf("failed to decode add-ons of booking %s: %w", booking.ID, err)
+		}
+	}
+	addOns, err := s.addOnDefinitions(ctx, requested)
+	if err != nil {
+		return nil, err
+	}
+
+	terms, err := s.bookingTerms(ctx, booking)
+	if err != nil {
+		return nil, err
+	}
+
+	// A redeemed code stays on its booking; it is not checked against its limits again
+	var promoCode *store.PromoCode
+	if booking.PromoCodeID != nil {
+		promoCode, err = s.promo.Get(ctx, *booking.PromoCodeID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.engine.Price(Inputs{
+		HourlyRate: booking.CleanerHourlyRate,
+		Service:    serviceDefinition,
+		AddOns:     addOns,
+		TravelFee:  booking.TravelFee,
+		Terms:      terms,
+		Promo:      promoCode,
+	}), nil
+}
+
+func (s *service) HourlyRate(ctx context.Context, profile *store.CleanerProfile) (int, error) {
+	if profile.HourlyRate != nil {
+		return *profile.HourlyRate, nil
+	}
+
+	if profile.CompanyID != nil {
+		company, err := s.store.Companies().Get(ctx, *profile.CompanyID)
+		if err != nil {
+			s.logger.Printf("Failed to get company %s of cleaner profile %s: %v", *profile.CompanyID, profile.ID, err)
+			return 0, fmt.Errorf("failed to get company: %w", err)
+		}
+		if company.HourlyRate != nil {
+			return *company.HourlyRate, nil
+		}
+	}
+
+	return 0, ErrHourlyRateNotSet
+}
+
+// bookingTerms returns the commission terms snapshotted on a booking, resolving
+// the current terms for bookings priced before terms were snapshotted
+func (s *service) bookingTerms(ctx context.Context, booking *store.Booking) (commission.Terms, error) {
+	if booking.PlatformFeePercent != nil {
+		return commission.Terms{
+			PlatformFeePercent: *booking.PlatformFeePercent,
+			CommissionPercent:  booking.CommissionPercent,
+		}, nil
+	}
+
+	profile, err := s.store.CleanerProfiles().Get(ctx, booking.CleanerProfileID)
+	if err != nil {
+		s.logger.Printf("Failed to get cleaner profile %s: %v", booking.CleanerProfileID, err)
+		return commission.Terms{}, ErrCleanerNotFound
+	}
+	return s.commission.Terms(ctx, profile, time.Now())
+}
+
+func (s *service) serviceDefinition(ctx context.Context, serviceType store.ServiceType) (*store.ServiceDefinition, error) {
+	definition, err := s.store.Services().GetServiceDefinition(ctx, serviceType)
+	if err != nil {
+		s.logger.Printf("Failed to get service definition %s: %v", serviceType, err)
+		return nil, ErrServiceNotFound
+	}
+	if !definition.IsActive {
+		return nil, ErrServiceInactive
+	}
+	return definition, nil
+}
+
+// addOnDefinitions resolves the requested add-ons to their active definitions, in request order.
+// Add-ons without an active definition are not offered and are left out, and repeats are counted once.
+func (s *service) addOnDefinitions(ctx context.Context, requested []store.ServiceAddOn) ([]*store.ServiceAddOnDefinition, error) {
+	if len(requested) == 0 {
+		return nil, nil
+	}
+
+	defs, err := s.store.Services().ListAddOnDefinitions(ctx, true)
+	if err != nil {
+		s.logger.Printf("Failed to list add-on definitions: %v", err)
+		return nil, fmt.Errorf("failed to list add-on definitions: %w", err)
+	}
+
+	byAddOn := make(map[store.ServiceAddOn]*store.ServiceAddOnDefinition, len(defs))
+	for _, def := range defs {
+		byAddOn[def.AddOn] = def
+	}
+
+	var resolved []*store.ServiceAddOnDefinition
+	for _, addOn := range requested {
+		if def, exists := byAddOn[addOn]; exists {
+			resolved = append(resolved, def)
+			delete(byAddOn, addOn)
+		}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,124 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"cleanbuddy-api/res/commission"
+	"cleanbuddy-api/res/store"
+)
+
+// fakeStore serves one active cleaner working in Cluj and one customer address there
+type fakeStore struct {
+	store.Store
+}
+
+func (f *fakeStore) CleanerProfiles() store.CleanerProfileStore { return &fakeProfiles{} }
+func (f *fakeStore) Services() store.ServiceStore               { return &fakeServices{} }
+func (f *fakeStore) Addresses() store.AddressStore              { return &fakeAddresses{} }
+func (f *fakeStore) ServiceAreas() store.ServiceAreaStore       { return &fakeServiceAreas{} }
+
+type fakeProfiles struct {
+	store.CleanerProfileStore
+}
+
+func (f *fakeProfiles) Get(ctx context.Context, id string) (*store.CleanerProfile, error) {
+	rate := 5000
+	return &store.CleanerProfile{ID: id, UserID: "cleaner", IsActive: true, HourlyRate: &rate}, nil
+}
+
+type fakeServices struct {
+	store.ServiceStore
+}
+
+func (f *fakeServices) GetServiceDefinition(ctx context.Context, serviceType store.ServiceType) (*store.ServiceDefinition, error) {
+	return &store.ServiceDefinition{Type: serviceType, BaseHours: 2, PriceMultiplier: 1, IsActive: true}, nil
+}
+
+func (f *fakeServices) ListAddOnDefinitions(ctx context.Context, activeOnly bool) ([]*store.ServiceAddOnDefinition, error) {
+	return []*store.ServiceAddOnDefinition{
+		{AddOn: store.ServiceAddOnOven, FixedPrice: 3000, EstimatedHours: 1},
+		{AddOn: store.ServiceAddOnFridge, FixedPrice: 2000, EstimatedHours: 0.5},
+	}, nil
+}
+
+type fakeAddresses struct {
+	store.AddressStore
+}
+
+func (f *fakeAddresses) Get(ctx context.Context, id string) (*store.Address, error) {
+	return &store.Address{ID: id, UserID: "customer", City: "Cluj-Napoca"}, nil
+}
+
+type fakeServiceAreas struct {
+	store.ServiceAreaStore
+}
+
+func (f *fakeServiceAreas) GetByCleanerProfile(ctx context.Context, cleanerProfileID string) ([]*store.ServiceArea, error) {
+	return []*store.ServiceArea{{City: "Cluj-Napoca"}}, nil
+}
+
+type fakeCommission struct {
+	commission.CommissionService
+}
+
+func (f *fakeCommission) Terms(ctx context.Context, profile *store.CleanerProfile, at time.Time) (commission.Terms, error) {
+	return commission.Terms{PlatformFeePercent: 10, CommissionPercent: 20}, nil
+}
+
+func TestQuoteChecksAddressAndAddOns(t *testing.T) {
+	s := &service{
+		store:      &fakeStore{},
+		engine:     HourlyEngine{},
+		commission: &fakeCommission{},
+		logger:     log.New(io.Discard, "", 0),
+	}
+
+	tests := []struct {
+		name       string
+		customerID string
+		addOns     []store.ServiceAddOn
+		wantErr    error
+		wantAddOns int
+	}{
+		{"own address", "customer", []store.ServiceAddOn{store.ServiceAddOnOven, store.ServiceAddOnFridge}, nil, 5000},
+		{"address of another customer", "someone-else", nil, ErrAddressNotOwned, 0},
+		{"add-on requested twice", "customer", []store.ServiceAddOn{store.ServiceAddOnOven, store.ServiceAddOnFridge, store.ServiceAddOnOven}, ErrDuplicateAddOn, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, err := s.Quote(context.Background(), QuoteRequest{
+				CleanerProfileID: "profile",
+				ServiceType:      store.ServiceTypeGeneral,
+				AddOns:           tt.addOns,
+				AddressID:        "address",
+				CustomerID:       tt.customerID,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Quote() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && quote.AddOnsPrice != tt.wantAddOns {
+				t.Errorf("Quote() add-ons price = %d, want %d", quote.AddOnsPrice, tt.wantAddOns)
+			}
+		})
+	}
+}
+
+func TestAddOnDefinitionsCountRepeatsOnce(t *testing.T) {
+	s := &service{store: &fakeStore{}, logger: log.New(io.Discard, "", 0)}
+
+	// Bookings stored before repeats were rejected are repriced with each add-on once
+	defs, err := s.addOnDefinitions(context.Background(), []store.ServiceAddOn{
+		store.ServiceAddOnOven, store.ServiceAddOnOven, store.ServiceAddOnWindows, store.ServiceAddOnFridge,
+	})
+	if err != nil {
+		t.Fatalf("addOnDefinitions() error = %v", err)
+	}
+	if len(defs) != 2 || defs[0].AddOn != store.ServiceAddOnOven || defs[1].AddOn != store.ServiceAddOnFridge {
+		t.Errorf("addOnDefinitions() resolved %d definitions, want oven then fridge", len(defs))
+	}
+}
@@ -0,0 +1,163 @@
+package promo
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+
+	"cleanbuddy-api/res/store"
+)
+
+// Normalize returns the canonical form codes are stored and looked up in
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Discount returns the amount a code takes off a booking, in bani.
+// Percentages apply to the total the customer would otherwise pay. The discount
+// never exceeds what its funder can give up: the total for platform-funded codes
+// and the cleaner payout for company-funded ones.
+func Discount(promoCode *store.PromoCode, totalPrice, cleanerPayout int) int {
+	var amount int
+	switch promoCode.DiscountType {
+	case store.PromoDiscountTypePercentage:
+		amount = int(math.Round(float64(totalPrice) * float64(promoCode.DiscountValue) / 100.0))
+		if promoCode.MaxDiscount != nil && amount > *promoCode.MaxDiscount {
+			amount = *promoCode.MaxDiscount
+		}
+	case store.PromoDiscountTypeFixed:
+		amount = promoCode.DiscountValue
+	}
+
+	limit := totalPrice
+	if promoCode.FundedBy == store.PromoFunderCompany && cleanerPayout < limit {
+		limit = cleanerPayout
+	}
+	if amount > limit {
+		amount = limit
+	}
+	if amount < 0 {
+		amount = 0
+	}
+	return amount
+}
+
+// Validate checks that a code is well-formed for its discount type and funder
+func Validate(promoCode *store.PromoCode) error {
+	if promoCode.Code == "" || strings.ContainsAny(promoCode.Code, " \t\n") {
+		return ErrInvalidCode
+	}
+
+	switch promoCode.DiscountType {
+	case store.PromoDiscountTypePercentage:
+		if promoCode.DiscountValue <= 0 || promoCode.DiscountValue > 100 {
+			return ErrInvalidCode
+		}
+		if promoCode.MaxDiscount != nil && *promoCode.MaxDiscount <= 0 {
+			return ErrInvalidCode
+		}
+	case store.PromoDiscountTypeFixed:
+		if promoCode.DiscountValue <= 0 || promoCode.MaxDiscount != nil {
+			return ErrInvalidCode
+		}
+	default:
+		return ErrInvalidCode
+	}
+
+	switch promoCode.FundedBy {
+	case store.PromoFunderPlatform:
+		if promoCode.CompanyID != nil {
+			return ErrInvalidCode
+		}
+	case store.PromoFunderCompany:
+		if promoCode.CompanyID == nil {
+			return ErrInvalidCode
+		}
+	default:
+		return ErrInvalidCode
+	}
+
+	if promoCode.MaxRedemptions != nil && *promoCode.MaxRedemptions <= 0 {
+		return ErrInvalidCode
+	}
+	if promoCode.MaxRedemptionsPerUser != nil && *promoCode.MaxRedemptionsPerUser <= 0 {
+		return ErrInvalidCode
+	}
+	if promoCode.ValidFrom != nil && promoCode.ValidUntil != nil && !promoCode.ValidFrom.Before(*promoCode.ValidUntil) {
+		return ErrInvalidCode
+	}
+
+	if _, err := ServiceTypes(promoCode); err != nil {
+		return ErrInvalidCode
+	}
+	if _, err := Cities(promoCode); err != nil {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// ServiceTypes decodes the service types a code is restricted to; empty means all
+func ServiceTypes(promoCode *store.PromoCode) ([]store.ServiceType, error) {
+	serviceTypes := []store.ServiceType{}
+	if promoCode.ServiceTypes == "" {
+		return serviceTypes, nil
+	}
+	if err := json.Unmarshal([]byte(promoCode.ServiceTypes), &serviceTypes); err != nil {
+		return nil, err
+	}
+	return serviceTypes, nil
+}
+
+// Cities decodes the cities a code is restricted to; empty means all
+func Cities(promoCode *store.PromoCode) ([]string, error) {
+	cities := []string{}
+	if promoCode.Cities == "" {
+		return cities, nil
+	}
+	if err := json.Unmarshal([]byte(promoCode.Cities), &cities); err != nil {
+		return nil, err
+	}
+	return cities, nil
+}
+
+// appliesTo reports whether a code's restrictions allow a checkout
+func appliesTo(promoCode *store.PromoCode, checkout Checkout) (bool, error) {
+	serviceTypes, err := ServiceTypes(promoCode)
+	if err != nil {
+		return false, err
+	}
+	if len(serviceTypes) > 0 && !containsServiceType(serviceTypes, checkout.ServiceType) {
+		return false, nil
+	}
+
+	cities, err := Cities(promoCode)
+	if err != nil {
+		return false, err
+	}
+	if len(cities) > 0 && !containsCity(cities, checkout.City) {
+		return false, nil
+	}
+
+	if promoCode.CompanyID != nil && (checkout.CompanyID == nil || *checkout.CompanyID != *promoCode.CompanyID) {
+		return false, nil
+	}
+	return true, nil
+}
+
+func containsServiceType(serviceTypes []store.ServiceType, serviceType store.ServiceType) bool {
+	for _, t := range serviceTypes {
+		if t == serviceType {
+			return true
+		}
+	}
+	return false
+}
+
+func containsCity(cities []string, city string) bool {
+	for _, c := range cities {
+		if strings.EqualFold(strings.TrimSpace(c), strings.TrimSpace(city)) {
+			return true
+		}
+	}
+	return false
+}
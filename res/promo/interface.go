@@ -0,0 +1,56 @@
+package promo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+var (
+	ErrCodeNotFound     = errors.New("promo code not found")
+	ErrCodeExists       = errors.New("promo code already exists")
+	ErrInvalidCode      = errors.New("invalid promo code")
+	ErrCodeInactive     = errors.New("promo code is not active")
+	ErrCodeExpired      = errors.New("promo code is not valid at this time")
+	ErrNotApplicable    = errors.New("promo code does not apply to this booking")
+	ErrFirstBookingOnly = errors.New("promo code is only valid on a first booking")
+	ErrLimitReached     = errors.New("promo code has reached its redemption limit")
+	ErrCompanyNotFound  = errors.New("company not found")
+)
+
+// PromoService manages promo codes and checks whether a checkout may use one.
+// A code's discount is computed by the pricing engine; redeeming it counts the
+// use against the code's limits once the booking is placed.
+type PromoService interface {
+	// Validate looks up a code and checks it against a checkout
+	Validate(ctx context.Context, code string, checkout Checkout) (*store.PromoCode, error)
+
+	// Redeem records the use of a booking's code, priced onto it beforehand
+	Redeem(ctx context.Context, booking *store.Booking) error
+
+	// Release returns the redemption of a booking that could not be placed
+	Release(ctx context.Context, bookingID string) error
+
+	// Get retrieves a single code
+	Get(ctx context.Context, id string) (*store.PromoCode, error)
+
+	// List returns the codes, newest first
+	List(ctx context.Context, activeOnly bool, limit, offset int) ([]*store.PromoCode, error)
+
+	// Create validates and stores a new code
+	Create(ctx context.Context, promoCode *store.PromoCode) (*store.PromoCode, error)
+
+	// Update validates and stores changes to a code
+	Update(ctx context.Context, promoCode *store.PromoCode) (*store.PromoCode, error)
+}
+
+// Checkout describes the booking a code is applied to
+type Checkout struct {
+	CustomerID  string
+	ServiceType store.ServiceType
+	City        string
+	CompanyID   *string // Company of the booked cleaner
+	At          time.Time
+}
@@ -0,0 +1,184 @@
+package promo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/store"
+)
+
+type service struct {
+	store  store.Store
+	logger *log.Logger
+}
+
+// NewService creates a new PromoService
+func NewService(dataStore store.Store, logger *log.Logger) PromoService {
+	return &service{
+		store:  dataStore,
+		logger: logger,
+	}
+}
+
+func (s *service) Validate(ctx context.Context, code string, checkout Checkout) (*store.PromoCode, error) {
+	promoCode, err := s.store.PromoCodes().GetByCode(ctx, Normalize(code))
+	if err != nil {
+		s.logger.Printf("Failed to get promo code %s: %v", Normalize(code), err)
+		return nil, ErrCodeNotFound
+	}
+
+	if !promoCode.IsActive {
+		return nil, ErrCodeInactive
+	}
+	if promoCode.ValidFrom != nil && checkout.At.Before(*promoCode.ValidFrom) {
+		return nil, ErrCodeExpired
+	}
+	if promoCode.ValidUntil != nil && !checkout.At.Before(*promoCode.ValidUntil) {
+		return nil, ErrCodeExpired
+	}
+
+	applies, err := appliesTo(promoCode, checkout)
+	if err != nil {
+		s.logger.Printf("Failed to decode restrictions of promo code %s: %v", promoCode.ID, err)
+		return nil, fmt.Errorf("failed to decode promo code restrictions: %w", err)
+	}
+	if !applies {
+		return nil, ErrNotApplicable
+	}
+
+	// Limits are checked again under lock when the code is redeemed
+	if promoCode.MaxRedemptions != nil && promoCode.RedemptionCount >= *promoCode.MaxRedemptions {
+		return nil, ErrLimitReached
+	}
+	if promoCode.MaxRedemptionsPerUser != nil {
+		used, err := s.store.PromoCodes().CountRedemptionsByUser(ctx, promoCode.ID, checkout.CustomerID)
+		if err != nil {
+			s.logger.Printf("Failed to count redemptions of promo code %s: %v", promoCode.ID, err)
+			return nil, fmt.Errorf("failed to count redemptions: %w", err)
+		}
+		if used >= int64(*promoCode.MaxRedemptionsPerUser) {
+			return nil, ErrLimitReached
+		}
+	}
+
+	if promoCode.FirstBookingOnly {
+		first, err := s.isFirstBooking(ctx, checkout.CustomerID)
+		if err != nil {
+			return nil, err
+		}
+		if !first {
+			return nil, ErrFirstBookingOnly
+		}
+	}
+
+	return promoCode, nil
+}
+
+func (s *service) Redeem(ctx context.Context, booking *store.Booking) error {
+	if booking.PromoCodeID == nil {
+		return nil
+	}
+
+	redemption := &store.PromoRedemption{
+		ID:             uuid.New().String(),
+		PromoCodeID:    *booking.PromoCodeID,
+		UserID:         booking.CustomerID,
+		BookingID:      booking.ID,
+		DiscountAmount: booking.DiscountAmount,
+	}
+	if err := s.store.PromoCodes().Redeem(ctx, redemption); err != nil {
+		if errors.Is(err, store.ErrPromoLimitReached) {
+			return ErrLimitReached
+		}
+		s.logger.Printf("Failed to redeem promo code %s on booking %s: %v", *booking.PromoCodeID, booking.ID, err)
+		return fmt.Errorf("failed to redeem promo code: %w", err)
+	}
+	return nil
+}
+
+func (s *service) Release(ctx context.Context, bookingID string) error {
+	if err := s.store.PromoCodes().ReleaseByBooking(ctx, bookingID); err != nil {
+		s.logger.Printf("Failed to release promo code of booking %s: %v", bookingID, err)
+		return fmt.Errorf("failed to release promo code: %w", err)
+	}
+	return nil
+}
+
+func (s *service) Get(ctx context.Context, id string) (*store.PromoCode, error) {
+	promoCode, err := s.store.PromoCodes().Get(ctx, id)
+	if err != nil {
+		s.logger.Printf("Failed to get promo code %s: %v", id, err)
+		return nil, ErrCodeNotFound
+	}
+	return promoCode, nil
+}
+
+func (s *service) List(ctx context.Context, activeOnly bool, limit, offset int) ([]*store.PromoCode, error) {
+	promoCodes, err := s.store.PromoCodes().List(ctx, activeOnly, limit, offset)
+	if err != nil {
+		s.logger.Printf("Failed to list promo codes: %v", err)
+		return nil, fmt.Errorf("failed to list promo codes: %w", err)
+	}
+	return promoCodes, nil
+}
+
+func (s *service) Create(ctx context.Context, promoCode *store.PromoCode) (*store.PromoCode, error) {
+	promoCode.Code = Normalize(promoCode.Code)
+	if err := s.validate(ctx, promoCode); err != nil {
+		return nil, err
+	}
+
+	promoCode.ID = uuid.New().String()
+	if err := s.store.PromoCodes().Create(ctx, promoCode); err != nil {
+		if errors.Is(err, store.ErrUniqueViolation) {
+			return nil, ErrCodeExists
+		}
+		s.logger.Printf("Failed to create promo code: %v", err)
+		return nil, fmt.Errorf("failed to create promo code: %w", err)
+	}
+	return promoCode, nil
+}
+
+func (s *service) Update(ctx context.Context, promoCode *store.PromoCode) (*store.PromoCode, error) {
+	if err := s.validate(ctx, promoCode); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.PromoCodes().Update(ctx, promoCode); err != nil {
+		s.logger.Printf("Failed to update promo code %s: %v", promoCode.ID, err)
+		return nil, fmt.Errorf("failed to update promo code: %w", err)
+	}
+	return promoCode, nil
+}
+
+func (s *service) validate(ctx context.Context, promoCode *store.PromoCode) error {
+	if err := Validate(promoCode); err != nil {
+		return err
+	}
+	if promoCode.CompanyID != nil {
+		if _, err := s.store.Companies().Get(ctx, *promoCode.CompanyID); err != nil {
+			s.logger.Printf("Failed to get company %s for promo code: %v", *promoCode.CompanyID, err)
+			return ErrCompanyNotFound
+		}
+	}
+	return nil
+}
+
+// isFirstBooking reports whether a customer has no bookings other than cancelled ones
+func (s *service) isFirstBooking(ctx context.Context, customerID string) (bool, error) {
+	bookings, err := s.store.Bookings().GetByCustomer(ctx, customerID, store.BookingFilters{})
+	if err != nil {
+		s.logger.Printf("Failed to get bookings of customer %s: %v", customerID, err)
+		return false, fmt.Errorf("failed to get bookings: %w", err)
+	}
+	for _, booking := range bookings {
+		if booking.Status != store.BookingStatusCancelled {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,39 @@
+package referral
+
+import (
+	"context"
+	"errors"
+
+	"cleanbuddy-api/res/store"
+)
+
+var (
+	ErrCodeNotFound     = errors.New("referral code not found")
+	ErrSelfReferral     = errors.New("referral code belongs to the same user")
+	ErrAlreadyReferred  = errors.New("user has already been referred")
+	ErrCodeNotGenerated = errors.New("failed to generate a unique referral code")
+)
+
+// ReferralService runs the customer referral program. Every user can share a code;
+// customers who sign up with it are attributed to the referrer, and when they
+// complete their first booking both parties are granted account credit.
+type ReferralService interface {
+	// Code returns the user's referral code, creating it on first use
+	Code(ctx context.Context, userID string) (*store.ReferralCode, error)
+
+	// Attribute records that a newly signed-up customer was invited with code
+	Attribute(ctx context.Context, refereeID, code string) (*store.Referral, error)
+
+	// Stats summarizes the referrals made by a user
+	Stats(ctx context.Context, userID string) (*store.ReferralStats, error)
+
+	// Reward credits both parties once a referred customer completes a booking.
+	// Referrals are rewarded once; later bookings are a no-op.
+	Reward(ctx context.Context, booking *store.Booking) error
+}
+
+// Rewards are the credits granted for a completed referral, in bani
+type Rewards struct {
+	ReferrerCredit int
+	RefereeCredit  int
+}
@@ -0,0 +1,182 @@
+package referral
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/bookinglifecycle"
+	"cleanbuddy-api/res/store"
+)
+
+const (
+	codeLength   = 8
+	codeAttempts = 5
+
+	// Letters and digits that cannot be confused with each other when read aloud or copied
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+type service struct {
+	store   store.Store
+	rewards Rewards
+	logger  *log.Logger
+}
+
+// NewService creates a new ReferralService and registers rewards on completed bookings
+func NewService(dataStore store.Store, lifecycle bookinglifecycle.LifecycleService, rewards Rewards, logger *log.Logger) ReferralService {
+	s := &service{
+		store:   dataStore,
+		rewards: rewards,
+		logger:  logger,
+	}
+
+	lifecycle.OnTransition(store.BookingStatusCompleted, s.rewardOnComplete)
+
+	return s
+}
+
+func (s *service) Code(ctx context.Context, userID string) (*store.ReferralCode, error) {
+	for attempt := 0; attempt < codeAttempts; attempt++ {
+		existing, err := s.store.Referrals().GetCodeByUser(ctx, userID)
+		if err != nil {
+			s.logger.Printf("Failed to get referral code of user %s: %v", userID, err)
+			return nil, fmt.Errorf("failed to get referral code: %w", err)
+		}
+		if existing != nil {
+			return existing, nil
+		}
+
+		value, err := generateCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate referral code: %w", err)
+		}
+
+		// A unique violation is either a taken code or a concurrent request creating
+		// this user's code; the next attempt picks up the latter
+		code := &store.ReferralCode{Code: value, UserID: userID}
+		err = s.store.Referrals().CreateCode(ctx, code)
+		if err == nil {
+			return code, nil
+		}
+		if !errors.Is(err, store.ErrUniqueViolation) {
+			s.logger.Printf("Failed to create referral code for user %s: %v", userID, err)
+			return nil, fmt.Errorf("failed to create referral code: %w", err)
+		}
+	}
+
+	s.logger.Printf("Failed to generate a unique referral code for user %s after %d attempts", userID, codeAttempts)
+	return nil, ErrCodeNotGenerated
+}
+
+func (s *service) Attribute(ctx context.Context, refereeID, code string) (*store.Referral, error) {
+	referralCode, err := s.store.Referrals().GetCode(ctx, Normalize(code))
+	if err != nil {
+		s.logger.Printf("Failed to get referral code %s: %v", Normalize(code), err)
+		return nil, ErrCodeNotFound
+	}
+	if referralCode.UserID == refereeID {
+		return nil, ErrSelfReferral
+	}
+
+	referral := &store.Referral{
+		ID:         uuid.New().String(),
+		Code:       referralCode.Code,
+		ReferrerID: referralCode.UserID,
+		RefereeID:  refereeID,
+		Status:     store.ReferralStatusPending,
+	}
+	if err := s.store.Referrals().Create(ctx, referral); err != nil {
+		if errors.Is(err, store.ErrUniqueViolation) {
+			return nil, ErrAlreadyReferred
+		}
+		s.logger.Printf("Failed to attribute user %s to referral code %s: %v", refereeID, referralCode.Code, err)
+		return nil, fmt.Errorf("failed to create referral: %w", err)
+	}
+
+	s.logger.Printf("Attributed user %s to referrer %s", refereeID, referralCode.UserID)
+	return referral, nil
+}
+
+func (s *service) Stats(ctx context.Context, userID string) (*store.ReferralStats, error) {
+	stats, err := s.store.Referrals().Stats(ctx, userID)
+	if err != nil {
+		s.logger.Printf("Failed to get referral stats of user %s: %v", userID, err)
+		return nil, fmt.Errorf("failed to get referral stats: %w", err)
+	}
+	return stats, nil
+}
+
+func (s *service) Reward(ctx context.Context, booking *store.Booking) error {
+	referral, err := s.store.Referrals().GetByReferee(ctx, booking.CustomerID)
+	if err != nil {
+		return fmt.Errorf("failed to get referral: %w", err)
+	}
+	if referral == nil || referral.Status != store.ReferralStatusPending {
+		return nil
+	}
+
+	now := time.Now()
+	referral.BookingID = &booking.ID
+	referral.ReferrerCredit = s.rewards.ReferrerCredit
+	referral.RefereeCredit = s.rewards.RefereeCredit
+	referral.RewardedAt = &now
+
+	var credits []*store.CreditEntry
+	for _, grant := range []struct {
+		userID string
+		amount int
+	}{
+		{referral.ReferrerID, referral.ReferrerCredit},
+		{referral.RefereeID, referral.RefereeCredit},
+	} {
+		if grant.amount <= 0 {
+			continue
+		}
+		credits = append(credits, &store.CreditEntry{
+			ID:         uuid.New().String(),
+			UserID:     grant.userID,
+			Amount:     grant.amount,
+			Reason:     store.CreditReasonReferral,
+			ReferralID: &referral.ID,
+			BookingID:  &booking.ID,
+		})
+	}
+
+	if err := s.store.Referrals().MarkRewarded(ctx, referral, credits); err != nil {
+		if errors.Is(err, store.ErrReferralAlreadyRewarded) {
+			return nil
+		}
+		return fmt.Errorf("failed to reward referral %s: %w", referral.ID, err)
+	}
+
+	s.logger.Printf("Rewarded referral %s: referrer %s credited %d, referee %s credited %d",
+		referral.ID, referral.ReferrerID, referral.ReferrerCredit, referral.RefereeID, referral.RefereeCredit)
+	return nil
+}
+
+func (s *service) rewardOnComplete(ctx context.Context, event bookinglifecycle.TransitionEvent) error {
+	return s.Reward(ctx, event.Booking)
+}
+
+// Normalize returns the canonical form referral codes are stored in
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func generateCode() (string, error) {
+	buf := make([]byte, codeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = codeAlphabet[int(b)%len(codeAlphabet)]
+	}
+	return string(buf), nil
+}
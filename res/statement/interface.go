@@ -0,0 +1,65 @@
+package statement
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidPeriod = errors.New("period must be a month formatted as YYYY-MM that has started")
+)
+
+// StatementService produces cleaners' monthly payout statements. A statement lists every booking
+// and tip paid out to a cleaner in the month, with its gross price, platform fee, travel fee,
+// adjustments and net payout, and is stored as CSV and PDF for download.
+type StatementService interface {
+	// Generate builds the statement of a cleaner for a month, e.g. "2026-09", stores its files
+	// and returns it with signed download URLs
+	Generate(ctx context.Context, cleanerID string, period string) (*Statement, error)
+}
+
+// FileStore keeps generated files and signs their download URLs
+type FileStore interface {
+	UploadGenerated(ctx context.Context, data []byte, contentType string, objectPath string) (string, error)
+	GenerateSignedURL(ctx context.Context, objectPath string, expiration time.Duration) (string, error)
+}
+
+// Statement is what a cleaner was paid out in a month. Amounts are in bani.
+type Statement struct {
+	CleanerID   string
+	CleanerName string
+	Period      string
+	PeriodStart time.Time
+	PeriodEnd   time.Time // exclusive
+
+	Lines  []*Line
+	Totals Line
+
+	// Payouts of any period not transferred yet
+	PendingPayouts int
+
+	CSVURL    string
+	PDFURL    string
+	ExpiresAt time.Time
+}
+
+// Line is a booking, a tip, a clawback, or a correction of a payout, on a statement
+type Line struct {
+	Date        time.Time // booking completed, tip charged, clawback decided, or payout made for corrections
+	BookingID   *string   // nil for corrections of a payout
+	Description string
+	GrossPrice  int // service, add-ons and travel fee the cleaner earns on, or the tip
+	PlatformFee int // commission kept by the platform
+	TravelFee   int // included in the gross price
+	Adjustments int // discounts funded by the cleaner's company, clawbacks and payout corrections
+	NetPayout   int
+}
+
+func (l *Line) add(other *Line) {
+	l.GrossPrice += other.GrossPrice
+	l.PlatformFee += other.PlatformFee
+	l.TravelFee += other.TravelFee
+	l.Adjustments += other.Adjustments
+	l.NetPayout += other.NetPayout
+}
@@ -0,0 +1,128 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+
+	"cleanbuddy-api/res/localtime"
+	"cleanbuddy-api/res/pdf"
+)
+
+var csvHeader = []string{
+	"Date", "Booking", "Description", "Gross price", "Platform fee", "Travel fee", "Adjustments", "Net payout",
+}
+
+// renderCSV writes one row per line and a row of totals, with amounts in RON
+func renderCSV(statement *Statement) ([]byte, error) {
+	var out bytes.Buffer
+	writer := csv.NewWriter(&out)
+
+	rows := [][]string{csvHeader}
+	for _, line := range statement.Lines {
+		bookingID := ""
+		if line.BookingID != nil {
+			bookingID = *line.BookingID
+		}
+		rows = append(rows, append([]string{
+			line.Date.In(localtime.Location()).Format("2006-01-02"), bookingID, line.Description,
+		}, amounts(line)...))
+	}
+	rows = append(rows, append([]string{"", "", "Total"}, amounts(&statement.Totals)...))
+
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Layout of the PDF in points
+const (
+	margin     = 40.0
+	rowHeight  = 14.0
+	tableSize  = 8.0
+	bottomEdge = pdf.PageHeight - margin
+)
+
+// amountColumns are the right edges of the amount columns, in the order of amounts
+var amountColumns = []float64{330, 385, 440, 500, pdf.PageWidth - margin}
+
+// renderPDF lays the statement out as a table that continues over as many pages as it needs
+func renderPDF(statement *Statement) []byte {
+	doc := pdf.New()
+	doc.AddPage()
+
+	doc.Text(margin, 60, 16, pdf.Bold, "Payout statement")
+	doc.Text(margin, 82, 10, pdf.Regular, statement.CleanerName)
+	doc.Text(margin, 96, 10, pdf.Regular, fmt.Sprintf("%s %d", statement.PeriodStart.Month(), statement.PeriodStart.Year()))
+	doc.TextRight(pdf.PageWidth-margin, 96, 10, pdf.Regular, "Amounts in RON")
+
+	y := tableHeader(doc, 130)
+	for _, line := range statement.Lines {
+		if y > bottomEdge {
+			doc.AddPage()
+			y = tableHeader(doc, margin+20)
+		}
+		bookingID := ""
+		if line.BookingID != nil {
+			bookingID = *line.BookingID
+		}
+		if len(bookingID) > 8 {
+			bookingID = bookingID[:8]
+		}
+		doc.Text(margin, y, tableSize, pdf.Regular, line.Date.In(localtime.Location()).Format("02.01.2006"))
+		doc.Text(margin+55, y, tableSize, pdf.Regular, bookingID)
+		doc.Text(margin+105, y, tableSize, pdf.Regular, line.Description)
+		amountCells(doc, y, pdf.Regular, line)
+		y += rowHeight
+	}
+
+	if y+3*rowHeight > bottomEdge {
+		doc.AddPage()
+		y = margin + 20
+	}
+	doc.Line(margin, y-rowHeight+4, pdf.PageWidth-margin, y-rowHeight+4)
+	doc.Text(margin+105, y, tableSize, pdf.Bold, "Total")
+	amountCells(doc, y, pdf.Bold, &statement.Totals)
+	y += 2 * rowHeight
+
+	doc.Text(margin, y, 9, pdf.Regular, "Payouts not transferred yet: "+formatBani(statement.PendingPayouts)+" RON")
+	return doc.Bytes()
+}
+
+// tableHeader draws the column titles at y and returns where the first row goes
+func tableHeader(doc *pdf.Document, y float64) float64 {
+	doc.Text(margin, y, tableSize, pdf.Bold, "Date")
+	doc.Text(margin+55, y, tableSize, pdf.Bold, "Booking")
+	doc.Text(margin+105, y, tableSize, pdf.Bold, "Description")
+	for i, title := range csvHeader[3:] {
+		doc.TextRight(amountColumns[i], y, tableSize, pdf.Bold, title)
+	}
+	doc.Line(margin, y+4, pdf.PageWidth-margin, y+4)
+	return y + rowHeight + 2
+}
+
+func amountCells(doc *pdf.Document, y float64, font pdf.Font, line *Line) {
+	for i, amount := range amounts(line) {
+		doc.TextRight(amountColumns[i], y, tableSize, font, amount)
+	}
+}
+
+func amounts(line *Line) []string {
+	return []string{
+		formatBani(line.GrossPrice),
+		formatBani(line.PlatformFee),
+		formatBani(line.TravelFee),
+		formatBani(line.Adjustments),
+		formatBani(line.NetPayout),
+	}
+}
+
+// formatBani writes an amount in bani as RON with two decimals, e.g. "-12.05"
+func formatBani(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
@@ -0,0 +1,263 @@
+package statement
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"cleanbuddy-api/res/localtime"
+	"cleanbuddy-api/res/storage"
+	"cleanbuddy-api/res/store"
+)
+
+// urlExpiry is how long the download URLs of a statement stay valid
+const urlExpiry = 15 * time.Minute
+
+type service struct {
+	store  store.Store
+	files  FileStore
+	logger *log.Logger
+}
+
+// NewService creates a new StatementService
+func NewService(dataStore store.Store, files FileStore, logger *log.Logger) StatementService {
+	return &service{
+		store:  dataStore,
+		files:  files,
+		logger: logger,
+	}
+}
+
+func (s *service) Generate(ctx context.Context, cleanerID string, period string) (*Statement, error) {
+	start, end, err := parsePeriod(period, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	cleaner, err := s.store.Users().Get(ctx, cleanerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cleaner %s: %w", cleanerID, err)
+	}
+
+	// Both bounds of the store are inclusive; a microsecond is the precision of stored times
+	last := end.Add(-time.Microsecond)
+	payoutType, completed := store.TransactionTypePayout, store.TransactionStatusCompleted
+	payouts, err := s.store.Transactions().GetByUser(ctx, cleanerID, store.TransactionFilters{
+		Type:          &payoutType,
+		Status:        &completed,
+		CompletedFrom: &start,
+		CompletedTo:   &last,
+		OrderBy:       "completed_at ASC",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payouts of cleaner %s: %w", cleanerID, err)
+	}
+	earnings, err := s.store.Transactions().GetCleanerEarnings(ctx, cleanerID, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get earnings of cleaner %s: %w", cleanerID, err)
+	}
+
+	// Batch payouts pay the bookings linked to them; compensation payouts carry their booking
+	var batchIDs []string
+	compensated := make(map[string]*store.Booking)
+	for _, payout := range payouts {
+		if payout.BookingID == nil {
+			batchIDs = append(batchIDs, payout.ID)
+			continue
+		}
+		booking, err := s.store.Bookings().Get(ctx, *payout.BookingID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get booking %s: %w", *payout.BookingID, err)
+		}
+		compensated[payout.ID] = booking
+	}
+	batched, err := s.store.Bookings().GetByPayoutTransactions(ctx, batchIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookings paid to cleaner %s: %w", cleanerID, err)
+	}
+	linked, err := s.store.Transactions().GetByPayouts(ctx, batchIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tips and clawbacks of cleaner %s: %w", cleanerID, err)
+	}
+
+	statement := &Statement{
+		CleanerID:      cleanerID,
+		CleanerName:    cleaner.DisplayName,
+		Period:         period,
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		Lines:          statementLines(payouts, batched, linked, compensated),
+		PendingPayouts: earnings.PendingPayouts,
+	}
+	for _, line := range statement.Lines {
+		statement.Totals.add(line)
+	}
+
+	if err := s.save(ctx, statement); err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+// save uploads the files of a statement, replacing those of an earlier run, and signs their URLs
+func (s *service) save(ctx context.Context, statement *Statement) error {
+	csvData, err := renderCSV(statement)
+	if err != nil {
+		return fmt.Errorf("failed to render statement CSV: %w", err)
+	}
+	files := []struct {
+		ext         string
+		contentType string
+		data        []byte
+		url         *string
+	}{
+		{".csv", "text/csv", csvData, &statement.CSVURL},
+		{".pdf", "application/pdf", renderPDF(statement), &statement.PDFURL},
+	}
+
+	for _, file := range files {
+		path := storage.BuildStatementPath(statement.CleanerID, statement.Period, file.ext)
+		object, err := s.files.UploadGenerated(ctx, file.data, file.contentType, path)
+		if err != nil {
+			s.logger.Printf("Failed to upload payout statement %s: %v", path, err)
+			return fmt.Errorf("failed to upload payout statement: %w", err)
+		}
+		url, err := s.files.GenerateSignedURL(ctx, object, urlExpiry)
+		if err != nil {
+			s.logger.Printf("Failed to sign payout statement %s: %v", path, err)
+			return fmt.Errorf("failed to sign payout statement URL: %w", err)
+		}
+		*file.url = url
+	}
+	statement.ExpiresAt = time.Now().Add(urlExpiry)
+	return nil
+}
+
+// parsePeriod returns the bounds of a month in the business timezone that has started by now
+func parsePeriod(period string, now time.Time) (time.Time, time.Time, error) {
+	month, err := time.ParseInLocation("2006-01", period, localtime.Location())
+	if err != nil || month.After(now) {
+		return time.Time{}, time.Time{}, ErrInvalidPeriod
+	}
+	return month, month.AddDate(0, 1, 0), nil
+}
+
+// statementLines lists the bookings and tips the payouts paid for and the clawbacks deducted from
+// them, in the order they happened. A payout that differs from what they add up to gets a
+// correction line for the difference.
+func statementLines(payouts []*store.Transaction, batched []*store.Booking, linked []*store.Transaction, compensated map[string]*store.Booking) []*Line {
+	byPayout := make(map[string][]*store.Booking)
+	for _, booking := range batched {
+		if booking.PayoutTransactionID != nil {
+			byPayout[*booking.PayoutTransactionID] = append(byPayout[*booking.PayoutTransactionID], booking)
+		}
+	}
+	linkedByPayout := make(map[string][]*store.Transaction)
+	for _, transaction := range linked {
+		if transaction.PayoutTransactionID != nil {
+			linkedByPayout[*transaction.PayoutTransactionID] = append(linkedByPayout[*transaction.PayoutTransactionID], transaction)
+		}
+	}
+
+	var lines []*Line
+	for _, payout := range payouts {
+		paidAt := payout.ProcessedAt
+		if payout.CompletedAt != nil {
+			paidAt = *payout.CompletedAt
+		}
+
+		earned := 0
+		if booking, ok := compensated[payout.ID]; ok {
+			line := compensationLine(payout, booking, paidAt)
+			lines = append(lines, line)
+			earned = line.NetPayout
+		}
+		for _, booking := range byPayout[payout.ID] {
+			line := bookingLine(booking)
+			lines = append(lines, line)
+			earned += line.NetPayout
+		}
+		for _, transaction := range linkedByPayout[payout.ID] {
+			line := linkedLine(transaction)
+			lines = append(lines, line)
+			earned += line.NetPayout
+		}
+
+		if difference := payout.NetAmount - earned; difference != 0 {
+			lines = append(lines, &Line{
+				Date:        paidAt,
+				Description: "Payout correction",
+				Adjustments: difference,
+				NetPayout:   difference,
+			})
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Date.Before(lines[j].Date)
+	})
+	return lines
+}
+
+// bookingLine breaks down the payout of a completed booking. What the pricing engine took off the
+// payout besides the commission, a discount funded by the cleaner's company, is an adjustment.
+func bookingLine(booking *store.Booking) *Line {
+	completedAt := booking.UpdatedAt
+	if booking.CompletedAt != nil {
+		completedAt = *booking.CompletedAt
+	}
+	gross := booking.ServicePrice + booking.AddOnsPrice + booking.TravelFee
+	return &Line{
+		Date:        completedAt,
+		BookingID:   &booking.ID,
+		Description: "Completed booking",
+		GrossPrice:  gross,
+		PlatformFee: booking.PlatformCommission,
+		TravelFee:   booking.TravelFee,
+		Adjustments: booking.CleanerPayout - (gross - booking.PlatformCommission),
+		NetPayout:   booking.CleanerPayout,
+	}
+}
+
+// compensationLine breaks down the compensation paid for a customer's no-show, which includes the
+// travel fee
+func compensationLine(payout *store.Transaction, booking *store.Booking, paidAt time.Time) *Line {
+	date := paidAt
+	if booking.NoShowResolvedAt != nil {
+		date = *booking.NoShowResolvedAt
+	}
+	return &Line{
+		Date:        date,
+		BookingID:   &booking.ID,
+		Description: "No-show compensation",
+		GrossPrice:  payout.Amount,
+		PlatformFee: payout.PlatformFee,
+		TravelFee:   min(booking.TravelFee, payout.Amount),
+		Adjustments: payout.NetAmount - (payout.Amount - payout.PlatformFee),
+		NetPayout:   payout.NetAmount,
+	}
+}
+
+// linkedLine is a tip passed on to the cleaner in full, or a clawback taken off their payout
+func linkedLine(transaction *store.Transaction) *Line {
+	date := transaction.ProcessedAt
+	if transaction.CompletedAt != nil {
+		date = *transaction.CompletedAt
+	}
+	line := &Line{
+		Date:        date,
+		BookingID:   transaction.BookingID,
+		Description: "Tip",
+		GrossPrice:  transaction.Amount,
+		NetPayout:   transaction.NetAmount,
+	}
+	if transaction.Type == store.TransactionTypeClawback {
+		line.Description = "Dispute clawback"
+		line.GrossPrice = 0
+		line.Adjustments = -transaction.Amount
+		line.NetPayout = -transaction.Amount
+	}
+	return line
+}
@@ -0,0 +1,100 @@
+package statement
+
+import (
+	"testing"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+func TestStatementLines(t *testing.T) {
+	day := func(d int) *time.Time {
+		at := time.Date(2026, 9, d, 12, 0, 0, 0, time.UTC)
+		return &at
+	}
+	batch, compensation := "payout-1", "payout-2"
+	payouts := []*store.Transaction{
+		// One booking earned 1000 less than paid, e.g. a correction made after the batch was opened
+		{ID: batch, Amount: 16500, NetAmount: 16500, CompletedAt: day(20)},
+		{ID: compensation, BookingID: strPtr("no-show"), Amount: 3000, NetAmount: 3000, CompletedAt: day(15)},
+	}
+	batched := []*store.Booking{
+		{ID: "b1", PayoutTransactionID: &batch, CompletedAt: day(3), ServicePrice: 8000, TravelFee: 2000,
+			PlatformCommission: 1000, CleanerPayout: 9000},
+		// Company funded a discount of 500 off the payout
+		{ID: "b2", PayoutTransactionID: &batch, CompletedAt: day(10), ServicePrice: 6000, AddOnsPrice: 1000,
+			PlatformCommission: 500, CleanerPayout: 6000},
+	}
+	linked := []*store.Transaction{
+		{ID: "tip-1", Type: store.TransactionTypeTip, BookingID: strPtr("b2"), PayoutTransactionID: &batch,
+			Amount: 1500, NetAmount: 1500, CompletedAt: day(11)},
+		{ID: "clawback-1", Type: store.TransactionTypeClawback, BookingID: strPtr("b0"), PayoutTransactionID: &batch,
+			Amount: 1000, NetAmount: 1000, CompletedAt: day(13)},
+	}
+	compensated := map[string]*store.Booking{
+		compensation: {ID: "no-show", TravelFee: 2000, NoShowResolvedAt: day(12)},
+	}
+
+	lines := statementLines(payouts, batched, linked, compensated)
+
+	want := []struct {
+		booking string
+		amounts [5]int // gross, platform fee, travel fee, adjustments, net
+	}{
+		{"b1", [5]int{10000, 1000, 2000, 0, 9000}},
+		{"b2", [5]int{7000, 500, 0, -500, 6000}},
+		{"b2", [5]int{1500, 0, 0, 0, 1500}},
+		{"no-show", [5]int{3000, 0, 2000, 0, 3000}},
+		{"b0", [5]int{0, 0, 0, -1000, -1000}},
+		{"", [5]int{0, 0, 0, 1000, 1000}},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(want))
+	}
+	var total Line
+	for i, line := range lines {
+		total.add(line)
+		booking := ""
+		if line.BookingID != nil {
+			booking = *line.BookingID
+		}
+		amounts := [5]int{line.GrossPrice, line.PlatformFee, line.TravelFee, line.Adjustments, line.NetPayout}
+		if booking != want[i].booking || amounts != want[i].amounts {
+			t.Errorf("line %d = %q %v, want %q %v", i, booking, amounts, want[i].booking, want[i].amounts)
+		}
+	}
+	if total.NetPayout != 19500 {
+		t.Errorf("total net payout = %d, want the 19500 paid out", total.NetPayout)
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	start, end, err := parsePeriod("2026-09", now)
+	if err != nil {
+		t.Fatalf("parsePeriod() error = %v", err)
+	}
+	if start.Month() != time.September || end.Month() != time.October || end.Day() != 1 {
+		t.Errorf("parsePeriod() = %v to %v, want September", start, end)
+	}
+
+	for _, period := range []string{"2026-11", "2026-9", "september"} {
+		if _, _, err := parsePeriod(period, now); err != ErrInvalidPeriod {
+			t.Errorf("parsePeriod(%q) error = %v, want ErrInvalidPeriod", period, err)
+		}
+	}
+}
+
+func TestFormatBani(t *testing.T) {
+	tests := map[int]string{0: "0.00", 5: "0.05", 123456: "1234.56", -1205: "-12.05"}
+	for amount, want := range tests {
+		if got := formatBani(amount); got != want {
+			t.Errorf("formatBani(%d) = %q, want %q", amount, got, want)
+		}
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -150,6 +150,24 @@ func (s *GCSService) UploadFromReader(ctx context.Context, reader io.Reader, fil
 	return fmt.Sprintf("gs://%s/%s", s.bucketName, objectPath), nil
 }
 
+// UploadGenerated uploads a file the application generated itself, such as a statement or an
+// invoice, so its type is not checked against the upload allowlist
+func (s *GCSService) UploadGenerated(ctx context.Context, data []byte, contentType string, objectPath string) (string, error) {
+	writer := s.client.Bucket(s.bucketName).Object(objectPath).NewWriter(ctx)
+	writer.ContentType = contentType
+
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return "", fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to close writer: %w", err)
+	}
+
+	return fmt.Sprintf("gs://%s/%s", s.bucketName, objectPath), nil
+}
+
 // GenerateSignedURL generates a signed URL for accessing a private file
 func (s *GCSService) GenerateSignedURL(ctx context.Context, objectPath string, expiration time.Duration) (string, error) {
 	// Remove gs:// prefix if present
@@ -192,3 +210,18 @@ func BuildApplicationDocumentPath(applicationID, documentType, filename string)
 
 	return fmt.Sprintf("applications/%s/%s-%d%s", applicationID, docType, timestamp, ext)
 }
+
+// BuildStatementPath builds a path for a cleaner's payout statement of a period, e.g. "2026-09"
+func BuildStatementPath(cleanerID, period, ext string) string {
+	return fmt.Sprintf("statements/%s/payout-statement-%s%s", cleanerID, period, ext)
+}
+
+// BuildInvoicePath builds a path for a file of an invoice, e.g. "CB-000042" of the platform
+func BuildInvoicePath(issuerID, documentNumber, ext string) string {
+	return fmt.Sprintf("invoices/%s/%s%s", issuerID, documentNumber, ext)
+}
+
+// BuildDisputeEvidencePath builds a path for a file attached to a dispute as evidence
+func BuildDisputeEvidencePath(disputeID, evidenceID, filename string) string {
+	return fmt.Sprintf("disputes/%s/%s%s", disputeID, evidenceID, strings.ToLower(filepath.Ext(filename)))
+}
@@ -67,11 +67,28 @@ type AvailabilityStore interface {
 	// GetByDateRange retrieves availability entries within a date range
 	GetByDateRange(ctx context.Context, cleanerProfileID string, startDate, endDate time.Time) ([]*Availability, error)
 
+	// GetEffectiveInRange retrieves one-off entries dated within the range and
+	// recurring entries whose recurrence overlaps it (recurring entries are not expanded)
+	GetEffectiveInRange(ctx context.Context, cleanerProfileID string, startDate, endDate time.Time) ([]*Availability, error)
+
+	// CreateMany creates several availability entries atomically
+	CreateMany(ctx context.Context, availabilities []*Availability) error
+
 	// IsCleanerAvailable checks if a cleaner is available at a specific date and time
 	IsCleanerAvailable(ctx context.Context, cleanerProfileID string, date time.Time, startTime, endTime string) (bool, error)
 
-	// GetAvailableCleaners finds cleaners available at a specific date and time
-	GetAvailableCleaners(ctx context.Context, date time.Time, startTime, endTime string, serviceAreaIDs []string) ([]*CleanerProfile, error)
+	// GetAvailableCleaners finds active cleaners serving one of the given areas who have
+	// no overlapping booking or unavailability during the requested time
+	GetAvailableCleaners(ctx context.Context, query AvailableCleanersQuery) ([]*CleanerProfile, error)
+}
+
+// AvailableCleanersQuery describes the time slot and service areas to find free cleaners for
+type AvailableCleanersQuery struct {
+	Start          time.Time     // Start instant of the job
+	Duration       time.Duration // Length of the job
+	Buffer         time.Duration // Gap kept free between the job and the cleaner's other bookings
+	ServiceAreaIDs []string      // Only cleaners serving one of these areas
+	Filters        CleanerProfileFilters
 }
 
 // AvailabilityFilters contains filter options for listing availability
@@ -3,6 +3,8 @@ package store
 import (
 	"context"
 	"time"
+
+	"cleanbuddy-api/res/localtime"
 )
 
 // BookingStatus represents the status of a booking
@@ -28,6 +30,30 @@ const (
 	CancellationReasonOther           CancellationReason = "other"
 )
 
+// NoShowStatus represents the settlement state of a no-show
+type NoShowStatus string
+
+const (
+	NoShowStatusContestable NoShowStatus = "contestable" // Customer can still contest the no-show
+	NoShowStatusContested   NoShowStatus = "contested"   // Customer contested, awaiting admin review
+	NoShowStatusFinal       NoShowStatus = "final"       // Fee charged and cleaner compensated
+	NoShowStatusWaived      NoShowStatus = "waived"      // Contest accepted, no fee charged
+)
+
+// BookingPaymentStatus represents the state of the card payment for a booking
+type BookingPaymentStatus string
+
+const (
+	BookingPaymentStatusPending           BookingPaymentStatus = "pending"            // Waiting for the customer to confirm the card
+	BookingPaymentStatusAuthorized        BookingPaymentStatus = "authorized"         // Amount held on the card
+	BookingPaymentStatusPaid              BookingPaymentStatus = "paid"               // Amount captured
+	BookingPaymentStatusFailed            BookingPaymentStatus = "failed"             // Card declined, the customer can try another
+	BookingPaymentStatusCancelled         BookingPaymentStatus = "cancelled"          // Authorization released without charging
+	BookingPaymentStatusPartiallyRefunded BookingPaymentStatus = "partially_refunded" // Part of the captured amount returned
+	BookingPaymentStatusRefunded          BookingPaymentStatus = "refunded"           // Captured amount returned in full
+	BookingPaymentStatusDisputed          BookingPaymentStatus = "disputed"           // Customer disputed the charge with their bank
+)
+
 // Booking represents a service booking
 type Booking struct {
 	ID               string          `gorm:"primaryKey;size:50;unique"`
@@ -44,9 +70,10 @@ type Booking struct {
 	ServiceAddOns    string           `gorm:"type:text"` // JSON array of ServiceAddOn values
 
 	// Scheduling
-	ScheduledDate time.Time `gorm:"not null;index:idx_booking_date"`
-	ScheduledTime string    `gorm:"size:10;not null"` // e.g., "14:00"
-	Duration      float64   `gorm:"not null"`         // Duration in hours
+	ScheduledStart time.Time `gorm:"index:idx_booking_start"`         // Start instant, the source of truth for when the booking takes place
+	ScheduledDate  time.Time `gorm:"not null;index:idx_booking_date"` // Local calendar day of ScheduledStart, stored as UTC midnight
+	ScheduledTime  string    `gorm:"size:10;not null"`                // Local time of day of ScheduledStart in the business timezone, e.g., "14:00"
+	Duration       float64   `gorm:"not null"`                        // Duration in hours
 
 	// Address
 	Address   *Address `gorm:"foreignKey:AddressID"`
@@ -61,6 +88,29 @@ type Booking struct {
 	TotalPrice         int   `gorm:"not null"` // Total price charged to customer in bani
 	CleanerPayout      int   `gorm:"not null"` // Amount cleaner receives in bani
 
+	// Commission terms snapshotted when the booking was priced
+	PlatformFeePercent *float64 // nil for bookings priced before commission rules existed
+	CommissionPercent  float64 `gorm:"not null;default:0"`
+	PlatformCommission int     `gorm:"not null;default:0"` // Kept from the cleaner payout in bani
+
+	// Promo code discount, deducted from TotalPrice
+	PromoCode        *PromoCode   `gorm:"foreignKey:PromoCodeID"`
+	PromoCodeID      *string      `gorm:"size:50;index:idx_booking_promo_code"`
+	DiscountAmount   int          `gorm:"not null;default:0"` // in bani
+	DiscountFundedBy *PromoFunder `gorm:"size:20"`
+
+	// Account credit spent on the booking, deducted from TotalPrice
+	CreditApplied int `gorm:"not null;default:0"` // in bani
+
+	// Gift card balance spent on the booking, deducted from TotalPrice
+	GiftCardApplied int `gorm:"not null;default:0"` // in bani
+
+	// Card payment of TotalPrice, driven by the payment provider; nil when nothing is charged to a card
+	PaymentStatus *BookingPaymentStatus `gorm:"size:20;index:idx_booking_payment_status"`
+
+	// Payout transaction that paid the cleaner their CleanerPayout; nil until taken into a payout batch
+	PayoutTransactionID *string `gorm:"size:50;index:idx_booking_payout_transaction"`
+
 	// Status and Progress
 	Status             BookingStatus       `gorm:"size:20;not null;default:'pending';index:idx_booking_status"`
 	CancellationReason *CancellationReason `gorm:"size:30"`
@@ -69,6 +119,15 @@ type Booking struct {
 	CancelledByID      *string             `gorm:"size:50"`
 	CancelledAt        *time.Time
 
+	// No-Show Settlement (set when the cleaner marks the customer as absent)
+	NoShowStatus          *NoShowStatus `gorm:"size:20;index:idx_booking_no_show"`
+	NoShowFee             int           `gorm:"not null;default:0"` // Fee charged to the customer in bani (includes travel fee)
+	NoShowCleanerPayout   int           `gorm:"not null;default:0"` // Travel fee plus share of the fee in bani
+	NoShowContestDeadline *time.Time
+	NoShowContestedAt     *time.Time
+	NoShowContestReason   string `gorm:"type:text"`
+	NoShowResolvedAt      *time.Time
+
 	// Timestamps
 	ConfirmedAt      *time.Time
 	StartedAt        *time.Time // When cleaner marks as in progress
@@ -78,6 +137,8 @@ type Booking struct {
 	IsRecurring      bool    `gorm:"not null;default:false"`
 	ParentBookingID  *string `gorm:"size:50;index:idx_booking_parent"` // References parent for recurring bookings
 	NextBookingID    *string `gorm:"size:50"` // References next booking in series
+	RecurrenceIndex  int     `gorm:"not null;default:0"` // Position of the occurrence in its series; the parent is 0
+	RecurrenceEndedAt *time.Time // Set on the parent when the whole series is cancelled
 
 	// Special Instructions
 	CustomerNotes string `gorm:"type:text"` // Customer's notes for the cleaner
@@ -87,6 +148,41 @@ type Booking struct {
 	UpdatedAt time.Time `gorm:"autoUpdateTime;not null"`
 }
 
+// StartsAt returns the start instant of the booking
+func (b *Booking) StartsAt() time.Time {
+	return b.ScheduledStart
+}
+
+// ScheduleAt starts the booking at the wall-clock time of day on the calendar day
+// of date in the business timezone. Times skipped by a daylight saving change
+// return localtime.ErrNonexistentClock.
+func (b *Booking) ScheduleAt(date time.Time, timeOfDay string) error {
+	start, err := localtime.ParseAt(date, timeOfDay)
+	if err != nil {
+		return err
+	}
+	b.SetStart(start)
+	return nil
+}
+
+// SetStart sets the start instant and derives the local date and time of day from it
+func (b *Booking) SetStart(start time.Time) {
+	b.ScheduledStart = start
+	b.ScheduledDate, b.ScheduledTime = b.LocalSchedule()
+}
+
+// LocalSchedule returns the calendar day (as UTC midnight) and time of day
+// on which the booking starts in the business timezone
+func (b *Booking) LocalSchedule() (time.Time, string) {
+	local := b.ScheduledStart.In(localtime.Location())
+	return localtime.Date(local), localtime.ClockOf(local).String()
+}
+
+// EndsAt returns the end instant of the booking
+func (b *Booking) EndsAt() time.Time {
+	return b.StartsAt().Add(time.Duration(b.Duration * float64(time.Hour)))
+}
+
 // BookingStore defines the data access interface for bookings
 type BookingStore interface {
 	// Create creates a new booking
@@ -98,6 +194,10 @@ type BookingStore interface {
 	// Update updates a booking
 	Update(ctx context.Context, booking *Booking) error
 
+	// UpdateMany updates several bookings in one transaction. Slots are checked against
+	// the new times of all of them, so bookings of one cleaner can move past each other.
+	UpdateMany(ctx context.Context, bookings []*Booking) error
+
 	// Delete deletes a booking (soft delete recommended)
 	Delete(ctx context.Context, id string) error
 
@@ -110,9 +210,17 @@ type BookingStore interface {
 	// UpdateStatus updates the status of a booking
 	UpdateStatus(ctx context.Context, bookingID string, status BookingStatus) error
 
+	// UpdatePaymentStatus updates the payment status of a booking
+	UpdatePaymentStatus(ctx context.Context, bookingID string, status BookingPaymentStatus) error
+
 	// CancelBooking cancels a booking with reason
 	CancelBooking(ctx context.Context, bookingID string, cancelledBy string, reason CancellationReason, note string) error
 
+	// ApplyTransition atomically moves a booking from fromStatus to booking.Status,
+	// persists the lifecycle fields and records the history entry.
+	// Returns ErrBookingStatusConflict if the booking is no longer in fromStatus.
+	ApplyTransition(ctx context.Context, booking *Booking, fromStatus BookingStatus, entry *BookingStatusHistory) error
+
 	// GetUpcoming retrieves upcoming bookings for a user (customer or cleaner)
 	GetUpcoming(ctx context.Context, userID string, limit int) ([]*Booking, error)
 
@@ -121,6 +229,30 @@ type BookingStore interface {
 
 	// ListAll retrieves all bookings with filters (for admin)
 	ListAll(ctx context.Context, filters BookingFilters) ([]*Booking, error)
+
+	// GetSeries retrieves the parent booking and all its occurrences ordered by scheduled date
+	GetSeries(ctx context.Context, parentBookingID string) ([]*Booking, error)
+
+	// UpdateNoShow atomically moves a no-show from one of fromStatuses to booking.NoShowStatus,
+	// persists the no-show fields and creates the settlement transactions.
+	// Returns ErrBookingStatusConflict if the no-show is no longer in one of fromStatuses.
+	UpdateNoShow(ctx context.Context, booking *Booking, fromStatuses []NoShowStatus, transactions []*Transaction) error
+
+	// GetNoShowsPastContestDeadline retrieves uncontested no-shows whose contest window closed before the given time
+	GetNoShowsPastContestDeadline(ctx context.Context, before time.Time) ([]*Booking, error)
+
+	// ListActiveSeriesParents retrieves parent bookings of recurring series that have not been ended
+	ListActiveSeriesParents(ctx context.Context) ([]*Booking, error)
+
+	// GetDueForPayout retrieves completed bookings in [completedFrom, completedBefore) whose cleaner
+	// payout has not been taken into a payout batch and whose card payment, if any, was captured.
+	// Bookings with an unresolved dispute are held back; earlier ones whose dispute was resolved
+	// since are included.
+	GetDueForPayout(ctx context.Context, completedFrom, completedBefore time.Time) ([]*Booking, error)
+
+	// GetByPayoutTransactions retrieves the bookings whose cleaner payout was paid by one of the
+	// given payout transactions, ordered by completion
+	GetByPayoutTransactions(ctx context.Context, payoutIDs []string) ([]*Booking, error)
 }
 
 // BookingFilters contains filter options for listing bookings
@@ -134,5 +266,5 @@ type BookingFilters struct {
 	IsRecurring   *bool
 	Limit         int
 	Offset        int
-	OrderBy       string // e.g., "scheduled_date DESC"
+	OrderBy       string // e.g., "scheduled_start DESC"
 }
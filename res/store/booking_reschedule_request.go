@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// RescheduleRequestStatus represents the state of a reschedule proposal
+type RescheduleRequestStatus string
+
+const (
+	RescheduleRequestStatusPending   RescheduleRequestStatus = "pending"   // Awaiting a response from the other party
+	RescheduleRequestStatusAccepted  RescheduleRequestStatus = "accepted"  // A proposed slot was accepted and applied
+	RescheduleRequestStatusDeclined  RescheduleRequestStatus = "declined"  // The other party declined all slots
+	RescheduleRequestStatusWithdrawn RescheduleRequestStatus = "withdrawn" // The proposer withdrew the request
+	RescheduleRequestStatusExpired   RescheduleRequestStatus = "expired"   // Nobody responded before ExpiresAt
+)
+
+// RescheduleSlot is a proposed alternative date and time for a booking
+type RescheduleSlot struct {
+	Date time.Time `json:"date"`
+	Time string    `json:"time"` // e.g., "14:00"
+}
+
+// BookingRescheduleRequest is a proposal by the customer or cleaner to move a booking
+type BookingRescheduleRequest struct {
+	ID        string   `gorm:"primaryKey;size:50;unique"`
+	Booking   *Booking `gorm:"foreignKey:BookingID"`
+	BookingID string   `gorm:"size:50;not null;index:idx_reschedule_request_booking"`
+
+	// Proposer
+	ProposedBy   *User            `gorm:"foreignKey:ProposedByID"`
+	ProposedByID string           `gorm:"size:50;not null"`
+	ProposerRole BookingActorRole `gorm:"size:20;not null"`
+
+	Status RescheduleRequestStatus `gorm:"size:20;not null;default:'pending';index:idx_reschedule_request_status"`
+
+	// Slots
+	ProposedSlots string    `gorm:"type:text;not null"` // JSON array of RescheduleSlot values
+	OriginalDate  time.Time `gorm:"not null"`           // Booking date when the request was made
+	OriginalTime  string    `gorm:"size:10;not null"`
+	AcceptedDate  *time.Time
+	AcceptedTime  *string `gorm:"size:10"`
+
+	// Messages
+	Message      string `gorm:"type:text"` // Proposer's explanation
+	ResponseNote string `gorm:"type:text"` // Responder's note
+
+	// Response
+	RespondedBy   *User   `gorm:"foreignKey:RespondedByID"`
+	RespondedByID *string `gorm:"size:50"`
+	RespondedAt   *time.Time
+	ExpiresAt     time.Time `gorm:"not null;index:idx_reschedule_request_expires"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;not null"`
+}
+
+// BookingRescheduleRequestStore defines the data access interface for reschedule requests
+type BookingRescheduleRequestStore interface {
+	// Create creates a new reschedule request
+	Create(ctx context.Context, request *BookingRescheduleRequest) error
+
+	// Get retrieves a reschedule request by ID
+	Get(ctx context.Context, id string) (*BookingRescheduleRequest, error)
+
+	// Update updates a reschedule request
+	Update(ctx context.Context, request *BookingRescheduleRequest) error
+
+	// Resolve moves a pending request to request.Status and persists the response.
+	// Returns ErrRescheduleRequestNotPending if the request was already resolved.
+	Resolve(ctx context.Context, request *BookingRescheduleRequest) error
+
+	// Accept resolves a pending request, moves the booking and records the history entry in one transaction.
+	// Returns ErrRescheduleRequestNotPending if the request was already resolved
+	// and ErrSlotUnavailable if the cleaner is not free at the new time.
+	Accept(ctx context.Context, request *BookingRescheduleRequest, booking *Booking, entry *BookingStatusHistory) error
+
+	// GetByBooking retrieves all reschedule requests of a booking, newest first
+	GetByBooking(ctx context.Context, bookingID string) ([]*BookingRescheduleRequest, error)
+}
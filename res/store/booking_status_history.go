@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// BookingActorRole identifies who triggered a booking status change
+type BookingActorRole string
+
+const (
+	BookingActorRoleCustomer BookingActorRole = "customer" // The customer who made the booking
+	BookingActorRoleCleaner  BookingActorRole = "cleaner"  // The cleaner assigned to the booking
+	BookingActorRoleAdmin    BookingActorRole = "admin"    // A global admin acting on the booking
+	BookingActorRoleSystem   BookingActorRole = "system"   // Automated process (no user involved)
+)
+
+// BookingStatusHistory records a single status transition of a booking
+type BookingStatusHistory struct {
+	ID        string   `gorm:"primaryKey;size:50;unique"`
+	Booking   *Booking `gorm:"foreignKey:BookingID"`
+	BookingID string   `gorm:"size:50;not null;index:idx_booking_status_history_booking"`
+
+	// Transition
+	FromStatus *BookingStatus `gorm:"size:20"` // Null for the initial status
+	ToStatus   BookingStatus  `gorm:"size:20;not null"`
+
+	// Actor
+	ChangedBy   *User            `gorm:"foreignKey:ChangedByID"`
+	ChangedByID *string          `gorm:"size:50"` // Null for system transitions
+	ActorRole   BookingActorRole `gorm:"size:20;not null"`
+
+	// Notes
+	Note string `gorm:"type:text"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;index:idx_booking_status_history_created"`
+}
+
+// TableName keeps the history table name singular
+func (BookingStatusHistory) TableName() string {
+	return "booking_status_history"
+}
+
+// BookingStatusHistoryStore defines the data access interface for booking status history
+type BookingStatusHistoryStore interface {
+	// Create records a status change
+	Create(ctx context.Context, entry *BookingStatusHistory) error
+
+	// GetByBooking retrieves the status history of a booking, oldest first
+	GetByBooking(ctx context.Context, bookingID string) ([]*BookingStatusHistory, error)
+}
@@ -36,6 +36,12 @@ type CleanerProfile struct {
 	TotalReviews        int         `gorm:"not null;default:0"`
 	TotalEarnings       int64       `gorm:"not null;default:0"` // Total earnings in bani
 
+	// Pricing
+	HourlyRate *int // Rate in bani per hour; nil uses the company's rate
+
+	// Payouts
+	PayoutAccountID *string `gorm:"size:256"` // Payment provider account earnings are transferred to
+
 	// Availability
 	IsActive         bool `gorm:"not null;default:true"`  // Can receive new bookings
 	IsAvailableToday bool `gorm:"not null;default:false"` // Quick filter for same-day bookings
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// CommissionRuleScope determines which bookings a commission rule applies to
+type CommissionRuleScope string
+
+const (
+	CommissionRuleScopePlatform  CommissionRuleScope = "platform"  // Default terms for every booking
+	CommissionRuleScopeCompany   CommissionRuleScope = "company"   // Overrides the default for a company's cleaners
+	CommissionRuleScopeTier      CommissionRuleScope = "tier"      // Discount on the commission for a cleaner tier
+	CommissionRuleScopePromotion CommissionRuleScope = "promotion" // Time-boxed rates, optionally limited to a company or tier
+)
+
+// CommissionRule defines the platform fee charged to customers and the commission kept
+// from cleaner payouts. Rates left nil keep the value of the less specific rules.
+type CommissionRule struct {
+	ID    string              `gorm:"primaryKey;size:50;unique"`
+	Scope CommissionRuleScope `gorm:"size:20;not null;index:idx_commission_rule_scope"`
+	Name  string              `gorm:"size:100;not null"`
+
+	// Targeting
+	Company   *Company     `gorm:"foreignKey:CompanyID"`
+	CompanyID *string      `gorm:"size:50;index:idx_commission_rule_company"`
+	Tier      *CleanerTier `gorm:"size:20"`
+
+	// Rates
+	PlatformFeePercent *float64 // Charged to the customer on top of the subtotal
+	CommissionPercent  *float64 // Kept from the cleaner payout
+	DiscountPercent    float64  `gorm:"not null;default:0"` // Tier rules: percentage points taken off the commission
+
+	// Promotion window
+	ValidFrom  *time.Time
+	ValidUntil *time.Time
+
+	IsActive bool `gorm:"not null;default:true"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;not null"`
+}
+
+// CommissionRuleStore defines the data access interface for commission rules
+type CommissionRuleStore interface {
+	// Create creates a new commission rule
+	Create(ctx context.Context, rule *CommissionRule) error
+
+	// Get retrieves a commission rule by ID
+	Get(ctx context.Context, id string) (*CommissionRule, error)
+
+	// Update updates a commission rule
+	Update(ctx context.Context, rule *CommissionRule) error
+
+	// Delete deletes a commission rule
+	Delete(ctx context.Context, id string) error
+
+	// List retrieves commission rules, optionally of one scope, newest first
+	List(ctx context.Context, scope *CommissionRuleScope) ([]*CommissionRule, error)
+
+	// GetActive retrieves the active rules relevant to a cleaner at a point in time:
+	// platform rules, the company's rules, the tier's rules and promotions running at that time
+	GetActive(ctx context.Context, companyID *string, tier CleanerTier, at time.Time) ([]*CommissionRule, error)
+}
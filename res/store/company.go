@@ -63,6 +63,9 @@ type Company struct {
 	// Message from applicant
 	Message *string `gorm:"type:text"`
 
+	// Pricing
+	HourlyRate *int // Default rate in bani per hour for the company's cleaners
+
 	// Status
 	IsActive bool `gorm:"not null;default:true"`
 
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// CreditReason represents why a customer's credit balance changed
+type CreditReason string
+
+const (
+	CreditReasonReferral   CreditReason = "referral"   // Reward for a referral
+	CreditReasonBooking    CreditReason = "booking"    // Spent on a booking
+	CreditReasonRefund     CreditReason = "refund"     // Returned from a cancelled booking
+	CreditReasonAdjustment CreditReason = "adjustment" // Granted or removed by an admin
+)
+
+// CreditEntry is one change to a customer's credit balance. The balance is the
+// sum of a customer's entries; spending is recorded as a negative amount.
+type CreditEntry struct {
+	ID     string       `gorm:"primaryKey;size:50;unique"`
+	User   *User        `gorm:"foreignKey:UserID"`
+	UserID string       `gorm:"size:50;not null;index:idx_credit_entry_user"`
+	Amount int          `gorm:"not null"` // in bani
+	Reason CreditReason `gorm:"size:20;not null"`
+
+	ReferralID *string `gorm:"size:50"`
+	BookingID  *string `gorm:"size:50;index:idx_credit_entry_booking"`
+	Note       string  `gorm:"type:text"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null"`
+}
+
+// CreditStore defines the data access interface for customer credit balances
+type CreditStore interface {
+	// Balance returns the sum of a user's credit entries
+	Balance(ctx context.Context, userID string) (int, error)
+
+	// ListByUser retrieves a user's entries, newest first
+	ListByUser(ctx context.Context, userID string, limit, offset int) ([]*CreditEntry, error)
+
+	// Add records an entry. Negative entries are checked against the balance under lock
+	// and return ErrInsufficientCredit when they would make it negative.
+	Add(ctx context.Context, entry *CreditEntry) error
+
+	// RefundBooking returns percent of the credit spent on a booking, once, returning the refund
+	// entry or nil when there is nothing to return or it was returned already
+	RefundBooking(ctx context.Context, bookingID string, percent int) (*CreditEntry, error)
+}
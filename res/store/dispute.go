@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// DisputeStatus represents where a customer's dispute of a booking is in its resolution
+type DisputeStatus string
+
+const (
+	DisputeStatusOpen             DisputeStatus = "open"                      // Raised by the customer, not picked up yet
+	DisputeStatusAwaitingCleaner  DisputeStatus = "awaiting_cleaner_response" // Waiting for the cleaner's side of the story
+	DisputeStatusUnderAdminReview DisputeStatus = "under_admin_review"        // Being decided by an admin
+	DisputeStatusResolved         DisputeStatus = "resolved"                  // Decided and its outcome applied
+)
+
+// DisputeReason represents what the customer complains about
+type DisputeReason string
+
+const (
+	DisputeReasonQuality    DisputeReason = "quality"    // Cleaning not up to standard
+	DisputeReasonIncomplete DisputeReason = "incomplete" // Tasks left undone
+	DisputeReasonDamage     DisputeReason = "damage"     // Property damaged or missing
+	DisputeReasonNoShow     DisputeReason = "no_show"    // Cleaner did not come although the booking was completed
+	DisputeReasonConduct    DisputeReason = "conduct"    // Unprofessional behaviour
+	DisputeReasonOther      DisputeReason = "other"
+)
+
+// Dispute is a customer's complaint about a completed booking. While it is not
+// resolved, the cleaner's earnings from the booking are held back from payout batches.
+type Dispute struct {
+	ID         string   `gorm:"primaryKey;size:50;unique"`
+	Booking    *Booking `gorm:"foreignKey:BookingID"`
+	BookingID  string   `gorm:"size:50;not null;index:idx_dispute_booking"`
+	CustomerID string   `gorm:"size:50;not null;index:idx_dispute_customer"`
+	CleanerID  string   `gorm:"size:50;not null;index:idx_dispute_cleaner"`
+
+	Reason      DisputeReason `gorm:"size:20;not null"`
+	Description string        `gorm:"type:text;not null"`
+	Status      DisputeStatus `gorm:"size:30;not null;index:idx_dispute_status"`
+
+	Evidence []*DisputeEvidence `gorm:"foreignKey:DisputeID"`
+
+	// Cleaner's side
+	CleanerResponse string `gorm:"type:text"`
+	RespondedAt     *time.Time
+
+	// Resolution; amounts in bani
+	ResolutionNotes       string  `gorm:"type:text"`
+	RefundAmount          int     `gorm:"not null;default:0"` // Returned to the customer's card
+	RefundTransactionID   *string `gorm:"size:50"`
+	ClawbackAmount        int     `gorm:"not null;default:0"` // Taken back from the cleaner's payouts
+	ClawbackTransactionID *string `gorm:"size:50"`
+	ReviewRemoved         bool    `gorm:"not null;default:false"`
+	ResolvedByID          *string `gorm:"size:50"`
+	ResolvedAt            *time.Time
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;index:idx_dispute_created"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;not null"`
+}
+
+// IsResolved reports whether the dispute was decided
+func (d *Dispute) IsResolved() bool {
+	return d.Status == DisputeStatusResolved
+}
+
+// DisputeEvidence is a photo or document supporting one side of a dispute, kept in storage
+type DisputeEvidence struct {
+	ID           string `gorm:"primaryKey;size:50;unique"`
+	DisputeID    string `gorm:"size:50;not null;index:idx_dispute_evidence_dispute"`
+	UploadedByID string `gorm:"size:50;not null"`
+	FileName     string `gorm:"size:256;not null"`
+	ContentType  string `gorm:"size:100"`
+	StorageURL   string `gorm:"size:512;not null"` // gs:// URL; downloads are signed
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null"`
+}
+
+// DisputeStore defines the data access interface for booking disputes
+type DisputeStore interface {
+	// Create creates a new dispute. Returns ErrDisputeExists if the booking has a dispute that
+	// is not resolved.
+	Create(ctx context.Context, dispute *Dispute) error
+
+	// Get retrieves a dispute by ID with its evidence
+	Get(ctx context.Context, id string) (*Dispute, error)
+
+	// List retrieves disputes with their evidence, newest first
+	List(ctx context.Context, filters DisputeFilters) ([]*Dispute, error)
+
+	// Update saves a dispute if its stored status is one of from. Returns
+	// ErrDisputeStatusConflict if the status was changed concurrently.
+	Update(ctx context.Context, dispute *Dispute, from []DisputeStatus) error
+
+	// AddEvidence attaches evidence to a dispute
+	AddEvidence(ctx context.Context, evidence *DisputeEvidence) error
+
+	// CountEvidence counts the evidence attached to a dispute
+	CountEvidence(ctx context.Context, disputeID string) (int, error)
+}
+
+// DisputeFilters contains filter options for listing disputes
+type DisputeFilters struct {
+	Status     *DisputeStatus
+	BookingID  *string
+	CustomerID *string
+	CleanerID  *string
+	Limit      int
+	Offset     int
+}
@@ -6,6 +6,36 @@ var (
 	ErrUniqueViolation = errors.New("store: duplicate key value violates unique constraint")
 	ErrInvalidInput    = errors.New("store: invalid input")
 
+	// Booking errors
+	ErrBookingStatusConflict = errors.New("store: booking status was changed concurrently")
+	ErrSlotUnavailable       = errors.New("store: cleaner is not available for the requested time slot")
+
+	// Reschedule request errors
+	ErrRescheduleRequestNotPending = errors.New("store: reschedule request is no longer pending")
+
+	// Promo code errors
+	ErrPromoLimitReached = errors.New("store: promo code redemption limit reached")
+
+	// Referral and credit errors
+	ErrReferralAlreadyRewarded = errors.New("store: referral has already been rewarded")
+	ErrInsufficientCredit      = errors.New("store: insufficient credit balance")
+
+	// Gift card errors
+	ErrGiftCardAlreadyRedeemed = errors.New("store: gift card has already been redeemed")
+	ErrGiftCardExpired         = errors.New("store: gift card has expired")
+	ErrGiftCardNotPaid         = errors.New("store: gift card has not been paid for")
+	ErrGiftCardNotPending      = errors.New("store: gift card is not awaiting payment")
+
+	// Payout errors
+	ErrPayoutConflict = errors.New("store: bookings were taken into another payout batch")
+
+	// Dispute errors
+	ErrDisputeExists         = errors.New("store: booking already has an unresolved dispute")
+	ErrDisputeStatusConflict = errors.New("store: dispute status was changed concurrently")
+
+	// Ledger errors
+	ErrUnbalancedEntry = errors.New("store: journal entry debits and credits differ")
+
 	// Invitation code errors
 	ErrInvitationCodeNotFound        = errors.New("store: invitation code not found")
 	ErrInvitationCodeAlreadyRedeemed = errors.New("store: invitation code has already been redeemed")
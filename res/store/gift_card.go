@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// GiftCardStatus represents whether a gift card can still be spent
+type GiftCardStatus string
+
+const (
+	GiftCardStatusPending  GiftCardStatus = "pending"  // Bought, waiting for the purchaser's card payment
+	GiftCardStatusActive   GiftCardStatus = "active"   // Has a balance and has not expired
+	GiftCardStatusDepleted GiftCardStatus = "depleted" // Balance fully spent on bookings
+	GiftCardStatusExpired  GiftCardStatus = "expired"  // Remaining balance forfeited at ExpiresAt
+)
+
+// GiftCard is a fixed-value card bought for someone else. Once redeemed its balance
+// belongs to the recipient and is spent on their bookings, possibly across several.
+type GiftCard struct {
+	ID            string         `gorm:"primaryKey;size:50;unique"`
+	Code          string         `gorm:"size:20;not null;unique"`
+	InitialAmount int            `gorm:"not null"` // Value bought in bani
+	Balance       int            `gorm:"not null"` // Unspent value in bani
+	Status        GiftCardStatus `gorm:"size:20;not null;default:'active';index:idx_gift_card_status"`
+
+	// Purchaser
+	Purchaser   *User  `gorm:"foreignKey:PurchaserID"`
+	PurchaserID string `gorm:"size:50;not null;index:idx_gift_card_purchaser"`
+
+	// Recipient, delivered by email; RecipientID is set when the code is redeemed
+	RecipientEmail string  `gorm:"size:256;not null"`
+	RecipientName  string  `gorm:"size:100"`
+	Message        string  `gorm:"type:text"`
+	Recipient      *User   `gorm:"foreignKey:RecipientID"`
+	RecipientID    *string `gorm:"size:50;index:idx_gift_card_recipient"`
+	RedeemedAt     *time.Time
+
+	ExpiresAt time.Time `gorm:"not null;index:idx_gift_card_expires"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;not null"`
+}
+
+// GiftCardLiability is the value of gift cards sold but not yet spent or expired
+type GiftCardLiability struct {
+	OutstandingCount  int
+	OutstandingAmount int // in bani
+}
+
+// GiftCardStore defines the data access interface for gift cards.
+// Every change to a balance is recorded as a Transaction linked to the card.
+type GiftCardStore interface {
+	// Create stores a new gift card together with its purchase transaction.
+	// Returns ErrUniqueViolation if the code is taken.
+	Create(ctx context.Context, card *GiftCard, purchase *Transaction) error
+
+	// Activate makes a card that was paid for spendable until expiresAt.
+	// Returns ErrGiftCardNotPending if it is not awaiting payment.
+	Activate(ctx context.Context, id string, expiresAt time.Time) (*GiftCard, error)
+
+	// Get retrieves a gift card by ID
+	Get(ctx context.Context, id string) (*GiftCard, error)
+
+	// GetPurchase retrieves the purchase transaction of a gift card
+	GetPurchase(ctx context.Context, cardID string) (*Transaction, error)
+
+	// GetByCode retrieves a gift card by its code
+	GetByCode(ctx context.Context, code string) (*GiftCard, error)
+
+	// Redeem assigns a paid, unredeemed, unexpired card to a user.
+	// Returns ErrGiftCardNotPaid, ErrGiftCardAlreadyRedeemed or ErrGiftCardExpired otherwise.
+	Redeem(ctx context.Context, code string, userID string, at time.Time) (*GiftCard, error)
+
+	// ListByUser retrieves the cards a user bought or redeemed, newest first
+	ListByUser(ctx context.Context, userID string) ([]*GiftCard, error)
+
+	// Balance returns the unspent value of the active cards a user redeemed
+	Balance(ctx context.Context, userID string, at time.Time) (int, error)
+
+	// Spend takes up to the booking's total from its customer's cards, soonest to expire first,
+	// records a redemption transaction per card and lowers the booking's total in one transaction.
+	// Returns the amount spent.
+	Spend(ctx context.Context, booking *Booking, at time.Time) (int, error)
+
+	// RefundBooking returns percent of the value spent on a booking to the cards it came from,
+	// once, and returns the amount returned. The redemptions are cancelled; what is kept of one is
+	// recorded as a redemption of its own.
+	RefundBooking(ctx context.Context, bookingID string, percent int) (int, error)
+
+	// ExpireDue forfeits the balance of active cards that expired before the given time,
+	// recording an expiry transaction for each, and returns how many cards expired
+	ExpireDue(ctx context.Context, before time.Time) (int, error)
+
+	// ListOutstanding retrieves active cards with a balance, soonest to expire first
+	ListOutstanding(ctx context.Context, at time.Time, limit, offset int) ([]*GiftCard, error)
+
+	// Liability sums the balances of active cards
+	Liability(ctx context.Context, at time.Time) (*GiftCardLiability, error)
+}
@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// InvoiceIssuerPlatform is the IssuerID of invoices the platform issues itself; companies issue
+// under their own ID
+const InvoiceIssuerPlatform = "platform"
+
+// InvoiceKind represents whether a document charges or credits the customer
+type InvoiceKind string
+
+const (
+	InvoiceKindInvoice    InvoiceKind = "invoice"     // Issued when a payment is captured
+	InvoiceKindCreditNote InvoiceKind = "credit_note" // Issued when a refund completes, against an invoice
+)
+
+// VATCategory is the UN/ECE 5305 code of the VAT treatment of an invoice
+type VATCategory string
+
+const (
+	VATCategoryStandard   VATCategory = "S" // Issuer is registered for VAT
+	VATCategoryNotSubject VATCategory = "O" // Issuer is not registered for VAT
+)
+
+// EFacturaStatus represents where an invoice is in its submission to the e-Factura system
+type EFacturaStatus string
+
+const (
+	EFacturaStatusPending  EFacturaStatus = "pending"  // Not uploaded yet
+	EFacturaStatusUploaded EFacturaStatus = "uploaded" // Uploaded, awaiting validation
+	EFacturaStatusAccepted EFacturaStatus = "accepted" // Validated and delivered
+	EFacturaStatusRejected EFacturaStatus = "rejected" // Failed validation; a corrected document must be issued
+)
+
+// InvoiceParty is the seller or buyer of an invoice as it was when the invoice was issued
+type InvoiceParty struct {
+	Name               string `gorm:"size:256;not null"`
+	TaxID              string `gorm:"size:100"` // CUI, prefixed with RO when registered for VAT; empty for consumers
+	RegistrationNumber string `gorm:"size:100"` // Trade register number, e.g. J40/1234/2020
+	Street             string `gorm:"size:256"`
+	City               string `gorm:"size:100"`
+	County             string `gorm:"size:100"` // Romanian: Județ
+	PostalCode         string `gorm:"size:20"`
+	Country            string `gorm:"size:100"`
+}
+
+// Invoice is an invoice or credit note issued to a customer for a card payment or its refund.
+// Each issuer numbers its documents in one gapless sequence per series. Issued documents are
+// never changed, except for their e-Factura submission state.
+type Invoice struct {
+	ID       string      `gorm:"primaryKey;size:50;unique"`
+	Kind     InvoiceKind `gorm:"size:20;not null"`
+	IssuerID string      `gorm:"size:50;not null;uniqueIndex:idx_invoice_number;uniqueIndex:idx_invoice_source"` // InvoiceIssuerPlatform or a company ID
+	Series   string      `gorm:"size:20;not null;uniqueIndex:idx_invoice_number"`
+	Number   int         `gorm:"not null;uniqueIndex:idx_invoice_number"`
+
+	// Payment for invoices, refund for credit notes
+	SourceTransactionID string  `gorm:"size:50;not null;uniqueIndex:idx_invoice_source"`
+	BookingID           *string `gorm:"size:50;index:idx_invoice_booking"`
+	CustomerID          string  `gorm:"size:50;not null;index:idx_invoice_customer"`
+
+	// Invoice a credit note corrects
+	CorrectedInvoice   *Invoice `gorm:"foreignKey:CorrectedInvoiceID"`
+	CorrectedInvoiceID *string  `gorm:"size:50;index:idx_invoice_corrected"`
+
+	Seller InvoiceParty `gorm:"embedded;embeddedPrefix:seller_"`
+	Buyer  InvoiceParty `gorm:"embedded;embeddedPrefix:buyer_"`
+
+	// Amounts in bani; credit notes hold positive amounts that are taken off the invoice
+	Currency       string      `gorm:"size:10;not null;default:'RON'"`
+	VATCategory    VATCategory `gorm:"size:2;not null"`
+	VATRate        float64     `gorm:"not null;default:0"` // Percent; zero when not subject to VAT
+	NetAmount      int         `gorm:"not null"`           // Sum of the lines, before VAT
+	VATAmount      int         `gorm:"not null"`
+	RoundingAmount int         `gorm:"not null;default:0"` // Makes the total match what was paid
+	TotalAmount    int         `gorm:"not null"`           // NetAmount + VATAmount + RoundingAmount
+
+	Lines []*InvoiceLine `gorm:"foreignKey:InvoiceID"`
+
+	IssuedAt time.Time `gorm:"not null;index:idx_invoice_issued"`
+
+	// e-Factura submission
+	EFacturaStatus   EFacturaStatus `gorm:"size:20;not null;default:'pending';index:idx_invoice_efactura"`
+	EFacturaUploadID *string        `gorm:"size:100"`
+	EFacturaError    string         `gorm:"type:text"`
+	SubmittedAt      *time.Time
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;not null"`
+}
+
+// DocumentNumber is the number printed on the document, e.g. "CB-000042"
+func (i *Invoice) DocumentNumber() string {
+	return fmt.Sprintf("%s-%06d", i.Series, i.Number)
+}
+
+// InvoiceLine is an item of an invoice. Discounts are lines with a quantity of -1.
+type InvoiceLine struct {
+	ID        string `gorm:"primaryKey;size:50;unique"`
+	InvoiceID string `gorm:"size:50;not null;index:idx_invoice_line_invoice"`
+	Position  int    `gorm:"not null"`
+
+	Description string `gorm:"size:256;not null"`
+	Quantity    int    `gorm:"not null;default:1"`
+	UnitPrice   int    `gorm:"not null"` // Before VAT, in bani
+	VATIncluded int    `gorm:"not null"` // Gross price the net price was derived from, in bani
+}
+
+// NetAmount returns the line total before VAT
+func (l *InvoiceLine) NetAmount() int {
+	return l.Quantity * l.UnitPrice
+}
+
+// InvoiceSequence holds the last number an issuer gave in a series
+type InvoiceSequence struct {
+	IssuerID   string `gorm:"primaryKey;size:50"`
+	Series     string `gorm:"primaryKey;size:20"`
+	LastNumber int    `gorm:"not null;default:0"`
+}
+
+// BillingDetails are the company details a customer wants on their invoices instead of their name
+type BillingDetails struct {
+	ID     string `gorm:"primaryKey;size:50;unique"`
+	UserID string `gorm:"size:50;not null;unique"`
+
+	InvoiceParty `gorm:"embedded"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;not null"`
+}
+
+// InvoiceFilters contains filter options for listing invoices
+type InvoiceFilters struct {
+	IssuerID   *string
+	CustomerID *string
+	BookingID  *string
+	Kind       *InvoiceKind
+	Limit      int
+	Offset     int
+}
+
+// InvoiceStore defines the data access interface for invoices
+type InvoiceStore interface {
+	// Issue numbers the documents issued for one source transaction in their issuers' series and
+	// stores them with their lines, all or none. Returns false without error if documents were
+	// already issued for the source transaction.
+	Issue(ctx context.Context, invoices []*Invoice) (bool, error)
+
+	// Get retrieves an invoice with its lines
+	Get(ctx context.Context, id string) (*Invoice, error)
+
+	// List retrieves invoices with their lines, newest first
+	List(ctx context.Context, filters InvoiceFilters) ([]*Invoice, error)
+
+	// UpdateSubmission persists the e-Factura submission fields of an invoice
+	UpdateSubmission(ctx context.Context, invoice *Invoice) error
+
+	// GetUnsubmitted retrieves invoices that are pending or awaiting validation, oldest first
+	GetUnsubmitted(ctx context.Context, limit int) ([]*Invoice, error)
+
+	// UninvoicedPayments retrieves captured card payments of bookings without an invoice, oldest first
+	UninvoicedPayments(ctx context.Context, limit int) ([]*Transaction, error)
+
+	// UncreditedRefunds retrieves completed card refunds of invoiced payments without a credit note, oldest first
+	UncreditedRefunds(ctx context.Context, limit int) ([]*Transaction, error)
+
+	// GetBillingDetails retrieves the billing details of a user
+	GetBillingDetails(ctx context.Context, userID string) (*BillingDetails, error)
+
+	// SaveBillingDetails creates or replaces the billing details of a user
+	SaveBillingDetails(ctx context.Context, details *BillingDetails) error
+
+	// DeleteBillingDetails removes the billing details of a user
+	DeleteBillingDetails(ctx context.Context, userID string) error
+}
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// LedgerAccount is an account of the platform's chart of accounts. Accounts held for a
+// party (a customer, cleaner or company) are kept per owner.
+type LedgerAccount string
+
+const (
+	LedgerAccountCash                LedgerAccount = "cash"                 // Funds held at the payment provider and bank
+	LedgerAccountCustomerReceivables LedgerAccount = "customer_receivables" // Owed by customers for their bookings; negative while prepaid
+	LedgerAccountPlatformRevenue     LedgerAccount = "platform_revenue"     // Fees, commission, kept cancellation charges and gift card breakage
+	LedgerAccountCleanerPayables     LedgerAccount = "cleaner_payables"     // Earned by independent cleaners, not yet paid out
+	LedgerAccountCompanyPayables     LedgerAccount = "company_payables"     // Earned by companies' cleaners, not yet paid out
+	LedgerAccountRefunds             LedgerAccount = "refunds"              // Money returned to customers for services already delivered
+	LedgerAccountCustomerCredits     LedgerAccount = "customer_credits"     // Credit customers can spend on bookings
+	LedgerAccountGiftCards           LedgerAccount = "gift_cards"           // Value of gift cards sold and not yet spent
+	LedgerAccountPromotions          LedgerAccount = "promotions"           // Discounts and rewards funded by the platform
+)
+
+// LedgerAccounts is the chart of accounts, in the order reports list them
+var LedgerAccounts = []LedgerAccount{
+	LedgerAccountCash,
+	LedgerAccountCustomerReceivables,
+	LedgerAccountCustomerCredits,
+	LedgerAccountGiftCards,
+	LedgerAccountCleanerPayables,
+	LedgerAccountCompanyPayables,
+	LedgerAccountPlatformRevenue,
+	LedgerAccountRefunds,
+	LedgerAccountPromotions,
+}
+
+// LedgerAccountType represents the kind of an account, which decides its normal side
+type LedgerAccountType string
+
+const (
+	LedgerAccountTypeAsset     LedgerAccountType = "asset"     // Increased by debits
+	LedgerAccountTypeLiability LedgerAccountType = "liability" // Increased by credits
+	LedgerAccountTypeRevenue   LedgerAccountType = "revenue"   // Increased by credits
+	LedgerAccountTypeExpense   LedgerAccountType = "expense"   // Increased by debits
+)
+
+// Type returns the kind of the account
+func (a LedgerAccount) Type() LedgerAccountType {
+	switch a {
+	case LedgerAccountCash, LedgerAccountCustomerReceivables:
+		return LedgerAccountTypeAsset
+	case LedgerAccountPlatformRevenue:
+		return LedgerAccountTypeRevenue
+	case LedgerAccountRefunds, LedgerAccountPromotions:
+		return LedgerAccountTypeExpense
+	}
+	return LedgerAccountTypeLiability
+}
+
+// DebitNormal reports whether debits increase the account
+func (t LedgerAccountType) DebitNormal() bool {
+	return t == LedgerAccountTypeAsset || t == LedgerAccountTypeExpense
+}
+
+// JournalSource represents what a journal entry was posted for
+type JournalSource string
+
+const (
+	JournalSourceTransaction JournalSource = "transaction"          // A completed transaction
+	JournalSourceReversal    JournalSource = "transaction_reversal" // A posted transaction that was cancelled afterwards
+	JournalSourceObligation  JournalSource = "payout_obligation"    // Compensation owed to a cleaner for a booking that did not take place
+	JournalSourceCreditEntry JournalSource = "credit_entry"         // A change to a customer's credit
+	JournalSourceBooking     JournalSource = "booking"              // A booking completed, marked as a no-show or cancelled
+)
+
+// JournalEntry is a balanced set of ledger lines posted together. Entries are never changed
+// or deleted; mistakes and cancellations are corrected by posting another entry. Each source
+// is posted at most once.
+type JournalEntry struct {
+	ID          string        `gorm:"primaryKey;size:50;unique"`
+	SourceType  JournalSource `gorm:"size:30;not null;uniqueIndex:idx_journal_entry_source"`
+	SourceID    string        `gorm:"size:50;not null;uniqueIndex:idx_journal_entry_source"`
+	BookingID   *string       `gorm:"size:50;index:idx_journal_entry_booking"`
+	Description string        `gorm:"type:text"`
+	PostedAt    time.Time     `gorm:"not null;index:idx_journal_entry_posted"` // When the underlying event happened
+
+	Lines []*JournalLine `gorm:"foreignKey:EntryID"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null"`
+}
+
+// JournalLine moves an amount into (debit) or out of (credit) one account. Exactly one of
+// Debit and Credit is set.
+type JournalLine struct {
+	ID      string        `gorm:"primaryKey;size:50;unique"`
+	EntryID string        `gorm:"size:50;not null;index:idx_journal_line_entry"`
+	Account LedgerAccount `gorm:"size:30;not null;index:idx_journal_line_account"`
+	OwnerID string        `gorm:"size:50;not null;default:'';index:idx_journal_line_account"` // Empty for platform accounts
+	Debit   int           `gorm:"not null;default:0"`                                         // in bani
+	Credit  int           `gorm:"not null;default:0"`                                         // in bani
+}
+
+// LedgerBalance is the total posted to an account, for one owner or for all of them
+type LedgerBalance struct {
+	Account LedgerAccount
+	OwnerID string // Empty for platform accounts and totals across owners
+	Debit   int    // in bani
+	Credit  int    // in bani
+}
+
+// Type returns the kind of the account
+func (b *LedgerBalance) Type() LedgerAccountType {
+	return b.Account.Type()
+}
+
+// Balance returns the balance on the account's normal side
+func (b *LedgerBalance) Balance() int {
+	if b.Account.Type().DebitNormal() {
+		return b.Debit - b.Credit
+	}
+	return b.Credit - b.Debit
+}
+
+// LedgerBalanceFilter narrows account balances
+type LedgerBalanceFilter struct {
+	Account *LedgerAccount
+	OwnerID *string
+	Before  *time.Time // Only entries posted before this time
+}
+
+// LedgerStore defines the data access interface for the append-only ledger
+type LedgerStore interface {
+	// Post stores an entry with its lines in one transaction. Returns ErrUnbalancedEntry if
+	// debits and credits differ, and false without error if its source was already posted.
+	Post(ctx context.Context, entry *JournalEntry) (bool, error)
+
+	// GetBySource retrieves the entry posted for a source, with its lines
+	GetBySource(ctx context.Context, source JournalSource, sourceID string) (*JournalEntry, error)
+
+	// BookingBalance returns debits minus credits posted to an account for a booking
+	BookingBalance(ctx context.Context, bookingID string, account LedgerAccount) (int, error)
+
+	// Balances returns the totals per account and owner
+	Balances(ctx context.Context, filter LedgerBalanceFilter) ([]*LedgerBalance, error)
+
+	// TrialBalance returns the totals per account across owners for entries posted before the
+	// given time, or all entries when it is nil
+	TrialBalance(ctx context.Context, before *time.Time) ([]*LedgerBalance, error)
+
+	// UnpostedTransactions retrieves completed transactions without an entry, oldest first
+	UnpostedTransactions(ctx context.Context, limit int) ([]*Transaction, error)
+
+	// UnpostedObligations retrieves compensation payouts of bookings, taken outside payout
+	// batches, that were not recorded as owed yet
+	UnpostedObligations(ctx context.Context, limit int) ([]*Transaction, error)
+
+	// UnreversedTransactions retrieves posted transactions that are no longer completed
+	UnreversedTransactions(ctx context.Context, limit int) ([]*Transaction, error)
+
+	// UnpostedCreditEntries retrieves credit entries without an entry, oldest first
+	UnpostedCreditEntries(ctx context.Context, limit int) ([]*CreditEntry, error)
+
+	// UnpostedBookings retrieves bookings whose outcome is known but not posted: completed
+	// bookings, final no-shows and cancelled bookings with no payment or refund in flight
+	// and nothing left to post before them
+	UnpostedBookings(ctx context.Context, limit int) ([]*Booking, error)
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// PaymentEventStatus represents how far a provider webhook event has been processed
+type PaymentEventStatus string
+
+const (
+	PaymentEventStatusReceived   PaymentEventStatus = "received"   // Stored, not yet processed
+	PaymentEventStatusProcessing PaymentEventStatus = "processing" // Claimed by a delivery
+	PaymentEventStatusProcessed  PaymentEventStatus = "processed"  // Applied to transactions and bookings
+	PaymentEventStatusFailed     PaymentEventStatus = "failed"     // Processing failed, will be retried
+)
+
+// PaymentEvent is a webhook event received from the payment provider, stored raw so it
+// can be replayed. ExternalID is unique, so a redelivered event is recognised.
+type PaymentEvent struct {
+	ID         string             `gorm:"primaryKey;size:50;unique"`
+	ExternalID string             `gorm:"size:256;not null;unique"` // Provider event ID
+	Type       string             `gorm:"size:100;not null;index:idx_payment_event_type"`
+	Payload    string             `gorm:"type:text;not null"` // Raw JSON body as delivered
+	Status     PaymentEventStatus `gorm:"size:20;not null;default:'received';index:idx_payment_event_status"`
+	Attempts   int                `gorm:"not null;default:0"`
+	LastError  string             `gorm:"type:text"`
+
+	ProcessedAt *time.Time
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;not null"`
+}
+
+// PaymentEventStore defines the data access interface for payment webhook events
+type PaymentEventStore interface {
+	// Record stores an event unless one with the same ExternalID exists, and returns the stored event
+	Record(ctx context.Context, event *PaymentEvent) (*PaymentEvent, error)
+
+	// Claim marks an event as processing and counts the attempt. Events that are processed or
+	// being processed by another delivery are not claimed, unless that delivery stalled before
+	// staleBefore. Returns false if the event was not claimed.
+	Claim(ctx context.Context, id string, staleBefore time.Time) (bool, error)
+
+	// MarkProcessed records that an event was applied
+	MarkProcessed(ctx context.Context, id string, at time.Time) error
+
+	// MarkFailed records why processing an event failed so it can be retried
+	MarkFailed(ctx context.Context, id string, reason string) error
+
+	// ListRetryable retrieves failed events and events left unprocessed or stalled since before staleBefore, oldest first
+	ListRetryable(ctx context.Context, staleBefore time.Time, limit int) ([]*PaymentEvent, error)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"cleanbuddy-api/res/localtime"
 	"cleanbuddy-api/res/store"
 
 	"gorm.io/gorm"
@@ -85,15 +86,45 @@ func (as *availabilityStore) GetByDateRange(ctx context.Context, cleanerProfileI
 	return availabilities, nil
 }
 
+func (as *availabilityStore) GetEffectiveInRange(ctx context.Context, cleanerProfileID string, startDate, endDate time.Time) ([]*store.Availability, error) {
+	var availabilities []*store.Availability
+	err := as.db.WithContext(ctx).
+		Where("cleaner_profile_id = ?", cleanerProfileID).
+		Where(`(
+			(is_recurring = ? AND date >= ? AND date <= ?)
+			OR (is_recurring = ? AND date <= ? AND (recurrence_end IS NULL OR recurrence_end >= ?))
+		)`, false, startDate, endDate, true, endDate, startDate).
+		Order("date ASC, start_time ASC").
+		Find(&availabilities).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return availabilities, nil
+}
+
+func (as *availabilityStore) CreateMany(ctx context.Context, availabilities []*store.Availability) error {
+	if len(availabilities) == 0 {
+		return nil
+	}
+
+	result := as.db.WithContext(ctx).Create(availabilities)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != int64(len(availabilities)) {
+		return fmt.Errorf("failed to create availability entries")
+	}
+	return nil
+}
+
 func (as *availabilityStore) IsCleanerAvailable(ctx context.Context, cleanerProfileID string, date time.Time, startTime, endTime string) (bool, error) {
-	// Check for unavailable entries that overlap
+	// Check for unavailable entries (one-off or weekly recurring) that overlap
 	var count int64
-	err := as.db.WithContext(ctx).
+	query := as.db.WithContext(ctx).
 		Model(&store.Availability{}).
-		Where("cleaner_profile_id = ?", cleanerProfileID).
-		Where("type = ?", store.AvailabilityTypeUnavailable).
-		Where("date = ?", date).
-		Where("NOT (end_time <= ? OR start_time >= ?)", startTime, endTime).
+		Where("availabilities.cleaner_profile_id = ?", cleanerProfileID)
+	err := whereUnavailableOverlaps(query, date, startTime, endTime).
 		Count(&count).Error
 
 	if err != nil {
@@ -104,56 +135,90 @@ func (as *availabilityStore) IsCleanerAvailable(ctx context.Context, cleanerProf
 	return count == 0, nil
 }
 
-func (as *availabilityStore) GetAvailableCleaners(ctx context.Context, date time.Time, startTime, endTime string, serviceAreaIDs []string) ([]*store.CleanerProfile, error) {
+func (as *availabilityStore) GetAvailableCleaners(ctx context.Context, query store.AvailableCleanersQuery) ([]*store.CleanerProfile, error) {
 	var profiles []*store.CleanerProfile
 
-	query := as.db.WithContext(ctx).
-		Model(&store.CleanerProfile{}).
-		Where("is_active = ?", true)
+	if len(query.ServiceAreaIDs) == 0 {
+		return profiles, nil
+	}
+
+	start := query.Start
+	end := start.Add(query.Duration)
 
-	// Filter by service areas if provided
-	if len(serviceAreaIDs) > 0 {
-		query = query.Joins("INNER JOIN service_areas ON service_areas.cleaner_profile_id = cleaner_profiles.id").
-			Where("service_areas.id IN ?", serviceAreaIDs)
+	db := as.db.WithContext(ctx).
+		Model(&store.CleanerProfile{}).
+		Where("cleaner_profiles.is_active = ?", true)
+	db = applyCleanerProfileFilters(db, query.Filters)
+
+	// Only cleaners serving the requested location
+	db = db.Where(`EXISTS (
+		SELECT 1 FROM service_areas
+		WHERE service_areas.cleaner_profile_id = cleaner_profiles.id
+		AND service_areas.id IN ?
+	)`, query.ServiceAreaIDs)
+	if len(query.Filters.ServiceAreaIDs) > 0 {
+		db = db.Where(`EXISTS (
+			SELECT 1 FROM service_areas
+			WHERE service_areas.cleaner_profile_id = cleaner_profiles.id
+			AND service_areas.id IN ?
+		)`, query.Filters.ServiceAreaIDs)
 	}
 
 	// Exclude cleaners who have unavailable entries for this time slot
-	query = query.Where(`
-		NOT EXISTS (
-			SELECT 1 FROM availabilities
-			WHERE availabilities.cleaner_profile_id = cleaner_profiles.id
-			AND availabilities.type = ?
-			AND availabilities.date = ?
-			AND NOT (availabilities.end_time <= ? OR availabilities.start_time >= ?)
-		)
-	`, store.AvailabilityTypeUnavailable, date, startTime, endTime)
-
-	// Exclude cleaners who have confirmed bookings at this time
-	query = query.Where(`
+	date, startTime, endTime := wallClockSpan(start, end)
+	unavailable := whereUnavailableOverlaps(
+		as.db.Model(&store.Availability{}).
+			Select("1").
+			Where("availabilities.cleaner_profile_id = cleaner_profiles.id"),
+		date, startTime, endTime)
+	db = db.Where("NOT EXISTS (?)", unavailable)
+
+	// Exclude cleaners who have active bookings too close to this time
+	db = db.Where(`
 		NOT EXISTS (
 			SELECT 1 FROM bookings
 			WHERE bookings.cleaner_id = cleaner_profiles.user_id
-			AND bookings.scheduled_date = ?
 			AND bookings.status IN ?
-			AND NOT (
-				TIME(bookings.scheduled_time) + (bookings.duration || ' hours')::interval <= ?::time
-				OR TIME(bookings.scheduled_time) >= ?::time
-			)
+			AND bookings.scheduled_start < ?
+			AND bookings.scheduled_start + bookings.duration * INTERVAL '1 hour' > ?
 		)
-	`, date, []store.BookingStatus{
-		store.BookingStatusConfirmed,
-		store.BookingStatusInProgress,
-	}, startTime, endTime)
-
-	query = query.Distinct().Order("average_rating DESC")
+	`, activeBookingStatuses, end.Add(query.Buffer), start.Add(-query.Buffer))
 
-	if err := query.Find(&profiles).Error; err != nil {
+	if err := db.Order("cleaner_profiles.average_rating DESC").Find(&profiles).Error; err != nil {
 		return nil, err
 	}
 
 	return profiles, nil
 }
 
+// wallClockSpan converts a time range into the calendar day and wall-clock times
+// availability entries use; ranges running past midnight end at "24:00"
+func wallClockSpan(start, end time.Time) (time.Time, string, string) {
+	start, end = start.In(localtime.Location()), end.In(localtime.Location())
+	date := localtime.Date(start)
+
+	endTime := end.Format("15:04")
+	if !localtime.Date(end).Equal(date) {
+		endTime = "24:00"
+	}
+	return date, start.Format("15:04"), endTime
+}
+
+// whereUnavailableOverlaps restricts an availabilities query to unavailable entries
+// that block the given time range on the date, including weekly recurring entries
+func whereUnavailableOverlaps(query *gorm.DB, date time.Time, startTime, endTime string) *gorm.DB {
+	date = localtime.Date(date)
+	return query.
+		Where("availabilities.type = ?", store.AvailabilityTypeUnavailable).
+		Where(`(availabilities.date = ? OR (
+			availabilities.is_recurring AND availabilities.recurrence_pattern = ?
+			AND EXTRACT(DOW FROM availabilities.date) = ?
+			AND availabilities.date <= ?
+			AND (availabilities.recurrence_end IS NULL OR availabilities.recurrence_end >= ?)
+		))`, date, store.RecurrencePatternWeekly, int(date.Weekday()), date, date).
+		Where("NOT (availabilities.end_time <= ? OR availabilities.start_time >= ?)", startTime, endTime)
+}
+
 // Helper method to apply filters
 func (as *availabilityStore) applyFilters(query *gorm.DB, filters store.AvailabilityFilters) *gorm.DB {
 	if filters.Type != nil {
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"time"
 
+	"cleanbuddy-api/res/localtime"
 	"cleanbuddy-api/res/store"
 )
 
@@ -19,14 +20,24 @@ func NewBookingStore(rootStore *storeImpl) *bookingStore {
 }
 
 func (bs *bookingStore) Create(ctx context.Context, booking *store.Booking) error {
-	result := bs.db.WithContext(ctx).Create(booking)
-	if result.Error != nil {
-		return result.Error
+	if err := normalizeSchedule(booking); err != nil {
+		return err
 	}
-	if result.RowsAffected != 1 {
-		return fmt.Errorf("failed to create booking")
-	}
-	return nil
+
+	return bs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := reserveSlot(tx, booking); err != nil {
+			return err
+		}
+
+		result := tx.Create(booking)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return fmt.Errorf("failed to create booking")
+		}
+		return nil
+	})
 }
 
 func (bs *bookingStore) Get(ctx context.Context, id string) (*store.Booking, error) {
@@ -39,14 +50,44 @@ func (bs *bookingStore) Get(ctx context.Context, id string) (*store.Booking, err
 }
 
 func (bs *bookingStore) Update(ctx context.Context, booking *store.Booking) error {
-	result := bs.db.WithContext(ctx).Save(booking)
-	if result.Error != nil {
-		return result.Error
+	if err := normalizeSchedule(booking); err != nil {
+		return err
 	}
-	if result.RowsAffected != 1 {
-		return fmt.Errorf("booking not found (id: %s)", booking.ID)
+
+	return bs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return updateBooking(tx, booking)
+	})
+}
+
+func (bs *bookingStore) UpdateMany(ctx context.Context, bookings []*store.Booking) error {
+	for _, booking := range bookings {
+		if err := normalizeSchedule(booking); err != nil {
+			return err
+		}
 	}
-	return nil
+
+	return bs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Save every booking before checking slots so none collides with another's old time
+		for _, booking := range bookings {
+			if err := lockCleanerSlots(tx, booking.CleanerID); err != nil {
+				return err
+			}
+			result := tx.Save(booking)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected != 1 {
+				return fmt.Errorf("booking not found (id: %s)", booking.ID)
+			}
+		}
+
+		for _, booking := range bookings {
+			if err := reserveSlot(tx, booking); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (bs *bookingStore) Delete(ctx context.Context, id string) error {
@@ -96,6 +137,20 @@ func (bs *bookingStore) UpdateStatus(ctx context.Context, bookingID string, stat
 	return nil
 }
 
+func (bs *bookingStore) UpdatePaymentStatus(ctx context.Context, bookingID string, status store.BookingPaymentStatus) error {
+	result := bs.db.WithContext(ctx).Model(&store.Booking{}).
+		Where("id = ?", bookingID).
+		Update("payment_status", status)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("booking not found (id: %s)", bookingID)
+	}
+	return nil
+}
+
 func (bs *bookingStore) CancelBooking(ctx context.Context, bookingID string, cancelledBy string, reason store.CancellationReason, note string) error {
 	now := time.Now()
 	updates := map[string]interface{}{
@@ -119,19 +174,57 @@ func (bs *bookingStore) CancelBooking(ctx context.Context, bookingID string, can
 	return nil
 }
 
+func (bs *bookingStore) ApplyTransition(ctx context.Context, booking *store.Booking, fromStatus store.BookingStatus, entry *store.BookingStatusHistory) error {
+	updates := map[string]interface{}{
+		"status":              booking.Status,
+		"confirmed_at":        booking.ConfirmedAt,
+		"started_at":          booking.StartedAt,
+		"completed_at":        booking.CompletedAt,
+		"cancelled_at":        booking.CancelledAt,
+		"cancelled_by_id":     booking.CancelledByID,
+		"cancellation_reason": booking.CancellationReason,
+		"cancellation_note":   booking.CancellationNote,
+		"cleaner_notes":       booking.CleanerNotes,
+
+		// Set when a booking becomes a no-show
+		"no_show_status":           booking.NoShowStatus,
+		"no_show_fee":              booking.NoShowFee,
+		"no_show_cleaner_payout":   booking.NoShowCleanerPayout,
+		"no_show_contest_deadline": booking.NoShowContestDeadline,
+	}
+
+	return bs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Guard on the previous status so concurrent transitions cannot both win
+		result := tx.Model(&store.Booking{}).
+			Where("id = ? AND status = ?", booking.ID, fromStatus).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return store.ErrBookingStatusConflict
+		}
+
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
 func (bs *bookingStore) GetUpcoming(ctx context.Context, userID string, limit int) ([]*store.Booking, error) {
 	now := time.Now()
 	var bookings []*store.Booking
 
 	query := bs.db.WithContext(ctx).
 		Where("(customer_id = ? OR cleaner_id = ?)", userID, userID).
-		Where("scheduled_date >= ?", now).
+		Where("scheduled_start >= ?", now).
 		Where("status IN ?", []store.BookingStatus{
 			store.BookingStatusPending,
 			store.BookingStatusConfirmed,
 			store.BookingStatusInProgress,
 		}).
-		Order("scheduled_date ASC, scheduled_time ASC")
+		Order("scheduled_start ASC")
 
 	if limit > 0 {
 		query = query.Limit(limit)
@@ -148,8 +241,8 @@ func (bs *bookingStore) GetByDateRange(ctx context.Context, cleanerID string, st
 
 	err := bs.db.WithContext(ctx).
 		Where("cleaner_id = ?", cleanerID).
-		Where("scheduled_date >= ? AND scheduled_date <= ?", startDate, endDate).
-		Order("scheduled_date ASC, scheduled_time ASC").
+		Where("scheduled_start >= ? AND scheduled_start < ?", localtime.Day(startDate), localtime.Day(endDate).AddDate(0, 0, 1)).
+		Order("scheduled_start ASC").
 		Find(&bookings).Error
 
 	if err != nil {
@@ -169,6 +262,241 @@ func (bs *bookingStore) ListAll(ctx context.Context, filters store.BookingFilter
 	return bookings, nil
 }
 
+func (bs *bookingStore) GetSeries(ctx context.Context, parentBookingID string) ([]*store.Booking, error) {
+	var bookings []*store.Booking
+
+	err := bs.db.WithContext(ctx).
+		Where("id = ? OR parent_booking_id = ?", parentBookingID, parentBookingID).
+		Order("scheduled_start ASC").
+		Find(&bookings).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+func (bs *bookingStore) ListActiveSeriesParents(ctx context.Context) ([]*store.Booking, error) {
+	var bookings []*store.Booking
+
+	err := bs.db.WithContext(ctx).
+		Where("is_recurring = ? AND parent_booking_id IS NULL AND recurrence_ended_at IS NULL", true).
+		Where("service_frequency <> ?", store.ServiceFrequencyOneTime).
+		Order("created_at ASC").
+		Find(&bookings).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+func (bs *bookingStore) UpdateNoShow(ctx context.Context, booking *store.Booking, fromStatuses []store.NoShowStatus, transactions []*store.Transaction) error {
+	updates := map[string]interface{}{
+		"no_show_status":           booking.NoShowStatus,
+		"no_show_contested_at":     booking.NoShowContestedAt,
+		"no_show_contest_reason":   booking.NoShowContestReason,
+		"no_show_resolved_at":      booking.NoShowResolvedAt,
+		"no_show_contest_deadline": booking.NoShowContestDeadline,
+	}
+
+	return bs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Guard on the previous no-show status so the fee is never settled twice
+		result := tx.Model(&store.Booking{}).
+			Where("id = ? AND status = ? AND no_show_status IN ?", booking.ID, store.BookingStatusNoShow, fromStatuses).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return store.ErrBookingStatusConflict
+		}
+
+		for _, transaction := range transactions {
+			if err := tx.Create(transaction).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (bs *bookingStore) GetNoShowsPastContestDeadline(ctx context.Context, before time.Time) ([]*store.Booking, error) {
+	var bookings []*store.Booking
+
+	err := bs.db.WithContext(ctx).
+		Where("status = ? AND no_show_status = ? AND no_show_contest_deadline < ?",
+			store.BookingStatusNoShow,
+			store.NoShowStatusContestable,
+			before).
+		Order("no_show_contest_deadline ASC").
+		Find(&bookings).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+func (bs *bookingStore) GetDueForPayout(ctx context.Context, completedFrom, completedBefore time.Time) ([]*store.Booking, error) {
+	var bookings []*store.Booking
+
+	// Bookings without a card payment were paid with credit or gift cards
+	err := bs.db.WithContext(ctx).
+		Where("status = ? AND completed_at < ? AND cleaner_payout > 0 AND payout_transaction_id IS NULL",
+			store.BookingStatusCompleted,
+			completedBefore).
+		Where("(completed_at >= ? OR "+fmt.Sprintf(disputeResolved, "bookings.id")+")", completedFrom).
+		Where("(payment_status IS NULL OR payment_status IN ?)",
+			[]store.BookingPaymentStatus{store.BookingPaymentStatusPaid, store.BookingPaymentStatusPartiallyRefunded}).
+		Where("NOT " + fmt.Sprintf(disputeUnresolved, "bookings.id")).
+		Order("completed_at ASC").
+		Find(&bookings).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+func (bs *bookingStore) GetByPayoutTransactions(ctx context.Context, payoutIDs []string) ([]*store.Booking, error) {
+	var bookings []*store.Booking
+	if len(payoutIDs) == 0 {
+		return bookings, nil
+	}
+
+	err := bs.db.WithContext(ctx).
+		Where("payout_transaction_id IN ?", payoutIDs).
+		Order("completed_at ASC, id ASC").
+		Find(&bookings).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// activeBookingStatuses are the statuses that hold a cleaner's time slot
+var activeBookingStatuses = []store.BookingStatus{
+	store.BookingStatusPending,
+	store.BookingStatusConfirmed,
+	store.BookingStatusInProgress,
+}
+
+// reserveSlot serializes bookings per cleaner and rejects overlaps with other
+// active bookings or unavailable availability entries. Must run inside a transaction;
+// the advisory lock is held until it commits.
+func reserveSlot(tx *gorm.DB, booking *store.Booking) error {
+	if !isActiveBookingStatus(booking.Status) {
+		return nil
+	}
+
+	if err := lockCleanerSlots(tx, booking.CleanerID); err != nil {
+		return err
+	}
+
+	start := booking.ScheduledStart
+	end := booking.EndsAt()
+
+	var overlapping int64
+	err := tx.Model(&store.Booking{}).
+		Where("cleaner_id = ? AND id <> ? AND status IN ?", booking.CleanerID, booking.ID, activeBookingStatuses).
+		Where("scheduled_start < ? AND scheduled_start + duration * INTERVAL '1 hour' > ?", end, start).
+		Count(&overlapping).Error
+	if err != nil {
+		return err
+	}
+	if overlapping > 0 {
+		return store.ErrSlotUnavailable
+	}
+
+	var blocked int64
+	date, startTime, endTime := wallClockSpan(start, end)
+	query := tx.Model(&store.Availability{}).
+		Joins("INNER JOIN cleaner_profiles ON cleaner_profiles.id = availabilities.cleaner_profile_id").
+		Where("cleaner_profiles.user_id = ?", booking.CleanerID)
+	err = whereUnavailableOverlaps(query, date, startTime, endTime).
+		Count(&blocked).Error
+	if err != nil {
+		return err
+	}
+	if blocked > 0 {
+		return store.ErrSlotUnavailable
+	}
+
+	return nil
+}
+
+// updateBooking saves a booking inside a transaction, re-checking the slot when it moves
+func updateBooking(tx *gorm.DB, booking *store.Booking) error {
+	var existing store.Booking
+	if err := tx.Where("id = ?", booking.ID).First(&existing).Error; err != nil {
+		return err
+	}
+
+	// Only re-check the slot when the booking moves
+	if !existing.ScheduledStart.Equal(booking.ScheduledStart) ||
+		existing.Duration != booking.Duration ||
+		existing.CleanerID != booking.CleanerID {
+		if err := reserveSlot(tx, booking); err != nil {
+			return err
+		}
+	}
+
+	result := tx.Save(booking)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("booking not found (id: %s)", booking.ID)
+	}
+	return nil
+}
+
+// lockCleanerSlots serializes slot checks of a cleaner until the transaction commits
+func lockCleanerSlots(tx *gorm.DB, cleanerID string) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "booking-slot:"+cleanerID).Error
+}
+
+// normalizeSchedule requires the start instant and re-derives the local date and
+// time of day from it, so the stored columns never disagree with the start
+func normalizeSchedule(booking *store.Booking) error {
+	if booking.ScheduledStart.IsZero() {
+		return fmt.Errorf("%w: booking start is required", store.ErrInvalidInput)
+	}
+	booking.SetStart(booking.ScheduledStart)
+	return nil
+}
+
+// backfillScheduledStart derives the start instant of bookings created before it was stored
+// from their local date and time of day; from then on the start is the source of truth
+func backfillScheduledStart(db *gorm.DB) error {
+	var bookings []*store.Booking
+	if err := db.Where("scheduled_start IS NULL").Find(&bookings).Error; err != nil {
+		return err
+	}
+
+	for _, booking := range bookings {
+		start, err := localtime.ParseAt(booking.ScheduledDate, booking.ScheduledTime)
+		if err != nil {
+			start = localtime.Day(booking.ScheduledDate)
+		}
+		if err := db.Model(&store.Booking{}).Where("id = ?", booking.ID).Update("scheduled_start", start).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isActiveBookingStatus(status store.BookingStatus) bool {
+	for _, active := range activeBookingStatuses {
+		if status == active {
+			return true
+		}
+	}
+	return false
+}
+
 // Helper method to apply filters
 func (bs *bookingStore) applyFilters(query *gorm.DB, filters store.BookingFilters) *gorm.DB {
 	if filters.Status != nil {
@@ -178,10 +506,10 @@ func (bs *bookingStore) applyFilters(query *gorm.DB, filters store.BookingFilter
 		query = query.Where("service_type = ?", *filters.ServiceType)
 	}
 	if filters.StartDate != nil {
-		query = query.Where("scheduled_date >= ?", *filters.StartDate)
+		query = query.Where("scheduled_start >= ?", localtime.Day(*filters.StartDate))
 	}
 	if filters.EndDate != nil {
-		query = query.Where("scheduled_date <= ?", *filters.EndDate)
+		query = query.Where("scheduled_start < ?", localtime.Day(*filters.EndDate).AddDate(0, 0, 1))
 	}
 	if filters.MinPrice != nil {
 		query = query.Where("total_price >= ?", *filters.MinPrice)
@@ -196,7 +524,7 @@ func (bs *bookingStore) applyFilters(query *gorm.DB, filters store.BookingFilter
 	if filters.OrderBy != "" {
 		query = query.Order(filters.OrderBy)
 	} else {
-		query = query.Order("scheduled_date DESC, created_at DESC")
+		query = query.Order("scheduled_start DESC, created_at DESC")
 	}
 
 	if filters.Limit > 0 {
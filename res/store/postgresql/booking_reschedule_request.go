@@ -0,0 +1,103 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"cleanbuddy-api/res/store"
+)
+
+type bookingRescheduleRequestStore struct {
+	*storeImpl
+}
+
+func NewBookingRescheduleRequestStore(rootStore *storeImpl) *bookingRescheduleRequestStore {
+	return &bookingRescheduleRequestStore{storeImpl: rootStore}
+}
+
+func (brrs *bookingRescheduleRequestStore) Create(ctx context.Context, request *store.BookingRescheduleRequest) error {
+	result := brrs.db.WithContext(ctx).Create(request)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("failed to create reschedule request")
+	}
+	return nil
+}
+
+func (brrs *bookingRescheduleRequestStore) Get(ctx context.Context, id string) (*store.BookingRescheduleRequest, error) {
+	var request store.BookingRescheduleRequest
+	result := brrs.db.WithContext(ctx).Where("id = ?", id).First(&request)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &request, nil
+}
+
+func (brrs *bookingRescheduleRequestStore) Update(ctx context.Context, request *store.BookingRescheduleRequest) error {
+	result := brrs.db.WithContext(ctx).Save(request)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("reschedule request not found (id: %s)", request.ID)
+	}
+	return nil
+}
+
+func (brrs *bookingRescheduleRequestStore) Resolve(ctx context.Context, request *store.BookingRescheduleRequest) error {
+	return resolveRescheduleRequest(brrs.db.WithContext(ctx), request)
+}
+
+func (brrs *bookingRescheduleRequestStore) Accept(ctx context.Context, request *store.BookingRescheduleRequest, booking *store.Booking, entry *store.BookingStatusHistory) error {
+	if err := normalizeSchedule(booking); err != nil {
+		return err
+	}
+
+	return brrs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := resolveRescheduleRequest(tx, request); err != nil {
+			return err
+		}
+		if err := updateBooking(tx, booking); err != nil {
+			return err
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+// resolveRescheduleRequest persists the response, guarded on the request still being pending
+// so two responses (or a response and a withdrawal) cannot both win
+func resolveRescheduleRequest(db *gorm.DB, request *store.BookingRescheduleRequest) error {
+	result := db.Model(&store.BookingRescheduleRequest{}).
+		Where("id = ? AND status = ?", request.ID, store.RescheduleRequestStatusPending).
+		Updates(map[string]interface{}{
+			"status":          request.Status,
+			"responded_by_id": request.RespondedByID,
+			"responded_at":    request.RespondedAt,
+			"response_note":   request.ResponseNote,
+			"accepted_date":   request.AcceptedDate,
+			"accepted_time":   request.AcceptedTime,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return store.ErrRescheduleRequestNotPending
+	}
+	return nil
+}
+
+func (brrs *bookingRescheduleRequestStore) GetByBooking(ctx context.Context, bookingID string) ([]*store.BookingRescheduleRequest, error) {
+	var requests []*store.BookingRescheduleRequest
+	result := brrs.db.WithContext(ctx).
+		Where("booking_id = ?", bookingID).
+		Order("created_at DESC").
+		Find(&requests)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return requests, nil
+}
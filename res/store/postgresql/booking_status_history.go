@@ -0,0 +1,39 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"cleanbuddy-api/res/store"
+)
+
+type bookingStatusHistoryStore struct {
+	*storeImpl
+}
+
+func NewBookingStatusHistoryStore(rootStore *storeImpl) *bookingStatusHistoryStore {
+	return &bookingStatusHistoryStore{storeImpl: rootStore}
+}
+
+func (bshs *bookingStatusHistoryStore) Create(ctx context.Context, entry *store.BookingStatusHistory) error {
+	result := bshs.db.WithContext(ctx).Create(entry)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("failed to create booking status history entry")
+	}
+	return nil
+}
+
+func (bshs *bookingStatusHistoryStore) GetByBooking(ctx context.Context, bookingID string) ([]*store.BookingStatusHistory, error) {
+	var entries []*store.BookingStatusHistory
+	result := bshs.db.WithContext(ctx).
+		Where("booking_id = ?", bookingID).
+		Order("created_at ASC").
+		Find(&entries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return entries, nil
+}
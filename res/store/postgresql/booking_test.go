@@ -0,0 +1,107 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/store"
+)
+
+func connectTestStore(t *testing.T) *storeImpl {
+	t.Helper()
+
+	url := os.Getenv("DATABASE_POSTGRES_URL")
+	if url == "" {
+		t.Skip("DATABASE_POSTGRES_URL is not set")
+	}
+
+	s, err := Connect(url)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	return s
+}
+
+// createBookingFixtures creates a customer, a cleaner with a profile and an address,
+// and removes them together with their bookings when the test ends
+func createBookingFixtures(t *testing.T, s *storeImpl) (customer *store.User, cleaner *store.User, profile *store.CleanerProfile, address *store.Address) {
+	t.Helper()
+
+	customer = &store.User{ID: uuid.New().String(), DisplayName: "Test Customer", Role: store.UserRoleClient, Email: uuid.New().String() + "@example.com"}
+	cleaner = &store.User{ID: uuid.New().String(), DisplayName: "Test Cleaner", Role: store.UserRoleCleaner, Email: uuid.New().String() + "@example.com"}
+	profile = &store.CleanerProfile{ID: uuid.New().String(), UserID: cleaner.ID}
+	address = &store.Address{ID: uuid.New().String(), UserID: customer.ID, Street: "Strada Test 1", City: "Bucuresti", PostalCode: "010101"}
+
+	for _, fixture := range []interface{}{customer, cleaner, profile, address} {
+		if err := s.db.Create(fixture).Error; err != nil {
+			t.Fatalf("failed to create fixture: %v", err)
+		}
+	}
+
+	t.Cleanup(func() {
+		s.db.Where("cleaner_id = ?", cleaner.ID).Delete(&store.Booking{})
+		s.db.Delete(address)
+		s.db.Delete(profile)
+		s.db.Delete(cleaner)
+		s.db.Delete(customer)
+	})
+	return customer, cleaner, profile, address
+}
+
+func TestBookingCreateReservesSlotOnce(t *testing.T) {
+	s := connectTestStore(t)
+	customer, cleaner, profile, address := createBookingFixtures(t, s)
+
+	const attempts = 10
+	start := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Hour)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			booking := &store.Booking{
+				ID:               uuid.New().String(),
+				CustomerID:       customer.ID,
+				CleanerID:        cleaner.ID,
+				CleanerProfileID: profile.ID,
+				ServiceType:      store.ServiceTypeGeneral,
+				ServiceFrequency: store.ServiceFrequencyOneTime,
+				ScheduledStart:   start,
+				Duration:         2,
+				AddressID:        address.ID,
+				Status:           store.BookingStatusConfirmed,
+			}
+			errs <- s.Bookings().Create(context.Background(), booking)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded, unavailable := 0, 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, store.ErrSlotUnavailable):
+			unavailable++
+		default:
+			t.Errorf("Create() unexpected error = %v", err)
+		}
+	}
+
+	if succeeded != 1 {
+		t.Errorf("successful creates = %d, want 1", succeeded)
+	}
+	if unavailable != attempts-1 {
+		t.Errorf("ErrSlotUnavailable = %d, want %d", unavailable, attempts-1)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	"cleanbuddy-api/res/store"
+
+	"gorm.io/gorm"
 )
 
 type cleanerProfileStore struct {
@@ -71,28 +73,7 @@ func (cps *cleanerProfileStore) Delete(ctx context.Context, id string) error {
 func (cps *cleanerProfileStore) List(ctx context.Context, filters store.CleanerProfileFilters) ([]*store.CleanerProfile, error) {
 	query := cps.db.WithContext(ctx).Model(&store.CleanerProfile{})
 
-	// Apply filters
-	if filters.Tier != nil {
-		query = query.Where("tier = ?", *filters.Tier)
-	}
-	if filters.MinRating != nil {
-		query = query.Where("average_rating >= ?", *filters.MinRating)
-	}
-	if filters.MaxRating != nil {
-		query = query.Where("average_rating <= ?", *filters.MaxRating)
-	}
-	if filters.IsActive != nil {
-		query = query.Where("is_active = ?", *filters.IsActive)
-	}
-	if filters.IsVerified != nil {
-		query = query.Where("is_verified = ?", *filters.IsVerified)
-	}
-	if filters.IsAvailableToday != nil {
-		query = query.Where("is_available_today = ?", *filters.IsAvailableToday)
-	}
-	if filters.CompanyID != nil {
-		query = query.Where("company_id = ?", *filters.CompanyID)
-	}
+	query = applyCleanerProfileFilters(query, filters)
 
 	// Filter by service areas if provided
 	if len(filters.ServiceAreaIDs) > 0 {
@@ -178,3 +159,30 @@ func (cps *cleanerProfileStore) UpdateTier(ctx context.Context, profileID string
 
 	return nil
 }
+
+// applyCleanerProfileFilters applies the attribute filters of a cleaner profile query
+func applyCleanerProfileFilters(query *gorm.DB, filters store.CleanerProfileFilters) *gorm.DB {
+	if filters.Tier != nil {
+		query = query.Where("tier = ?", *filters.Tier)
+	}
+	if filters.MinRating != nil {
+		query = query.Where("average_rating >= ?", *filters.MinRating)
+	}
+	if filters.MaxRating != nil {
+		query = query.Where("average_rating <= ?", *filters.MaxRating)
+	}
+	if filters.IsActive != nil {
+		query = query.Where("is_active = ?", *filters.IsActive)
+	}
+	if filters.IsVerified != nil {
+		query = query.Where("is_verified = ?", *filters.IsVerified)
+	}
+	if filters.IsAvailableToday != nil {
+		query = query.Where("is_available_today = ?", *filters.IsAvailableToday)
+	}
+	if filters.CompanyID != nil {
+		query = query.Where("company_id = ?", *filters.CompanyID)
+	}
+
+	return query
+}
@@ -0,0 +1,101 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+type commissionRuleStore struct {
+	*storeImpl
+}
+
+func NewCommissionRuleStore(rootStore *storeImpl) *commissionRuleStore {
+	return &commissionRuleStore{storeImpl: rootStore}
+}
+
+func (crs *commissionRuleStore) Create(ctx context.Context, rule *store.CommissionRule) error {
+	result := crs.db.WithContext(ctx).Create(rule)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("failed to create commission rule")
+	}
+	return nil
+}
+
+func (crs *commissionRuleStore) Get(ctx context.Context, id string) (*store.CommissionRule, error) {
+	var rule store.CommissionRule
+	result := crs.db.WithContext(ctx).Where("id = ?", id).First(&rule)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &rule, nil
+}
+
+func (crs *commissionRuleStore) Update(ctx context.Context, rule *store.CommissionRule) error {
+	result := crs.db.WithContext(ctx).Save(rule)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("commission rule not found (id: %s)", rule.ID)
+	}
+	return nil
+}
+
+func (crs *commissionRuleStore) Delete(ctx context.Context, id string) error {
+	result := crs.db.WithContext(ctx).Delete(&store.CommissionRule{ID: id})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("commission rule not found (id: %s)", id)
+	}
+	return nil
+}
+
+func (crs *commissionRuleStore) List(ctx context.Context, scope *store.CommissionRuleScope) ([]*store.CommissionRule, error) {
+	var rules []*store.CommissionRule
+
+	query := crs.db.WithContext(ctx)
+	if scope != nil {
+		query = query.Where("scope = ?", *scope)
+	}
+
+	if err := query.Order("created_at DESC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (crs *commissionRuleStore) GetActive(ctx context.Context, companyID *string, tier store.CleanerTier, at time.Time) ([]*store.CommissionRule, error) {
+	var rules []*store.CommissionRule
+
+	err := crs.db.WithContext(ctx).
+		Where("is_active = ?", true).
+		Where(`(
+			scope = ?
+			OR (scope = ? AND company_id = ?)
+			OR (scope = ? AND tier = ?)
+			OR (scope = ?
+				AND (company_id IS NULL OR company_id = ?)
+				AND (tier IS NULL OR tier = ?)
+				AND (valid_from IS NULL OR valid_from <= ?)
+				AND (valid_until IS NULL OR valid_until > ?))
+		)`,
+			store.CommissionRuleScopePlatform,
+			store.CommissionRuleScopeCompany, companyID,
+			store.CommissionRuleScopeTier, tier,
+			store.CommissionRuleScopePromotion, companyID, tier, at, at).
+		Order("created_at ASC").
+		Find(&rules).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
@@ -0,0 +1,130 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/store"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type creditStore struct {
+	*storeImpl
+}
+
+func NewCreditStore(rootStore *storeImpl) *creditStore {
+	return &creditStore{storeImpl: rootStore}
+}
+
+func (cs *creditStore) Balance(ctx context.Context, userID string) (int, error) {
+	return creditBalance(cs.db.WithContext(ctx), userID)
+}
+
+func (cs *creditStore) ListByUser(ctx context.Context, userID string, limit, offset int) ([]*store.CreditEntry, error) {
+	var entries []*store.CreditEntry
+
+	query := cs.db.WithContext(ctx).Where("user_id = ?", userID)
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	if err := query.Order("created_at DESC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (cs *creditStore) Add(ctx context.Context, entry *store.CreditEntry) error {
+	return cs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if entry.Amount < 0 {
+			// Lock the user so concurrent spends cannot overdraw the balance
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", entry.UserID).First(&store.User{}).Error; err != nil {
+				return err
+			}
+			balance, err := creditBalance(tx, entry.UserID)
+			if err != nil {
+				return err
+			}
+			if balance+entry.Amount < 0 {
+				return store.ErrInsufficientCredit
+			}
+		}
+
+		result := tx.Create(entry)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return fmt.Errorf("failed to create credit entry")
+		}
+		return nil
+	})
+}
+
+func (cs *creditStore) RefundBooking(ctx context.Context, bookingID string, percent int) (*store.CreditEntry, error) {
+	var refund *store.CreditEntry
+	err := cs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var entries []*store.CreditEntry
+		if err := tx.Where("booking_id = ? AND reason IN ?", bookingID, []store.CreditReason{store.CreditReasonBooking, store.CreditReasonRefund}).
+			Limit(1).
+			Find(&entries).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		// Lock the customer so a retried refund sees the previous one
+		userID := entries[0].UserID
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userID).First(&store.User{}).Error; err != nil {
+			return err
+		}
+
+		var spent int
+		var refunds int64
+		if err := tx.Model(&store.CreditEntry{}).
+			Select("COALESCE(-SUM(amount), 0)").
+			Where("booking_id = ? AND reason = ?", bookingID, store.CreditReasonBooking).
+			Scan(&spent).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&store.CreditEntry{}).
+			Where("booking_id = ? AND reason = ?", bookingID, store.CreditReasonRefund).
+			Count(&refunds).Error; err != nil {
+			return err
+		}
+		amount := spent * percent / 100
+		if refunds > 0 || amount <= 0 {
+			return nil
+		}
+
+		refund = &store.CreditEntry{
+			ID:        uuid.New().String(),
+			UserID:    userID,
+			Amount:    amount,
+			Reason:    store.CreditReasonRefund,
+			BookingID: &bookingID,
+		}
+		return tx.Create(refund).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+func creditBalance(db *gorm.DB, userID string) (int, error) {
+	var balance int
+	err := db.Model(&store.CreditEntry{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("user_id = ?", userID).
+		Scan(&balance).Error
+	return balance, err
+}
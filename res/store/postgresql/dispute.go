@@ -0,0 +1,122 @@
+package postgresql
+
+import (
+	"context"
+
+	"cleanbuddy-api/res/store"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// disputeUnresolved matches bookings with a dispute that is not resolved yet
+const disputeUnresolved = "EXISTS (SELECT 1 FROM disputes WHERE disputes.booking_id = %s AND disputes.status <> 'resolved')"
+
+// disputeResolved matches bookings with a dispute that was resolved
+const disputeResolved = "EXISTS (SELECT 1 FROM disputes WHERE disputes.booking_id = %s AND disputes.status = 'resolved')"
+
+type disputeStore struct {
+	*storeImpl
+}
+
+func NewDisputeStore(rootStore *storeImpl) *disputeStore {
+	return &disputeStore{storeImpl: rootStore}
+}
+
+func (ds *disputeStore) Create(ctx context.Context, dispute *store.Dispute) error {
+	return ds.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking the booking makes a concurrent dispute of it wait and then find this one
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", dispute.BookingID).
+			First(&store.Booking{}).Error; err != nil {
+			return err
+		}
+
+		var unresolved int64
+		if err := tx.Model(&store.Dispute{}).
+			Where("booking_id = ? AND status <> ?", dispute.BookingID, store.DisputeStatusResolved).
+			Count(&unresolved).Error; err != nil {
+			return err
+		}
+		if unresolved > 0 {
+			return store.ErrDisputeExists
+		}
+
+		return tx.Omit("Evidence").Create(dispute).Error
+	})
+}
+
+func (ds *disputeStore) Get(ctx context.Context, id string) (*store.Dispute, error) {
+	var dispute store.Dispute
+	err := ds.db.WithContext(ctx).
+		Preload("Evidence", orderEvidence).
+		Where("id = ?", id).
+		First(&dispute).Error
+	if err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+func (ds *disputeStore) List(ctx context.Context, filters store.DisputeFilters) ([]*store.Dispute, error) {
+	query := ds.db.WithContext(ctx).Preload("Evidence", orderEvidence)
+	if filters.Status != nil {
+		query = query.Where("status = ?", *filters.Status)
+	}
+	if filters.BookingID != nil {
+		query = query.Where("booking_id = ?", *filters.BookingID)
+	}
+	if filters.CustomerID != nil {
+		query = query.Where("customer_id = ?", *filters.CustomerID)
+	}
+	if filters.CleanerID != nil {
+		query = query.Where("cleaner_id = ?", *filters.CleanerID)
+	}
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+	if filters.Offset > 0 {
+		query = query.Offset(filters.Offset)
+	}
+
+	var disputes []*store.Dispute
+	if err := query.Order("created_at DESC").Find(&disputes).Error; err != nil {
+		return nil, err
+	}
+	return disputes, nil
+}
+
+func (ds *disputeStore) Update(ctx context.Context, dispute *store.Dispute, from []store.DisputeStatus) error {
+	result := ds.db.WithContext(ctx).
+		Model(&store.Dispute{}).
+		Where("id = ? AND status IN ?", dispute.ID, from).
+		Select("*").
+		Omit("id", "created_at", "Evidence", "Booking").
+		Updates(dispute)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return store.ErrDisputeStatusConflict
+	}
+	return nil
+}
+
+func (ds *disputeStore) AddEvidence(ctx context.Context, evidence *store.DisputeEvidence) error {
+	return ds.db.WithContext(ctx).Create(evidence).Error
+}
+
+func (ds *disputeStore) CountEvidence(ctx context.Context, disputeID string) (int, error) {
+	var count int64
+	if err := ds.db.WithContext(ctx).
+		Model(&store.DisputeEvidence{}).
+		Where("dispute_id = ?", disputeID).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+func orderEvidence(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at ASC")
+}
@@ -0,0 +1,138 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/store"
+)
+
+func TestDisputeFreezesPayoutsUntilResolved(t *testing.T) {
+	s := connectTestStore(t)
+	customer, cleaner, profile, address := createBookingFixtures(t, s)
+	ctx := context.Background()
+
+	// Completed before the payout window, so only a resolved dispute makes it due again
+	completedAt := time.Now().Add(-30 * 24 * time.Hour)
+	booking := &store.Booking{
+		ID:               uuid.New().String(),
+		CustomerID:       customer.ID,
+		CleanerID:        cleaner.ID,
+		CleanerProfileID: profile.ID,
+		ServiceType:      store.ServiceTypeGeneral,
+		ServiceFrequency: store.ServiceFrequencyOneTime,
+		ScheduledStart:   completedAt.Add(-3 * time.Hour).Truncate(time.Hour),
+		Duration:         2,
+		AddressID:        address.ID,
+		Status:           store.BookingStatusCompleted,
+		CompletedAt:      &completedAt,
+		CleanerPayout:    16000,
+	}
+	if err := s.Bookings().Create(ctx, booking); err != nil {
+		t.Fatalf("Create() booking error = %v", err)
+	}
+
+	tip := &store.Transaction{
+		ID:            uuid.New().String(),
+		Type:          store.TransactionTypeTip,
+		Status:        store.TransactionStatusCompleted,
+		BookingID:     &booking.ID,
+		PayerID:       customer.ID,
+		PayeeID:       cleaner.ID,
+		Amount:        2000,
+		NetAmount:     2000,
+		PaymentMethod: store.PaymentMethodCard,
+		Currency:      "RON",
+		ProcessedAt:   completedAt,
+		CompletedAt:   &completedAt,
+	}
+	payout := &store.Transaction{
+		ID:            uuid.New().String(),
+		Type:          store.TransactionTypePayout,
+		Status:        store.TransactionStatusPending,
+		BookingID:     &booking.ID,
+		PayerID:       customer.ID,
+		PayeeID:       cleaner.ID,
+		Amount:        16000,
+		NetAmount:     16000,
+		PaymentMethod: store.PaymentMethodBankTransfer,
+		Currency:      "RON",
+		ProcessedAt:   completedAt,
+	}
+	dispute := &store.Dispute{
+		ID:          uuid.New().String(),
+		BookingID:   booking.ID,
+		CustomerID:  customer.ID,
+		CleanerID:   cleaner.ID,
+		Reason:      store.DisputeReasonQuality,
+		Description: "Kitchen was left dirty",
+		Status:      store.DisputeStatusOpen,
+	}
+	for _, fixture := range []interface{}{tip, payout} {
+		if err := s.db.Create(fixture).Error; err != nil {
+			t.Fatalf("failed to create fixture: %v", err)
+		}
+	}
+	if err := s.Disputes().Create(ctx, dispute); err != nil {
+		t.Fatalf("Create() dispute error = %v", err)
+	}
+	t.Cleanup(func() {
+		s.db.Where("booking_id = ?", booking.ID).Delete(&store.Transaction{})
+		s.db.Where("booking_id = ?", booking.ID).Delete(&store.Dispute{})
+	})
+
+	// due reports which of the booking, its tip and its pending payout are picked up for payout
+	due := func() (bookingDue, tipDue, payoutDue bool) {
+		t.Helper()
+
+		completedFrom, before := time.Now().Add(-7*24*time.Hour), time.Now()
+		bookings, err := s.Bookings().GetDueForPayout(ctx, completedFrom, before)
+		if err != nil {
+			t.Fatalf("GetDueForPayout() error = %v", err)
+		}
+		tips, err := s.Transactions().GetTipsDueForPayout(ctx, completedFrom, before)
+		if err != nil {
+			t.Fatalf("GetTipsDueForPayout() error = %v", err)
+		}
+		payouts, err := s.Transactions().GetPayoutsDue(ctx, before)
+		if err != nil {
+			t.Fatalf("GetPayoutsDue() error = %v", err)
+		}
+
+		for _, candidate := range bookings {
+			bookingDue = bookingDue || candidate.ID == booking.ID
+		}
+		for _, candidate := range tips {
+			tipDue = tipDue || candidate.ID == tip.ID
+		}
+		for _, candidate := range payouts {
+			payoutDue = payoutDue || candidate.ID == payout.ID
+		}
+		return bookingDue, tipDue, payoutDue
+	}
+
+	if bookingDue, tipDue, payoutDue := due(); bookingDue || tipDue || payoutDue {
+		t.Errorf("open dispute: booking due %t, tip due %t, payout due %t; want all frozen", bookingDue, tipDue, payoutDue)
+	}
+
+	dispute.Status = store.DisputeStatusUnderAdminReview
+	if err := s.Disputes().Update(ctx, dispute, []store.DisputeStatus{store.DisputeStatusOpen}); err != nil {
+		t.Fatalf("Update() dispute error = %v", err)
+	}
+	if bookingDue, tipDue, payoutDue := due(); bookingDue || tipDue || payoutDue {
+		t.Errorf("dispute under review: booking due %t, tip due %t, payout due %t; want all frozen", bookingDue, tipDue, payoutDue)
+	}
+
+	resolvedAt := time.Now()
+	dispute.Status = store.DisputeStatusResolved
+	dispute.ResolvedAt = &resolvedAt
+	if err := s.Disputes().Update(ctx, dispute, []store.DisputeStatus{store.DisputeStatusUnderAdminReview}); err != nil {
+		t.Fatalf("Update() dispute error = %v", err)
+	}
+	if bookingDue, tipDue, payoutDue := due(); !bookingDue || !tipDue || !payoutDue {
+		t.Errorf("resolved dispute: booking due %t, tip due %t, payout due %t; want all released", bookingDue, tipDue, payoutDue)
+	}
+}
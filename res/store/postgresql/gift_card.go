@@ -0,0 +1,372 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/store"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type giftCardStore struct {
+	*storeImpl
+}
+
+func NewGiftCardStore(rootStore *storeImpl) *giftCardStore {
+	return &giftCardStore{storeImpl: rootStore}
+}
+
+func (gcs *giftCardStore) Create(ctx context.Context, card *store.GiftCard, purchase *store.Transaction) error {
+	return gcs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Create(card)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+				return store.ErrUniqueViolation
+			}
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return fmt.Errorf("failed to create gift card")
+		}
+
+		purchase.GiftCardID = &card.ID
+		return tx.Create(purchase).Error
+	})
+}
+
+func (gcs *giftCardStore) Activate(ctx context.Context, id string, expiresAt time.Time) (*store.GiftCard, error) {
+	result := gcs.db.WithContext(ctx).
+		Model(&store.GiftCard{}).
+		Where("id = ? AND status = ?", id, store.GiftCardStatusPending).
+		Updates(map[string]interface{}{
+			"status":     store.GiftCardStatusActive,
+			"expires_at": expiresAt,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return nil, store.ErrGiftCardNotPending
+	}
+	return gcs.Get(ctx, id)
+}
+
+func (gcs *giftCardStore) Get(ctx context.Context, id string) (*store.GiftCard, error) {
+	var card store.GiftCard
+	result := gcs.db.WithContext(ctx).Where("id = ?", id).First(&card)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &card, nil
+}
+
+func (gcs *giftCardStore) GetPurchase(ctx context.Context, cardID string) (*store.Transaction, error) {
+	var purchase store.Transaction
+	result := gcs.db.WithContext(ctx).
+		Where("gift_card_id = ? AND type = ?", cardID, store.TransactionTypeGiftCardPurchase).
+		First(&purchase)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &purchase, nil
+}
+
+func (gcs *giftCardStore) GetByCode(ctx context.Context, code string) (*store.GiftCard, error) {
+	var card store.GiftCard
+	result := gcs.db.WithContext(ctx).Where("code = ?", code).First(&card)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &card, nil
+}
+
+func (gcs *giftCardStore) Redeem(ctx context.Context, code string, userID string, at time.Time) (*store.GiftCard, error) {
+	var card store.GiftCard
+	err := gcs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", code).First(&card).Error; err != nil {
+			return err
+		}
+		if card.Status == store.GiftCardStatusPending {
+			return store.ErrGiftCardNotPaid
+		}
+		if card.RecipientID != nil {
+			return store.ErrGiftCardAlreadyRedeemed
+		}
+		if card.Status == store.GiftCardStatusExpired || !at.Before(card.ExpiresAt) {
+			return store.ErrGiftCardExpired
+		}
+
+		card.RecipientID = &userID
+		card.RedeemedAt = &at
+		return tx.Model(&card).Updates(map[string]interface{}{
+			"recipient_id": card.RecipientID,
+			"redeemed_at":  card.RedeemedAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+func (gcs *giftCardStore) ListByUser(ctx context.Context, userID string) ([]*store.GiftCard, error) {
+	var cards []*store.GiftCard
+	err := gcs.db.WithContext(ctx).
+		Where("purchaser_id = ? OR recipient_id = ?", userID, userID).
+		Order("created_at DESC").
+		Find(&cards).Error
+	if err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+func (gcs *giftCardStore) Balance(ctx context.Context, userID string, at time.Time) (int, error) {
+	var balance int
+	err := spendableCards(gcs.db.WithContext(ctx).Model(&store.GiftCard{}), userID, at).
+		Select("COALESCE(SUM(balance), 0)").
+		Scan(&balance).Error
+	return balance, err
+}
+
+func (gcs *giftCardStore) Spend(ctx context.Context, booking *store.Booking, at time.Time) (int, error) {
+	spent := 0
+	err := gcs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var cards []*store.GiftCard
+		if err := spendableCards(tx.Clauses(clause.Locking{Strength: "UPDATE"}), booking.CustomerID, at).
+			Order("expires_at ASC").
+			Find(&cards).Error; err != nil {
+			return err
+		}
+
+		remaining := booking.TotalPrice
+		for _, card := range cards {
+			if remaining == 0 {
+				break
+			}
+			amount := card.Balance
+			if amount > remaining {
+				amount = remaining
+			}
+
+			card.Balance -= amount
+			if card.Balance == 0 {
+				card.Status = store.GiftCardStatusDepleted
+			}
+			if err := tx.Model(card).Updates(map[string]interface{}{
+				"balance": card.Balance,
+				"status":  card.Status,
+			}).Error; err != nil {
+				return err
+			}
+
+			redemption := &store.Transaction{
+				ID:            uuid.New().String(),
+				Type:          store.TransactionTypeGiftCardRedemption,
+				Status:        store.TransactionStatusCompleted,
+				BookingID:     &booking.ID,
+				GiftCardID:    &card.ID,
+				PayerID:       booking.CustomerID,
+				PayeeID:       booking.CleanerID,
+				Amount:        amount,
+				NetAmount:     amount,
+				PaymentMethod: store.PaymentMethodGiftCard,
+				Currency:      "RON",
+				Description:   fmt.Sprintf("Gift card %s", card.Code),
+				ProcessedAt:   at,
+				CompletedAt:   &at,
+			}
+			if err := tx.Create(redemption).Error; err != nil {
+				return err
+			}
+
+			remaining -= amount
+			spent += amount
+		}
+		if spent == 0 {
+			return nil
+		}
+
+		booking.GiftCardApplied += spent
+		booking.TotalPrice -= spent
+		return tx.Model(&store.Booking{}).Where("id = ?", booking.ID).Updates(map[string]interface{}{
+			"gift_card_applied": booking.GiftCardApplied,
+			"total_price":       booking.TotalPrice,
+		}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return spent, nil
+}
+
+func (gcs *giftCardStore) RefundBooking(ctx context.Context, bookingID string, percent int) (int, error) {
+	refunded := 0
+	err := gcs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking the redemptions makes a retried refund wait and then find the booking refunded
+		var redemptions []*store.Transaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("booking_id = ? AND type = ?", bookingID, store.TransactionTypeGiftCardRedemption).
+			Order("processed_at ASC").
+			Find(&redemptions).Error; err != nil {
+			return err
+		}
+
+		spent := 0
+		var open []*store.Transaction
+		for _, redemption := range redemptions {
+			switch {
+			case redemption.Status == store.TransactionStatusCancelled:
+				// Refunded before
+				return nil
+			case redemption.Status == store.TransactionStatusCompleted && redemption.GiftCardID != nil:
+				spent += redemption.Amount
+				open = append(open, redemption)
+			}
+		}
+
+		remaining := spent * percent / 100
+		for _, redemption := range open {
+			if remaining == 0 {
+				break
+			}
+			returned := min(redemption.Amount, remaining)
+			remaining -= returned
+
+			// Returned value is spendable again until the card expires; the expiry run forfeits it after that
+			result := tx.Model(&store.GiftCard{}).
+				Where("id = ?", *redemption.GiftCardID).
+				Updates(map[string]interface{}{
+					"balance": gorm.Expr("balance + ?", returned),
+					"status":  store.GiftCardStatusActive,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if err := tx.Model(redemption).Update("status", store.TransactionStatusCancelled).Error; err != nil {
+				return err
+			}
+			if kept := redemption.Amount - returned; kept > 0 {
+				now := time.Now()
+				retained := *redemption
+				retained.ID = uuid.New().String()
+				retained.Status = store.TransactionStatusCompleted
+				retained.Amount, retained.NetAmount = kept, kept
+				retained.ProcessedAt, retained.CompletedAt = now, &now
+				retained.CreatedAt, retained.UpdatedAt = time.Time{}, time.Time{}
+				if err := tx.Create(&retained).Error; err != nil {
+					return err
+				}
+			}
+			refunded += returned
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return refunded, nil
+}
+
+func (gcs *giftCardStore) ExpireDue(ctx context.Context, before time.Time) (int, error) {
+	expired := 0
+	err := gcs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var cards []*store.GiftCard
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status = ? AND expires_at <= ?", store.GiftCardStatusActive, before).
+			Find(&cards).Error; err != nil {
+			return err
+		}
+
+		for _, card := range cards {
+			forfeited := card.Balance
+			if err := tx.Model(card).Updates(map[string]interface{}{
+				"balance": 0,
+				"status":  store.GiftCardStatusExpired,
+			}).Error; err != nil {
+				return err
+			}
+			expired++
+
+			if forfeited == 0 {
+				continue
+			}
+
+			// Unredeemed cards forfeit the purchaser's value
+			holderID := card.PurchaserID
+			if card.RecipientID != nil {
+				holderID = *card.RecipientID
+			}
+			expiry := &store.Transaction{
+				ID:            uuid.New().String(),
+				Type:          store.TransactionTypeGiftCardExpiry,
+				Status:        store.TransactionStatusCompleted,
+				GiftCardID:    &card.ID,
+				PayerID:       holderID,
+				PayeeID:       holderID,
+				Amount:        forfeited,
+				NetAmount:     forfeited,
+				PaymentMethod: store.PaymentMethodGiftCard,
+				Currency:      "RON",
+				Description:   fmt.Sprintf("Gift card %s expired", card.Code),
+				ProcessedAt:   before,
+				CompletedAt:   &before,
+			}
+			if err := tx.Create(expiry).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return expired, nil
+}
+
+func (gcs *giftCardStore) ListOutstanding(ctx context.Context, at time.Time, limit, offset int) ([]*store.GiftCard, error) {
+	var cards []*store.GiftCard
+
+	query := outstandingCards(gcs.db.WithContext(ctx), at)
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	if err := query.Order("expires_at ASC").Find(&cards).Error; err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+func (gcs *giftCardStore) Liability(ctx context.Context, at time.Time) (*store.GiftCardLiability, error) {
+	var liability store.GiftCardLiability
+	err := outstandingCards(gcs.db.WithContext(ctx).Model(&store.GiftCard{}), at).
+		Select("COUNT(*) AS outstanding_count, COALESCE(SUM(balance), 0) AS outstanding_amount").
+		Scan(&liability).Error
+	if err != nil {
+		return nil, err
+	}
+	return &liability, nil
+}
+
+// spendableCards restricts a query to the active, unexpired cards a user redeemed
+func spendableCards(query *gorm.DB, userID string, at time.Time) *gorm.DB {
+	return query.
+		Where("recipient_id = ?", userID).
+		Where("status = ? AND balance > 0 AND expires_at > ?", store.GiftCardStatusActive, at)
+}
+
+// outstandingCards restricts a query to cards whose value is still owed, redeemed or not
+func outstandingCards(query *gorm.DB, at time.Time) *gorm.DB {
+	return query.Where("status = ? AND balance > 0 AND expires_at > ?", store.GiftCardStatusActive, at)
+}
@@ -0,0 +1,107 @@
+package postgresql
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/store"
+)
+
+func TestGiftCardRefundBookingReturnsPartOfEachRedemptionOnce(t *testing.T) {
+	s := connectTestStore(t)
+	customer, cleaner, profile, address := createBookingFixtures(t, s)
+	ctx := context.Background()
+
+	booking := &store.Booking{
+		ID:               uuid.New().String(),
+		CustomerID:       customer.ID,
+		CleanerID:        cleaner.ID,
+		CleanerProfileID: profile.ID,
+		ServiceType:      store.ServiceTypeGeneral,
+		ServiceFrequency: store.ServiceFrequencyOneTime,
+		ScheduledStart:   time.Now().Add(7 * 24 * time.Hour).Truncate(time.Hour),
+		Duration:         2,
+		AddressID:        address.ID,
+		Status:           store.BookingStatusCancelled,
+		GiftCardApplied:  10000,
+	}
+	if err := s.Bookings().Create(ctx, booking); err != nil {
+		t.Fatalf("Create() booking error = %v", err)
+	}
+
+	// Two cards paid 6000 and 4000 of the booking
+	var cards []*store.GiftCard
+	for i, amount := range []int{6000, 4000} {
+		card := &store.GiftCard{
+			ID:             uuid.New().String(),
+			Code:           strings.ToUpper(uuid.New().String()[:14]),
+			InitialAmount:  amount,
+			Status:         store.GiftCardStatusDepleted,
+			PurchaserID:    customer.ID,
+			RecipientEmail: customer.Email,
+			RecipientID:    &customer.ID,
+			ExpiresAt:      time.Now().Add(365 * 24 * time.Hour),
+		}
+		processedAt := time.Now().Add(time.Duration(i) * time.Second)
+		redemption := &store.Transaction{
+			ID:            uuid.New().String(),
+			Type:          store.TransactionTypeGiftCardRedemption,
+			Status:        store.TransactionStatusCompleted,
+			BookingID:     &booking.ID,
+			GiftCardID:    &card.ID,
+			PayerID:       customer.ID,
+			PayeeID:       cleaner.ID,
+			Amount:        amount,
+			NetAmount:     amount,
+			PaymentMethod: store.PaymentMethodGiftCard,
+			Currency:      "RON",
+			ProcessedAt:   processedAt,
+			CompletedAt:   &processedAt,
+		}
+		for _, fixture := range []interface{}{card, redemption} {
+			if err := s.db.Create(fixture).Error; err != nil {
+				t.Fatalf("failed to create fixture: %v", err)
+			}
+		}
+		cards = append(cards, card)
+	}
+	t.Cleanup(func() {
+		s.db.Where("booking_id = ?", booking.ID).Delete(&store.Transaction{})
+		for _, card := range cards {
+			s.db.Delete(card)
+		}
+	})
+
+	// Half of 10000 comes back: all of the first card's 6000 would be too much, so 5000 of it
+	refunded, err := s.GiftCards().RefundBooking(ctx, booking.ID, 50)
+	if err != nil {
+		t.Fatalf("RefundBooking() error = %v", err)
+	}
+	if refunded != 5000 {
+		t.Errorf("RefundBooking() = %d, want 5000", refunded)
+	}
+
+	var first, second store.GiftCard
+	s.db.First(&first, "id = ?", cards[0].ID)
+	s.db.First(&second, "id = ?", cards[1].ID)
+	if first.Balance != 5000 || first.Status != store.GiftCardStatusActive || second.Balance != 0 {
+		t.Errorf("balances = %d (%s) and %d, want 5000 back on the first card only", first.Balance, first.Status, second.Balance)
+	}
+
+	var kept []*store.Transaction
+	s.db.Where("booking_id = ? AND type = ? AND status = ?", booking.ID,
+		store.TransactionTypeGiftCardRedemption, store.TransactionStatusCompleted).Order("amount").Find(&kept)
+	if len(kept) != 2 || kept[0].Amount != 1000 || kept[1].Amount != 4000 {
+		t.Errorf("kept %d redemptions, want 1000 of the first card and 4000 of the second", len(kept))
+	}
+
+	// A retry finds the booking refunded
+	refunded, err = s.GiftCards().RefundBooking(ctx, booking.ID, 50)
+	if err != nil || refunded != 0 {
+		t.Errorf("second RefundBooking() = %d, %v; want nothing returned", refunded, err)
+	}
+}
@@ -0,0 +1,240 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/store"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// notInvoiced matches transactions no document was issued for
+const notInvoiced = "NOT EXISTS (SELECT 1 FROM invoices WHERE invoices.source_transaction_id = transactions.id)"
+
+// errAlreadyIssued rolls back an issue whose source got its documents concurrently
+var errAlreadyIssued = errors.New("documents already issued for the source transaction")
+
+type invoiceStore struct {
+	*storeImpl
+}
+
+func NewInvoiceStore(rootStore *storeImpl) *invoiceStore {
+	return &invoiceStore{storeImpl: rootStore}
+}
+
+func (is *invoiceStore) Issue(ctx context.Context, invoices []*store.Invoice) (bool, error) {
+	if len(invoices) == 0 {
+		return false, store.ErrInvalidInput
+	}
+	sourceID := invoices[0].SourceTransactionID
+	for _, invoice := range invoices {
+		if invoice.SourceTransactionID != sourceID || invoice.IssuerID == "" || invoice.Series == "" {
+			return false, store.ErrInvalidInput
+		}
+	}
+
+	// Sequences are locked in a fixed order so concurrent issues cannot deadlock
+	ordered := append([]*store.Invoice(nil), invoices...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].IssuerID != ordered[j].IssuerID {
+			return ordered[i].IssuerID < ordered[j].IssuerID
+		}
+		return ordered[i].Series < ordered[j].Series
+	})
+
+	err := is.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, invoice := range ordered {
+			sequence := store.InvoiceSequence{IssuerID: invoice.IssuerID, Series: invoice.Series}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&sequence).Error; err != nil {
+				return err
+			}
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("issuer_id = ? AND series = ?", invoice.IssuerID, invoice.Series).
+				First(&sequence).Error; err != nil {
+				return err
+			}
+
+			// Checked under the lock, so a number is only taken by a document that is stored
+			var issued int64
+			if err := tx.Model(&store.Invoice{}).
+				Where("source_transaction_id = ? AND issuer_id = ?", sourceID, invoice.IssuerID).
+				Count(&issued).Error; err != nil {
+				return err
+			}
+			if issued > 0 {
+				return errAlreadyIssued
+			}
+
+			sequence.LastNumber++
+			if err := tx.Model(&store.InvoiceSequence{}).
+				Where("issuer_id = ? AND series = ?", sequence.IssuerID, sequence.Series).
+				Update("last_number", sequence.LastNumber).Error; err != nil {
+				return err
+			}
+			invoice.Number = sequence.LastNumber
+		}
+
+		for _, invoice := range invoices {
+			if invoice.ID == "" {
+				invoice.ID = uuid.New().String()
+			}
+			if err := tx.Omit("Lines", "CorrectedInvoice").Create(invoice).Error; err != nil {
+				return err
+			}
+			for i, line := range invoice.Lines {
+				if line.ID == "" {
+					line.ID = uuid.New().String()
+				}
+				line.InvoiceID = invoice.ID
+				line.Position = i + 1
+			}
+			if len(invoice.Lines) > 0 {
+				if err := tx.Create(invoice.Lines).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errAlreadyIssued) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (is *invoiceStore) Get(ctx context.Context, id string) (*store.Invoice, error) {
+	var invoice store.Invoice
+	err := is.db.WithContext(ctx).
+		Preload("Lines", orderLines).
+		Preload("CorrectedInvoice").
+		Where("id = ?", id).
+		First(&invoice).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+func (is *invoiceStore) List(ctx context.Context, filters store.InvoiceFilters) ([]*store.Invoice, error) {
+	query := is.db.WithContext(ctx).Preload("Lines", orderLines).Preload("CorrectedInvoice")
+	if filters.IssuerID != nil {
+		query = query.Where("issuer_id = ?", *filters.IssuerID)
+	}
+	if filters.CustomerID != nil {
+		query = query.Where("customer_id = ?", *filters.CustomerID)
+	}
+	if filters.BookingID != nil {
+		query = query.Where("booking_id = ?", *filters.BookingID)
+	}
+	if filters.Kind != nil {
+		query = query.Where("kind = ?", *filters.Kind)
+	}
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+	if filters.Offset > 0 {
+		query = query.Offset(filters.Offset)
+	}
+
+	var invoices []*store.Invoice
+	if err := query.Order("issued_at DESC, number DESC").Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+func (is *invoiceStore) UpdateSubmission(ctx context.Context, invoice *store.Invoice) error {
+	return is.db.WithContext(ctx).
+		Model(&store.Invoice{}).
+		Where("id = ?", invoice.ID).
+		Updates(map[string]interface{}{
+			"e_factura_status":    invoice.EFacturaStatus,
+			"e_factura_upload_id": invoice.EFacturaUploadID,
+			"e_factura_error":     invoice.EFacturaError,
+			"submitted_at":        invoice.SubmittedAt,
+		}).Error
+}
+
+func (is *invoiceStore) GetUnsubmitted(ctx context.Context, limit int) ([]*store.Invoice, error) {
+	var invoices []*store.Invoice
+	err := is.db.WithContext(ctx).
+		Preload("Lines", orderLines).
+		Preload("CorrectedInvoice").
+		Where("e_factura_status IN ?", []store.EFacturaStatus{store.EFacturaStatusPending, store.EFacturaStatusUploaded}).
+		Order("issued_at ASC, number ASC").
+		Limit(limit).
+		Find(&invoices).Error
+	if err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+func (is *invoiceStore) UninvoicedPayments(ctx context.Context, limit int) ([]*store.Transaction, error) {
+	var transactions []*store.Transaction
+	err := is.db.WithContext(ctx).
+		Where("type = ? AND status = ? AND stripe_payment_id IS NOT NULL AND booking_id IS NOT NULL",
+			store.TransactionTypePayment,
+			store.TransactionStatusCompleted).
+		Where(notInvoiced).
+		Order("processed_at ASC").
+		Limit(limit).
+		Find(&transactions).Error
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (is *invoiceStore) UncreditedRefunds(ctx context.Context, limit int) ([]*store.Transaction, error) {
+	var transactions []*store.Transaction
+	err := is.db.WithContext(ctx).
+		Where("type = ? AND status = ? AND payment_method = ? AND booking_id IS NOT NULL",
+			store.TransactionTypeRefund,
+			store.TransactionStatusCompleted,
+			store.PaymentMethodCard).
+		Where(notInvoiced).
+		Where("EXISTS (SELECT 1 FROM invoices WHERE invoices.booking_id = transactions.booking_id AND invoices.kind = ?)",
+			store.InvoiceKindInvoice).
+		Order("processed_at ASC").
+		Limit(limit).
+		Find(&transactions).Error
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (is *invoiceStore) GetBillingDetails(ctx context.Context, userID string) (*store.BillingDetails, error) {
+	var details store.BillingDetails
+	if err := is.db.WithContext(ctx).Where("user_id = ?", userID).First(&details).Error; err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+func (is *invoiceStore) SaveBillingDetails(ctx context.Context, details *store.BillingDetails) error {
+	if details.ID == "" {
+		details.ID = uuid.New().String()
+	}
+	return is.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "tax_id", "registration_number", "street", "city", "county", "postal_code", "country", "updated_at"}),
+	}).Create(details).Error
+}
+
+func (is *invoiceStore) DeleteBillingDetails(ctx context.Context, userID string) error {
+	return is.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&store.BillingDetails{}).Error
+}
+
+func orderLines(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC")
+}
@@ -0,0 +1,254 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/store"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// notPosted matches rows of the outer table that have no entry for the given source
+const notPosted = "NOT EXISTS (SELECT 1 FROM journal_entries WHERE journal_entries.source_type = ? AND journal_entries.source_id = %s.id)"
+
+type ledgerStore struct {
+	*storeImpl
+}
+
+func NewLedgerStore(rootStore *storeImpl) *ledgerStore {
+	return &ledgerStore{storeImpl: rootStore}
+}
+
+func (ls *ledgerStore) Post(ctx context.Context, entry *store.JournalEntry) (bool, error) {
+	debits, credits := 0, 0
+	for _, line := range entry.Lines {
+		if line.Debit < 0 || line.Credit < 0 || (line.Debit > 0) == (line.Credit > 0) {
+			return false, store.ErrInvalidInput
+		}
+		debits += line.Debit
+		credits += line.Credit
+	}
+	if debits != credits {
+		return false, store.ErrUnbalancedEntry
+	}
+
+	posted := false
+	err := ls.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// A source posted concurrently keeps the first entry
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "source_type"}, {Name: "source_id"}},
+			DoNothing: true,
+		}).Omit("Lines").Create(entry)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return nil
+		}
+
+		for _, line := range entry.Lines {
+			if line.ID == "" {
+				line.ID = uuid.New().String()
+			}
+			line.EntryID = entry.ID
+		}
+		if len(entry.Lines) > 0 {
+			if err := tx.Create(entry.Lines).Error; err != nil {
+				return err
+			}
+		}
+		posted = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return posted, nil
+}
+
+func (ls *ledgerStore) GetBySource(ctx context.Context, source store.JournalSource, sourceID string) (*store.JournalEntry, error) {
+	var entry store.JournalEntry
+	err := ls.db.WithContext(ctx).
+		Preload("Lines").
+		Where("source_type = ? AND source_id = ?", source, sourceID).
+		First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (ls *ledgerStore) BookingBalance(ctx context.Context, bookingID string, account store.LedgerAccount) (int, error) {
+	var balance int
+	err := ls.db.WithContext(ctx).Model(&store.JournalLine{}).
+		Select("COALESCE(SUM(journal_lines.debit - journal_lines.credit), 0)").
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.entry_id").
+		Where("journal_entries.booking_id = ? AND journal_lines.account = ?", bookingID, account).
+		Scan(&balance).Error
+	return balance, err
+}
+
+func (ls *ledgerStore) Balances(ctx context.Context, filter store.LedgerBalanceFilter) ([]*store.LedgerBalance, error) {
+	query := ls.lines(ctx, filter.Before).
+		Select("journal_lines.account, journal_lines.owner_id, SUM(journal_lines.debit) AS debit, SUM(journal_lines.credit) AS credit")
+
+	if filter.Account != nil {
+		query = query.Where("journal_lines.account = ?", *filter.Account)
+	}
+	if filter.OwnerID != nil {
+		query = query.Where("journal_lines.owner_id = ?", *filter.OwnerID)
+	}
+
+	var balances []*store.LedgerBalance
+	err := query.
+		Group("journal_lines.account, journal_lines.owner_id").
+		Order("journal_lines.account, journal_lines.owner_id").
+		Scan(&balances).Error
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+func (ls *ledgerStore) TrialBalance(ctx context.Context, before *time.Time) ([]*store.LedgerBalance, error) {
+	var balances []*store.LedgerBalance
+	err := ls.lines(ctx, before).
+		Select("journal_lines.account, SUM(journal_lines.debit) AS debit, SUM(journal_lines.credit) AS credit").
+		Group("journal_lines.account").
+		Order("journal_lines.account").
+		Scan(&balances).Error
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// lines selects journal lines, of entries posted before the given time if it is set
+func (ls *ledgerStore) lines(ctx context.Context, before *time.Time) *gorm.DB {
+	query := ls.db.WithContext(ctx).Model(&store.JournalLine{})
+	if before != nil {
+		query = query.
+			Joins("JOIN journal_entries ON journal_entries.id = journal_lines.entry_id").
+			Where("journal_entries.posted_at < ?", *before)
+	}
+	return query
+}
+
+func (ls *ledgerStore) UnpostedTransactions(ctx context.Context, limit int) ([]*store.Transaction, error) {
+	var transactions []*store.Transaction
+	err := ls.db.WithContext(ctx).
+		Where("status = ?", store.TransactionStatusCompleted).
+		Where(sourceNotPosted("transactions"), store.JournalSourceTransaction).
+		Order("processed_at ASC").
+		Limit(limit).
+		Find(&transactions).Error
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (ls *ledgerStore) UnpostedObligations(ctx context.Context, limit int) ([]*store.Transaction, error) {
+	var transactions []*store.Transaction
+	err := whereObligation(ls.db.WithContext(ctx)).
+		Where(sourceNotPosted("transactions"), store.JournalSourceObligation).
+		Order("processed_at ASC").
+		Limit(limit).
+		Find(&transactions).Error
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (ls *ledgerStore) UnreversedTransactions(ctx context.Context, limit int) ([]*store.Transaction, error) {
+	db := ls.db.WithContext(ctx)
+	posted := db.Model(&store.JournalEntry{}).
+		Select("source_id").
+		Where("source_type = ?", store.JournalSourceTransaction)
+
+	var transactions []*store.Transaction
+	err := db.
+		Where("status <> ? AND id IN (?)", store.TransactionStatusCompleted, posted).
+		Where(sourceNotPosted("transactions"), store.JournalSourceReversal).
+		Order("processed_at ASC").
+		Limit(limit).
+		Find(&transactions).Error
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (ls *ledgerStore) UnpostedCreditEntries(ctx context.Context, limit int) ([]*store.CreditEntry, error) {
+	var entries []*store.CreditEntry
+	err := ls.db.WithContext(ctx).
+		Where(sourceNotPosted("credit_entries"), store.JournalSourceCreditEntry).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (ls *ledgerStore) UnpostedBookings(ctx context.Context, limit int) ([]*store.Booking, error) {
+	db := ls.db.WithContext(ctx)
+
+	// A cancelled booking is posted from what is left of its payments, so it waits for them
+	inFlight := db.Model(&store.Transaction{}).
+		Select("booking_id").
+		Where("booking_id IS NOT NULL AND type IN ? AND status IN ?",
+			[]store.TransactionType{store.TransactionTypePayment, store.TransactionTypeRefund},
+			[]store.TransactionStatus{store.TransactionStatusPending, store.TransactionStatusProcessing, store.TransactionStatusAuthorized})
+	unpostedTransactions := db.Model(&store.Transaction{}).
+		Select("booking_id").
+		Where("booking_id IS NOT NULL AND status = ?", store.TransactionStatusCompleted).
+		Where(sourceNotPosted("transactions"), store.JournalSourceTransaction)
+	unpostedObligations := whereObligation(db.Model(&store.Transaction{}).Select("booking_id")).
+		Where(sourceNotPosted("transactions"), store.JournalSourceObligation)
+	unpostedCredit := db.Model(&store.CreditEntry{}).
+		Select("booking_id").
+		Where("booking_id IS NOT NULL").
+		Where(sourceNotPosted("credit_entries"), store.JournalSourceCreditEntry)
+
+	settled := db.
+		Where("bookings.status = ?", store.BookingStatusCancelled).
+		Where("bookings.id NOT IN (?)", inFlight).
+		Where("bookings.id NOT IN (?)", unpostedTransactions).
+		Where("bookings.id NOT IN (?)", unpostedObligations).
+		Where("bookings.id NOT IN (?)", unpostedCredit)
+
+	var bookings []*store.Booking
+	err := db.
+		Where(sourceNotPosted("bookings"), store.JournalSourceBooking).
+		Where(db.
+			Where("bookings.status = ?", store.BookingStatusCompleted).
+			Or("bookings.status = ? AND bookings.no_show_status = ?", store.BookingStatusNoShow, store.NoShowStatusFinal).
+			Or(settled)).
+		Order("bookings.updated_at ASC").
+		Limit(limit).
+		Find(&bookings).Error
+	if err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// whereObligation matches compensation payouts: payouts of a single booking, which keep their
+// booking when they are added to a payout batch
+func whereObligation(query *gorm.DB) *gorm.DB {
+	return query.Where("type = ? AND booking_id IS NOT NULL AND status <> ?",
+		store.TransactionTypePayout, store.TransactionStatusCancelled)
+}
+
+// sourceNotPosted returns the condition matching rows of table without an entry for a source
+func sourceNotPosted(table string) string {
+	return fmt.Sprintf(notPosted, table)
+}
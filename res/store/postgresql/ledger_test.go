@@ -0,0 +1,59 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/store"
+)
+
+func TestLedgerPostOncePerSourceAndBalanced(t *testing.T) {
+	s := connectTestStore(t)
+	ctx := context.Background()
+
+	sourceID := uuid.New().String()
+	customerID := uuid.New().String()
+	newEntry := func(credit int) *store.JournalEntry {
+		return &store.JournalEntry{
+			ID:         uuid.New().String(),
+			SourceType: store.JournalSourceTransaction,
+			SourceID:   sourceID,
+			PostedAt:   time.Now(),
+			Lines: []*store.JournalLine{
+				{Account: store.LedgerAccountCash, Debit: 5000},
+				{Account: store.LedgerAccountCustomerReceivables, OwnerID: customerID, Credit: credit},
+			},
+		}
+	}
+	t.Cleanup(func() {
+		entries := s.db.Model(&store.JournalEntry{}).Select("id").Where("source_id = ?", sourceID)
+		s.db.Where("entry_id IN (?)", entries).Delete(&store.JournalLine{})
+		s.db.Where("source_id = ?", sourceID).Delete(&store.JournalEntry{})
+	})
+
+	if _, err := s.Ledger().Post(ctx, newEntry(4000)); !errors.Is(err, store.ErrUnbalancedEntry) {
+		t.Fatalf("Post() unbalanced error = %v, want ErrUnbalancedEntry", err)
+	}
+
+	posted, err := s.Ledger().Post(ctx, newEntry(5000))
+	if err != nil || !posted {
+		t.Fatalf("Post() = %v, %v, want true", posted, err)
+	}
+	posted, err = s.Ledger().Post(ctx, newEntry(5000))
+	if err != nil || posted {
+		t.Fatalf("second Post() = %v, %v, want false", posted, err)
+	}
+
+	account := store.LedgerAccountCustomerReceivables
+	balances, err := s.Ledger().Balances(ctx, store.LedgerBalanceFilter{Account: &account, OwnerID: &customerID})
+	if err != nil {
+		t.Fatalf("Balances() error = %v", err)
+	}
+	if len(balances) != 1 || balances[0].Credit != 5000 || balances[0].Balance() != -5000 {
+		t.Fatalf("Balances() = %+v, want one receivable credited 5000", balances)
+	}
+}
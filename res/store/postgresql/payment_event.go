@@ -0,0 +1,108 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cleanbuddy-api/res/store"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type paymentEventStore struct {
+	*storeImpl
+}
+
+func NewPaymentEventStore(rootStore *storeImpl) *paymentEventStore {
+	return &paymentEventStore{storeImpl: rootStore}
+}
+
+func (pes *paymentEventStore) Record(ctx context.Context, event *store.PaymentEvent) (*store.PaymentEvent, error) {
+	db := pes.db.WithContext(ctx)
+
+	// A redelivered event keeps the row of its first delivery
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "external_id"}},
+		DoNothing: true,
+	}).Create(event)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return event, nil
+	}
+
+	var existing store.PaymentEvent
+	if err := db.Where("external_id = ?", event.ExternalID).First(&existing).Error; err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func (pes *paymentEventStore) Claim(ctx context.Context, id string, staleBefore time.Time) (bool, error) {
+	result := pes.db.WithContext(ctx).Model(&store.PaymentEvent{}).
+		Where("id = ?", id).
+		Where("(status IN ? OR (status = ? AND updated_at < ?))",
+			[]store.PaymentEventStatus{store.PaymentEventStatusReceived, store.PaymentEventStatusFailed},
+			store.PaymentEventStatusProcessing, staleBefore).
+		Updates(map[string]interface{}{
+			"status":   store.PaymentEventStatusProcessing,
+			"attempts": gorm.Expr("attempts + 1"),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (pes *paymentEventStore) MarkProcessed(ctx context.Context, id string, at time.Time) error {
+	result := pes.db.WithContext(ctx).Model(&store.PaymentEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       store.PaymentEventStatusProcessed,
+			"processed_at": at,
+			"last_error":   "",
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("payment event not found (id: %s)", id)
+	}
+	return nil
+}
+
+func (pes *paymentEventStore) MarkFailed(ctx context.Context, id string, reason string) error {
+	result := pes.db.WithContext(ctx).Model(&store.PaymentEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     store.PaymentEventStatusFailed,
+			"last_error": reason,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("payment event not found (id: %s)", id)
+	}
+	return nil
+}
+
+func (pes *paymentEventStore) ListRetryable(ctx context.Context, staleBefore time.Time, limit int) ([]*store.PaymentEvent, error) {
+	var events []*store.PaymentEvent
+	query := pes.db.WithContext(ctx).
+		Where("status = ? OR (status IN ? AND updated_at < ?)",
+			store.PaymentEventStatusFailed,
+			[]store.PaymentEventStatus{store.PaymentEventStatusReceived, store.PaymentEventStatusProcessing},
+			staleBefore).
+		Order("created_at ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,58 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cleanbuddy-api/res/store"
+)
+
+func TestPaymentEventRecordAndClaimOnce(t *testing.T) {
+	s := connectTestStore(t)
+	ctx := context.Background()
+
+	externalID := "evt_" + uuid.New().String()
+	newEvent := func() *store.PaymentEvent {
+		return &store.PaymentEvent{
+			ID:         uuid.New().String(),
+			ExternalID: externalID,
+			Type:       "payment_intent.succeeded",
+			Payload:    `{}`,
+			Status:     store.PaymentEventStatusReceived,
+		}
+	}
+	t.Cleanup(func() { s.db.Where("external_id = ?", externalID).Delete(&store.PaymentEvent{}) })
+
+	first, err := s.PaymentEvents().Record(ctx, newEvent())
+	if err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	redelivered, err := s.PaymentEvents().Record(ctx, newEvent())
+	if err != nil {
+		t.Fatalf("Record() redelivery error = %v", err)
+	}
+	if redelivered.ID != first.ID {
+		t.Errorf("redelivered event ID = %s, want the first delivery's %s", redelivered.ID, first.ID)
+	}
+
+	staleBefore := time.Now().Add(-time.Minute)
+	claimed, err := s.PaymentEvents().Claim(ctx, first.ID, staleBefore)
+	if err != nil || !claimed {
+		t.Fatalf("Claim() = %v, %v, want true", claimed, err)
+	}
+	claimed, err = s.PaymentEvents().Claim(ctx, first.ID, staleBefore)
+	if err != nil || claimed {
+		t.Fatalf("second Claim() = %v, %v, want false", claimed, err)
+	}
+
+	if err := s.PaymentEvents().MarkFailed(ctx, first.ID, "boom"); err != nil {
+		t.Fatalf("MarkFailed() error = %v", err)
+	}
+	claimed, err = s.PaymentEvents().Claim(ctx, first.ID, staleBefore)
+	if err != nil || !claimed {
+		t.Fatalf("Claim() after failure = %v, %v, want true", claimed, err)
+	}
+}
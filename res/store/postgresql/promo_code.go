@@ -0,0 +1,146 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cleanbuddy-api/res/store"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type promoCodeStore struct {
+	*storeImpl
+}
+
+func NewPromoCodeStore(rootStore *storeImpl) *promoCodeStore {
+	return &promoCodeStore{storeImpl: rootStore}
+}
+
+func (pcs *promoCodeStore) Create(ctx context.Context, code *store.PromoCode) error {
+	result := pcs.db.WithContext(ctx).Create(code)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return store.ErrUniqueViolation
+		}
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("failed to create promo code")
+	}
+	return nil
+}
+
+func (pcs *promoCodeStore) Get(ctx context.Context, id string) (*store.PromoCode, error) {
+	var code store.PromoCode
+	result := pcs.db.WithContext(ctx).Where("id = ?", id).First(&code)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &code, nil
+}
+
+func (pcs *promoCodeStore) GetByCode(ctx context.Context, code string) (*store.PromoCode, error) {
+	var promoCode store.PromoCode
+	result := pcs.db.WithContext(ctx).Where("code = ?", code).First(&promoCode)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &promoCode, nil
+}
+
+func (pcs *promoCodeStore) Update(ctx context.Context, code *store.PromoCode) error {
+	// The redemption count is only changed by Redeem and ReleaseByBooking
+	result := pcs.db.WithContext(ctx).Omit("redemption_count").Save(code)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("promo code not found (id: %s)", code.ID)
+	}
+	return nil
+}
+
+func (pcs *promoCodeStore) List(ctx context.Context, activeOnly bool, limit, offset int) ([]*store.PromoCode, error) {
+	var codes []*store.PromoCode
+
+	query := pcs.db.WithContext(ctx)
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	if err := query.Order("created_at DESC").Find(&codes).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (pcs *promoCodeStore) CountRedemptionsByUser(ctx context.Context, promoCodeID, userID string) (int64, error) {
+	var count int64
+	err := pcs.db.WithContext(ctx).
+		Model(&store.PromoRedemption{}).
+		Where("promo_code_id = ? AND user_id = ?", promoCodeID, userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (pcs *promoCodeStore) Redeem(ctx context.Context, redemption *store.PromoRedemption) error {
+	return pcs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the code so concurrent checkouts cannot exceed its limits
+		var code store.PromoCode
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", redemption.PromoCodeID).First(&code).Error; err != nil {
+			return err
+		}
+
+		if code.MaxRedemptions != nil && code.RedemptionCount >= *code.MaxRedemptions {
+			return store.ErrPromoLimitReached
+		}
+		if code.MaxRedemptionsPerUser != nil {
+			var used int64
+			err := tx.Model(&store.PromoRedemption{}).
+				Where("promo_code_id = ? AND user_id = ?", code.ID, redemption.UserID).
+				Count(&used).Error
+			if err != nil {
+				return err
+			}
+			if used >= int64(*code.MaxRedemptionsPerUser) {
+				return store.ErrPromoLimitReached
+			}
+		}
+
+		if err := tx.Create(redemption).Error; err != nil {
+			return err
+		}
+		return tx.Model(&store.PromoCode{}).
+			Where("id = ?", code.ID).
+			Update("redemption_count", gorm.Expr("redemption_count + 1")).Error
+	})
+}
+
+func (pcs *promoCodeStore) ReleaseByBooking(ctx context.Context, bookingID string) error {
+	return pcs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var redemption store.PromoRedemption
+		result := tx.Where("booking_id = ?", bookingID).Limit(1).Find(&redemption)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Delete(&redemption).Error; err != nil {
+			return err
+		}
+		return tx.Model(&store.PromoCode{}).
+			Where("id = ? AND redemption_count > 0", redemption.PromoCodeID).
+			Update("redemption_count", gorm.Expr("redemption_count - 1")).Error
+	})
+}
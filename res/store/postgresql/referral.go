@@ -0,0 +1,130 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cleanbuddy-api/res/store"
+
+	"gorm.io/gorm"
+)
+
+type referralStore struct {
+	*storeImpl
+}
+
+func NewReferralStore(rootStore *storeImpl) *referralStore {
+	return &referralStore{storeImpl: rootStore}
+}
+
+func (rs *referralStore) CreateCode(ctx context.Context, code *store.ReferralCode) error {
+	result := rs.db.WithContext(ctx).Create(code)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return store.ErrUniqueViolation
+		}
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("failed to create referral code")
+	}
+	return nil
+}
+
+func (rs *referralStore) GetCode(ctx context.Context, code string) (*store.ReferralCode, error) {
+	var referralCode store.ReferralCode
+	result := rs.db.WithContext(ctx).Where("code = ?", code).First(&referralCode)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &referralCode, nil
+}
+
+func (rs *referralStore) GetCodeByUser(ctx context.Context, userID string) (*store.ReferralCode, error) {
+	var referralCodes []*store.ReferralCode
+	if err := rs.db.WithContext(ctx).Where("user_id = ?", userID).Limit(1).Find(&referralCodes).Error; err != nil {
+		return nil, err
+	}
+	if len(referralCodes) == 0 {
+		return nil, nil
+	}
+	return referralCodes[0], nil
+}
+
+func (rs *referralStore) Create(ctx context.Context, referral *store.Referral) error {
+	result := rs.db.WithContext(ctx).Create(referral)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return store.ErrUniqueViolation
+		}
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("failed to create referral")
+	}
+	return nil
+}
+
+func (rs *referralStore) GetByReferee(ctx context.Context, refereeID string) (*store.Referral, error) {
+	var referrals []*store.Referral
+	if err := rs.db.WithContext(ctx).Where("referee_id = ?", refereeID).Limit(1).Find(&referrals).Error; err != nil {
+		return nil, err
+	}
+	if len(referrals) == 0 {
+		return nil, nil
+	}
+	return referrals[0], nil
+}
+
+func (rs *referralStore) MarkRewarded(ctx context.Context, referral *store.Referral, credits []*store.CreditEntry) error {
+	err := rs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only a pending referral moves, so concurrent completions reward it once
+		result := tx.Model(&store.Referral{}).
+			Where("id = ? AND status = ?", referral.ID, store.ReferralStatusPending).
+			Updates(map[string]interface{}{
+				"status":          store.ReferralStatusRewarded,
+				"booking_id":      referral.BookingID,
+				"referrer_credit": referral.ReferrerCredit,
+				"referee_credit":  referral.RefereeCredit,
+				"rewarded_at":     referral.RewardedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return store.ErrReferralAlreadyRewarded
+		}
+
+		for _, credit := range credits {
+			if err := tx.Create(credit).Error; err != nil {
+				return fmt.Errorf("failed to create credit entry: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	referral.Status = store.ReferralStatusRewarded
+	return nil
+}
+
+func (rs *referralStore) Stats(ctx context.Context, referrerID string) (*store.ReferralStats, error) {
+	var stats store.ReferralStats
+	err := rs.db.WithContext(ctx).
+		Model(&store.Referral{}).
+		Select(
+			"COUNT(*) FILTER (WHERE status = ?) AS pending, "+
+				"COUNT(*) FILTER (WHERE status = ?) AS rewarded, "+
+				"COALESCE(SUM(referrer_credit), 0) AS credits_earned",
+			store.ReferralStatusPending, store.ReferralStatusRewarded,
+		).
+		Where("referrer_id = ?", referrerID).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
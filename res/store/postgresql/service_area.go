@@ -105,3 +105,22 @@ func (sas *serviceAreaStore) FindCleanersByPostalCode(ctx context.Context, posta
 
 	return profiles, nil
 }
+
+func (sas *serviceAreaStore) FindMatchingAreas(ctx context.Context, city, neighborhood, postalCode string) ([]*store.ServiceArea, error) {
+	var areas []*store.ServiceArea
+
+	err := sas.db.WithContext(ctx).
+		Where("city = ?", city).
+		Where(`(
+			(postal_code <> '' AND postal_code = ?)
+			OR (neighborhood <> '' AND neighborhood = ?)
+			OR (postal_code = '' AND neighborhood = '')
+		)`, postalCode, neighborhood).
+		Find(&areas).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return areas, nil
+}
@@ -22,11 +22,22 @@ type storeImpl struct {
 	addressStore        *addressStore
 	serviceStore        *serviceStore
 	bookingStore        *bookingStore
+	bookingHistoryStore *bookingStatusHistoryStore
+	rescheduleStore     *bookingRescheduleRequestStore
 	reviewStore         *reviewStore
 	transactionStore    *transactionStore
 	availabilityStore   *availabilityStore
 	companyStore        *companyStore
 	cleanerInviteStore  *cleanerInviteStore
+	commissionStore     *commissionRuleStore
+	promoCodeStore      *promoCodeStore
+	referralStore       *referralStore
+	creditStore         *creditStore
+	giftCardStore       *giftCardStore
+	paymentEventStore   *paymentEventStore
+	ledgerStore         *ledgerStore
+	invoiceStore        *invoiceStore
+	disputeStore        *disputeStore
 }
 
 func (sImpl *storeImpl) AuthSessions() store.AuthSessionStore {
@@ -57,6 +68,50 @@ func (sImpl *storeImpl) Bookings() store.BookingStore {
 	return sImpl.bookingStore
 }
 
+func (sImpl *storeImpl) BookingStatusHistory() store.BookingStatusHistoryStore {
+	return sImpl.bookingHistoryStore
+}
+
+func (sImpl *storeImpl) BookingRescheduleRequests() store.BookingRescheduleRequestStore {
+	return sImpl.rescheduleStore
+}
+
+func (sImpl *storeImpl) CommissionRules() store.CommissionRuleStore {
+	return sImpl.commissionStore
+}
+
+func (sImpl *storeImpl) PromoCodes() store.PromoCodeStore {
+	return sImpl.promoCodeStore
+}
+
+func (sImpl *storeImpl) Referrals() store.ReferralStore {
+	return sImpl.referralStore
+}
+
+func (sImpl *storeImpl) Credits() store.CreditStore {
+	return sImpl.creditStore
+}
+
+func (sImpl *storeImpl) GiftCards() store.GiftCardStore {
+	return sImpl.giftCardStore
+}
+
+func (sImpl *storeImpl) PaymentEvents() store.PaymentEventStore {
+	return sImpl.paymentEventStore
+}
+
+func (sImpl *storeImpl) Ledger() store.LedgerStore {
+	return sImpl.ledgerStore
+}
+
+func (sImpl *storeImpl) Invoices() store.InvoiceStore {
+	return sImpl.invoiceStore
+}
+
+func (sImpl *storeImpl) Disputes() store.DisputeStore {
+	return sImpl.disputeStore
+}
+
 func (sImpl *storeImpl) Reviews() store.ReviewStore {
 	return sImpl.reviewStore
 }
@@ -109,15 +164,37 @@ func Connect(connectionUrl string) (*storeImpl, error) {
 		&store.ServiceDefinition{},
 		&store.ServiceAddOnDefinition{},
 		&store.Booking{},
+		&store.BookingStatusHistory{},
+		&store.BookingRescheduleRequest{},
 		&store.Review{},
 		&store.Transaction{},
 		&store.PayoutBatch{},
 		&store.Availability{},
+		&store.CommissionRule{},
+		&store.PromoCode{},
+		&store.PromoRedemption{},
+		&store.ReferralCode{},
+		&store.Referral{},
+		&store.CreditEntry{},
+		&store.GiftCard{},
+		&store.PaymentEvent{},
+		&store.JournalEntry{},
+		&store.JournalLine{},
+		&store.Invoice{},
+		&store.InvoiceLine{},
+		&store.InvoiceSequence{},
+		&store.BillingDetails{},
+		&store.Dispute{},
+		&store.DisputeEvidence{},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to auto-migrate tables: %w", err)
 	}
 
+	if err := backfillScheduledStart(db); err != nil {
+		return nil, fmt.Errorf("failed to backfill booking start times: %w", err)
+	}
+
 	s := &storeImpl{db: db}
 
 	s.authSessionStore = NewAuthSessionStore(s)
@@ -127,11 +204,22 @@ func Connect(connectionUrl string) (*storeImpl, error) {
 	s.addressStore = NewAddressStore(s)
 	s.serviceStore = NewServiceStore(s)
 	s.bookingStore = NewBookingStore(s)
+	s.bookingHistoryStore = NewBookingStatusHistoryStore(s)
+	s.rescheduleStore = NewBookingRescheduleRequestStore(s)
 	s.reviewStore = NewReviewStore(s)
 	s.transactionStore = NewTransactionStore(s)
 	s.availabilityStore = NewAvailabilityStore(s)
 	s.companyStore = NewCompanyStore(s)
 	s.cleanerInviteStore = NewCleanerInviteStore(s)
+	s.commissionStore = NewCommissionRuleStore(s)
+	s.promoCodeStore = NewPromoCodeStore(s)
+	s.referralStore = NewReferralStore(s)
+	s.creditStore = NewCreditStore(s)
+	s.giftCardStore = NewGiftCardStore(s)
+	s.paymentEventStore = NewPaymentEventStore(s)
+	s.ledgerStore = NewLedgerStore(s)
+	s.invoiceStore = NewInvoiceStore(s)
+	s.disputeStore = NewDisputeStore(s)
 
 	return s, nil
 }
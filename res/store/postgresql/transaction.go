@@ -3,6 +3,7 @@ package postgresql
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"cleanbuddy-api/res/store"
@@ -47,6 +48,15 @@ func (ts *transactionStore) GetByStripePaymentID(ctx context.Context, stripePaym
 	return &transaction, nil
 }
 
+func (ts *transactionStore) GetByStripeRefundID(ctx context.Context, stripeRefundID string) (*store.Transaction, error) {
+	var transaction store.Transaction
+	result := ts.db.WithContext(ctx).Where("stripe_refund_id = ?", stripeRefundID).First(&transaction)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &transaction, nil
+}
+
 func (ts *transactionStore) Update(ctx context.Context, transaction *store.Transaction) error {
 	result := ts.db.WithContext(ctx).Save(transaction)
 	if result.Error != nil {
@@ -59,9 +69,17 @@ func (ts *transactionStore) Update(ctx context.Context, transaction *store.Trans
 }
 
 func (ts *transactionStore) UpdateStatus(ctx context.Context, transactionID string, status store.TransactionStatus) error {
+	updates := map[string]interface{}{"status": status}
+	switch status {
+	case store.TransactionStatusCompleted:
+		updates["completed_at"] = gorm.Expr("COALESCE(completed_at, ?)", time.Now())
+	case store.TransactionStatusFailed:
+		updates["failed_at"] = gorm.Expr("COALESCE(failed_at, ?)", time.Now())
+	}
+
 	result := ts.db.WithContext(ctx).Model(&store.Transaction{}).
 		Where("id = ?", transactionID).
-		Update("status", status)
+		Updates(updates)
 
 	if result.Error != nil {
 		return result.Error
@@ -86,8 +104,11 @@ func (ts *transactionStore) GetByBooking(ctx context.Context, bookingID string)
 
 func (ts *transactionStore) GetByUser(ctx context.Context, userID string, filters store.TransactionFilters) ([]*store.Transaction, error) {
 	query := ts.db.WithContext(ctx).
-		Where("payer_id = ? OR payee_id = ?", userID, userID)
-	query = ts.applyFilters(query, filters)
+		Where("(payer_id = ? OR payee_id = ?)", userID, userID)
+	query, err := ts.applyFilters(query, filters)
+	if err != nil {
+		return nil, err
+	}
 
 	var transactions []*store.Transaction
 	if err := query.Find(&transactions).Error; err != nil {
@@ -96,6 +117,123 @@ func (ts *transactionStore) GetByUser(ctx context.Context, userID string, filter
 	return transactions, nil
 }
 
+func (ts *transactionStore) GetTotalsByUser(ctx context.Context, userID string, filters store.TransactionFilters) (*store.TransactionTotals, error) {
+	query := ts.db.WithContext(ctx).
+		Where("(payer_id = ? OR payee_id = ?)", userID, userID)
+	return ts.totals(query, filters)
+}
+
+func (ts *transactionStore) GetExpiringHolds(ctx context.Context, before time.Time) ([]*store.Transaction, error) {
+	var transactions []*store.Transaction
+	err := ts.db.WithContext(ctx).
+		Where("type = ? AND status = ? AND hold_expires_at < ?",
+			store.TransactionTypePayment,
+			store.TransactionStatusAuthorized,
+			before).
+		Order("hold_expires_at ASC").
+		Find(&transactions).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (ts *transactionStore) GetHoldsToCapture(ctx context.Context, completedBefore time.Time) ([]*store.Transaction, error) {
+	db := ts.db.WithContext(ctx)
+	completed := db.Model(&store.Booking{}).
+		Select("id").
+		Where("status = ? AND completed_at < ?", store.BookingStatusCompleted, completedBefore)
+
+	var transactions []*store.Transaction
+	err := db.
+		Where("type = ? AND status = ? AND booking_id IN (?)",
+			store.TransactionTypePayment,
+			store.TransactionStatusAuthorized,
+			completed).
+		Order("processed_at ASC").
+		Find(&transactions).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (ts *transactionStore) GetTipsDueForPayout(ctx context.Context, completedFrom, completedBefore time.Time) ([]*store.Transaction, error) {
+	var tips []*store.Transaction
+	err := ts.db.WithContext(ctx).
+		Where("type = ? AND status = ? AND completed_at < ? AND payout_transaction_id IS NULL",
+			store.TransactionTypeTip,
+			store.TransactionStatusCompleted,
+			completedBefore).
+		Where("(completed_at >= ? OR "+fmt.Sprintf(disputeResolved, "transactions.booking_id")+")", completedFrom).
+		Where("NOT " + fmt.Sprintf(disputeUnresolved, "transactions.booking_id")).
+		Order("completed_at ASC").
+		Find(&tips).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return tips, nil
+}
+
+func (ts *transactionStore) GetClawbacksDue(ctx context.Context) ([]*store.Transaction, error) {
+	var clawbacks []*store.Transaction
+	err := ts.db.WithContext(ctx).
+		Where("type = ? AND status = ? AND payout_transaction_id IS NULL",
+			store.TransactionTypeClawback,
+			store.TransactionStatusCompleted).
+		Order("processed_at ASC").
+		Find(&clawbacks).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return clawbacks, nil
+}
+
+func (ts *transactionStore) GetCompensationDue(ctx context.Context, before time.Time) ([]*store.Transaction, error) {
+	var compensations []*store.Transaction
+	err := ts.db.WithContext(ctx).
+		Where("type = ? AND status = ? AND booking_id IS NOT NULL AND payout_batch_id IS NULL AND processed_at < ?",
+			store.TransactionTypePayout,
+			store.TransactionStatusPending,
+			before).
+		Order("processed_at ASC").
+		Find(&compensations).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return compensations, nil
+}
+
+func (ts *transactionStore) GetByPayouts(ctx context.Context, payoutIDs []string) ([]*store.Transaction, error) {
+	var transactions []*store.Transaction
+	if len(payoutIDs) == 0 {
+		return transactions, nil
+	}
+
+	err := ts.db.WithContext(ctx).
+		Where("type IN ? AND payout_transaction_id IN ?",
+			[]store.TransactionType{store.TransactionTypeTip, store.TransactionTypeClawback},
+			payoutIDs).
+		Order("processed_at ASC").
+		Find(&transactions).Error
+
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (ts *transactionStore) GetTipTotals(ctx context.Context, cleanerID string) (*store.TransactionTotals, error) {
+	tip, completed := store.TransactionTypeTip, store.TransactionStatusCompleted
+	query := ts.db.WithContext(ctx).Where("payee_id = ?", cleanerID)
+	return ts.totals(query, store.TransactionFilters{Type: &tip, Status: &completed})
+}
+
 func (ts *transactionStore) GetPayoutsDue(ctx context.Context, beforeDate time.Time) ([]*store.Transaction, error) {
 	var transactions []*store.Transaction
 	err := ts.db.WithContext(ctx).
@@ -103,6 +241,7 @@ func (ts *transactionStore) GetPayoutsDue(ctx context.Context, beforeDate time.T
 			store.TransactionTypePayout,
 			store.TransactionStatusPending,
 			beforeDate).
+		Where("(booking_id IS NULL OR NOT " + fmt.Sprintf(disputeUnresolved, "transactions.booking_id") + ")").
 		Order("processed_at ASC").
 		Find(&transactions).Error
 
@@ -113,8 +252,10 @@ func (ts *transactionStore) GetPayoutsDue(ctx context.Context, beforeDate time.T
 }
 
 func (ts *transactionStore) ListAll(ctx context.Context, filters store.TransactionFilters) ([]*store.Transaction, error) {
-	query := ts.db.WithContext(ctx)
-	query = ts.applyFilters(query, filters)
+	query, err := ts.applyFilters(ts.db.WithContext(ctx), filters)
+	if err != nil {
+		return nil, err
+	}
 
 	var transactions []*store.Transaction
 	if err := query.Find(&transactions).Error; err != nil {
@@ -123,6 +264,10 @@ func (ts *transactionStore) ListAll(ctx context.Context, filters store.Transacti
 	return transactions, nil
 }
 
+func (ts *transactionStore) GetTotals(ctx context.Context, filters store.TransactionFilters) (*store.TransactionTotals, error) {
+	return ts.totals(ts.db.WithContext(ctx), filters)
+}
+
 func (ts *transactionStore) CreatePayoutBatch(ctx context.Context, batch *store.PayoutBatch) error {
 	result := ts.db.WithContext(ctx).Create(batch)
 	if result.Error != nil {
@@ -160,36 +305,226 @@ func (ts *transactionStore) ListPayoutBatches(ctx context.Context, limit, offset
 	return batches, nil
 }
 
-func (ts *transactionStore) GetCleanerEarnings(ctx context.Context, cleanerID string, startDate, endDate time.Time) (int64, error) {
-	var result struct {
-		TotalEarnings int64
+func (ts *transactionStore) OpenPayoutBatch(ctx context.Context, batch *store.PayoutBatch, allocations []*store.PayoutAllocation) error {
+	return ts.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+
+		for _, allocation := range allocations {
+			if allocation.Compensation {
+				// Only compensation still pending outside of a batch is added, so no batch pays it twice
+				result := tx.Model(&store.Transaction{}).
+					Where("id = ? AND type = ? AND status = ? AND payout_batch_id IS NULL",
+						allocation.Payout.ID, store.TransactionTypePayout, store.TransactionStatusPending).
+					Update("payout_batch_id", batch.ID)
+				if result.Error != nil {
+					return result.Error
+				}
+				if result.RowsAffected != 1 {
+					return store.ErrPayoutConflict
+				}
+				allocation.Payout.PayoutBatchID = &batch.ID
+				continue
+			}
+
+			allocation.Payout.PayoutBatchID = &batch.ID
+			if err := tx.Create(allocation.Payout).Error; err != nil {
+				return err
+			}
+
+			// Only bookings not paid out yet are linked, so concurrent batches cannot both pay one
+			result := tx.Model(&store.Booking{}).
+				Where("id IN ? AND payout_transaction_id IS NULL", allocation.BookingIDs).
+				Update("payout_transaction_id", allocation.Payout.ID)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected != int64(len(allocation.BookingIDs)) {
+				return store.ErrPayoutConflict
+			}
+
+			linked := append(append([]string{}, allocation.TipIDs...), allocation.ClawbackIDs...)
+			if len(linked) == 0 {
+				continue
+			}
+			result = tx.Model(&store.Transaction{}).
+				Where("id IN ? AND type IN ? AND payout_transaction_id IS NULL", linked,
+					[]store.TransactionType{store.TransactionTypeTip, store.TransactionTypeClawback}).
+				Update("payout_transaction_id", allocation.Payout.ID)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected != int64(len(linked)) {
+				return store.ErrPayoutConflict
+			}
+		}
+		return nil
+	})
+}
+
+func (ts *transactionStore) ClaimPayoutBatch(ctx context.Context, id string, staleBefore time.Time) (bool, error) {
+	result := ts.db.WithContext(ctx).Model(&store.PayoutBatch{}).
+		Where("id = ?", id).
+		Where("(status IN ? OR (status = ? AND updated_at < ?))",
+			[]store.TransactionStatus{store.TransactionStatusPending, store.TransactionStatusFailed},
+			store.TransactionStatusProcessing, staleBefore).
+		Updates(map[string]interface{}{
+			"status":       store.TransactionStatusProcessing,
+			"processed_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
 	}
+	return result.RowsAffected == 1, nil
+}
 
-	query := ts.db.WithContext(ctx).
-		Model(&store.Transaction{}).
-		Select("COALESCE(SUM(net_amount), 0) as total_earnings").
+func (ts *transactionStore) UpdatePayoutBatch(ctx context.Context, batch *store.PayoutBatch) error {
+	result := ts.db.WithContext(ctx).Save(batch)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("payout batch not found (id: %s)", batch.ID)
+	}
+	return nil
+}
+
+func (ts *transactionStore) GetByPayoutBatch(ctx context.Context, batchID string) ([]*store.Transaction, error) {
+	var transactions []*store.Transaction
+	result := ts.db.WithContext(ctx).
+		Where("payout_batch_id = ?", batchID).
+		Order("created_at ASC").
+		Find(&transactions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return transactions, nil
+}
+
+func (ts *transactionStore) GetCleanerEarnings(ctx context.Context, cleanerID string, startDate, endDate time.Time) (*store.EarningsSummary, error) {
+	db := ts.db.WithContext(ctx)
+
+	paid := db.Model(&store.Transaction{}).
 		Where("payee_id = ? AND type = ? AND status = ?",
 			cleanerID,
 			store.TransactionTypePayout,
 			store.TransactionStatusCompleted)
-
 	if !startDate.IsZero() {
-		query = query.Where("completed_at >= ?", startDate)
+		paid = paid.Where("completed_at >= ?", startDate)
 	}
 	if !endDate.IsZero() {
-		query = query.Where("completed_at <= ?", endDate)
+		paid = paid.Where("completed_at <= ?", endDate)
+	}
+	// The query of paid payouts is reused by each of the totals below
+	paid = paid.Session(&gorm.Session{})
+
+	earnings := &store.EarningsSummary{}
+	if err := paid.
+		Select("COALESCE(SUM(net_amount), 0)").
+		Scan(&earnings.TotalEarnings).Error; err != nil {
+		return nil, err
+	}
+
+	// Batched payouts pay the bookings linked to them; compensation payouts carry their booking
+	var batched, compensated int64
+	if err := db.Model(&store.Booking{}).
+		Where("payout_transaction_id IN (?)", paid.Select("id")).
+		Count(&batched).Error; err != nil {
+		return nil, err
+	}
+	if err := paid.
+		Where("booking_id IS NOT NULL").
+		Distinct("booking_id").
+		Count(&compensated).Error; err != nil {
+		return nil, err
+	}
+	earnings.PaidBookings = int(batched + compensated)
+
+	if err := db.Model(&store.Transaction{}).
+		Select("COALESCE(SUM(net_amount), 0)").
+		Where("type = ? AND payout_transaction_id IN (?)", store.TransactionTypeTip, paid.Select("id")).
+		Scan(&earnings.Tips).Error; err != nil {
+		return nil, err
 	}
 
-	err := query.Scan(&result).Error
+	if err := db.Model(&store.Transaction{}).
+		Select("COALESCE(SUM(net_amount), 0)").
+		Where("payee_id = ? AND type = ? AND status IN ?",
+			cleanerID,
+			store.TransactionTypePayout,
+			[]store.TransactionStatus{store.TransactionStatusPending, store.TransactionStatusProcessing, store.TransactionStatusFailed}).
+		Scan(&earnings.PendingPayouts).Error; err != nil {
+		return nil, err
+	}
+
+	return earnings, nil
+}
+
+// transactionOrderColumns are the columns transactions can be ordered by
+var transactionOrderColumns = map[string]bool{
+	"created_at":   true,
+	"processed_at": true,
+	"completed_at": true,
+	"amount":       true,
+}
+
+// transactionOrder checks an order of the form "column [ASC|DESC]", as it is put into the
+// query as is
+func transactionOrder(orderBy string) (string, error) {
+	if orderBy == "" {
+		return "created_at DESC", nil
+	}
+
+	parts := strings.Fields(orderBy)
+	if len(parts) == 0 || len(parts) > 2 || !transactionOrderColumns[strings.ToLower(parts[0])] {
+		return "", store.ErrInvalidInput
+	}
+	direction := "ASC"
+	if len(parts) == 2 {
+		direction = strings.ToUpper(parts[1])
+		if direction != "ASC" && direction != "DESC" {
+			return "", store.ErrInvalidInput
+		}
+	}
+	// The ID breaks ties so pages do not overlap
+	return strings.ToLower(parts[0]) + " " + direction + ", id " + direction, nil
+}
+
+// totals counts and sums the transactions of a query matching the filters
+func (ts *transactionStore) totals(query *gorm.DB, filters store.TransactionFilters) (*store.TransactionTotals, error) {
+	var result struct {
+		Count  int
+		Amount int
+	}
+	err := ts.applyConditions(query.Model(&store.Transaction{}), filters).
+		Select("COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Scan(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return &store.TransactionTotals{Count: result.Count, Amount: result.Amount}, nil
+}
+
+// Helper method to apply filters, ordering and paging
+func (ts *transactionStore) applyFilters(query *gorm.DB, filters store.TransactionFilters) (*gorm.DB, error) {
+	order, err := transactionOrder(filters.OrderBy)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
+	query = ts.applyConditions(query, filters).Order(order)
 
-	return result.TotalEarnings, nil
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+	if filters.Offset > 0 {
+		query = query.Offset(filters.Offset)
+	}
+
+	return query, nil
 }
 
-// Helper method to apply filters
-func (ts *transactionStore) applyFilters(query *gorm.DB, filters store.TransactionFilters) *gorm.DB {
+func (ts *transactionStore) applyConditions(query *gorm.DB, filters store.TransactionFilters) *gorm.DB {
 	if filters.Type != nil {
 		query = query.Where("type = ?", *filters.Type)
 	}
@@ -205,25 +540,17 @@ func (ts *transactionStore) applyFilters(query *gorm.DB, filters store.Transacti
 	if filters.EndDate != nil {
 		query = query.Where("created_at <= ?", *filters.EndDate)
 	}
+	if filters.CompletedFrom != nil {
+		query = query.Where("completed_at >= ?", *filters.CompletedFrom)
+	}
+	if filters.CompletedTo != nil {
+		query = query.Where("completed_at <= ?", *filters.CompletedTo)
+	}
 	if filters.MinAmount != nil {
 		query = query.Where("amount >= ?", *filters.MinAmount)
 	}
 	if filters.MaxAmount != nil {
 		query = query.Where("amount <= ?", *filters.MaxAmount)
 	}
-
-	if filters.OrderBy != "" {
-		query = query.Order(filters.OrderBy)
-	} else {
-		query = query.Order("created_at DESC")
-	}
-
-	if filters.Limit > 0 {
-		query = query.Limit(filters.Limit)
-	}
-	if filters.Offset > 0 {
-		query = query.Offset(filters.Offset)
-	}
-
 	return query
 }
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// PromoDiscountType represents how a promo code discount is computed
+type PromoDiscountType string
+
+const (
+	PromoDiscountTypePercentage PromoDiscountType = "percentage" // Percent of the booking total
+	PromoDiscountTypeFixed      PromoDiscountType = "fixed"      // Fixed amount in bani
+)
+
+// PromoFunder represents who bears the cost of a discount
+type PromoFunder string
+
+const (
+	PromoFunderPlatform PromoFunder = "platform" // Taken from the platform's fee and commission
+	PromoFunderCompany  PromoFunder = "company"  // Taken from the cleaner payout of the company's cleaners
+)
+
+// PromoCode is a discount customers can apply at checkout
+type PromoCode struct {
+	ID          string `gorm:"primaryKey;size:50;unique"`
+	Code        string `gorm:"size:50;not null;unique;index:idx_promo_code_code"` // Stored upper-case
+	Description string `gorm:"type:text"`
+
+	// Discount
+	DiscountType  PromoDiscountType `gorm:"size:20;not null"`
+	DiscountValue int               `gorm:"not null"` // Percent for percentage codes, bani for fixed codes
+	MaxDiscount   *int              // Cap in bani for percentage codes
+
+	// Funding
+	FundedBy  PromoFunder `gorm:"size:20;not null;default:'platform'"`
+	Company   *Company    `gorm:"foreignKey:CompanyID"`
+	CompanyID *string     `gorm:"size:50;index:idx_promo_code_company"` // Company codes only apply to its cleaners
+
+	// Restrictions
+	FirstBookingOnly bool   `gorm:"not null;default:false"`
+	ServiceTypes     string `gorm:"type:text"` // JSON array of ServiceType values; empty allows all
+	Cities           string `gorm:"type:text"` // JSON array of city names; empty allows all
+
+	// Limits
+	MaxRedemptions        *int // Across all customers
+	MaxRedemptionsPerUser *int
+	RedemptionCount       int `gorm:"not null;default:0"`
+
+	// Validity window
+	ValidFrom  *time.Time
+	ValidUntil *time.Time
+
+	IsActive bool `gorm:"not null;default:true"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;not null"`
+}
+
+// PromoRedemption records a promo code used on a booking
+type PromoRedemption struct {
+	ID          string     `gorm:"primaryKey;size:50;unique"`
+	PromoCode   *PromoCode `gorm:"foreignKey:PromoCodeID"`
+	PromoCodeID string     `gorm:"size:50;not null;index:idx_promo_redemption_code_user"`
+	User        *User      `gorm:"foreignKey:UserID"`
+	UserID      string     `gorm:"size:50;not null;index:idx_promo_redemption_code_user"`
+	BookingID   string     `gorm:"size:50;not null;unique"`
+
+	DiscountAmount int `gorm:"not null"` // in bani
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null"`
+}
+
+// PromoCodeStore defines the data access interface for promo codes and their redemptions
+type PromoCodeStore interface {
+	// Create creates a new promo code
+	Create(ctx context.Context, code *PromoCode) error
+
+	// Get retrieves a promo code by ID
+	Get(ctx context.Context, id string) (*PromoCode, error)
+
+	// GetByCode retrieves a promo code by its (upper-case) code
+	GetByCode(ctx context.Context, code string) (*PromoCode, error)
+
+	// Update updates a promo code
+	Update(ctx context.Context, code *PromoCode) error
+
+	// List retrieves promo codes, newest first
+	List(ctx context.Context, activeOnly bool, limit, offset int) ([]*PromoCode, error)
+
+	// CountRedemptionsByUser counts how often a user has redeemed a code
+	CountRedemptionsByUser(ctx context.Context, promoCodeID, userID string) (int64, error)
+
+	// Redeem records a redemption and increments the code's count atomically,
+	// returning ErrPromoLimitReached when the global or per-user limit is exhausted
+	Redeem(ctx context.Context, redemption *PromoRedemption) error
+
+	// ReleaseByBooking removes the redemption of a booking and decrements the code's count
+	ReleaseByBooking(ctx context.Context, bookingID string) error
+}
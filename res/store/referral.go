@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// ReferralStatus represents where a referral is in the reward flow
+type ReferralStatus string
+
+const (
+	ReferralStatusPending  ReferralStatus = "pending"  // Referee signed up, no completed booking yet
+	ReferralStatusRewarded ReferralStatus = "rewarded" // Referee completed a first booking, both parties credited
+)
+
+// ReferralCode is the code a user shares to invite friends
+type ReferralCode struct {
+	Code      string    `gorm:"primaryKey;size:20;unique"`
+	User      *User     `gorm:"foreignKey:UserID"`
+	UserID    string    `gorm:"size:50;not null;unique"`
+	CreatedAt time.Time `gorm:"autoCreateTime;not null"`
+}
+
+// Referral attributes a new customer to the user who invited them
+type Referral struct {
+	ID         string         `gorm:"primaryKey;size:50;unique"`
+	Code       string         `gorm:"size:20;not null"`
+	Referrer   *User          `gorm:"foreignKey:ReferrerID"`
+	ReferrerID string         `gorm:"size:50;not null;index:idx_referral_referrer"`
+	Referee    *User          `gorm:"foreignKey:RefereeID"`
+	RefereeID  string         `gorm:"size:50;not null;unique"` // A customer can only be referred once
+	Status     ReferralStatus `gorm:"size:20;not null;default:'pending';index:idx_referral_status"`
+
+	// Reward, set when the referee completes a first booking (amounts in bani)
+	BookingID      *string `gorm:"size:50"`
+	ReferrerCredit int     `gorm:"not null;default:0"`
+	RefereeCredit  int     `gorm:"not null;default:0"`
+	RewardedAt     *time.Time
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;not null"`
+}
+
+// ReferralStats summarizes a referrer's invitations
+type ReferralStats struct {
+	Pending       int
+	Rewarded      int
+	CreditsEarned int // in bani
+}
+
+// ReferralStore defines the data access interface for referral codes and referrals
+type ReferralStore interface {
+	// CreateCode stores a user's referral code
+	CreateCode(ctx context.Context, code *ReferralCode) error
+
+	// GetCode retrieves a referral code by its value
+	GetCode(ctx context.Context, code string) (*ReferralCode, error)
+
+	// GetCodeByUser retrieves the referral code of a user, returning nil when they have none yet
+	GetCodeByUser(ctx context.Context, userID string) (*ReferralCode, error)
+
+	// Create creates a new referral
+	Create(ctx context.Context, referral *Referral) error
+
+	// GetByReferee retrieves the referral of a user, returning nil when they were not referred
+	GetByReferee(ctx context.Context, refereeID string) (*Referral, error)
+
+	// MarkRewarded moves a pending referral to rewarded and records its credit entries in the
+	// same transaction, returning ErrReferralAlreadyRewarded when it was rewarded already so
+	// credits are only granted once
+	MarkRewarded(ctx context.Context, referral *Referral, credits []*CreditEntry) error
+
+	// Stats summarizes the referrals made by a user
+	Stats(ctx context.Context, referrerID string) (*ReferralStats, error)
+}
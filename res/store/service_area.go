@@ -46,4 +46,36 @@ type ServiceAreaStore interface {
 
 	// FindCleanersByPostalCode finds all cleaners serving a specific postal code
 	FindCleanersByPostalCode(ctx context.Context, postalCode string) ([]*CleanerProfile, error)
+
+	// FindMatchingAreas finds the service areas covering a location: areas in the city
+	// with the same postal code or neighborhood, and areas covering the whole city
+	FindMatchingAreas(ctx context.Context, city, neighborhood, postalCode string) ([]*ServiceArea, error)
+}
+
+// MatchServiceArea picks the area whose travel fee applies to a location,
+// preferring a postal code match, then a neighborhood match, then a city-wide area
+func MatchServiceArea(areas []*ServiceArea, city, neighborhood, postalCode string) *ServiceArea {
+	var byNeighborhood, byCity *ServiceArea
+	for _, area := range areas {
+		if area.City != city {
+			continue
+		}
+		switch {
+		case postalCode != "" && area.PostalCode == postalCode:
+			return area
+		case neighborhood != "" && area.Neighborhood == neighborhood:
+			if byNeighborhood == nil {
+				byNeighborhood = area
+			}
+		case area.PostalCode == "" && area.Neighborhood == "":
+			if byCity == nil {
+				byCity = area
+			}
+		}
+	}
+
+	if byNeighborhood != nil {
+		return byNeighborhood
+	}
+	return byCity
 }
@@ -13,11 +13,22 @@ type Store interface {
 	Addresses() AddressStore
 	Services() ServiceStore
 	Bookings() BookingStore
+	BookingStatusHistory() BookingStatusHistoryStore
+	BookingRescheduleRequests() BookingRescheduleRequestStore
 	Reviews() ReviewStore
 	Transactions() TransactionStore
 	Availability() AvailabilityStore
 	Companies() CompanyStore
 	CleanerInvites() CleanerInviteStore
+	CommissionRules() CommissionRuleStore
+	PromoCodes() PromoCodeStore
+	Referrals() ReferralStore
+	Credits() CreditStore
+	GiftCards() GiftCardStore
+	PaymentEvents() PaymentEventStore
+	Ledger() LedgerStore
+	Invoices() InvoiceStore
+	Disputes() DisputeStore
 
 	// Database access for advanced operations
 	GetDB() interface{} // Returns the underlying database connection
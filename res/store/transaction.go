@@ -12,6 +12,16 @@ const (
 	TransactionTypePayment TransactionType = "payment" // Customer payment
 	TransactionTypePayout  TransactionType = "payout"  // Cleaner payout
 	TransactionTypeRefund  TransactionType = "refund"  // Refund to customer
+	TransactionTypeTip     TransactionType = "tip"     // Customer tip, passed to the cleaner in full
+
+	// Taken back from a cleaner's earnings, e.g. when a dispute is resolved against them, and
+	// deducted from their next payout
+	TransactionTypeClawback TransactionType = "clawback"
+
+	// Gift card movements, linked to the card through GiftCardID
+	TransactionTypeGiftCardPurchase   TransactionType = "gift_card_purchase"   // Customer buys a gift card
+	TransactionTypeGiftCardRedemption TransactionType = "gift_card_redemption" // Gift card balance spent on a booking
+	TransactionTypeGiftCardExpiry     TransactionType = "gift_card_expiry"     // Unspent balance forfeited at expiry
 )
 
 // TransactionStatus represents the status of a transaction
@@ -19,6 +29,7 @@ type TransactionStatus string
 
 const (
 	TransactionStatusPending   TransactionStatus = "pending"   // Transaction initiated
+	TransactionStatusAuthorized TransactionStatus = "authorized" // Card payment held, not yet captured
 	TransactionStatusProcessing TransactionStatus = "processing" // Being processed
 	TransactionStatusCompleted TransactionStatus = "completed" // Successfully completed
 	TransactionStatusFailed    TransactionStatus = "failed"    // Failed
@@ -32,6 +43,7 @@ const (
 	PaymentMethodCard         PaymentMethod = "card"
 	PaymentMethodBankTransfer PaymentMethod = "bank_transfer"
 	PaymentMethodCash         PaymentMethod = "cash"
+	PaymentMethodGiftCard     PaymentMethod = "gift_card"
 )
 
 // Transaction represents a financial transaction in the system
@@ -44,6 +56,17 @@ type Transaction struct {
 	Booking   *Booking `gorm:"foreignKey:BookingID"`
 	BookingID *string  `gorm:"size:50;index:idx_transaction_booking"`
 
+	// Gift card the value moved on or off (gift card transactions only)
+	GiftCard   *GiftCard `gorm:"foreignKey:GiftCardID"`
+	GiftCardID *string   `gorm:"size:50;index:idx_transaction_gift_card"`
+
+	// Payout batch a payout was made in (batched payouts only)
+	PayoutBatch   *PayoutBatch `gorm:"foreignKey:PayoutBatchID"`
+	PayoutBatchID *string      `gorm:"size:50;index:idx_transaction_payout_batch"`
+
+	// Payout a tip was paid out in, or a clawback deducted from (tips and clawbacks only)
+	PayoutTransactionID *string `gorm:"size:50;index:idx_transaction_payout"`
+
 	// Payer (customer for payments, platform for payouts)
 	Payer   *User  `gorm:"foreignKey:PayerID"`
 	PayerID string `gorm:"size:50;not null;index:idx_transaction_payer"`
@@ -79,6 +102,9 @@ type Transaction struct {
 	CompletedAt *time.Time
 	FailedAt    *time.Time
 
+	// HoldExpiresAt is when the card authorization of an authorized payment lapses at the provider
+	HoldExpiresAt *time.Time `gorm:"index:idx_transaction_hold_expires"`
+
 	CreatedAt time.Time `gorm:"autoCreateTime;not null;index:idx_transaction_created"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime;not null"`
 }
@@ -91,6 +117,8 @@ type PayoutBatch struct {
 	// Batch Details
 	TotalAmount    int       `gorm:"not null"` // Total amount in batch (in bani)
 	TotalPayouts   int       `gorm:"not null"` // Number of payouts in batch
+	PaidAmount     int       `gorm:"not null;default:0"` // Amount transferred so far (in bani)
+	FailedPayouts  int       `gorm:"not null;default:0"` // Payouts whose last transfer attempt failed
 	PeriodStart    time.Time `gorm:"not null"` // Start of payout period
 	PeriodEnd      time.Time `gorm:"not null"` // End of payout period
 
@@ -107,6 +135,17 @@ type PayoutBatch struct {
 	UpdatedAt time.Time `gorm:"autoUpdateTime;not null"`
 }
 
+// PayoutAllocation is a payout of a batch, the bookings and tips whose cleaner earnings it pays
+// and the clawbacks deducted from it. A compensation allocation adds an existing compensation
+// payout to the batch as it is.
+type PayoutAllocation struct {
+	Payout       *Transaction
+	BookingIDs   []string
+	TipIDs       []string
+	ClawbackIDs  []string
+	Compensation bool
+}
+
 // TransactionStore defines the data access interface for transactions
 type TransactionStore interface {
 	// Create creates a new transaction
@@ -118,24 +157,62 @@ type TransactionStore interface {
 	// GetByStripePaymentID retrieves a transaction by Stripe payment ID
 	GetByStripePaymentID(ctx context.Context, stripePaymentID string) (*Transaction, error)
 
+	// GetByStripeRefundID retrieves a refund transaction by Stripe refund ID
+	GetByStripeRefundID(ctx context.Context, stripeRefundID string) (*Transaction, error)
+
 	// Update updates a transaction
 	Update(ctx context.Context, transaction *Transaction) error
 
-	// UpdateStatus updates the status of a transaction
+	// UpdateStatus updates the status of a transaction, stamping CompletedAt or FailedAt
+	// when it completes or fails
 	UpdateStatus(ctx context.Context, transactionID string, status TransactionStatus) error
 
 	// GetByBooking retrieves all transactions for a booking
 	GetByBooking(ctx context.Context, bookingID string) ([]*Transaction, error)
 
-	// GetByUser retrieves all transactions for a user (as payer or payee)
+	// GetByUser retrieves all transactions for a user (as payer or payee).
+	// Returns ErrInvalidInput if the filters order by an unknown column.
 	GetByUser(ctx context.Context, userID string, filters TransactionFilters) ([]*Transaction, error)
 
-	// GetPayoutsDue retrieves transactions that are due for payout
+	// GetTotalsByUser counts and sums the transactions of a user matching the filters, ignoring
+	// their limit and offset
+	GetTotalsByUser(ctx context.Context, userID string, filters TransactionFilters) (*TransactionTotals, error)
+
+	// GetExpiringHolds retrieves authorized card payments whose hold lapses before the given time
+	GetExpiringHolds(ctx context.Context, before time.Time) ([]*Transaction, error)
+
+	// GetHoldsToCapture retrieves authorized card payments of bookings completed before the given time
+	GetHoldsToCapture(ctx context.Context, completedBefore time.Time) ([]*Transaction, error)
+
+	// GetTipsDueForPayout retrieves tips completed in [completedFrom, completedBefore) that were not
+	// paid out yet, and earlier ones held back by a dispute resolved since. Tips of bookings with an
+	// unresolved dispute are held back.
+	GetTipsDueForPayout(ctx context.Context, completedFrom, completedBefore time.Time) ([]*Transaction, error)
+
+	// GetClawbacksDue retrieves clawbacks not deducted from a payout yet, oldest first
+	GetClawbacksDue(ctx context.Context) ([]*Transaction, error)
+
+	// GetCompensationDue retrieves pending compensation payouts of cancelled and no-show bookings
+	// recorded before the given time and not added to a payout batch yet, oldest first
+	GetCompensationDue(ctx context.Context, before time.Time) ([]*Transaction, error)
+
+	// GetByPayouts retrieves the tips paid out and the clawbacks deducted by the given payouts
+	GetByPayouts(ctx context.Context, payoutIDs []string) ([]*Transaction, error)
+
+	// GetTipTotals counts and sums the completed tips a cleaner received
+	GetTipTotals(ctx context.Context, cleanerID string) (*TransactionTotals, error)
+
+	// GetPayoutsDue retrieves transactions that are due for payout, except those of bookings with an
+	// unresolved dispute
 	GetPayoutsDue(ctx context.Context, beforeDate time.Time) ([]*Transaction, error)
 
-	// ListAll retrieves all transactions with filters (for admin)
+	// ListAll retrieves all transactions with filters (for admin).
+	// Returns ErrInvalidInput if the filters order by an unknown column.
 	ListAll(ctx context.Context, filters TransactionFilters) ([]*Transaction, error)
 
+	// GetTotals counts and sums all transactions matching the filters, ignoring their limit and offset
+	GetTotals(ctx context.Context, filters TransactionFilters) (*TransactionTotals, error)
+
 	// CreatePayoutBatch creates a new payout batch
 	CreatePayoutBatch(ctx context.Context, batch *PayoutBatch) error
 
@@ -145,8 +222,38 @@ type TransactionStore interface {
 	// ListPayoutBatches lists all payout batches
 	ListPayoutBatches(ctx context.Context, limit, offset int) ([]*PayoutBatch, error)
 
-	// GetCleanerEarnings calculates total earnings for a cleaner
-	GetCleanerEarnings(ctx context.Context, cleanerID string, startDate, endDate time.Time) (int64, error)
+	// OpenPayoutBatch creates a batch with its payout transactions, links every allocated booking,
+	// tip and clawback to its payout and adds the allocated compensation payouts to the batch.
+	// Returns ErrPayoutConflict, creating nothing, if one was already linked or batched.
+	OpenPayoutBatch(ctx context.Context, batch *PayoutBatch, allocations []*PayoutAllocation) error
+
+	// ClaimPayoutBatch marks a pending or failed batch as processing, or one whose processing stalled
+	// before staleBefore. Returns false if the batch was not claimed.
+	ClaimPayoutBatch(ctx context.Context, id string, staleBefore time.Time) (bool, error)
+
+	// UpdatePayoutBatch updates a payout batch
+	UpdatePayoutBatch(ctx context.Context, batch *PayoutBatch) error
+
+	// GetByPayoutBatch retrieves the payouts of a batch
+	GetByPayoutBatch(ctx context.Context, batchID string) ([]*Transaction, error)
+
+	// GetCleanerEarnings sums a cleaner's payouts completed between the given times, either of
+	// which may be zero to leave the period open, and the payouts still to be transferred
+	GetCleanerEarnings(ctx context.Context, cleanerID string, startDate, endDate time.Time) (*EarningsSummary, error)
+}
+
+// TransactionTotals summarizes the transactions matching a filter
+type TransactionTotals struct {
+	Count  int
+	Amount int // in bani
+}
+
+// EarningsSummary summarizes what a cleaner was paid
+type EarningsSummary struct {
+	TotalEarnings  int // Net amount of completed payouts in bani
+	PaidBookings   int // Bookings the completed payouts paid for
+	PendingPayouts int // Net amount of payouts not transferred yet in bani, regardless of the period
+	Tips           int // Part of TotalEarnings that was tips, in bani
 }
 
 // TransactionFilters contains filter options for listing transactions
@@ -156,9 +263,11 @@ type TransactionFilters struct {
 	PaymentMethod *PaymentMethod
 	StartDate     *time.Time
 	EndDate       *time.Time
+	CompletedFrom *time.Time // completed_at bounds, inclusive; StartDate and EndDate bound created_at
+	CompletedTo   *time.Time
 	MinAmount     *int
 	MaxAmount     *int
 	Limit         int
 	Offset        int
-	OrderBy       string // e.g., "created_at DESC"
+	OrderBy       string // e.g., "created_at DESC"; one of created_at, processed_at, completed_at or amount
 }
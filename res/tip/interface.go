@@ -0,0 +1,50 @@
+package tip
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cleanbuddy-api/res/store"
+)
+
+var (
+	ErrBookingNotFound = errors.New("booking not found")
+	ErrNotCustomer     = errors.New("only the customer of a booking can tip its cleaner")
+	ErrNotCompleted    = errors.New("only completed bookings can be tipped")
+	ErrWindowClosed    = errors.New("the booking can no longer be tipped")
+	ErrInvalidAmount   = errors.New("tip amount is outside the allowed range")
+	ErrAlreadyTipped   = errors.New("the booking was already tipped")
+	ErrTipPending      = errors.New("a tip of another amount is awaiting payment confirmation")
+)
+
+// TipService lets customers tip the cleaner of a completed booking for a while after it was
+// completed. Tips are charged to the customer's card through the payment service and go to the
+// cleaner in full with their next payout; the platform takes no fee.
+type TipService interface {
+	// AddTip charges a tip of amount bani on a booking the customer had completed. A booking is
+	// tipped once; a tip still awaiting confirmation of the same amount is returned as it is.
+	AddTip(ctx context.Context, customer *store.User, bookingID string, amount int) (*store.Transaction, error)
+
+	// Totals counts and sums the tips a cleaner received
+	Totals(ctx context.Context, cleanerID string) (*store.TransactionTotals, error)
+}
+
+// Options configures when and how much customers can tip
+type Options struct {
+	// Window is how long after completion a booking can be tipped
+	Window time.Duration
+
+	// MinAmount and MaxAmount bound a tip in bani
+	MinAmount int
+	MaxAmount int
+}
+
+// DefaultOptions allows tips of 5 to 500 RON for three days after completion
+func DefaultOptions() Options {
+	return Options{
+		Window:    72 * time.Hour,
+		MinAmount: 500,
+		MaxAmount: 50000,
+	}
+}
@@ -0,0 +1,92 @@
+package tip
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"cleanbuddy-api/res/payment"
+	"cleanbuddy-api/res/store"
+)
+
+type service struct {
+	store    store.Store
+	payments payment.PaymentService
+	options  Options
+	logger   *log.Logger
+}
+
+// NewService creates a new TipService charging tips through the given payment service
+func NewService(dataStore store.Store, payments payment.PaymentService, options Options, logger *log.Logger) TipService {
+	return &service{
+		store:    dataStore,
+		payments: payments,
+		options:  options,
+		logger:   logger,
+	}
+}
+
+func (s *service) AddTip(ctx context.Context, customer *store.User, bookingID string, amount int) (*store.Transaction, error) {
+	booking, err := s.store.Bookings().Get(ctx, bookingID)
+	if err != nil {
+		return nil, ErrBookingNotFound
+	}
+	if customer == nil || booking.CustomerID != customer.ID {
+		return nil, ErrNotCustomer
+	}
+	if booking.Status != store.BookingStatusCompleted || booking.CompletedAt == nil {
+		return nil, ErrNotCompleted
+	}
+	if time.Since(*booking.CompletedAt) > s.options.Window {
+		return nil, ErrWindowClosed
+	}
+	if amount < s.options.MinAmount || amount > s.options.MaxAmount {
+		return nil, ErrInvalidAmount
+	}
+
+	existing, err := s.openTip(ctx, booking.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		// The customer may still confirm a tip that is pending or was declined, with another card
+		switch {
+		case existing.Status != store.TransactionStatusPending && existing.Status != store.TransactionStatusFailed:
+			return nil, ErrAlreadyTipped
+		case existing.Amount != amount:
+			return nil, ErrTipPending
+		}
+		return existing, nil
+	}
+
+	tip, err := s.payments.OpenTip(ctx, booking, amount)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Printf("Customer %s tipped %d on booking %s (%s)", customer.ID, amount, booking.ID, tip.Status)
+	return tip, nil
+}
+
+func (s *service) Totals(ctx context.Context, cleanerID string) (*store.TransactionTotals, error) {
+	totals, err := s.store.Transactions().GetTipTotals(ctx, cleanerID)
+	if err != nil {
+		s.logger.Printf("Failed to sum tips of cleaner %s: %v", cleanerID, err)
+		return nil, fmt.Errorf("failed to sum tips: %w", err)
+	}
+	return totals, nil
+}
+
+// openTip returns the tip of a booking that was not cancelled, or nil if it has none
+func (s *service) openTip(ctx context.Context, bookingID string) (*store.Transaction, error) {
+	transactions, err := s.store.Transactions().GetByBooking(ctx, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking transactions: %w", err)
+	}
+	for _, transaction := range transactions {
+		if transaction.Type == store.TransactionTypeTip && transaction.Status != store.TransactionStatusCancelled {
+			return transaction, nil
+		}
+	}
+	return nil, nil
+}
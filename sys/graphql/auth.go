@@ -87,7 +87,7 @@ func (mr *mutationResolver) AuthWithRefreshToken(ctx context.Context, token stri
 	return &gen.AuthResult{AccessToken: accessToken, RefreshToken: refreshToken}, nil
 }
 
-func (mr *mutationResolver) AuthWithIdentityProvider(ctx context.Context, code string, kind gen.AuthIdentityKind, intent *string, inviteToken *string) (*gen.AuthResult, error) {
+func (mr *mutationResolver) AuthWithIdentityProvider(ctx context.Context, code string, kind gen.AuthIdentityKind, intent *string, inviteToken *string, referralCode *string) (*gen.AuthResult, error) {
 	currentUser := middleware.GetCurrentUser(ctx)
 	if currentUser != nil {
 		return nil, errors.New("access forbidden, session already associated with a user")
@@ -213,6 +213,8 @@ func (mr *mutationResolver) AuthWithIdentityProvider(ctx context.Context, code s
 			}
 		}
 
+		mr.attributeReferral(ctx, newUser, referralCode)
+
 		finalUserID = newUser.ID
 	}
 
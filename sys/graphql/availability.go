@@ -3,42 +3,255 @@ package graphql
 import (
 	"context"
 	"errors"
+	"log"
+	"time"
 
+	"cleanbuddy-api/res/availability"
 	"cleanbuddy-api/res/store"
 	"cleanbuddy-api/sys/graphql/gen"
 	"cleanbuddy-api/sys/graphql/scalar"
+	"cleanbuddy-api/sys/http/middleware"
 )
 
 // QUERY RESOLVERS
 func (qr *queryResolver) Availability(ctx context.Context, id string) (*store.Availability, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	entry, err := qr.AvailabilityService.Get(ctx, id)
+	if err != nil {
+		return nil, translateAvailabilityError(qr.Logger, err, "error retrieving availability")
+	}
+	return entry, nil
 }
 
 func (qr *queryResolver) AvailabilityForCleaner(ctx context.Context, cleanerProfileID string, filters *gen.AvailabilityFiltersInput, limit, offset *int) ([]*store.Availability, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	entries, err := qr.AvailabilityService.ListForCleaner(ctx, cleanerProfileID, buildAvailabilityFilters(filters, limit, offset))
+	if err != nil {
+		return nil, translateAvailabilityError(qr.Logger, err, "error retrieving availability")
+	}
+	return entries, nil
 }
 
 func (qr *queryResolver) MyAvailability(ctx context.Context, filters *gen.AvailabilityFiltersInput, limit, offset *int) ([]*store.Availability, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	profile, err := qr.AvailabilityService.ProfileForUser(ctx, currentUser.ID)
+	if err != nil {
+		return nil, translateAvailabilityError(qr.Logger, err, "error retrieving availability")
+	}
+
+	entries, err := qr.AvailabilityService.ListForCleaner(ctx, profile.ID, buildAvailabilityFilters(filters, limit, offset))
+	if err != nil {
+		return nil, translateAvailabilityError(qr.Logger, err, "error retrieving availability")
+	}
+	return entries, nil
 }
 
 func (qr *queryResolver) IsCleanerAvailable(ctx context.Context, input gen.CheckAvailabilityInput) (bool, error) {
-	return false, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return false, errors.New("authentication required")
+	}
+
+	available, err := qr.AvailabilityService.IsAvailable(ctx, input.CleanerProfileID, input.Date, input.StartTime, input.EndTime)
+	if err != nil {
+		return false, translateAvailabilityError(qr.Logger, err, "error checking availability")
+	}
+	return available, nil
+}
+
+func (qr *queryResolver) AvailableSlots(ctx context.Context, cleanerProfileID string, serviceType store.ServiceType, addOns []store.ServiceAddOn, dateRange scalar.TimeInterval, granularity *int) ([]*availability.DaySlots, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	if dateRange[0] == nil || dateRange[1] == nil {
+		return nil, errors.New("date range must have a start and an end")
+	}
+
+	query := availability.SlotQuery{
+		CleanerProfileID: cleanerProfileID,
+		ServiceType:      serviceType,
+		AddOns:           addOns,
+		StartDate:        *dateRange[0],
+		EndDate:          *dateRange[1],
+	}
+	if granularity != nil {
+		query.Granularity = time.Duration(*granularity) * time.Minute
+	}
+
+	slots, err := qr.AvailabilityService.AvailableSlots(ctx, query)
+	if err != nil {
+		return nil, translateAvailabilityError(qr.Logger, err, "error computing available slots")
+	}
+	return slots, nil
 }
 
 // MUTATION RESOLVERS
 func (mr *mutationResolver) CreateAvailability(ctx context.Context, input gen.CreateAvailabilityInput) (*store.Availability, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	created, err := mr.AvailabilityService.Create(ctx, currentUser, []*store.Availability{availabilityFromInput(&input)})
+	if err != nil {
+		return nil, translateAvailabilityError(mr.Logger, err, "error creating availability")
+	}
+	return created[0], nil
 }
 
 func (mr *mutationResolver) UpdateAvailability(ctx context.Context, input gen.UpdateAvailabilityInput) (*store.Availability, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	entry, err := mr.AvailabilityService.Get(ctx, input.ID)
+	if err != nil {
+		return nil, translateAvailabilityError(mr.Logger, err, "error updating availability")
+	}
+
+	// Update fields
+	if input.Type != nil {
+		entry.Type = *input.Type
+	}
+	if input.Date != nil {
+		entry.Date = *input.Date
+	}
+	if input.StartTime != nil {
+		entry.StartTime = *input.StartTime
+	}
+	if input.EndTime != nil {
+		entry.EndTime = *input.EndTime
+	}
+	if input.IsRecurring != nil {
+		entry.IsRecurring = *input.IsRecurring
+	}
+	if input.RecurrencePattern != nil {
+		entry.RecurrencePattern = *input.RecurrencePattern
+	}
+	if input.RecurrenceEnd != nil {
+		entry.RecurrenceEnd = input.RecurrenceEnd
+	}
+	if input.Notes != nil {
+		entry.Notes = *input.Notes
+	}
+
+	updated, err := mr.AvailabilityService.Update(ctx, currentUser, entry)
+	if err != nil {
+		return nil, translateAvailabilityError(mr.Logger, err, "error updating availability")
+	}
+	return updated, nil
 }
 
 func (mr *mutationResolver) DeleteAvailability(ctx context.Context, id string) (*scalar.Void, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	if err := mr.AvailabilityService.Delete(ctx, currentUser, id); err != nil {
+		return nil, translateAvailabilityError(mr.Logger, err, "error deleting availability")
+	}
+	return &scalar.Void{}, nil
 }
 
 func (mr *mutationResolver) BulkCreateAvailability(ctx context.Context, inputs []*gen.CreateAvailabilityInput) ([]*store.Availability, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	entries := make([]*store.Availability, len(inputs))
+	for i, input := range inputs {
+		entries[i] = availabilityFromInput(input)
+	}
+
+	created, err := mr.AvailabilityService.Create(ctx, currentUser, entries)
+	if err != nil {
+		return nil, translateAvailabilityError(mr.Logger, err, "error creating availability")
+	}
+	return created, nil
+}
+
+// availabilityFromInput converts a create input into a store entry
+func availabilityFromInput(input *gen.CreateAvailabilityInput) *store.Availability {
+	entry := &store.Availability{
+		Type:      input.Type,
+		Date:      input.Date,
+		StartTime: input.StartTime,
+		EndTime:   input.EndTime,
+	}
+	if input.IsRecurring != nil {
+		entry.IsRecurring = *input.IsRecurring
+	}
+	if input.RecurrencePattern != nil {
+		entry.RecurrencePattern = *input.RecurrencePattern
+	}
+	if input.RecurrenceEnd != nil {
+		entry.RecurrenceEnd = input.RecurrenceEnd
+	}
+	if input.Notes != nil {
+		entry.Notes = *input.Notes
+	}
+	return entry
+}
+
+// buildAvailabilityFilters converts GraphQL filters and pagination into store filters
+func buildAvailabilityFilters(filters *gen.AvailabilityFiltersInput, limit, offset *int) store.AvailabilityFilters {
+	availabilityFilters := store.AvailabilityFilters{}
+	if filters != nil {
+		availabilityFilters.Type = filters.Type
+		availabilityFilters.StartDate = filters.StartDate
+		availabilityFilters.EndDate = filters.EndDate
+	}
+	if limit != nil {
+		availabilityFilters.Limit = *limit
+	}
+	if offset != nil {
+		availabilityFilters.Offset = *offset
+	}
+	return availabilityFilters
+}
+
+// translateAvailabilityError maps availability errors to user-facing messages
+func translateAvailabilityError(logger *log.Logger, err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, availability.ErrAvailabilityNotFound):
+		return errors.New("availability not found")
+	case errors.Is(err, availability.ErrCleanerProfileNotFound):
+		return errors.New("cleaner profile not found")
+	case errors.Is(err, availability.ErrAccessDenied):
+		return errors.New("access forbidden")
+	case errors.Is(err, availability.ErrInvalidTimeRange):
+		return errors.New("times must use HH:MM and start before they end")
+	case errors.Is(err, availability.ErrInvalidRecurrence):
+		return errors.New("recurring entries must repeat weekly and end after they start")
+	case errors.Is(err, availability.ErrInvalidDateRange):
+		return errors.New("start date must not be after end date")
+	case errors.Is(err, availability.ErrDateRangeTooLong):
+		return errors.New("date range is too long")
+	case errors.Is(err, availability.ErrServiceNotFound):
+		return errors.New("service not found")
+	case errors.Is(err, availability.ErrInvalidGranularity):
+		return errors.New("granularity must be between 5 and 240 minutes")
+	case errors.Is(err, availability.ErrInvalidDuration):
+		return errors.New("duration must be positive and at most 24 hours")
+	case errors.Is(err, availability.ErrLocationRequired):
+		return errors.New("city is required")
+	}
+	return logAndReturnError(logger, fallbackMsg, err, fallbackMsg)
 }
@@ -4,10 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"log"
 	"time"
 
+	"cleanbuddy-api/res/bookinglifecycle"
+	"cleanbuddy-api/res/bookingseries"
+	"cleanbuddy-api/res/cancellationpolicy"
+	"cleanbuddy-api/res/localtime"
+	"cleanbuddy-api/res/noshow"
+	"cleanbuddy-api/res/pricing"
 	"cleanbuddy-api/res/store"
 	"cleanbuddy-api/sys/graphql/gen"
+	"cleanbuddy-api/sys/graphql/scalar"
 	"cleanbuddy-api/sys/http/middleware"
 
 	"github.com/google/uuid"
@@ -17,6 +25,16 @@ import (
 type bookingResolver struct{ *Resolver }
 func (r *Resolver) Booking() gen.BookingResolver { return &bookingResolver{r} }
 
+func (br *bookingResolver) ScheduledDate(ctx context.Context, booking *store.Booking) (*time.Time, error) {
+	date, _ := booking.LocalSchedule()
+	return &date, nil
+}
+
+func (br *bookingResolver) ScheduledTime(ctx context.Context, booking *store.Booking) (string, error) {
+	_, timeOfDay := booking.LocalSchedule()
+	return timeOfDay, nil
+}
+
 func (br *bookingResolver) ServiceAddOns(ctx context.Context, booking *store.Booking) ([]store.ServiceAddOn, error) {
 	// Parse JSON array from booking.ServiceAddOns string
 	if booking.ServiceAddOns == "" {
@@ -37,7 +55,25 @@ func (br *bookingResolver) Review(ctx context.Context, booking *store.Booking) (
 	return review, nil
 }
 
+func (br *bookingResolver) StatusHistory(ctx context.Context, booking *store.Booking) ([]*store.BookingStatusHistory, error) {
+	history, err := br.BookingLifecycle.History(ctx, booking.ID)
+	if err != nil {
+		br.Logger.Printf("Error retrieving booking status history: %s", err)
+		return nil, errors.New("error retrieving booking status history")
+	}
+	return history, nil
+}
+
 func (br *bookingResolver) Transaction(ctx context.Context, booking *store.Booking) (*store.Transaction, error) {
+	if br.Payments != nil {
+		transaction, err := br.Payments.BookingPayment(ctx, booking.ID)
+		if err != nil {
+			br.Logger.Printf("Error retrieving payment of booking %s: %s", booking.ID, err)
+			return nil, nil
+		}
+		return transaction, nil
+	}
+
 	transactions, err := br.Store.Transactions().GetByBooking(ctx, booking.ID)
 	if err != nil || len(transactions) == 0 {
 		return nil, nil
@@ -45,6 +81,23 @@ func (br *bookingResolver) Transaction(ctx context.Context, booking *store.Booki
 	return transactions[0], nil
 }
 
+type bookingStatusHistoryResolver struct{ *Resolver }
+func (r *Resolver) BookingStatusHistory() gen.BookingStatusHistoryResolver {
+	return &bookingStatusHistoryResolver{r}
+}
+
+func (bshr *bookingStatusHistoryResolver) ChangedBy(ctx context.Context, entry *store.BookingStatusHistory) (*store.User, error) {
+	if entry.ChangedByID == nil {
+		return nil, nil
+	}
+	user, err := bshr.Store.Users().Get(ctx, *entry.ChangedByID)
+	if err != nil {
+		bshr.Logger.Printf("Error retrieving user for booking status history: %s", err)
+		return nil, nil
+	}
+	return user, nil
+}
+
 // QUERY RESOLVERS
 func (qr *queryResolver) Booking(ctx context.Context, id string) (*store.Booking, error) {
 	currentUser := middleware.GetCurrentUser(ctx)
@@ -259,6 +312,53 @@ func (qr *queryResolver) UpcomingBookings(ctx context.Context, limit *int) ([]*s
 	return upcomingBookings, nil
 }
 
+func (qr *queryResolver) BookingSeries(ctx context.Context, id string) ([]*store.Booking, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	booking, err := qr.Store.Bookings().Get(ctx, id)
+	if err != nil {
+		qr.Logger.Printf("Error retrieving booking: %s", err)
+		return nil, errors.New("booking not found")
+	}
+
+	// Verify user has access to this booking (customer, cleaner, or admin)
+	if booking.CustomerID != currentUser.ID && booking.CleanerID != currentUser.ID && !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("access denied")
+	}
+
+	series, err := qr.RecurringBookings.GetSeries(ctx, id)
+	if err != nil {
+		return nil, translateSeriesError(qr.Logger, err, "error retrieving booking series")
+	}
+
+	return series, nil
+}
+
+func (qr *queryResolver) CancellationQuote(ctx context.Context, bookingID string, reason *store.CancellationReason) (*cancellationpolicy.Quote, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	quote, err := qr.CancellationPolicy.Quote(ctx, bookingID, currentUser, reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, cancellationpolicy.ErrBookingNotFound):
+			return nil, errors.New("booking not found")
+		case errors.Is(err, cancellationpolicy.ErrAccessDenied):
+			return nil, errors.New("access denied")
+		case errors.Is(err, cancellationpolicy.ErrNotCancellable):
+			return nil, errors.New("booking can no longer be cancelled")
+		}
+		return nil, logAndReturnError(qr.Logger, "Error computing cancellation quote", err, "error computing cancellation quote")
+	}
+
+	return quote, nil
+}
+
 func (qr *queryResolver) AllBookings(ctx context.Context, filters *gen.BookingFiltersInput, limit, offset *int, orderBy *string) (*gen.BookingConnection, error) {
 	currentUser := middleware.GetCurrentUser(ctx)
 	if currentUser == nil {
@@ -302,6 +402,7 @@ func (mr *mutationResolver) CreateBooking(ctx context.Context, input gen.CreateB
 		}
 		userID = newUser.ID
 		mr.Logger.Printf("Created guest user account: %s (%s)", newUser.Email, newUser.ID)
+		mr.attributeReferral(ctx, newUser, input.ReferralCode)
 	} else {
 		return nil, errors.New("authentication required or user details must be provided")
 	}
@@ -367,83 +468,22 @@ func (mr *mutationResolver) CreateBooking(ctx context.Context, input gen.CreateB
 		return nil, errors.New("cleaner is not currently accepting bookings")
 	}
 
-	// Get service definition
-	serviceDefinition, err := mr.Store.Services().GetServiceDefinition(ctx, input.ServiceType)
-	if err != nil {
-		mr.Logger.Printf("Error retrieving service definition: %s", err)
-		return nil, errors.New("service definition not found")
+	// Price the booking exactly as calculateServicePrice quotes it
+	quoteRequest := pricing.QuoteRequest{
+		CleanerProfileID: cleanerProfile.ID,
+		ServiceType:      input.ServiceType,
+		AddOns:           input.ServiceAddOns,
+		AddressID:        addressID,
+		CustomerID:       userID,
 	}
-	if !serviceDefinition.IsActive {
-		return nil, errors.New("service is not currently available")
+	if input.PromoCode != nil {
+		quoteRequest.PromoCode = *input.PromoCode
 	}
-
-	// Calculate base service price
-	// TODO: hourlyRate will be re-implemented later with a proper pricing system
-	baseHours := serviceDefinition.BaseHours
-	hourlyRate := 0 // Placeholder until pricing is re-implemented
-	priceMultiplier := serviceDefinition.PriceMultiplier
-	servicePrice := int(float64(hourlyRate) * baseHours * priceMultiplier)
-
-	// Calculate add-ons price and duration
-	addOnsPrice := 0
-	addOnsDuration := 0.0
-	if len(input.ServiceAddOns) > 0 {
-		addOnDefs, err := mr.Store.Services().ListAddOnDefinitions(ctx, true)
-		if err != nil {
-			mr.Logger.Printf("Error retrieving add-on definitions: %s", err)
-		} else {
-			addOnMap := make(map[store.ServiceAddOn]*store.ServiceAddOnDefinition)
-			for _, def := range addOnDefs {
-				addOnMap[def.AddOn] = def
-			}
-			for _, addOn := range input.ServiceAddOns {
-				if def, exists := addOnMap[addOn]; exists {
-					addOnsPrice += def.FixedPrice
-					addOnsDuration += def.EstimatedHours
-				}
-			}
-		}
-	}
-
-	// Calculate travel fee based on service area
-	travelFee := 0
-	serviceAreas, err := mr.Store.ServiceAreas().GetByCleanerProfile(ctx, cleanerProfile.ID)
-	if err == nil && len(serviceAreas) > 0 {
-		// Get the address to match with service area
-		address, _ := mr.Store.Addresses().Get(ctx, addressID)
-		if address != nil {
-			for _, area := range serviceAreas {
-				if area.City == address.City {
-					// Prefer exact postal code match
-					if address.PostalCode != "" && area.PostalCode != "" && area.PostalCode == address.PostalCode {
-						travelFee = area.TravelFee
-						break
-					}
-					// Then neighborhood match
-					if address.Neighborhood != "" && area.Neighborhood != "" && area.Neighborhood == address.Neighborhood {
-						travelFee = area.TravelFee
-						break
-					}
-					// Finally, just city match
-					if area.PostalCode == "" && area.Neighborhood == "" {
-						travelFee = area.TravelFee
-					}
-				}
-			}
-		}
+	quote, err := mr.Pricing.Quote(ctx, quoteRequest)
+	if err != nil {
+		return nil, translatePricingError(mr.Logger, err, "error calculating price")
 	}
 
-	// Calculate platform fee and totals
-	// Platform fee is charged to customer, cleaner receives the service amount minus platform fee
-	subtotal := servicePrice + addOnsPrice + travelFee
-	platformFeePercentage := 15.0 // Default, could be from config
-	platformFee := int(float64(subtotal) * platformFeePercentage / 100.0)
-	totalPrice := subtotal + platformFee
-	cleanerPayout := subtotal // Cleaner gets the subtotal (service + add-ons + travel)
-
-	// Calculate total duration
-	totalDuration := baseHours + addOnsDuration
-
 	// Serialize service add-ons to JSON
 	var addOnsJSON string
 	if len(input.ServiceAddOns) > 0 {
@@ -465,38 +505,88 @@ func (mr *mutationResolver) CreateBooking(ctx context.Context, input gen.CreateB
 	if input.IsRecurring != nil {
 		isRecurring = *input.IsRecurring
 	}
+	if isRecurring && input.ServiceFrequency == store.ServiceFrequencyOneTime {
+		return nil, errors.New("recurring bookings require a weekly, bi-monthly or monthly frequency")
+	}
 
 	booking := &store.Booking{
-		CustomerID:        userID,
-		CleanerID:         cleanerProfile.UserID,
-		CleanerProfileID:  cleanerProfile.ID,
-		ServiceType:       input.ServiceType,
-		ServiceFrequency:  input.ServiceFrequency,
-		ServiceAddOns:     addOnsJSON,
-		ScheduledDate:     input.ScheduledDate,
-		ScheduledTime:     input.ScheduledTime,
-		Duration:          totalDuration,
-		AddressID:         addressID,
-		CleanerHourlyRate: hourlyRate,
-		ServicePrice:      servicePrice,
-		AddOnsPrice:       addOnsPrice,
-		TravelFee:         travelFee,
-		PlatformFee:       platformFee,
-		TotalPrice:        totalPrice,
-		CleanerPayout:     cleanerPayout,
-		Status:            store.BookingStatusPending,
-		IsRecurring:       isRecurring,
-		CustomerNotes:     customerNotes,
+		ID:               uuid.New().String(),
+		CustomerID:       userID,
+		CleanerID:        cleanerProfile.UserID,
+		CleanerProfileID: cleanerProfile.ID,
+		ServiceType:      input.ServiceType,
+		ServiceFrequency: input.ServiceFrequency,
+		ServiceAddOns:    addOnsJSON,
+		AddressID:        addressID,
+		Status:           store.BookingStatusPending,
+		IsRecurring:      isRecurring,
+		CustomerNotes:    customerNotes,
+	}
+	if err := booking.ScheduleAt(input.ScheduledDate, input.ScheduledTime); err != nil {
+		// The scalar validates the format, so this is a time skipped by a DST change
+		return nil, errors.New("scheduled time does not exist on that date")
+	}
+	quote.ApplyTo(booking)
+
+	// Count the code against its limits before the booking is placed so concurrent checkouts cannot overuse it
+	if err := mr.Promo.Redeem(ctx, booking); err != nil {
+		return nil, translatePromoError(mr.Logger, err, "error applying promo code")
+	}
+
+	if input.ApplyCredit != nil && *input.ApplyCredit {
+		if err := mr.Credit.Apply(ctx, booking); err != nil {
+			mr.releasePromo(ctx, booking)
+			return nil, translateCreditError(mr.Logger, err, "error applying credit")
+		}
 	}
 
 	if err := mr.Store.Bookings().Create(ctx, booking); err != nil {
+		mr.releasePromo(ctx, booking)
+		if booking.CreditApplied > 0 {
+			if releaseErr := mr.Credit.Release(ctx, booking.ID); releaseErr != nil {
+				mr.Logger.Printf("Error returning credit of unplaced booking %s: %s", booking.ID, releaseErr)
+			}
+		}
+		if errors.Is(err, store.ErrSlotUnavailable) {
+			return nil, slotUnavailableError()
+		}
 		mr.Logger.Printf("Error creating booking: %s", err)
 		return nil, errors.New("error creating booking")
 	}
 
+	// Gift card redemptions reference the booking, so the balance is spent once it is placed
+	if input.ApplyGiftCard != nil && *input.ApplyGiftCard {
+		if err := mr.GiftCards.Apply(ctx, booking); err != nil {
+			// The booking stands at its full price; totalPrice and giftCardApplied show the outcome
+			mr.Logger.Printf("Error applying gift card balance to booking %s: %s", booking.ID, err)
+		}
+	}
+
+	if err := mr.BookingLifecycle.RecordCreated(ctx, booking, currentUser); err != nil {
+		// History is an audit trail, the booking itself was created
+		mr.Logger.Printf("Error recording booking creation: %s", err)
+	}
+
+	if booking.IsRecurring {
+		if _, err := mr.RecurringBookings.Materialize(ctx, booking.ID); err != nil {
+			// Occurrences are topped up again on the next completion or scheduled run
+			mr.Logger.Printf("Error materializing recurring series %s: %s", booking.ID, err)
+		}
+	}
+
 	return booking, nil
 }
 
+// releasePromo returns the promo code redemption of a booking that could not be placed
+func (mr *mutationResolver) releasePromo(ctx context.Context, booking *store.Booking) {
+	if booking.PromoCodeID == nil {
+		return
+	}
+	if err := mr.Promo.Release(ctx, booking.ID); err != nil {
+		mr.Logger.Printf("Error releasing promo code of unplaced booking %s: %s", booking.ID, err)
+	}
+}
+
 func (mr *mutationResolver) UpdateBooking(ctx context.Context, input gen.UpdateBookingInput) (*store.Booking, error) {
 	currentUser := middleware.GetCurrentUser(ctx)
 	if currentUser == nil {
@@ -521,11 +611,18 @@ func (mr *mutationResolver) UpdateBooking(ctx context.Context, input gen.UpdateB
 	}
 
 	// Update fields
-	if input.ScheduledDate != nil {
-		booking.ScheduledDate = *input.ScheduledDate
-	}
-	if input.ScheduledTime != nil {
-		booking.ScheduledTime = *input.ScheduledTime
+	if input.ScheduledDate != nil || input.ScheduledTime != nil {
+		date, timeOfDay := booking.LocalSchedule()
+		if input.ScheduledDate != nil {
+			date = *input.ScheduledDate
+		}
+		if input.ScheduledTime != nil {
+			timeOfDay = *input.ScheduledTime
+		}
+		if err := booking.ScheduleAt(date, timeOfDay); err != nil {
+			// The scalar validates the format, so this is a time skipped by a DST change
+			return nil, errors.New("scheduled time does not exist on that date")
+		}
 	}
 	if input.CustomerNotes != nil {
 		booking.CustomerNotes = *input.CustomerNotes
@@ -535,6 +632,9 @@ func (mr *mutationResolver) UpdateBooking(ctx context.Context, input gen.UpdateB
 	}
 
 	if err := mr.Store.Bookings().Update(ctx, booking); err != nil {
+		if errors.Is(err, store.ErrSlotUnavailable) {
+			return nil, slotUnavailableError()
+		}
 		mr.Logger.Printf("Error updating booking: %s", err)
 		return nil, errors.New("error updating booking")
 	}
@@ -548,33 +648,13 @@ func (mr *mutationResolver) ConfirmBooking(ctx context.Context, id string) (*sto
 		return nil, errors.New("authentication required")
 	}
 
-	// Get booking
-	booking, err := mr.Store.Bookings().Get(ctx, id)
+	booking, err := mr.BookingLifecycle.Transition(ctx, bookinglifecycle.TransitionRequest{
+		BookingID: id,
+		To:        store.BookingStatusConfirmed,
+		Actor:     currentUser,
+	})
 	if err != nil {
-		mr.Logger.Printf("Error retrieving booking: %s", err)
-		return nil, errors.New("booking not found")
-	}
-
-	// Only cleaner assigned to this booking can confirm
-	if booking.CleanerID != currentUser.ID {
-		return nil, errors.New("only the assigned cleaner can confirm this booking")
-	}
-
-	// Can only confirm if booking is pending
-	if booking.Status != store.BookingStatusPending {
-		return nil, errors.New("can only confirm pending bookings")
-	}
-
-	// Update status
-	booking.Status = store.BookingStatusConfirmed
-	// Note: ConfirmedAt timestamp will be set by database trigger or we can set it here
-	// For now, let's set it manually
-	now := time.Now()
-	booking.ConfirmedAt = &now
-
-	if err := mr.Store.Bookings().Update(ctx, booking); err != nil {
-		mr.Logger.Printf("Error confirming booking: %s", err)
-		return nil, errors.New("error confirming booking")
+		return nil, translateTransitionError(mr.Logger, err, "error confirming booking")
 	}
 
 	return booking, nil
@@ -586,156 +666,246 @@ func (mr *mutationResolver) StartBooking(ctx context.Context, id string) (*store
 		return nil, errors.New("authentication required")
 	}
 
-	// Get booking
-	booking, err := mr.Store.Bookings().Get(ctx, id)
+	booking, err := mr.BookingLifecycle.Transition(ctx, bookinglifecycle.TransitionRequest{
+		BookingID: id,
+		To:        store.BookingStatusInProgress,
+		Actor:     currentUser,
+	})
 	if err != nil {
-		mr.Logger.Printf("Error retrieving booking: %s", err)
-		return nil, errors.New("booking not found")
+		return nil, translateTransitionError(mr.Logger, err, "error starting booking")
 	}
 
-	// Only cleaner assigned to this booking can start it
-	if booking.CleanerID != currentUser.ID {
-		return nil, errors.New("only the assigned cleaner can start this booking")
-	}
+	return booking, nil
+}
 
-	// Can only start if booking is confirmed
-	if booking.Status != store.BookingStatusConfirmed {
-		return nil, errors.New("can only start confirmed bookings")
+func (mr *mutationResolver) CompleteBooking(ctx context.Context, id string, cleanerNotes *string) (*store.Booking, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
 	}
 
-	// Update status
-	booking.Status = store.BookingStatusInProgress
-	now := time.Now()
-	booking.StartedAt = &now
-
-	if err := mr.Store.Bookings().Update(ctx, booking); err != nil {
-		mr.Logger.Printf("Error starting booking: %s", err)
-		return nil, errors.New("error starting booking")
+	booking, err := mr.BookingLifecycle.Transition(ctx, bookinglifecycle.TransitionRequest{
+		BookingID:    id,
+		To:           store.BookingStatusCompleted,
+		Actor:        currentUser,
+		CleanerNotes: cleanerNotes,
+	})
+	if err != nil {
+		return nil, translateTransitionError(mr.Logger, err, "error completing booking")
 	}
 
+	// The card hold is charged by the payment side effect, or after the dispute window when one is configured
+	// TODO: Send notification to customer to leave a review
+
 	return booking, nil
 }
 
-func (mr *mutationResolver) CompleteBooking(ctx context.Context, id string, cleanerNotes *string) (*store.Booking, error) {
+func (mr *mutationResolver) CancelBooking(ctx context.Context, input gen.CancelBookingInput) (*store.Booking, error) {
 	currentUser := middleware.GetCurrentUser(ctx)
 	if currentUser == nil {
 		return nil, errors.New("authentication required")
 	}
 
-	// Get booking
-	booking, err := mr.Store.Bookings().Get(ctx, id)
+	booking, err := mr.BookingLifecycle.Transition(ctx, bookinglifecycle.TransitionRequest{
+		BookingID:          input.ID,
+		To:                 store.BookingStatusCancelled,
+		Actor:              currentUser,
+		CancellationReason: &input.Reason,
+		Note:               input.Note,
+	})
 	if err != nil {
-		mr.Logger.Printf("Error retrieving booking: %s", err)
-		return nil, errors.New("booking not found")
+		return nil, translateTransitionError(mr.Logger, err, "error cancelling booking")
 	}
 
-	// Only cleaner assigned to this booking can complete it
-	if booking.CleanerID != currentUser.ID {
-		return nil, errors.New("only the assigned cleaner can complete this booking")
+	// Refunds and cleaner compensation are settled by the cancellation policy side effect
+	return booking, nil
+}
+
+func (mr *mutationResolver) MarkNoShow(ctx context.Context, id string) (*store.Booking, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
 	}
 
-	// Can only complete if booking is in progress
-	if booking.Status != store.BookingStatusInProgress {
-		return nil, errors.New("can only complete bookings that are in progress")
+	booking, err := mr.BookingLifecycle.Transition(ctx, bookinglifecycle.TransitionRequest{
+		BookingID: id,
+		To:        store.BookingStatusNoShow,
+		Actor:     currentUser,
+	})
+	if err != nil {
+		return nil, translateTransitionError(mr.Logger, err, "error marking booking as no-show")
 	}
 
-	// Update status
-	booking.Status = store.BookingStatusCompleted
-	now := time.Now()
-	booking.CompletedAt = &now
+	// The no-show fee and travel compensation are settled by the no-show side effect
+	return booking, nil
+}
 
-	if cleanerNotes != nil {
-		booking.CleanerNotes = *cleanerNotes
+func (mr *mutationResolver) ContestNoShow(ctx context.Context, id string, reason string) (*store.Booking, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
 	}
 
-	if err := mr.Store.Bookings().Update(ctx, booking); err != nil {
-		mr.Logger.Printf("Error completing booking: %s", err)
-		return nil, errors.New("error completing booking")
+	booking, err := mr.NoShowSettlement.Contest(ctx, id, currentUser, reason)
+	if err != nil {
+		return nil, translateNoShowError(mr.Logger, err, "error contesting no-show")
 	}
 
-	// TODO: Trigger payout process
-	// TODO: Send notification to customer to leave a review
-
 	return booking, nil
 }
 
-func (mr *mutationResolver) CancelBooking(ctx context.Context, input gen.CancelBookingInput) (*store.Booking, error) {
+func (mr *mutationResolver) ResolveNoShowContest(ctx context.Context, id string, upheld bool) (*store.Booking, error) {
 	currentUser := middleware.GetCurrentUser(ctx)
 	if currentUser == nil {
 		return nil, errors.New("authentication required")
 	}
 
-	// Get booking
-	booking, err := mr.Store.Bookings().Get(ctx, input.ID)
+	booking, err := mr.NoShowSettlement.ResolveContest(ctx, id, currentUser, upheld)
 	if err != nil {
-		mr.Logger.Printf("Error retrieving booking: %s", err)
-		return nil, errors.New("booking not found")
+		return nil, translateNoShowError(mr.Logger, err, "error resolving no-show contest")
 	}
 
-	// Customer or cleaner can cancel
-	if booking.CustomerID != currentUser.ID && booking.CleanerID != currentUser.ID && !currentUser.IsGlobalAdmin() {
-		return nil, errors.New("only customer, cleaner, or admin can cancel this booking")
+	return booking, nil
+}
+
+func (mr *mutationResolver) FinalizeNoShows(ctx context.Context) (int, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return 0, errors.New("authentication required")
 	}
 
-	// Can't cancel if already completed or cancelled
-	if booking.Status == store.BookingStatusCompleted || booking.Status == store.BookingStatusCancelled {
-		return nil, errors.New("cannot cancel completed or already cancelled bookings")
+	if !currentUser.IsGlobalAdmin() {
+		return 0, errors.New("admin access required")
 	}
 
-	// Update status
-	booking.Status = store.BookingStatusCancelled
-	booking.CancellationReason = (*store.CancellationReason)(&input.Reason)
-	if input.Note != nil {
-		booking.CancellationNote = *input.Note
+	finalized, err := mr.NoShowSettlement.FinalizeDue(ctx)
+	if err != nil {
+		return finalized, logAndReturnError(mr.Logger, "Error finalizing no-shows", err, "some no-shows could not be finalized")
 	}
-	now := time.Now()
-	booking.CancelledAt = &now
-	booking.CancelledByID = &currentUser.ID
 
-	if err := mr.Store.Bookings().Update(ctx, booking); err != nil {
-		mr.Logger.Printf("Error cancelling booking: %s", err)
-		return nil, errors.New("error cancelling booking")
+	return finalized, nil
+}
+
+func (mr *mutationResolver) SkipBookingOccurrence(ctx context.Context, id string) (*store.Booking, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
 	}
 
-	// TODO: Implement refund logic based on cancellation policy
-	// Full refund if cancelled 24+ hours before scheduled date
+	booking, err := mr.RecurringBookings.SkipOccurrence(ctx, id, currentUser)
+	if err != nil {
+		return nil, translateSeriesError(mr.Logger, err, "error skipping booking occurrence")
+	}
 
 	return booking, nil
 }
 
-func (mr *mutationResolver) MarkNoShow(ctx context.Context, id string) (*store.Booking, error) {
+func (mr *mutationResolver) UpdateBookingSeries(ctx context.Context, input gen.UpdateBookingSeriesInput) ([]*store.Booking, error) {
 	currentUser := middleware.GetCurrentUser(ctx)
 	if currentUser == nil {
 		return nil, errors.New("authentication required")
 	}
 
-	// Get booking
-	booking, err := mr.Store.Bookings().Get(ctx, id)
+	bookings, err := mr.RecurringBookings.UpdateThisAndFollowing(ctx, input.FromBookingID, currentUser, bookingseries.SeriesChanges{
+		ScheduledDate: input.ScheduledDate,
+		ScheduledTime: input.ScheduledTime,
+		CustomerNotes: input.CustomerNotes,
+	})
 	if err != nil {
-		mr.Logger.Printf("Error retrieving booking: %s", err)
-		return nil, errors.New("booking not found")
+		return nil, translateSeriesError(mr.Logger, err, "error updating booking series")
 	}
 
-	// Only cleaner assigned to this booking can mark no-show
-	if booking.CleanerID != currentUser.ID {
-		return nil, errors.New("only the assigned cleaner can mark customer as no-show")
+	return bookings, nil
+}
+
+func (mr *mutationResolver) CancelBookingSeries(ctx context.Context, input gen.CancelBookingInput) ([]*store.Booking, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
 	}
 
-	// Can only mark no-show if booking is confirmed
-	if booking.Status != store.BookingStatusConfirmed {
-		return nil, errors.New("can only mark no-show for confirmed bookings")
+	bookings, err := mr.RecurringBookings.CancelSeries(ctx, input.ID, currentUser, input.Reason, input.Note)
+	if err != nil {
+		return nil, translateSeriesError(mr.Logger, err, "error cancelling booking series")
 	}
 
-	// Update status
-	booking.Status = store.BookingStatusNoShow
+	return bookings, nil
+}
 
-	if err := mr.Store.Bookings().Update(ctx, booking); err != nil {
-		mr.Logger.Printf("Error marking booking as no-show: %s", err)
-		return nil, errors.New("error marking booking as no-show")
+func (mr *mutationResolver) MaterializeRecurringBookings(ctx context.Context) (*scalar.Void, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
 	}
 
-	// TODO: Apply no-show fee to customer
-	// TODO: Compensate cleaner for travel
+	if err := mr.RecurringBookings.MaterializeAll(ctx); err != nil {
+		return nil, logAndReturnError(mr.Logger, "Error materializing recurring bookings", err, "some recurring series could not be extended")
+	}
 
-	return booking, nil
+	return &scalar.Void{}, nil
+}
+
+// translateNoShowError maps no-show settlement errors to user-facing messages
+func translateNoShowError(logger *log.Logger, err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, noshow.ErrBookingNotFound):
+		return errors.New("booking not found")
+	case errors.Is(err, noshow.ErrNotNoShow):
+		return errors.New("booking is not marked as a no-show")
+	case errors.Is(err, noshow.ErrAccessDenied):
+		return errors.New("access denied")
+	case errors.Is(err, noshow.ErrContestWindowClosed):
+		return errors.New("the no-show can no longer be contested")
+	case errors.Is(err, noshow.ErrNotContested):
+		return errors.New("the no-show is not awaiting review")
+	case errors.Is(err, noshow.ErrContestReasonMissing):
+		return errors.New("a reason is required to contest a no-show")
+	case errors.Is(err, noshow.ErrAlreadySettled):
+		return errors.New("the no-show was changed by another request, please reload it")
+	}
+	return logAndReturnError(logger, fallbackMsg, err, fallbackMsg)
+}
+
+// translateSeriesError maps recurring series errors to user-facing messages
+func translateSeriesError(logger *log.Logger, err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, bookingseries.ErrBookingNotFound):
+		return errors.New("booking not found")
+	case errors.Is(err, bookingseries.ErrNotRecurring):
+		return errors.New("booking is not part of a recurring series")
+	case errors.Is(err, bookingseries.ErrSeriesEnded):
+		return errors.New("recurring series has already been cancelled")
+	case errors.Is(err, bookingseries.ErrAccessDenied):
+		return errors.New("only the customer can change a recurring series")
+	case errors.Is(err, bookingseries.ErrNothingToUpdate):
+		return errors.New("no changes provided")
+	case errors.Is(err, store.ErrSlotUnavailable):
+		return slotUnavailableError()
+	case errors.Is(err, localtime.ErrNonexistentClock):
+		return errors.New("scheduled time does not exist on that date")
+	case errors.Is(err, localtime.ErrInvalidClock):
+		return errors.New("invalid scheduled time")
+	}
+	return translateTransitionError(logger, err, fallbackMsg)
+}
+
+// translateTransitionError maps lifecycle errors to user-facing messages
+func translateTransitionError(logger *log.Logger, err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, bookinglifecycle.ErrBookingNotFound):
+		return errors.New("booking not found")
+	case errors.Is(err, bookinglifecycle.ErrActorNotAllowed):
+		return errors.New("you are not allowed to perform this action on the booking")
+	case errors.Is(err, bookinglifecycle.ErrTransitionNotAllowed):
+		return errors.New("this action is not allowed in the booking's current status")
+	case errors.Is(err, bookinglifecycle.ErrConcurrentTransition):
+		return errors.New("booking was updated by someone else, please refresh and try again")
+	case errors.Is(err, bookinglifecycle.ErrCancellationReasonMissing):
+		return errors.New("cancellation reason is required")
+	}
+	return logAndReturnError(logger, fallbackMsg, err, fallbackMsg)
 }
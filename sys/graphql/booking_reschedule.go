@@ -0,0 +1,136 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"cleanbuddy-api/res/bookingreschedule"
+	"cleanbuddy-api/res/store"
+	"cleanbuddy-api/sys/graphql/gen"
+	"cleanbuddy-api/sys/http/middleware"
+)
+
+// FIELD RESOLVERS
+type bookingRescheduleRequestResolver struct{ *Resolver }
+
+func (r *Resolver) BookingRescheduleRequest() gen.BookingRescheduleRequestResolver {
+	return &bookingRescheduleRequestResolver{r}
+}
+
+func (brrr *bookingRescheduleRequestResolver) Booking(ctx context.Context, request *store.BookingRescheduleRequest) (*store.Booking, error) {
+	booking, err := brrr.Store.Bookings().Get(ctx, request.BookingID)
+	if err != nil {
+		brrr.Logger.Printf("Error retrieving booking for reschedule request: %s", err)
+		return nil, errors.New("booking not found")
+	}
+	return booking, nil
+}
+
+func (brrr *bookingRescheduleRequestResolver) ProposedBy(ctx context.Context, request *store.BookingRescheduleRequest) (*store.User, error) {
+	user, err := brrr.Store.Users().Get(ctx, request.ProposedByID)
+	if err != nil {
+		brrr.Logger.Printf("Error retrieving proposer for reschedule request: %s", err)
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (brrr *bookingRescheduleRequestResolver) ProposedSlots(ctx context.Context, request *store.BookingRescheduleRequest) ([]*store.RescheduleSlot, error) {
+	var slots []*store.RescheduleSlot
+	if err := json.Unmarshal([]byte(request.ProposedSlots), &slots); err != nil {
+		brrr.Logger.Printf("Error parsing proposed slots: %s", err)
+		return []*store.RescheduleSlot{}, nil
+	}
+	return slots, nil
+}
+
+func (br *bookingResolver) RescheduleRequests(ctx context.Context, booking *store.Booking) ([]*store.BookingRescheduleRequest, error) {
+	requests, err := br.BookingReschedule.ListForBooking(ctx, booking.ID)
+	if err != nil {
+		br.Logger.Printf("Error retrieving reschedule requests: %s", err)
+		return nil, errors.New("error retrieving reschedule requests")
+	}
+	return requests, nil
+}
+
+// MUTATION RESOLVERS
+func (mr *mutationResolver) ProposeReschedule(ctx context.Context, input gen.ProposeRescheduleInput) (*store.BookingRescheduleRequest, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	slots := make([]store.RescheduleSlot, len(input.Slots))
+	for i, slot := range input.Slots {
+		slots[i] = store.RescheduleSlot{Date: slot.Date, Time: slot.Time}
+	}
+
+	message := ""
+	if input.Message != nil {
+		message = *input.Message
+	}
+
+	request, err := mr.BookingReschedule.Propose(ctx, input.BookingID, currentUser, slots, message)
+	if err != nil {
+		return nil, translateRescheduleError(mr.Logger, err, "error proposing reschedule")
+	}
+
+	return request, nil
+}
+
+func (mr *mutationResolver) RespondToReschedule(ctx context.Context, input gen.RespondToRescheduleInput) (*store.BookingRescheduleRequest, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	note := ""
+	if input.Note != nil {
+		note = *input.Note
+	}
+
+	request, err := mr.BookingReschedule.Respond(ctx, input.RequestID, currentUser, input.Accept, input.SlotIndex, note)
+	if err != nil {
+		return nil, translateRescheduleError(mr.Logger, err, "error responding to reschedule request")
+	}
+
+	return request, nil
+}
+
+func (mr *mutationResolver) WithdrawReschedule(ctx context.Context, id string) (*store.BookingRescheduleRequest, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	request, err := mr.BookingReschedule.Withdraw(ctx, id, currentUser)
+	if err != nil {
+		return nil, translateRescheduleError(mr.Logger, err, "error withdrawing reschedule request")
+	}
+
+	return request, nil
+}
+
+// translateRescheduleError maps reschedule errors to user-facing messages
+func translateRescheduleError(logger *log.Logger, err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, store.ErrSlotUnavailable):
+		return slotUnavailableError()
+	case errors.Is(err, bookingreschedule.ErrBookingNotFound),
+		errors.Is(err, bookingreschedule.ErrRequestNotFound),
+		errors.Is(err, bookingreschedule.ErrAccessDenied),
+		errors.Is(err, bookingreschedule.ErrNotReschedulable),
+		errors.Is(err, bookingreschedule.ErrPendingRequestExists),
+		errors.Is(err, bookingreschedule.ErrNoSlots),
+		errors.Is(err, bookingreschedule.ErrTooManySlots),
+		errors.Is(err, bookingreschedule.ErrInvalidSlot),
+		errors.Is(err, bookingreschedule.ErrRequestNotPending),
+		errors.Is(err, bookingreschedule.ErrRequestExpired),
+		errors.Is(err, bookingreschedule.ErrSlotRequired):
+		// Service errors are already phrased for the user
+		return errors.New(err.Error())
+	}
+	return logAndReturnError(logger, fallbackMsg, err, fallbackMsg)
+}
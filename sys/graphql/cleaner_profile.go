@@ -5,6 +5,7 @@ import (
 	"errors"
 	"time"
 
+	"cleanbuddy-api/res/availability"
 	"cleanbuddy-api/res/store"
 	"cleanbuddy-api/sys/graphql/gen"
 	"cleanbuddy-api/sys/graphql/scalar"
@@ -30,6 +31,27 @@ func (cpr *cleanerProfileResolver) User(ctx context.Context, profile *store.Clea
 	return user, nil
 }
 
+func (cpr *cleanerProfileResolver) PayoutAccountID(ctx context.Context, profile *store.CleanerProfile) (*string, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil || (currentUser.ID != profile.UserID && !currentUser.IsGlobalAdmin()) {
+		return nil, nil
+	}
+	return profile.PayoutAccountID, nil
+}
+
+func (cpr *cleanerProfileResolver) TipTotals(ctx context.Context, profile *store.CleanerProfile) (*store.TransactionTotals, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil || currentUser.ID != profile.UserID || cpr.Tips == nil {
+		return nil, nil
+	}
+
+	totals, err := cpr.Tips.Totals(ctx, profile.UserID)
+	if err != nil {
+		return nil, errors.New("error retrieving tips")
+	}
+	return totals, nil
+}
+
 func (cpr *cleanerProfileResolver) ServiceAreas(ctx context.Context, profile *store.CleanerProfile) ([]*store.ServiceArea, error) {
 	areas, err := cpr.Store.ServiceAreas().GetByCleanerProfile(ctx, profile.ID)
 	if err != nil {
@@ -130,16 +152,9 @@ func (qr *queryResolver) MyCleanerProfile(ctx context.Context) (*store.CleanerPr
 
 func (qr *queryResolver) SearchCleaners(ctx context.Context, filters *gen.CleanerProfileFiltersInput, limit *int, offset *int, orderBy *string) (*gen.CleanerProfileConnection, error) {
 	// Build filters
-	storeFilters := store.CleanerProfileFilters{}
+	storeFilters := cleanerProfileFiltersFromInput(filters)
 
 	if filters != nil {
-		storeFilters.Tier = filters.Tier
-		storeFilters.MinRating = filters.MinRating
-		storeFilters.MaxRating = filters.MaxRating
-		storeFilters.IsActive = filters.IsActive
-		storeFilters.IsVerified = filters.IsVerified
-		storeFilters.IsAvailableToday = filters.IsAvailableToday
-		storeFilters.ServiceAreaIDs = filters.ServiceAreaIds
 
 		// Location-based filtering
 		if filters.City != nil || filters.Neighborhood != nil || filters.PostalCode != nil {
@@ -206,10 +221,49 @@ func (qr *queryResolver) SearchCleaners(ctx context.Context, filters *gen.Cleane
 	}, nil
 }
 
-func (qr *queryResolver) AvailableCleaners(ctx context.Context, date time.Time, startTime string, duration float64, city string, neighborhood, postalCode *string, filters *gen.CleanerProfileFiltersInput) ([]*store.CleanerProfile, error) {
-	// TODO: Implement complex availability checking
-	// For now, return basic search results
-	return nil, errors.New("not yet implemented")
+func (qr *queryResolver) AvailableCleaners(ctx context.Context, date time.Time, startTime string, duration float64, city string, neighborhood, postalCode *string, filters *gen.CleanerProfileFiltersInput) ([]*availability.AvailableCleaner, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	query := availability.CleanerQuery{
+		Date:      date,
+		StartTime: startTime,
+		Duration:  time.Duration(duration * float64(time.Hour)),
+		City:      city,
+		Filters:   cleanerProfileFiltersFromInput(filters),
+	}
+	if neighborhood != nil {
+		query.Neighborhood = *neighborhood
+	}
+	if postalCode != nil {
+		query.PostalCode = *postalCode
+	}
+
+	cleaners, err := qr.AvailabilityService.AvailableCleaners(ctx, query)
+	if err != nil {
+		return nil, translateAvailabilityError(qr.Logger, err, "error finding available cleaners")
+	}
+	return cleaners, nil
+}
+
+// cleanerProfileFiltersFromInput maps the attribute filters of a cleaner search;
+// location filters are resolved by the callers
+func cleanerProfileFiltersFromInput(filters *gen.CleanerProfileFiltersInput) store.CleanerProfileFilters {
+	storeFilters := store.CleanerProfileFilters{}
+	if filters == nil {
+		return storeFilters
+	}
+
+	storeFilters.Tier = filters.Tier
+	storeFilters.MinRating = filters.MinRating
+	storeFilters.MaxRating = filters.MaxRating
+	storeFilters.IsActive = filters.IsActive
+	storeFilters.IsVerified = filters.IsVerified
+	storeFilters.IsAvailableToday = filters.IsAvailableToday
+	storeFilters.ServiceAreaIDs = filters.ServiceAreaIds
+	return storeFilters
 }
 
 // MUTATION RESOLVERS
@@ -263,6 +317,13 @@ func (mr *mutationResolver) CreateCleanerProfile(ctx context.Context, input gen.
 		profile.ProfilePicture = input.ProfilePicture
 	}
 
+	if input.HourlyRate != nil {
+		if *input.HourlyRate < 0 {
+			return nil, errors.New("hourly rate must not be negative")
+		}
+		profile.HourlyRate = input.HourlyRate
+	}
+
 	if err := mr.Store.CleanerProfiles().Create(ctx, profile); err != nil {
 		mr.Logger.Printf("Error creating cleaner profile: %s", err)
 		return nil, errors.New("error creating cleaner profile")
@@ -321,6 +382,12 @@ func (mr *mutationResolver) UpdateCleanerProfile(ctx context.Context, input gen.
 	if input.ProfilePicture != nil {
 		profile.ProfilePicture = input.ProfilePicture
 	}
+	if input.HourlyRate != nil {
+		if *input.HourlyRate < 0 {
+			return nil, errors.New("hourly rate must not be negative")
+		}
+		profile.HourlyRate = input.HourlyRate
+	}
 	if input.IsActive != nil {
 		profile.IsActive = *input.IsActive
 	}
@@ -387,3 +454,22 @@ func (mr *mutationResolver) UpdateCleanerTier(ctx context.Context, profileID str
 
 	return profile, nil
 }
+
+func (mr *mutationResolver) SetCleanerPayoutAccount(ctx context.Context, profileID string, accountID string) (*store.CleanerProfile, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("access forbidden, authorization required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("access forbidden, admin privileges required")
+	}
+	if mr.Payouts == nil {
+		return nil, errors.New("payouts are not configured")
+	}
+
+	profile, err := mr.Payouts.SetPayoutAccount(ctx, profileID, accountID)
+	if err != nil {
+		return nil, translatePayoutError(mr.Logger, err, "error setting payout account")
+	}
+	return profile, nil
+}
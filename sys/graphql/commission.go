@@ -0,0 +1,153 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"cleanbuddy-api/res/commission"
+	"cleanbuddy-api/res/store"
+	"cleanbuddy-api/sys/graphql/gen"
+	"cleanbuddy-api/sys/graphql/scalar"
+	"cleanbuddy-api/sys/http/middleware"
+)
+
+// FIELD RESOLVERS
+type commissionRuleResolver struct{ *Resolver }
+
+func (r *Resolver) CommissionRule() gen.CommissionRuleResolver {
+	return &commissionRuleResolver{r}
+}
+
+func (crr *commissionRuleResolver) Company(ctx context.Context, rule *store.CommissionRule) (*store.Company, error) {
+	if rule.CompanyID == nil {
+		return nil, nil
+	}
+
+	company, err := crr.Store.Companies().Get(ctx, *rule.CompanyID)
+	if err != nil {
+		crr.Logger.Printf("Error retrieving company for commission rule: %s", err)
+		return nil, nil
+	}
+	return company, nil
+}
+
+// QUERY RESOLVERS
+func (qr *queryResolver) CommissionRules(ctx context.Context, scope *store.CommissionRuleScope) ([]*store.CommissionRule, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+
+	rules, err := qr.Commission.List(ctx, scope)
+	if err != nil {
+		return nil, translateCommissionError(qr.Logger, err, "error retrieving commission rules")
+	}
+	return rules, nil
+}
+
+// MUTATION RESOLVERS
+func (mr *mutationResolver) CreateCommissionRule(ctx context.Context, input gen.CreateCommissionRuleInput) (*store.CommissionRule, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+
+	rule := &store.CommissionRule{
+		Scope:              input.Scope,
+		Name:               input.Name,
+		CompanyID:          input.CompanyID,
+		Tier:               input.Tier,
+		PlatformFeePercent: input.PlatformFeePercent,
+		CommissionPercent:  input.CommissionPercent,
+		ValidFrom:          input.ValidFrom,
+		ValidUntil:         input.ValidUntil,
+		IsActive:           true,
+	}
+	if input.DiscountPercent != nil {
+		rule.DiscountPercent = *input.DiscountPercent
+	}
+
+	created, err := mr.Commission.Create(ctx, rule)
+	if err != nil {
+		return nil, translateCommissionError(mr.Logger, err, "error creating commission rule")
+	}
+	return created, nil
+}
+
+func (mr *mutationResolver) UpdateCommissionRule(ctx context.Context, input gen.UpdateCommissionRuleInput) (*store.CommissionRule, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+
+	rule, err := mr.Commission.Get(ctx, input.ID)
+	if err != nil {
+		return nil, translateCommissionError(mr.Logger, err, "error retrieving commission rule")
+	}
+
+	if input.Name != nil {
+		rule.Name = *input.Name
+	}
+	if input.PlatformFeePercent != nil {
+		rule.PlatformFeePercent = input.PlatformFeePercent
+	}
+	if input.CommissionPercent != nil {
+		rule.CommissionPercent = input.CommissionPercent
+	}
+	if input.DiscountPercent != nil {
+		rule.DiscountPercent = *input.DiscountPercent
+	}
+	if input.ValidFrom != nil {
+		rule.ValidFrom = input.ValidFrom
+	}
+	if input.ValidUntil != nil {
+		rule.ValidUntil = input.ValidUntil
+	}
+	if input.IsActive != nil {
+		rule.IsActive = *input.IsActive
+	}
+
+	updated, err := mr.Commission.Update(ctx, rule)
+	if err != nil {
+		return nil, translateCommissionError(mr.Logger, err, "error updating commission rule")
+	}
+	return updated, nil
+}
+
+func (mr *mutationResolver) DeleteCommissionRule(ctx context.Context, id string) (*scalar.Void, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+
+	if err := mr.Commission.Delete(ctx, id); err != nil {
+		return nil, translateCommissionError(mr.Logger, err, "error deleting commission rule")
+	}
+	return &scalar.Void{}, nil
+}
+
+// translateCommissionError maps commission errors to user-facing messages
+func translateCommissionError(logger *log.Logger, err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, commission.ErrRuleNotFound):
+		return errors.New("commission rule not found")
+	case errors.Is(err, commission.ErrInvalidRule):
+		return errors.New("commission rule is incomplete or has rates outside 0-100% for its scope")
+	case errors.Is(err, commission.ErrCompanyNotFound):
+		return errors.New("company not found")
+	}
+	return logAndReturnError(logger, fallbackMsg, err, fallbackMsg)
+}
@@ -156,6 +156,12 @@ func (mr *mutationResolver) UpdateCompany(ctx context.Context, input gen.UpdateC
 	if input.BusinessType != nil {
 		company.BusinessType = input.BusinessType
 	}
+	if input.HourlyRate != nil {
+		if *input.HourlyRate < 0 {
+			return nil, errors.New("hourly rate must not be negative")
+		}
+		company.HourlyRate = input.HourlyRate
+	}
 	if input.IsActive != nil {
 		company.IsActive = *input.IsActive
 	}
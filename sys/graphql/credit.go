@@ -0,0 +1,81 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"cleanbuddy-api/res/credit"
+	"cleanbuddy-api/res/store"
+	"cleanbuddy-api/sys/http/middleware"
+)
+
+// QUERY RESOLVERS
+func (qr *queryResolver) CreditBalance(ctx context.Context) (int, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return 0, errors.New("authentication required")
+	}
+
+	balance, err := qr.Credit.Balance(ctx, currentUser.ID)
+	if err != nil {
+		return 0, translateCreditError(qr.Logger, err, "error retrieving credit balance")
+	}
+	return balance, nil
+}
+
+func (qr *queryResolver) CreditHistory(ctx context.Context, limit, offset *int) ([]*store.CreditEntry, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	l, o := 50, 0
+	if limit != nil {
+		l = *limit
+	}
+	if offset != nil {
+		o = *offset
+	}
+
+	entries, err := qr.Credit.History(ctx, currentUser.ID, l, o)
+	if err != nil {
+		return nil, translateCreditError(qr.Logger, err, "error retrieving credit history")
+	}
+	return entries, nil
+}
+
+// MUTATION RESOLVERS
+func (mr *mutationResolver) AdjustCredit(ctx context.Context, userID string, amount int, note *string) (*store.CreditEntry, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+
+	adjustmentNote := ""
+	if note != nil {
+		adjustmentNote = *note
+	}
+
+	entry, err := mr.Credit.Adjust(ctx, userID, amount, adjustmentNote)
+	if err != nil {
+		return nil, translateCreditError(mr.Logger, err, "error adjusting credit")
+	}
+	return entry, nil
+}
+
+// translateCreditError maps credit errors to user-facing messages
+func translateCreditError(logger *log.Logger, err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, credit.ErrUserNotFound):
+		return errors.New("user not found")
+	case errors.Is(err, credit.ErrInvalidAmount):
+		return errors.New("credit amount must not be zero")
+	case errors.Is(err, credit.ErrInsufficientCredit):
+		return errors.New("insufficient credit balance")
+	}
+	return logAndReturnError(logger, fallbackMsg, err, fallbackMsg)
+}
@@ -0,0 +1,273 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"cleanbuddy-api/res/dispute"
+	"cleanbuddy-api/res/payment"
+	"cleanbuddy-api/res/store"
+	"cleanbuddy-api/sys/graphql/gen"
+	"cleanbuddy-api/sys/http/middleware"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// FIELD RESOLVERS
+
+type disputeEvidenceResolver struct{ *Resolver }
+
+func (r *Resolver) DisputeEvidence() gen.DisputeEvidenceResolver {
+	return &disputeEvidenceResolver{r}
+}
+
+func (der *disputeEvidenceResolver) URL(ctx context.Context, evidence *store.DisputeEvidence) (string, error) {
+	if der.DisputeService == nil {
+		return "", errors.New("disputes are not configured")
+	}
+	url, err := der.DisputeService.EvidenceURL(ctx, evidence)
+	if err != nil {
+		return "", translateDisputeError(der.Logger, err, "error signing evidence URL")
+	}
+	return url, nil
+}
+
+// QUERY RESOLVERS
+func (qr *queryResolver) Dispute(ctx context.Context, id string) (*store.Dispute, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if qr.DisputeService == nil {
+		return nil, errors.New("disputes are not configured")
+	}
+
+	result, err := qr.DisputeService.Get(ctx, currentUser, id)
+	if err != nil {
+		return nil, translateDisputeError(qr.Logger, err, "error retrieving dispute")
+	}
+	return result, nil
+}
+
+func (qr *queryResolver) MyDisputes(ctx context.Context, status *store.DisputeStatus, limit *int, offset *int) ([]*store.Dispute, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	filters := toDisputeFilters(status, limit, offset)
+	if currentUser.IsCleaner() {
+		filters.CleanerID = &currentUser.ID
+	} else {
+		filters.CustomerID = &currentUser.ID
+	}
+	disputes, err := qr.Store.Disputes().List(ctx, filters)
+	if err != nil {
+		return nil, logAndReturnError(qr.Logger, "Error retrieving disputes", err, "error retrieving disputes")
+	}
+	return disputes, nil
+}
+
+func (qr *queryResolver) BookingDisputes(ctx context.Context, bookingID string) ([]*store.Dispute, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	booking, err := qr.Store.Bookings().Get(ctx, bookingID)
+	if err != nil {
+		return nil, errors.New("booking not found")
+	}
+	if !currentUser.IsGlobalAdmin() && booking.CustomerID != currentUser.ID && booking.CleanerID != currentUser.ID {
+		return nil, errors.New("access denied")
+	}
+
+	disputes, err := qr.Store.Disputes().List(ctx, store.DisputeFilters{BookingID: &bookingID})
+	if err != nil {
+		return nil, logAndReturnError(qr.Logger, "Error retrieving disputes of booking", err, "error retrieving disputes")
+	}
+	return disputes, nil
+}
+
+func (qr *queryResolver) Disputes(ctx context.Context, status *store.DisputeStatus, limit *int, offset *int) ([]*store.Dispute, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+
+	disputes, err := qr.Store.Disputes().List(ctx, toDisputeFilters(status, limit, offset))
+	if err != nil {
+		return nil, logAndReturnError(qr.Logger, "Error retrieving disputes", err, "error retrieving disputes")
+	}
+	return disputes, nil
+}
+
+// MUTATION RESOLVERS
+func (mr *mutationResolver) OpenDispute(ctx context.Context, input gen.OpenDisputeInput) (*store.Dispute, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if mr.DisputeService == nil {
+		return nil, errors.New("disputes are not configured")
+	}
+
+	open := dispute.OpenInput{
+		BookingID:   input.BookingID,
+		Reason:      input.Reason,
+		Description: input.Description,
+	}
+	for _, file := range input.Evidence {
+		open.Evidence = append(open.Evidence, toDisputeUpload(file))
+	}
+
+	result, err := mr.DisputeService.Open(ctx, currentUser, open)
+	if err != nil {
+		return nil, translateDisputeError(mr.Logger, err, "error opening dispute")
+	}
+	return result, nil
+}
+
+func (mr *mutationResolver) AddDisputeEvidence(ctx context.Context, disputeID string, file graphql.Upload) (*store.DisputeEvidence, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if mr.DisputeService == nil {
+		return nil, errors.New("disputes are not configured")
+	}
+
+	evidence, err := mr.DisputeService.AddEvidence(ctx, currentUser, disputeID, toDisputeUpload(&file))
+	if err != nil {
+		return nil, translateDisputeError(mr.Logger, err, "error uploading evidence")
+	}
+	return evidence, nil
+}
+
+func (mr *mutationResolver) RequestDisputeResponse(ctx context.Context, id string) (*store.Dispute, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+	if mr.DisputeService == nil {
+		return nil, errors.New("disputes are not configured")
+	}
+
+	result, err := mr.DisputeService.RequestResponse(ctx, currentUser, id)
+	if err != nil {
+		return nil, translateDisputeError(mr.Logger, err, "error updating dispute")
+	}
+	return result, nil
+}
+
+func (mr *mutationResolver) RespondToDispute(ctx context.Context, id string, response string) (*store.Dispute, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if mr.DisputeService == nil {
+		return nil, errors.New("disputes are not configured")
+	}
+
+	result, err := mr.DisputeService.Respond(ctx, currentUser, id, response)
+	if err != nil {
+		return nil, translateDisputeError(mr.Logger, err, "error responding to dispute")
+	}
+	return result, nil
+}
+
+func (mr *mutationResolver) StartDisputeReview(ctx context.Context, id string) (*store.Dispute, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+	if mr.DisputeService == nil {
+		return nil, errors.New("disputes are not configured")
+	}
+
+	result, err := mr.DisputeService.StartReview(ctx, currentUser, id)
+	if err != nil {
+		return nil, translateDisputeError(mr.Logger, err, "error updating dispute")
+	}
+	return result, nil
+}
+
+func (mr *mutationResolver) ResolveDispute(ctx context.Context, id string, input gen.DisputeResolutionInput) (*store.Dispute, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+	if mr.DisputeService == nil {
+		return nil, errors.New("disputes are not configured")
+	}
+
+	resolution := dispute.Resolution{}
+	if input.RefundAmount != nil {
+		resolution.RefundAmount = *input.RefundAmount
+	}
+	if input.ClawbackAmount != nil {
+		resolution.ClawbackAmount = *input.ClawbackAmount
+	}
+	if input.RemoveReview != nil {
+		resolution.RemoveReview = *input.RemoveReview
+	}
+	if input.Notes != nil {
+		resolution.Notes = *input.Notes
+	}
+
+	result, err := mr.DisputeService.Resolve(ctx, currentUser, id, resolution)
+	if err != nil {
+		return nil, translateDisputeError(mr.Logger, err, "error resolving dispute")
+	}
+	return result, nil
+}
+
+// translateDisputeError passes on what a user can act on and logs the rest
+func translateDisputeError(logger *log.Logger, err error, fallbackMsg string) error {
+	for _, known := range []error{
+		dispute.ErrDisputeNotFound, dispute.ErrBookingNotFound, dispute.ErrAccessDenied,
+		dispute.ErrNotCustomer, dispute.ErrNotDisputable, dispute.ErrWindowClosed,
+		dispute.ErrAlreadyDisputed, dispute.ErrInvalidDispute, dispute.ErrInvalidStatus,
+		dispute.ErrTooMuchEvidence, dispute.ErrStorageUnavailable, dispute.ErrInvalidResolution,
+		dispute.ErrRefundsUnavailable, payment.ErrNoPayment, payment.ErrNotRefundable,
+		payment.ErrPaymentProcessing,
+	} {
+		if errors.Is(err, known) {
+			return known
+		}
+	}
+	return logAndReturnError(logger, fallbackMsg, err, fallbackMsg)
+}
+
+func toDisputeUpload(file *graphql.Upload) dispute.Upload {
+	return dispute.Upload{
+		File:        file.File,
+		FileName:    file.Filename,
+		Size:        file.Size,
+		ContentType: file.ContentType,
+	}
+}
+
+func toDisputeFilters(status *store.DisputeStatus, limit, offset *int) store.DisputeFilters {
+	filters := store.DisputeFilters{Status: status, Limit: 50}
+	if limit != nil {
+		filters.Limit = *limit
+	}
+	if offset != nil {
+		filters.Offset = *offset
+	}
+	return filters
+}
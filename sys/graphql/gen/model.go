@@ -42,6 +42,17 @@ type AvailabilityFiltersInput struct {
 	EndDate   *time.Time              `json:"endDate,omitempty"`
 }
 
+type BillingDetailsInput struct {
+	Name               string  `json:"name"`
+	TaxID              string  `json:"taxId"`
+	RegistrationNumber *string `json:"registrationNumber,omitempty"`
+	Street             string  `json:"street"`
+	City               string  `json:"city"`
+	County             *string `json:"county,omitempty"`
+	PostalCode         *string `json:"postalCode,omitempty"`
+	Country            *string `json:"country,omitempty"`
+}
+
 type BookingConnection struct {
 	Edges      []*BookingEdge `json:"edges"`
 	TotalCount int            `json:"totalCount"`
@@ -67,6 +78,7 @@ type CalculateServicePriceInput struct {
 	ServiceType      store.ServiceType    `json:"serviceType"`
 	AddOns           []store.ServiceAddOn `json:"addOns,omitempty"`
 	AddressID        string               `json:"addressId"`
+	PromoCode        *string              `json:"promoCode,omitempty"`
 }
 
 type CancelBookingInput struct {
@@ -88,6 +100,7 @@ type CleanerEarnings struct {
 	CompletedBookings         int    `json:"completedBookings"`
 	AverageEarningsPerBooking int    `json:"averageEarningsPerBooking"`
 	PendingPayouts            int    `json:"pendingPayouts"`
+	Tips                      int    `json:"tips"`
 }
 
 type CleanerInviteResult struct {
@@ -189,6 +202,10 @@ type CreateBookingInput struct {
 	ScheduledTime    string                     `json:"scheduledTime"`
 	CustomerNotes    *string                    `json:"customerNotes,omitempty"`
 	IsRecurring      *bool                      `json:"isRecurring,omitempty"`
+	PromoCode        *string                    `json:"promoCode,omitempty"`
+	ApplyCredit      *bool                      `json:"applyCredit,omitempty"`
+	ApplyGiftCard    *bool                      `json:"applyGiftCard,omitempty"`
+	ReferralCode     *string                    `json:"referralCode,omitempty"`
 	User             *CreateBookingUserInput    `json:"user,omitempty"`
 }
 
@@ -206,9 +223,22 @@ type CreateCleanerInviteInput struct {
 type CreateCleanerProfileInput struct {
 	Bio               *string                   `json:"bio,omitempty"`
 	ProfilePicture    *string                   `json:"profilePicture,omitempty"`
+	HourlyRate        *int                      `json:"hourlyRate,omitempty"`
 	ServiceAreaInputs []*CreateServiceAreaInput `json:"serviceAreaInputs,omitempty"`
 }
 
+type CreateCommissionRuleInput struct {
+	Scope              store.CommissionRuleScope `json:"scope"`
+	Name               string                    `json:"name"`
+	CompanyID          *string                   `json:"companyId,omitempty"`
+	Tier               *store.CleanerTier        `json:"tier,omitempty"`
+	PlatformFeePercent *float64                  `json:"platformFeePercent,omitempty"`
+	CommissionPercent  *float64                  `json:"commissionPercent,omitempty"`
+	DiscountPercent    *float64                  `json:"discountPercent,omitempty"`
+	ValidFrom          *time.Time                `json:"validFrom,omitempty"`
+	ValidUntil         *time.Time                `json:"validUntil,omitempty"`
+}
+
 type CreateCompanyInput struct {
 	CompanyType store.CompanyType          `json:"companyType"`
 	CompanyInfo *CompanyInfoInput          `json:"companyInfo"`
@@ -222,6 +252,23 @@ type CreatePayoutBatchInput struct {
 	Notes       *string   `json:"notes,omitempty"`
 }
 
+type CreatePromoCodeInput struct {
+	Code                  string                  `json:"code"`
+	Description           *string                 `json:"description,omitempty"`
+	DiscountType          store.PromoDiscountType `json:"discountType"`
+	DiscountValue         int                     `json:"discountValue"`
+	MaxDiscount           *int                    `json:"maxDiscount,omitempty"`
+	FundedBy              store.PromoFunder       `json:"fundedBy"`
+	CompanyID             *string                 `json:"companyId,omitempty"`
+	FirstBookingOnly      *bool                   `json:"firstBookingOnly,omitempty"`
+	ServiceTypes          []store.ServiceType     `json:"serviceTypes,omitempty"`
+	Cities                []string                `json:"cities,omitempty"`
+	MaxRedemptions        *int                    `json:"maxRedemptions,omitempty"`
+	MaxRedemptionsPerUser *int                    `json:"maxRedemptionsPerUser,omitempty"`
+	ValidFrom             *time.Time              `json:"validFrom,omitempty"`
+	ValidUntil            *time.Time              `json:"validUntil,omitempty"`
+}
+
 type CreateReviewInput struct {
 	BookingID             string  `json:"bookingId"`
 	Rating                int     `json:"rating"`
@@ -249,6 +296,13 @@ type CreateServiceDefinitionInput struct {
 	PriceMultiplier float64           `json:"priceMultiplier"`
 }
 
+type DisputeResolutionInput struct {
+	RefundAmount   *int    `json:"refundAmount,omitempty"`
+	ClawbackAmount *int    `json:"clawbackAmount,omitempty"`
+	RemoveReview   *bool   `json:"removeReview,omitempty"`
+	Notes          *string `json:"notes,omitempty"`
+}
+
 type FlagReviewInput struct {
 	ReviewID string `json:"reviewId"`
 	Reason   string `json:"reason"`
@@ -268,9 +322,41 @@ type ModerateReviewInput struct {
 type Mutation struct {
 }
 
+type OpenDisputeInput struct {
+	BookingID   string              `json:"bookingId"`
+	Reason      store.DisputeReason `json:"reason"`
+	Description string              `json:"description"`
+	Evidence    []*graphql.Upload   `json:"evidence,omitempty"`
+}
+
+type ProposeRescheduleInput struct {
+	BookingID string                 `json:"bookingId"`
+	Slots     []*RescheduleSlotInput `json:"slots"`
+	Message   *string                `json:"message,omitempty"`
+}
+
+type PurchaseGiftCardInput struct {
+	Amount         int     `json:"amount"`
+	RecipientEmail string  `json:"recipientEmail"`
+	RecipientName  *string `json:"recipientName,omitempty"`
+	Message        *string `json:"message,omitempty"`
+}
+
 type Query struct {
 }
 
+type RescheduleSlotInput struct {
+	Date time.Time `json:"date"`
+	Time string    `json:"time"`
+}
+
+type RespondToRescheduleInput struct {
+	RequestID string  `json:"requestId"`
+	Accept    bool    `json:"accept"`
+	SlotIndex *int    `json:"slotIndex,omitempty"`
+	Note      *string `json:"note,omitempty"`
+}
+
 type ReviewConnection struct {
 	Edges         []*ReviewEdge `json:"edges"`
 	TotalCount    int           `json:"totalCount"`
@@ -289,16 +375,6 @@ type ReviewFiltersInput struct {
 	HasComment *bool               `json:"hasComment,omitempty"`
 }
 
-type ServicePriceCalculation struct {
-	ServicePrice      int     `json:"servicePrice"`
-	AddOnsPrice       int     `json:"addOnsPrice"`
-	TravelFee         int     `json:"travelFee"`
-	PlatformFee       int     `json:"platformFee"`
-	TotalPrice        int     `json:"totalPrice"`
-	CleanerPayout     int     `json:"cleanerPayout"`
-	EstimatedDuration float64 `json:"estimatedDuration"`
-}
-
 type TransactionConnection struct {
 	Edges       []*TransactionEdge `json:"edges"`
 	TotalCount  int                `json:"totalCount"`
@@ -367,13 +443,32 @@ type UpdateBookingInput struct {
 	CleanerNotes  *string    `json:"cleanerNotes,omitempty"`
 }
 
+type UpdateBookingSeriesInput struct {
+	FromBookingID string     `json:"fromBookingId"`
+	ScheduledDate *time.Time `json:"scheduledDate,omitempty"`
+	ScheduledTime *string    `json:"scheduledTime,omitempty"`
+	CustomerNotes *string    `json:"customerNotes,omitempty"`
+}
+
 type UpdateCleanerProfileInput struct {
 	Bio              *string `json:"bio,omitempty"`
 	ProfilePicture   *string `json:"profilePicture,omitempty"`
+	HourlyRate       *int    `json:"hourlyRate,omitempty"`
 	IsActive         *bool   `json:"isActive,omitempty"`
 	IsAvailableToday *bool   `json:"isAvailableToday,omitempty"`
 }
 
+type UpdateCommissionRuleInput struct {
+	ID                 string     `json:"id"`
+	Name               *string    `json:"name,omitempty"`
+	PlatformFeePercent *float64   `json:"platformFeePercent,omitempty"`
+	CommissionPercent  *float64   `json:"commissionPercent,omitempty"`
+	DiscountPercent    *float64   `json:"discountPercent,omitempty"`
+	ValidFrom          *time.Time `json:"validFrom,omitempty"`
+	ValidUntil         *time.Time `json:"validUntil,omitempty"`
+	IsActive           *bool      `json:"isActive,omitempty"`
+}
+
 type UpdateCompanyInput struct {
 	CompanyName       *string `json:"companyName,omitempty"`
 	CompanyStreet     *string `json:"companyStreet,omitempty"`
@@ -381,6 +476,7 @@ type UpdateCompanyInput struct {
 	CompanyPostalCode *string `json:"companyPostalCode,omitempty"`
 	CompanyCounty     *string `json:"companyCounty,omitempty"`
 	BusinessType      *string `json:"businessType,omitempty"`
+	HourlyRate        *int    `json:"hourlyRate,omitempty"`
 	IsActive          *bool   `json:"isActive,omitempty"`
 }
 
@@ -388,6 +484,20 @@ type UpdateCurrentUserInput struct {
 	DisplayName *string `json:"displayName,omitempty"`
 }
 
+type UpdatePromoCodeInput struct {
+	ID                    string              `json:"id"`
+	Description           *string             `json:"description,omitempty"`
+	MaxDiscount           *int                `json:"maxDiscount,omitempty"`
+	FirstBookingOnly      *bool               `json:"firstBookingOnly,omitempty"`
+	ServiceTypes          []store.ServiceType `json:"serviceTypes,omitempty"`
+	Cities                []string            `json:"cities,omitempty"`
+	MaxRedemptions        *int                `json:"maxRedemptions,omitempty"`
+	MaxRedemptionsPerUser *int                `json:"maxRedemptionsPerUser,omitempty"`
+	ValidFrom             *time.Time          `json:"validFrom,omitempty"`
+	ValidUntil            *time.Time          `json:"validUntil,omitempty"`
+	IsActive              *bool               `json:"isActive,omitempty"`
+}
+
 type UpdateReviewInput struct {
 	ID                    string  `json:"id"`
 	Rating                *int    `json:"rating,omitempty"`
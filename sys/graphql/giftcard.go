@@ -0,0 +1,180 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"cleanbuddy-api/res/giftcard"
+	"cleanbuddy-api/res/store"
+	"cleanbuddy-api/sys/graphql/gen"
+	"cleanbuddy-api/sys/http/middleware"
+)
+
+// FIELD RESOLVERS
+
+type giftCardResolver struct{ *Resolver }
+
+func (r *Resolver) GiftCard() gen.GiftCardResolver {
+	return &giftCardResolver{r}
+}
+
+func (gcr *giftCardResolver) Payment(ctx context.Context, card *store.GiftCard) (*store.Transaction, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil || (currentUser.ID != card.PurchaserID && !currentUser.IsGlobalAdmin()) {
+		return nil, nil
+	}
+
+	purchase, err := gcr.Store.GiftCards().GetPurchase(ctx, card.ID)
+	if err != nil {
+		gcr.Logger.Printf("Error retrieving purchase of gift card %s: %s", card.ID, err)
+		return nil, nil
+	}
+	return purchase, nil
+}
+
+// QUERY RESOLVERS
+func (qr *queryResolver) GiftCardAmounts(ctx context.Context) ([]int, error) {
+	return qr.GiftCards.Amounts(), nil
+}
+
+func (qr *queryResolver) GiftCardBalance(ctx context.Context) (int, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return 0, errors.New("authentication required")
+	}
+
+	balance, err := qr.GiftCards.Balance(ctx, currentUser.ID)
+	if err != nil {
+		return 0, translateGiftCardError(qr.Logger, err, "error retrieving gift card balance")
+	}
+	return balance, nil
+}
+
+func (qr *queryResolver) MyGiftCards(ctx context.Context) ([]*store.GiftCard, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	cards, err := qr.GiftCards.ListForUser(ctx, currentUser.ID)
+	if err != nil {
+		return nil, translateGiftCardError(qr.Logger, err, "error retrieving gift cards")
+	}
+	return cards, nil
+}
+
+func (qr *queryResolver) GiftCardLiability(ctx context.Context) (*store.GiftCardLiability, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+
+	liability, err := qr.GiftCards.Liability(ctx)
+	if err != nil {
+		return nil, translateGiftCardError(qr.Logger, err, "error retrieving gift card liability")
+	}
+	return liability, nil
+}
+
+func (qr *queryResolver) OutstandingGiftCards(ctx context.Context, limit, offset *int) ([]*store.GiftCard, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+
+	l, o := 50, 0
+	if limit != nil {
+		l = *limit
+	}
+	if offset != nil {
+		o = *offset
+	}
+
+	cards, err := qr.GiftCards.Outstanding(ctx, l, o)
+	if err != nil {
+		return nil, translateGiftCardError(qr.Logger, err, "error retrieving outstanding gift cards")
+	}
+	return cards, nil
+}
+
+// MUTATION RESOLVERS
+func (mr *mutationResolver) PurchaseGiftCard(ctx context.Context, input gen.PurchaseGiftCardInput) (*store.GiftCard, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	request := giftcard.PurchaseRequest{
+		Amount:         input.Amount,
+		RecipientEmail: input.RecipientEmail,
+	}
+	if input.RecipientName != nil {
+		request.RecipientName = *input.RecipientName
+	}
+	if input.Message != nil {
+		request.Message = *input.Message
+	}
+
+	card, err := mr.GiftCards.Purchase(ctx, currentUser, request)
+	if err != nil {
+		return nil, translateGiftCardError(mr.Logger, err, "error purchasing gift card")
+	}
+	return card, nil
+}
+
+func (mr *mutationResolver) RedeemGiftCard(ctx context.Context, code string) (*store.GiftCard, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	card, err := mr.GiftCards.Redeem(ctx, currentUser.ID, code)
+	if err != nil {
+		return nil, translateGiftCardError(mr.Logger, err, "error redeeming gift card")
+	}
+	return card, nil
+}
+
+func (mr *mutationResolver) ExpireGiftCards(ctx context.Context) (int, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return 0, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return 0, errors.New("admin access required")
+	}
+
+	expired, err := mr.GiftCards.ExpireDue(ctx)
+	if err != nil {
+		return 0, logAndReturnError(mr.Logger, "Error expiring gift cards", err, "error expiring gift cards")
+	}
+	return expired, nil
+}
+
+// translateGiftCardError maps gift card errors to user-facing messages
+func translateGiftCardError(logger *log.Logger, err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, giftcard.ErrInvalidAmount):
+		return errors.New("gift card amount is not available")
+	case errors.Is(err, giftcard.ErrRecipientRequired):
+		return errors.New("recipient email is required")
+	case errors.Is(err, giftcard.ErrCodeNotFound):
+		return errors.New("gift card not found")
+	case errors.Is(err, giftcard.ErrAlreadyRedeemed):
+		return errors.New("gift card has already been redeemed")
+	case errors.Is(err, giftcard.ErrExpired):
+		return errors.New("gift card has expired")
+	case errors.Is(err, giftcard.ErrNotPaid):
+		return errors.New("gift card has not been paid for yet")
+	case errors.Is(err, giftcard.ErrPaymentsDisabled):
+		return errors.New("payments are not configured")
+	}
+	return logAndReturnError(logger, fallbackMsg, err, fallbackMsg)
+}
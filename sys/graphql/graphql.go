@@ -9,10 +9,29 @@ import (
 	"strings"
 
 	"cleanbuddy-api/res/auth"
+	"cleanbuddy-api/res/availability"
+	"cleanbuddy-api/res/bookinglifecycle"
+	"cleanbuddy-api/res/bookingreschedule"
+	"cleanbuddy-api/res/bookingseries"
+	"cleanbuddy-api/res/cancellationpolicy"
+	"cleanbuddy-api/res/commission"
+	"cleanbuddy-api/res/credit"
+	"cleanbuddy-api/res/dispute"
+	"cleanbuddy-api/res/giftcard"
+	"cleanbuddy-api/res/invoice"
+	"cleanbuddy-api/res/ledger"
 	"cleanbuddy-api/res/mail"
+	"cleanbuddy-api/res/noshow"
 	"cleanbuddy-api/res/notification"
+	"cleanbuddy-api/res/payment"
+	"cleanbuddy-api/res/payout"
+	"cleanbuddy-api/res/pricing"
+	"cleanbuddy-api/res/promo"
+	"cleanbuddy-api/res/referral"
+	"cleanbuddy-api/res/statement"
 	"cleanbuddy-api/res/storage"
 	"cleanbuddy-api/res/store"
+	"cleanbuddy-api/res/tip"
 	"cleanbuddy-api/sys/graphql/directive"
 	"cleanbuddy-api/sys/graphql/gen"
 	"cleanbuddy-api/sys/http/middleware"
@@ -33,6 +52,25 @@ type Config struct {
 	NotificationService notification.NotificationService
 	StorageService      *storage.GCSService
 	Auth                auth.Auth
+	BookingLifecycle    bookinglifecycle.LifecycleService
+	Commission          commission.CommissionService
+	Pricing             pricing.PricingService
+	Promo               promo.PromoService
+	Referral            referral.ReferralService
+	Credit              credit.CreditService
+	GiftCards           giftcard.GiftCardService
+	Payments            payment.PaymentService
+	Payouts             payout.PayoutService
+	Ledger              ledger.LedgerService
+	Statements          statement.StatementService
+	Invoicing           invoice.InvoiceService
+	Tips                tip.TipService
+	DisputeService      dispute.DisputeService
+	RecurringBookings   bookingseries.SeriesService
+	CancellationPolicy  cancellationpolicy.PolicyService
+	NoShowSettlement    noshow.SettlementService
+	BookingReschedule   bookingreschedule.RescheduleService
+	AvailabilityService availability.AvailabilityService
 }
 
 type Resolver struct {
@@ -7,6 +7,13 @@ import (
 
 	"cleanbuddy-api/res/store"
 	"cleanbuddy-api/sys/http/middleware"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Error codes returned in the "code" extension of GraphQL errors
+const (
+	ErrorCodeSlotUnavailable = "SLOT_UNAVAILABLE"
 )
 
 // requireAuth validates that a user is authenticated
@@ -26,3 +33,16 @@ func logAndReturnError(logger *log.Logger, logMsg string, err error, userMsg str
 	logger.Printf("%s: %s", logMsg, err)
 	return errors.New(userMsg)
 }
+
+// newCodedError returns a GraphQL error carrying a machine-readable code extension
+func newCodedError(code string, message string) error {
+	return &gqlerror.Error{
+		Message:    message,
+		Extensions: map[string]interface{}{"code": code},
+	}
+}
+
+// slotUnavailableError is returned when a booking overlaps the cleaner's other bookings or blocked time
+func slotUnavailableError() error {
+	return newCodedError(ErrorCodeSlotUnavailable, "the cleaner is not available for the requested time slot")
+}
@@ -0,0 +1,250 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+
+	"cleanbuddy-api/res/invoice"
+	"cleanbuddy-api/res/store"
+	"cleanbuddy-api/sys/graphql/gen"
+	"cleanbuddy-api/sys/http/middleware"
+)
+
+// QUERY RESOLVERS
+func (qr *queryResolver) Invoice(ctx context.Context, id string) (*store.Invoice, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	result, err := qr.Store.Invoices().Get(ctx, id)
+	if err != nil {
+		qr.Logger.Printf("Error retrieving invoice %s: %s", id, err)
+		return nil, errors.New("invoice not found")
+	}
+	if !canViewInvoice(currentUser, result, qr.adminCompanyID(ctx, currentUser)) {
+		return nil, errors.New("access denied")
+	}
+	return result, nil
+}
+
+func (qr *queryResolver) MyInvoices(ctx context.Context, limit *int, offset *int) ([]*store.Invoice, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	filters := toInvoiceFilters(limit, offset)
+	filters.CustomerID = &currentUser.ID
+	invoices, err := qr.Store.Invoices().List(ctx, filters)
+	if err != nil {
+		return nil, logAndReturnError(qr.Logger, "Error retrieving invoices", err, "error retrieving invoices")
+	}
+	return invoices, nil
+}
+
+func (qr *queryResolver) BookingInvoices(ctx context.Context, bookingID string) ([]*store.Invoice, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	invoices, err := qr.Store.Invoices().List(ctx, store.InvoiceFilters{BookingID: &bookingID})
+	if err != nil {
+		return nil, logAndReturnError(qr.Logger, "Error retrieving invoices of booking", err, "error retrieving invoices")
+	}
+
+	// Company admins only see what their company issued
+	companyID := qr.adminCompanyID(ctx, currentUser)
+	visible := make([]*store.Invoice, 0, len(invoices))
+	for _, result := range invoices {
+		if canViewInvoice(currentUser, result, companyID) {
+			visible = append(visible, result)
+		}
+	}
+	return visible, nil
+}
+
+func (qr *queryResolver) Invoices(ctx context.Context, issuerID *string, kind *store.InvoiceKind, limit *int, offset *int) ([]*store.Invoice, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	filters := toInvoiceFilters(limit, offset)
+	filters.Kind = kind
+	switch {
+	case currentUser.IsGlobalAdmin():
+		filters.IssuerID = issuerID
+	case currentUser.IsCleanerAdmin():
+		companyID := qr.adminCompanyID(ctx, currentUser)
+		if companyID == "" {
+			return nil, errors.New("company not found")
+		}
+		if issuerID != nil && *issuerID != companyID {
+			return nil, errors.New("access denied")
+		}
+		filters.IssuerID = &companyID
+	default:
+		return nil, errors.New("admin access required")
+	}
+
+	invoices, err := qr.Store.Invoices().List(ctx, filters)
+	if err != nil {
+		return nil, logAndReturnError(qr.Logger, "Error retrieving invoices", err, "error retrieving invoices")
+	}
+	return invoices, nil
+}
+
+func (qr *queryResolver) InvoiceDocuments(ctx context.Context, id string) (*invoice.Documents, error) {
+	if qr.Invoicing == nil {
+		return nil, errors.New("invoicing is not configured")
+	}
+
+	result, err := qr.Invoice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	documents, err := qr.Invoicing.Documents(ctx, result)
+	if err != nil {
+		if errors.Is(err, invoice.ErrStorageUnavailable) {
+			return nil, err
+		}
+		return nil, logAndReturnError(qr.Logger, "Error generating invoice documents", err, "error generating invoice documents")
+	}
+	return documents, nil
+}
+
+func (qr *queryResolver) MyBillingDetails(ctx context.Context) (*store.BillingDetails, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	details, err := qr.Store.Invoices().GetBillingDetails(ctx, currentUser.ID)
+	if err != nil {
+		return nil, nil
+	}
+	return details, nil
+}
+
+// MUTATION RESOLVERS
+func (mr *mutationResolver) UpdateMyBillingDetails(ctx context.Context, input gen.BillingDetailsInput) (*store.BillingDetails, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if mr.Invoicing == nil {
+		return nil, errors.New("invoicing is not configured")
+	}
+
+	details := &store.BillingDetails{
+		UserID: currentUser.ID,
+		InvoiceParty: store.InvoiceParty{
+			Name:   input.Name,
+			TaxID:  input.TaxID,
+			Street: input.Street,
+			City:   input.City,
+		},
+	}
+	for _, field := range []struct {
+		value  *string
+		target *string
+	}{
+		{input.RegistrationNumber, &details.RegistrationNumber},
+		{input.County, &details.County},
+		{input.PostalCode, &details.PostalCode},
+		{input.Country, &details.Country},
+	} {
+		if field.value != nil {
+			*field.target = *field.value
+		}
+	}
+
+	if err := mr.Invoicing.SaveBillingDetails(ctx, details); err != nil {
+		if errors.Is(err, invoice.ErrInvalidBillingInfo) {
+			return nil, err
+		}
+		return nil, logAndReturnError(mr.Logger, "Error saving billing details", err, "error saving billing details")
+	}
+	return details, nil
+}
+
+func (mr *mutationResolver) DeleteMyBillingDetails(ctx context.Context) (bool, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return false, errors.New("authentication required")
+	}
+
+	if err := mr.Store.Invoices().DeleteBillingDetails(ctx, currentUser.ID); err != nil {
+		return false, logAndReturnError(mr.Logger, "Error deleting billing details", err, "error deleting billing details")
+	}
+	return true, nil
+}
+
+func (mr *mutationResolver) IssuePendingInvoices(ctx context.Context) (int, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return 0, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return 0, errors.New("admin access required")
+	}
+	if mr.Invoicing == nil {
+		return 0, errors.New("invoicing is not configured")
+	}
+
+	issued, err := mr.Invoicing.IssuePending(ctx)
+	if err != nil {
+		return issued, logAndReturnError(mr.Logger, "Error issuing pending invoices", err, "some invoices could not be issued")
+	}
+	return issued, nil
+}
+
+func (mr *mutationResolver) SubmitInvoices(ctx context.Context) (int, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return 0, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return 0, errors.New("admin access required")
+	}
+	if mr.Invoicing == nil {
+		return 0, errors.New("invoicing is not configured")
+	}
+
+	changed, err := mr.Invoicing.SubmitPending(ctx)
+	if err != nil {
+		return changed, logAndReturnError(mr.Logger, "Error submitting invoices", err, "some invoices could not be submitted")
+	}
+	return changed, nil
+}
+
+// canViewInvoice lets customers see what was issued to them, company admins what their company
+// issued and admins everything
+func canViewInvoice(user *store.User, result *store.Invoice, companyID string) bool {
+	return user.IsGlobalAdmin() || result.CustomerID == user.ID || (companyID != "" && result.IssuerID == companyID)
+}
+
+// adminCompanyID returns the company a cleaner admin runs, or empty for anyone else
+func (r *Resolver) adminCompanyID(ctx context.Context, user *store.User) string {
+	if !user.IsCleanerAdmin() {
+		return ""
+	}
+	company, err := r.Store.Companies().GetByAdminUserID(ctx, user.ID)
+	if err != nil {
+		return ""
+	}
+	return company.ID
+}
+
+func toInvoiceFilters(limit, offset *int) store.InvoiceFilters {
+	filters := store.InvoiceFilters{Limit: 50}
+	if limit != nil {
+		filters.Limit = *limit
+	}
+	if offset != nil {
+		filters.Offset = *offset
+	}
+	return filters
+}
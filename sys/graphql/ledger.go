@@ -0,0 +1,65 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cleanbuddy-api/res/ledger"
+	"cleanbuddy-api/res/store"
+	"cleanbuddy-api/sys/http/middleware"
+)
+
+// QUERY RESOLVERS
+func (qr *queryResolver) LedgerBalances(ctx context.Context, account *store.LedgerAccount, ownerID *string, asOf *time.Time) ([]*store.LedgerBalance, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+
+	balances, err := qr.Ledger.Balances(ctx, store.LedgerBalanceFilter{
+		Account: account,
+		OwnerID: ownerID,
+		Before:  asOf,
+	})
+	if err != nil {
+		return nil, logAndReturnError(qr.Logger, "Error retrieving ledger balances", err, "error retrieving ledger balances")
+	}
+	return balances, nil
+}
+
+func (qr *queryResolver) TrialBalance(ctx context.Context, asOf *time.Time) (*ledger.TrialBalance, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+
+	trial, err := qr.Ledger.TrialBalance(ctx, asOf)
+	if err != nil {
+		return nil, logAndReturnError(qr.Logger, "Error retrieving trial balance", err, "error retrieving trial balance")
+	}
+	return trial, nil
+}
+
+// MUTATION RESOLVERS
+func (mr *mutationResolver) PostLedgerEntries(ctx context.Context) (int, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return 0, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return 0, errors.New("admin access required")
+	}
+
+	posted, err := mr.Ledger.PostPending(ctx)
+	if err != nil {
+		return posted, logAndReturnError(mr.Logger, "Error posting ledger entries", err, "some ledger entries could not be posted")
+	}
+	return posted, nil
+}
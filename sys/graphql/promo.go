@@ -0,0 +1,227 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"cleanbuddy-api/res/promo"
+	"cleanbuddy-api/res/store"
+	"cleanbuddy-api/sys/graphql/gen"
+	"cleanbuddy-api/sys/http/middleware"
+)
+
+// FIELD RESOLVERS
+type promoCodeResolver struct{ *Resolver }
+
+func (r *Resolver) PromoCode() gen.PromoCodeResolver {
+	return &promoCodeResolver{r}
+}
+
+func (pcr *promoCodeResolver) Company(ctx context.Context, promoCode *store.PromoCode) (*store.Company, error) {
+	if promoCode.CompanyID == nil {
+		return nil, nil
+	}
+
+	company, err := pcr.Store.Companies().Get(ctx, *promoCode.CompanyID)
+	if err != nil {
+		pcr.Logger.Printf("Error retrieving company for promo code: %s", err)
+		return nil, nil
+	}
+	return company, nil
+}
+
+func (pcr *promoCodeResolver) ServiceTypes(ctx context.Context, promoCode *store.PromoCode) ([]store.ServiceType, error) {
+	serviceTypes, err := promo.ServiceTypes(promoCode)
+	if err != nil {
+		pcr.Logger.Printf("Error parsing promo code service types: %s", err)
+		return []store.ServiceType{}, nil
+	}
+	return serviceTypes, nil
+}
+
+func (pcr *promoCodeResolver) Cities(ctx context.Context, promoCode *store.PromoCode) ([]string, error) {
+	cities, err := promo.Cities(promoCode)
+	if err != nil {
+		pcr.Logger.Printf("Error parsing promo code cities: %s", err)
+		return []string{}, nil
+	}
+	return cities, nil
+}
+
+// QUERY RESOLVERS
+func (qr *queryResolver) PromoCodes(ctx context.Context, activeOnly *bool, limit, offset *int) ([]*store.PromoCode, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+
+	active := false
+	if activeOnly != nil {
+		active = *activeOnly
+	}
+	l, o := 50, 0
+	if limit != nil {
+		l = *limit
+	}
+	if offset != nil {
+		o = *offset
+	}
+
+	promoCodes, err := qr.Promo.List(ctx, active, l, o)
+	if err != nil {
+		return nil, translatePromoError(qr.Logger, err, "error retrieving promo codes")
+	}
+	return promoCodes, nil
+}
+
+// MUTATION RESOLVERS
+func (mr *mutationResolver) CreatePromoCode(ctx context.Context, input gen.CreatePromoCodeInput) (*store.PromoCode, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+
+	promoCode := &store.PromoCode{
+		Code:                  input.Code,
+		DiscountType:          input.DiscountType,
+		DiscountValue:         input.DiscountValue,
+		MaxDiscount:           input.MaxDiscount,
+		FundedBy:              input.FundedBy,
+		CompanyID:             input.CompanyID,
+		MaxRedemptions:        input.MaxRedemptions,
+		MaxRedemptionsPerUser: input.MaxRedemptionsPerUser,
+		ValidFrom:             input.ValidFrom,
+		ValidUntil:            input.ValidUntil,
+		IsActive:              true,
+	}
+	if input.Description != nil {
+		promoCode.Description = *input.Description
+	}
+	if input.FirstBookingOnly != nil {
+		promoCode.FirstBookingOnly = *input.FirstBookingOnly
+	}
+	if err := setPromoRestrictions(promoCode, input.ServiceTypes, input.Cities); err != nil {
+		mr.Logger.Printf("Error encoding promo code restrictions: %s", err)
+		return nil, errors.New("error processing promo code restrictions")
+	}
+
+	created, err := mr.Promo.Create(ctx, promoCode)
+	if err != nil {
+		return nil, translatePromoError(mr.Logger, err, "error creating promo code")
+	}
+	return created, nil
+}
+
+func (mr *mutationResolver) UpdatePromoCode(ctx context.Context, input gen.UpdatePromoCodeInput) (*store.PromoCode, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+
+	promoCode, err := mr.Promo.Get(ctx, input.ID)
+	if err != nil {
+		return nil, translatePromoError(mr.Logger, err, "error retrieving promo code")
+	}
+
+	if input.Description != nil {
+		promoCode.Description = *input.Description
+	}
+	if input.MaxDiscount != nil {
+		promoCode.MaxDiscount = input.MaxDiscount
+	}
+	if input.FirstBookingOnly != nil {
+		promoCode.FirstBookingOnly = *input.FirstBookingOnly
+	}
+	if input.MaxRedemptions != nil {
+		promoCode.MaxRedemptions = input.MaxRedemptions
+	}
+	if input.MaxRedemptionsPerUser != nil {
+		promoCode.MaxRedemptionsPerUser = input.MaxRedemptionsPerUser
+	}
+	if input.ValidFrom != nil {
+		promoCode.ValidFrom = input.ValidFrom
+	}
+	if input.ValidUntil != nil {
+		promoCode.ValidUntil = input.ValidUntil
+	}
+	if input.IsActive != nil {
+		promoCode.IsActive = *input.IsActive
+	}
+	if input.ServiceTypes != nil || input.Cities != nil {
+		serviceTypes, cities := input.ServiceTypes, input.Cities
+		if serviceTypes == nil {
+			serviceTypes, _ = promo.ServiceTypes(promoCode)
+		}
+		if cities == nil {
+			cities, _ = promo.Cities(promoCode)
+		}
+		if err := setPromoRestrictions(promoCode, serviceTypes, cities); err != nil {
+			mr.Logger.Printf("Error encoding promo code restrictions: %s", err)
+			return nil, errors.New("error processing promo code restrictions")
+		}
+	}
+
+	updated, err := mr.Promo.Update(ctx, promoCode)
+	if err != nil {
+		return nil, translatePromoError(mr.Logger, err, "error updating promo code")
+	}
+	return updated, nil
+}
+
+// setPromoRestrictions stores the service type and city restrictions as JSON; empty lists allow all
+func setPromoRestrictions(promoCode *store.PromoCode, serviceTypes []store.ServiceType, cities []string) error {
+	promoCode.ServiceTypes = ""
+	if len(serviceTypes) > 0 {
+		data, err := json.Marshal(serviceTypes)
+		if err != nil {
+			return err
+		}
+		promoCode.ServiceTypes = string(data)
+	}
+
+	promoCode.Cities = ""
+	if len(cities) > 0 {
+		data, err := json.Marshal(cities)
+		if err != nil {
+			return err
+		}
+		promoCode.Cities = string(data)
+	}
+	return nil
+}
+
+// translatePromoError maps promo code errors to user-facing messages
+func translatePromoError(logger *log.Logger, err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, promo.ErrCodeNotFound):
+		return errors.New("promo code not found")
+	case errors.Is(err, promo.ErrCodeExists):
+		return errors.New("promo code already exists")
+	case errors.Is(err, promo.ErrInvalidCode):
+		return errors.New("promo code is incomplete or its discount does not match its type and funder")
+	case errors.Is(err, promo.ErrCodeInactive):
+		return errors.New("promo code is not active")
+	case errors.Is(err, promo.ErrCodeExpired):
+		return errors.New("promo code is not valid at this time")
+	case errors.Is(err, promo.ErrNotApplicable):
+		return errors.New("promo code does not apply to this booking")
+	case errors.Is(err, promo.ErrFirstBookingOnly):
+		return errors.New("promo code is only valid on a first booking")
+	case errors.Is(err, promo.ErrLimitReached):
+		return errors.New("promo code has reached its redemption limit")
+	case errors.Is(err, promo.ErrCompanyNotFound):
+		return errors.New("company not found")
+	}
+	return logAndReturnError(logger, fallbackMsg, err, fallbackMsg)
+}
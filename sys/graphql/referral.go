@@ -0,0 +1,63 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"cleanbuddy-api/res/referral"
+	"cleanbuddy-api/res/store"
+	"cleanbuddy-api/sys/http/middleware"
+)
+
+// QUERY RESOLVERS
+func (qr *queryResolver) ReferralStats(ctx context.Context) (*store.ReferralStats, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	stats, err := qr.Referral.Stats(ctx, currentUser.ID)
+	if err != nil {
+		return nil, translateReferralError(qr.Logger, err, "error retrieving referral stats")
+	}
+	return stats, nil
+}
+
+// MUTATION RESOLVERS
+func (mr *mutationResolver) CreateReferralCode(ctx context.Context) (*store.ReferralCode, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	code, err := mr.Referral.Code(ctx, currentUser.ID)
+	if err != nil {
+		return nil, translateReferralError(mr.Logger, err, "error creating referral code")
+	}
+	return code, nil
+}
+
+// attributeReferral links a newly signed-up customer to the friend who invited them.
+// Sign-up never fails on a bad code, so errors are only logged.
+func (r *Resolver) attributeReferral(ctx context.Context, user *store.User, code *string) {
+	if code == nil || *code == "" || user.Role != store.UserRoleClient {
+		return
+	}
+	if _, err := r.Referral.Attribute(ctx, user.ID, *code); err != nil {
+		r.Logger.Printf("Error attributing user %s to referral code %s: %s", user.ID, *code, err)
+	}
+}
+
+// translateReferralError maps referral errors to user-facing messages
+func translateReferralError(logger *log.Logger, err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, referral.ErrCodeNotFound):
+		return errors.New("referral code not found")
+	case errors.Is(err, referral.ErrSelfReferral):
+		return errors.New("you cannot use your own referral code")
+	case errors.Is(err, referral.ErrAlreadyReferred):
+		return errors.New("this account has already been referred")
+	}
+	return logAndReturnError(logger, fallbackMsg, err, fallbackMsg)
+}
@@ -0,0 +1,31 @@
+package scalar
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"cleanbuddy-api/res/localtime"
+)
+
+// MarshalLocalTime marshals a wall-clock time of day as an "HH:MM" string
+func MarshalLocalTime(v string) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		io.WriteString(w, strconv.Quote(v))
+	})
+}
+
+// UnmarshalLocalTime validates an "HH:MM" string and returns its canonical form
+func UnmarshalLocalTime(v interface{}) (string, error) {
+	tmpStr, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("LocalTime should be an HH:MM formatted string")
+	}
+	clock, err := localtime.Parse(tmpStr)
+	if err != nil {
+		return "", err
+	}
+	return clock.String(), nil
+}
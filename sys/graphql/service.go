@@ -3,11 +3,12 @@ package graphql
 import (
 	"context"
 	"errors"
-	"os"
-	"strconv"
+	"log"
 
+	"cleanbuddy-api/res/pricing"
 	"cleanbuddy-api/res/store"
 	"cleanbuddy-api/sys/graphql/gen"
+	"cleanbuddy-api/sys/http/middleware"
 )
 
 // QUERY RESOLVERS
@@ -58,121 +59,54 @@ func (qr *queryResolver) AddOnDefinitions(ctx context.Context, activeOnly *bool)
 	return addOns, nil
 }
 
-func (qr *queryResolver) CalculateServicePrice(ctx context.Context, input gen.CalculateServicePriceInput) (*gen.ServicePriceCalculation, error) {
-	// 1. Get cleaner profile and hourly rate
-	cleanerProfile, err := qr.Store.CleanerProfiles().Get(ctx, input.CleanerProfileID)
-	if err != nil {
-		qr.Logger.Printf("Error retrieving cleaner profile: %s", err)
-		return nil, errors.New("cleaner profile not found")
+func (qr *queryResolver) CalculateServicePrice(ctx context.Context, input gen.CalculateServicePriceInput) (*pricing.Quote, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
 	}
 
-	if !cleanerProfile.IsActive {
-		return nil, errors.New("cleaner is not currently accepting bookings")
+	request := pricing.QuoteRequest{
+		CleanerProfileID: input.CleanerProfileID,
+		ServiceType:      input.ServiceType,
+		AddOns:           input.AddOns,
+		AddressID:        input.AddressID,
+		CustomerID:       currentUser.ID,
 	}
-
-	// 2. Get service definition
-	serviceDefinition, err := qr.Store.Services().GetServiceDefinition(ctx, input.ServiceType)
-	if err != nil {
-		qr.Logger.Printf("Error retrieving service definition: %s", err)
-		return nil, errors.New("service definition not found")
-	}
-
-	if !serviceDefinition.IsActive {
-		return nil, errors.New("service is not currently available")
+	if input.PromoCode != nil {
+		request.PromoCode = *input.PromoCode
 	}
 
-	// 3. Get customer address to determine travel fee
-	address, err := qr.Store.Addresses().Get(ctx, input.AddressID)
+	quote, err := qr.Pricing.Quote(ctx, request)
 	if err != nil {
-		qr.Logger.Printf("Error retrieving address: %s", err)
-		return nil, errors.New("address not found")
+		return nil, translatePricingError(qr.Logger, err, "error calculating price")
 	}
+	return quote, nil
+}
 
-	// 4. Find service area and travel fee
-	travelFee := 0
-	serviceAreas, err := qr.Store.ServiceAreas().GetByCleanerProfile(ctx, cleanerProfile.ID)
-	if err == nil && len(serviceAreas) > 0 {
-		// Find matching service area by city and optionally postal code
-		for _, area := range serviceAreas {
-			if area.City == address.City {
-				// Exact match with postal code if available
-				if address.PostalCode != "" && area.PostalCode != "" && area.PostalCode == address.PostalCode {
-					travelFee = area.TravelFee
-					break
-				}
-				// Match by city and neighborhood
-				if address.Neighborhood != "" && area.Neighborhood != "" && area.Neighborhood == address.Neighborhood {
-					travelFee = area.TravelFee
-					break
-				}
-				// Fallback to city match
-				if area.PostalCode == "" && area.Neighborhood == "" {
-					travelFee = area.TravelFee
-				}
-			}
-		}
-	}
-
-	// 5. Calculate base service price
-	// Price = (Base Hours * Hourly Rate * Price Multiplier)
-	// TODO: hourlyRate will be re-implemented later with a proper pricing system
-	baseHours := serviceDefinition.BaseHours
-	hourlyRate := 0 // Placeholder until pricing is re-implemented
-	priceMultiplier := serviceDefinition.PriceMultiplier
-
-	servicePrice := int(float64(hourlyRate) * baseHours * priceMultiplier)
-
-	// 6. Calculate add-ons price
-	addOnsPrice := 0
-	totalAddOnHours := 0.0
-	if len(input.AddOns) > 0 {
-		addOnDefs, err := qr.Store.Services().ListAddOnDefinitions(ctx, true)
-		if err != nil {
-			qr.Logger.Printf("Error retrieving add-on definitions: %s", err)
-		} else {
-			// Create map for quick lookup
-			addOnMap := make(map[store.ServiceAddOn]*store.ServiceAddOnDefinition)
-			for _, def := range addOnDefs {
-				addOnMap[def.AddOn] = def
-			}
-
-			// Sum up add-on prices and hours
-			for _, addOn := range input.AddOns {
-				if def, exists := addOnMap[addOn]; exists {
-					addOnsPrice += def.FixedPrice
-					totalAddOnHours += def.EstimatedHours
-				}
-			}
-		}
-	}
-
-	// Total estimated duration including add-ons
-	totalEstimatedHours := baseHours + totalAddOnHours
-
-	// 7. Calculate platform fee (percentage from ENV, default 15%)
-	platformFeePercentage := 15.0 // default
-	if feeEnv := os.Getenv("PLATFORM_FEE_PERCENTAGE"); feeEnv != "" {
-		if fee, err := strconv.ParseFloat(feeEnv, 64); err == nil {
-			platformFeePercentage = fee
-		}
+// translatePricingError maps pricing errors to user-facing messages
+func translatePricingError(logger *log.Logger, err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, pricing.ErrCleanerNotFound):
+		return errors.New("cleaner profile not found")
+	case errors.Is(err, pricing.ErrCleanerInactive):
+		return errors.New("cleaner is not currently accepting bookings")
+	case errors.Is(err, pricing.ErrServiceNotFound):
+		return errors.New("service definition not found")
+	case errors.Is(err, pricing.ErrServiceInactive):
+		return errors.New("service is not currently available")
+	case errors.Is(err, pricing.ErrAddressNotFound):
+		return errors.New("address not found")
+	case errors.Is(err, pricing.ErrAddressNotOwned):
+		return errors.New("address does not belong to user")
+	case errors.Is(err, pricing.ErrDuplicateAddOn):
+		return errors.New("each add-on may only be selected once")
+	case errors.Is(err, pricing.ErrHourlyRateNotSet):
+		return errors.New("cleaner has not set an hourly rate")
+	case errors.Is(err, pricing.ErrLocationNotServed):
+		return errors.New("cleaner does not serve this address")
 	}
-
-	subtotal := servicePrice + addOnsPrice + travelFee
-	platformFee := int(float64(subtotal) * platformFeePercentage / 100.0)
-	totalPrice := subtotal + platformFee
-
-	// 8. Calculate cleaner payout (subtotal minus platform fee)
-	cleanerPayout := subtotal - platformFee
-
-	return &gen.ServicePriceCalculation{
-		ServicePrice:      servicePrice,
-		AddOnsPrice:       addOnsPrice,
-		TravelFee:         travelFee,
-		PlatformFee:       platformFee,
-		TotalPrice:        totalPrice,
-		CleanerPayout:     cleanerPayout,
-		EstimatedDuration: totalEstimatedHours,
-	}, nil
+	// Quotes carry the checks of the promo code applied to them
+	return translatePromoError(logger, err, fallbackMsg)
 }
 
 // MUTATION RESOLVERS (Admin only)
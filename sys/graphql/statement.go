@@ -0,0 +1,66 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+
+	"cleanbuddy-api/res/statement"
+	"cleanbuddy-api/res/store"
+	"cleanbuddy-api/sys/http/middleware"
+)
+
+// QUERY RESOLVERS
+func (qr *queryResolver) PayoutStatement(ctx context.Context, period string, cleanerID *string) (*statement.Statement, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if qr.Statements == nil {
+		return nil, errors.New("payout statements are not configured")
+	}
+
+	targetID := currentUser.ID
+	if cleanerID != nil {
+		targetID = *cleanerID
+	}
+	if err := qr.checkStatementAccess(ctx, currentUser, targetID); err != nil {
+		return nil, err
+	}
+
+	result, err := qr.Statements.Generate(ctx, targetID, period)
+	if err != nil {
+		if errors.Is(err, statement.ErrInvalidPeriod) {
+			return nil, err
+		}
+		return nil, logAndReturnError(qr.Logger, "Error generating payout statement", err, "error generating payout statement")
+	}
+	return result, nil
+}
+
+// checkStatementAccess lets cleaners see their own statements, company admins those of their
+// company's cleaners and admins everyone's
+func (qr *queryResolver) checkStatementAccess(ctx context.Context, currentUser *store.User, cleanerID string) error {
+	if currentUser.IsGlobalAdmin() {
+		return nil
+	}
+	if cleanerID == currentUser.ID {
+		if !currentUser.IsCleaner() && !currentUser.IsCleanerAdmin() {
+			return errors.New("cleaner access required")
+		}
+		return nil
+	}
+	if !currentUser.IsCleanerAdmin() {
+		return errors.New("access denied")
+	}
+
+	company, err := qr.Store.Companies().GetByAdminUserID(ctx, currentUser.ID)
+	if err != nil {
+		qr.Logger.Printf("Error retrieving company: %s", err)
+		return errors.New("company not found")
+	}
+	profile, err := qr.Store.CleanerProfiles().GetByUserID(ctx, cleanerID)
+	if err != nil || profile.CompanyID == nil || *profile.CompanyID != company.ID {
+		return errors.New("access denied")
+	}
+	return nil
+}
@@ -3,54 +3,498 @@ package graphql
 import (
 	"context"
 	"errors"
+	"log"
+	"strings"
 	"time"
 
+	"cleanbuddy-api/res/payment"
+	"cleanbuddy-api/res/payout"
 	"cleanbuddy-api/res/store"
+	"cleanbuddy-api/res/tip"
 	"cleanbuddy-api/sys/graphql/gen"
+	"cleanbuddy-api/sys/http/middleware"
 )
 
+// FIELD RESOLVERS
+type transactionResolver struct{ *Resolver }
+
+func (r *Resolver) Transaction() gen.TransactionResolver { return &transactionResolver{r} }
+
+func (tr *transactionResolver) Booking(ctx context.Context, transaction *store.Transaction) (*store.Booking, error) {
+	if transaction.BookingID == nil {
+		return nil, nil
+	}
+	booking, err := tr.Store.Bookings().Get(ctx, *transaction.BookingID)
+	if err != nil {
+		tr.Logger.Printf("Error retrieving booking for transaction %s: %s", transaction.ID, err)
+		return nil, errors.New("booking not found")
+	}
+	return booking, nil
+}
+
+func (tr *transactionResolver) Payer(ctx context.Context, transaction *store.Transaction) (*store.User, error) {
+	user, err := tr.Store.Users().Get(ctx, transaction.PayerID)
+	if err != nil {
+		tr.Logger.Printf("Error retrieving payer of transaction %s: %s", transaction.ID, err)
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (tr *transactionResolver) Payee(ctx context.Context, transaction *store.Transaction) (*store.User, error) {
+	user, err := tr.Store.Users().Get(ctx, transaction.PayeeID)
+	if err != nil {
+		tr.Logger.Printf("Error retrieving payee of transaction %s: %s", transaction.ID, err)
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (tr *transactionResolver) ClientSecret(ctx context.Context, transaction *store.Transaction) (*string, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil || tr.Payments == nil {
+		return nil, nil
+	}
+
+	secret, err := tr.Payments.ClientSecret(ctx, transaction, currentUser)
+	if err != nil {
+		// Only the payer of an unconfirmed payment sees the secret
+		if !errors.Is(err, payment.ErrNotPayer) && !errors.Is(err, payment.ErrNotConfirmable) && !errors.Is(err, payment.ErrNoPayment) {
+			tr.Logger.Printf("Error retrieving client secret of transaction %s: %s", transaction.ID, err)
+		}
+		return nil, nil
+	}
+	return &secret, nil
+}
+
+type payoutBatchResolver struct{ *Resolver }
+
+func (r *Resolver) PayoutBatch() gen.PayoutBatchResolver { return &payoutBatchResolver{r} }
+
+func (pbr *payoutBatchResolver) Payouts(ctx context.Context, batch *store.PayoutBatch) ([]*store.Transaction, error) {
+	payouts, err := pbr.Store.Transactions().GetByPayoutBatch(ctx, batch.ID)
+	if err != nil {
+		return nil, logAndReturnError(pbr.Logger, "Error retrieving payouts of batch", err, "error retrieving payouts")
+	}
+	return payouts, nil
+}
+
+func (pbr *payoutBatchResolver) InitiatedBy(ctx context.Context, batch *store.PayoutBatch) (*store.User, error) {
+	user, err := pbr.Store.Users().Get(ctx, batch.InitiatedByID)
+	if err != nil {
+		pbr.Logger.Printf("Error retrieving initiator of payout batch %s: %s", batch.ID, err)
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
 // QUERY RESOLVERS
 func (qr *queryResolver) Transaction(ctx context.Context, id string) (*store.Transaction, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	transaction, err := qr.Store.Transactions().Get(ctx, id)
+	if err != nil {
+		qr.Logger.Printf("Error retrieving transaction %s: %s", id, err)
+		return nil, errors.New("transaction not found")
+	}
+	if !canViewTransaction(currentUser, transaction) {
+		return nil, errors.New("access denied")
+	}
+	return transaction, nil
 }
 
 func (qr *queryResolver) TransactionByStripePaymentID(ctx context.Context, stripePaymentID string) (*store.Transaction, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	transaction, err := qr.Store.Transactions().GetByStripePaymentID(ctx, stripePaymentID)
+	if err != nil {
+		qr.Logger.Printf("Error retrieving transaction of payment %s: %s", stripePaymentID, err)
+		return nil, errors.New("transaction not found")
+	}
+	if !canViewTransaction(currentUser, transaction) {
+		return nil, errors.New("access denied")
+	}
+	return transaction, nil
 }
 
 func (qr *queryResolver) TransactionsByBooking(ctx context.Context, bookingID string) ([]*store.Transaction, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	if !currentUser.IsGlobalAdmin() {
+		booking, err := qr.Store.Bookings().Get(ctx, bookingID)
+		if err != nil {
+			qr.Logger.Printf("Error retrieving booking %s: %s", bookingID, err)
+			return nil, errors.New("booking not found")
+		}
+		if booking.CustomerID != currentUser.ID && booking.CleanerID != currentUser.ID {
+			return nil, errors.New("access denied")
+		}
+	}
+
+	transactions, err := qr.Store.Transactions().GetByBooking(ctx, bookingID)
+	if err != nil {
+		return nil, logAndReturnError(qr.Logger, "Error retrieving transactions of booking", err, "error retrieving transactions")
+	}
+
+	// Customer and cleaner of a booking only see the money they paid or received
+	visible := make([]*store.Transaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		if canViewTransaction(currentUser, transaction) {
+			visible = append(visible, transaction)
+		}
+	}
+	return visible, nil
 }
 
 func (qr *queryResolver) MyTransactions(ctx context.Context, filters *gen.TransactionFiltersInput, limit, offset *int, orderBy *string) (*gen.TransactionConnection, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+
+	storeFilters := toTransactionFilters(filters, limit, offset, orderBy)
+	transactions, err := qr.Store.Transactions().GetByUser(ctx, currentUser.ID, storeFilters)
+	if err != nil {
+		return nil, translateTransactionListError(qr.Logger, err)
+	}
+	totals, err := qr.Store.Transactions().GetTotalsByUser(ctx, currentUser.ID, storeFilters)
+	if err != nil {
+		return nil, translateTransactionListError(qr.Logger, err)
+	}
+	return newTransactionConnection(transactions, totals), nil
 }
 
 func (qr *queryResolver) MyEarnings(ctx context.Context, startDate, endDate *time.Time) (*gen.CleanerEarnings, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsCleaner() && !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("cleaner access required")
+	}
+
+	// Without a period, everything paid out so far
+	var start time.Time
+	end := time.Now()
+	if startDate != nil {
+		start = *startDate
+	}
+	if endDate != nil {
+		end = *endDate
+	}
+	if end.Before(start) {
+		return nil, errors.New("endDate must not be before startDate")
+	}
+
+	summary, err := qr.Store.Transactions().GetCleanerEarnings(ctx, currentUser.ID, start, end)
+	if err != nil {
+		return nil, logAndReturnError(qr.Logger, "Error retrieving cleaner earnings", err, "error retrieving earnings")
+	}
+
+	average := 0
+	if summary.PaidBookings > 0 {
+		average = summary.TotalEarnings / summary.PaidBookings
+	}
+	return &gen.CleanerEarnings{
+		CleanerID:                 currentUser.ID,
+		TotalEarnings:             summary.TotalEarnings,
+		CompletedBookings:         summary.PaidBookings,
+		AverageEarningsPerBooking: average,
+		PendingPayouts:            summary.PendingPayouts,
+		Tips:                      summary.Tips,
+	}, nil
 }
 
 func (qr *queryResolver) AllTransactions(ctx context.Context, filters *gen.TransactionFiltersInput, limit, offset *int, orderBy *string) (*gen.TransactionConnection, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+
+	storeFilters := toTransactionFilters(filters, limit, offset, orderBy)
+	transactions, err := qr.Store.Transactions().ListAll(ctx, storeFilters)
+	if err != nil {
+		return nil, translateTransactionListError(qr.Logger, err)
+	}
+	totals, err := qr.Store.Transactions().GetTotals(ctx, storeFilters)
+	if err != nil {
+		return nil, translateTransactionListError(qr.Logger, err)
+	}
+	return newTransactionConnection(transactions, totals), nil
 }
 
 func (qr *queryResolver) TransactionsDueForPayout(ctx context.Context, beforeDate time.Time) ([]*store.Transaction, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+
+	transactions, err := qr.Store.Transactions().GetPayoutsDue(ctx, beforeDate)
+	if err != nil {
+		return nil, logAndReturnError(qr.Logger, "Error retrieving payouts due", err, "error retrieving payouts due")
+	}
+	return transactions, nil
 }
 
 func (qr *queryResolver) PayoutBatch(ctx context.Context, id string) (*store.PayoutBatch, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+
+	batch, err := qr.Store.Transactions().GetPayoutBatch(ctx, id)
+	if err != nil {
+		qr.Logger.Printf("Error retrieving payout batch %s: %s", id, err)
+		return nil, errors.New("payout batch not found")
+	}
+	return batch, nil
 }
 
 func (qr *queryResolver) PayoutBatches(ctx context.Context, limit, offset *int) ([]*store.PayoutBatch, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+
+	l, o := 20, 0
+	if limit != nil {
+		l = *limit
+	}
+	if offset != nil {
+		o = *offset
+	}
+
+	batches, err := qr.Store.Transactions().ListPayoutBatches(ctx, l, o)
+	if err != nil {
+		return nil, logAndReturnError(qr.Logger, "Error listing payout batches", err, "error listing payout batches")
+	}
+	return batches, nil
 }
 
 // MUTATION RESOLVERS
 func (mr *mutationResolver) CreatePayoutBatch(ctx context.Context, input gen.CreatePayoutBatchInput) (*store.PayoutBatch, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+	if mr.Payouts == nil {
+		return nil, errors.New("payouts are not configured")
+	}
+
+	request := payout.BatchRequest{
+		PeriodStart: input.PeriodStart,
+		PeriodEnd:   input.PeriodEnd,
+	}
+	if input.Notes != nil {
+		request.Notes = *input.Notes
+	}
+
+	batch, err := mr.Payouts.CreateBatch(ctx, request, currentUser)
+	if err != nil {
+		return nil, translatePayoutError(mr.Logger, err, "error creating payout batch")
+	}
+	return batch, nil
 }
 
 func (mr *mutationResolver) ProcessPayoutBatch(ctx context.Context, id string) (*store.PayoutBatch, error) {
-	return nil, errors.New("not yet implemented")
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return nil, errors.New("admin access required")
+	}
+	if mr.Payouts == nil {
+		return nil, errors.New("payouts are not configured")
+	}
+
+	batch, err := mr.Payouts.ProcessBatch(ctx, id)
+	if err != nil {
+		return nil, translatePayoutError(mr.Logger, err, "error processing payout batch")
+	}
+	return batch, nil
+}
+
+func (mr *mutationResolver) RetryPaymentEvents(ctx context.Context) (int, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return 0, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return 0, errors.New("admin access required")
+	}
+	if mr.Payments == nil {
+		return 0, errors.New("payments are not configured")
+	}
+
+	applied, err := mr.Payments.RetryEvents(ctx)
+	if err != nil {
+		return applied, logAndReturnError(mr.Logger, "Error retrying payment events", err, "some payment events could not be applied")
+	}
+	return applied, nil
+}
+
+func (mr *mutationResolver) CapturePayments(ctx context.Context) (int, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return 0, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return 0, errors.New("admin access required")
+	}
+	if mr.Payments == nil {
+		return 0, errors.New("payments are not configured")
+	}
+
+	captured, err := mr.Payments.CaptureDue(ctx)
+	if err != nil {
+		return captured, logAndReturnError(mr.Logger, "Error capturing payments", err, "some payments could not be captured")
+	}
+	return captured, nil
+}
+
+func (mr *mutationResolver) RenewPaymentHolds(ctx context.Context) (int, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return 0, errors.New("authentication required")
+	}
+	if !currentUser.IsGlobalAdmin() {
+		return 0, errors.New("admin access required")
+	}
+	if mr.Payments == nil {
+		return 0, errors.New("payments are not configured")
+	}
+
+	handled, err := mr.Payments.RenewHolds(ctx)
+	if err != nil {
+		return handled, logAndReturnError(mr.Logger, "Error renewing payment holds", err, "some payment holds could not be renewed")
+	}
+	return handled, nil
+}
+
+func (mr *mutationResolver) AddTip(ctx context.Context, bookingID string, amount int) (*store.Transaction, error) {
+	currentUser := middleware.GetCurrentUser(ctx)
+	if currentUser == nil {
+		return nil, errors.New("authentication required")
+	}
+	if mr.Tips == nil {
+		return nil, errors.New("payments are not configured")
+	}
+
+	transaction, err := mr.Tips.AddTip(ctx, currentUser, bookingID, amount)
+	if err != nil {
+		switch {
+		case errors.Is(err, tip.ErrBookingNotFound), errors.Is(err, tip.ErrNotCustomer),
+			errors.Is(err, tip.ErrNotCompleted), errors.Is(err, tip.ErrWindowClosed),
+			errors.Is(err, tip.ErrInvalidAmount), errors.Is(err, tip.ErrAlreadyTipped),
+			errors.Is(err, tip.ErrTipPending):
+			return nil, err
+		}
+		return nil, logAndReturnError(mr.Logger, "Error adding tip", err, "error adding tip")
+	}
+	return transaction, nil
+}
+
+func translatePayoutError(logger *log.Logger, err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, payout.ErrBatchNotFound):
+		return errors.New("payout batch not found")
+	case errors.Is(err, payout.ErrInvalidPeriod):
+		return errors.New("payout period must start before it ends and end in the past")
+	case errors.Is(err, payout.ErrNothingToPay):
+		return errors.New("no completed bookings, tips or compensation are due for payout in the period")
+	case errors.Is(err, payout.ErrBatchConflict):
+		return errors.New("bookings of the period were taken into another payout batch, try again")
+	case errors.Is(err, payout.ErrBatchNotProcessable):
+		return errors.New("payout batch is already paid or being processed")
+	case errors.Is(err, payout.ErrProfileNotFound):
+		return errors.New("cleaner profile not found")
+	}
+	return logAndReturnError(logger, fallbackMsg, err, fallbackMsg)
+}
+
+// canViewTransaction reports whether a user took part in a transaction or is an admin
+func canViewTransaction(user *store.User, transaction *store.Transaction) bool {
+	return user.IsGlobalAdmin() || transaction.PayerID == user.ID || transaction.PayeeID == user.ID
+}
+
+// transactionOrderColumns maps the orderBy fields of the API to their columns
+var transactionOrderColumns = map[string]string{
+	"createdAt":   "created_at",
+	"processedAt": "processed_at",
+	"completedAt": "completed_at",
+	"amount":      "amount",
+}
+
+func toTransactionFilters(input *gen.TransactionFiltersInput, limit, offset *int, orderBy *string) store.TransactionFilters {
+	filters := store.TransactionFilters{Limit: 50}
+	if input != nil {
+		filters.Type = input.Type
+		filters.Status = input.Status
+		filters.PaymentMethod = input.PaymentMethod
+		filters.StartDate = input.StartDate
+		filters.EndDate = input.EndDate
+		filters.MinAmount = input.MinAmount
+		filters.MaxAmount = input.MaxAmount
+	}
+	if limit != nil {
+		filters.Limit = *limit
+	}
+	if offset != nil {
+		filters.Offset = *offset
+	}
+	if orderBy != nil {
+		// Unknown fields are passed on for the store to reject
+		parts := strings.Fields(*orderBy)
+		if len(parts) > 0 {
+			if column, ok := transactionOrderColumns[parts[0]]; ok {
+				parts[0] = column
+			}
+		}
+		filters.OrderBy = strings.Join(parts, " ")
+	}
+	return filters
+}
+
+func newTransactionConnection(transactions []*store.Transaction, totals *store.TransactionTotals) *gen.TransactionConnection {
+	edges := make([]*gen.TransactionEdge, len(transactions))
+	for i, transaction := range transactions {
+		edges[i] = &gen.TransactionEdge{Node: transaction, Cursor: transaction.ID}
+	}
+	return &gen.TransactionConnection{
+		Edges:       edges,
+		TotalCount:  totals.Count,
+		TotalAmount: totals.Amount,
+	}
+}
+
+func translateTransactionListError(logger *log.Logger, err error) error {
+	if errors.Is(err, store.ErrInvalidInput) {
+		return errors.New("invalid orderBy, use createdAt, processedAt, completedAt or amount with ASC or DESC")
+	}
+	return logAndReturnError(logger, "Error listing transactions", err, "error retrieving transactions")
 }
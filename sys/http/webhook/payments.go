@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"cleanbuddy-api/res/payment"
+)
+
+// SignatureHeader carries the provider's signature of a webhook delivery
+const SignatureHeader = "Stripe-Signature"
+
+// maxPayloadBytes bounds the size of a webhook body; provider events are a few kilobytes
+const maxPayloadBytes = 1 << 20
+
+// NewPaymentsHandler receives payment provider webhooks. A 2xx response tells the provider the
+// event was taken; any other status makes it deliver the event again later, so processing
+// failures answer 500 while deliveries that can never succeed answer 400.
+func NewPaymentsHandler(logger *log.Logger, payments payment.PaymentService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if payments == nil {
+			http.Error(w, "payments are not configured", http.StatusServiceUnavailable)
+			return
+		}
+
+		payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadBytes))
+		if err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+
+		event, err := payments.VerifyWebhook(payload, r.Header.Get(SignatureHeader))
+		if err != nil {
+			if !errors.Is(err, payment.ErrInvalidSignature) {
+				logger.Printf("Rejected payment webhook: %v", err)
+			}
+			http.Error(w, "invalid webhook", http.StatusBadRequest)
+			return
+		}
+
+		if err := payments.HandleEvent(r.Context(), event, payload); err != nil {
+			http.Error(w, "event could not be processed", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
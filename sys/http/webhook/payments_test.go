@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cleanbuddy-api/res/payment"
+	"cleanbuddy-api/res/payment/fake"
+)
+
+// eventRecorder is a PaymentService that verifies with the fake provider and records handled events
+type eventRecorder struct {
+	payment.PaymentService
+	provider *fake.FakeProvider
+	handled  []string
+	err      error
+}
+
+func (r *eventRecorder) VerifyWebhook(payload []byte, signatureHeader string) (*payment.Event, error) {
+	return r.provider.VerifyWebhook(payload, signatureHeader)
+}
+
+func (r *eventRecorder) HandleEvent(ctx context.Context, event *payment.Event, payload []byte) error {
+	r.handled = append(r.handled, event.ID)
+	return r.err
+}
+
+func deliver(t *testing.T, handler http.Handler, payload []byte, signature string) int {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/payments", bytes.NewReader(payload))
+	req.Header.Set(SignatureHeader, signature)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestPaymentsHandler(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	provider := fake.New("whsec_test", logger)
+
+	payload, signature, err := provider.SignedEvent(payment.EventIntentSucceeded, &payment.Intent{ID: "pi_1", Status: payment.IntentStatusSucceeded})
+	if err != nil {
+		t.Fatalf("SignedEvent() error = %v", err)
+	}
+
+	t.Run("accepts a signed event", func(t *testing.T) {
+		recorder := &eventRecorder{provider: provider}
+		if code := deliver(t, NewPaymentsHandler(logger, recorder), payload, signature); code != http.StatusOK {
+			t.Errorf("status = %d, want %d", code, http.StatusOK)
+		}
+		if len(recorder.handled) != 1 {
+			t.Errorf("handled %d events, want 1", len(recorder.handled))
+		}
+	})
+
+	t.Run("rejects a bad signature", func(t *testing.T) {
+		recorder := &eventRecorder{provider: provider}
+		if code := deliver(t, NewPaymentsHandler(logger, recorder), payload, "t=1,v1=bad"); code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", code, http.StatusBadRequest)
+		}
+		if len(recorder.handled) != 0 {
+			t.Errorf("handled %d events, want 0", len(recorder.handled))
+		}
+	})
+
+	t.Run("asks for redelivery when processing fails", func(t *testing.T) {
+		recorder := &eventRecorder{provider: provider, err: errors.New("database unavailable")}
+		if code := deliver(t, NewPaymentsHandler(logger, recorder), payload, signature); code != http.StatusInternalServerError {
+			t.Errorf("status = %d, want %d", code, http.StatusInternalServerError)
+		}
+	})
+}